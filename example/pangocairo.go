@@ -96,11 +96,12 @@ func main() {
 
 	// Verify rendering by checking if pixels were modified
 	if imageSurface, ok := surface.(cairo.ImageSurface); ok {
+		imageSurface.Flush()
 		data := imageSurface.GetData()
 		hasNonBackground := false
 		// Check if any pixels are not the background color
 		for i := 0; i < len(data); i += 4 {
-			b, g, r := data[i], data[i+1], data[i+2]
+			r, g, b := data[i+1], data[i+2], data[i+3]
 			// Check if pixel is not background color (allowing some tolerance)
 			if r < 220 || g < 235 || b < 250 {
 				hasNonBackground = true
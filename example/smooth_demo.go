@@ -341,32 +341,16 @@ func addLabel(surface cairo.Surface, text string, labelNum int) {
 
 // applySmoothToSurface 对表面应用平滑处理
 //
-// 工作流程：
-// 1. 从 Cairo 表面获取 Go image.RGBA 数据
-// 2. 复制到 ImageBackend（提供平滑算法）
-// 3. 应用指定的平滑算法
-// 4. 将处理后的数据复制回 Cairo 表面
+// ImageSurface.Backend 返回的 ImageBackend 直接引用表面的像素缓冲区，
+// 平滑算法会原地修改表面，无需来回复制数据。
 //
 // 参数：
 //   - surface: 要处理的 Cairo 表面
-//   - method: 平滑方法 ("bilinear", "gaussian", "median")
+//   - method: 平滑方法 ("edge_gaussian", "anisotropic", "bilateral")
 func applySmoothToSurface(surface cairo.Surface, method string) {
-	imgSurface := surface.(cairo.ImageSurface)
-	goImg := imgSurface.GetGoImage()
-
-	// 获取图像尺寸
-	bounds := goImg.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-
-	// 创建 ImageBackend - 提供高性能的像素级操作和平滑算法
-	backend := cairo.NewImageBackend(width, height)
-	backendImg := backend.GetImage()
-
-	// 复制像素数据到后端
-	// 使用 copy() 直接复制底层字节数组，比逐像素复制快得多
-	if rgba, ok := goImg.(*image.RGBA); ok {
-		copy(backendImg.Pix, rgba.Pix)
+	backend := surface.(cairo.ImageSurface).Backend()
+	if backend == nil {
+		return
 	}
 
 	// 应用平滑算法
@@ -392,17 +376,4 @@ func applySmoothToSurface(surface cairo.Surface, method string) {
 		// colorSigma=30: 颜色域标准差
 		backend.SmoothBilateral(3, 30)
 	}
-
-	// 重要：平滑算法会创建新的图像，需要重新获取
-	backendImg = backend.GetImage()
-
-	// 将平滑后的数据复制回 Cairo 表面
-	// 这样原始表面就包含了平滑后的图像数据
-	if rgba, ok := goImg.(*image.RGBA); ok {
-		copy(rgba.Pix, backendImg.Pix)
-	}
-
-	// 注意：不要调用 MarkDirty()！
-	// MarkDirty() 会从 ARGB 数据读取并覆盖 RGBA 数据，导致修改丢失
-	// 因为我们直接修改了 RGBA 数据，所以不需要调用 MarkDirty()
 }
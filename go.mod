@@ -5,6 +5,5 @@ go 1.24.4
 require (
 	github.com/go-text/typesetting v0.1.2
 	golang.org/x/image v0.18.0
+	golang.org/x/text v0.16.0
 )
-
-require golang.org/x/text v0.16.0 // indirect
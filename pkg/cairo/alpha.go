@@ -0,0 +1,65 @@
+package cairo
+
+import "image"
+
+// Image surfaces store premultiplied alpha, as cairo's ARGB32 does: each
+// color channel is already scaled by the pixel's alpha. The Go images
+// returned by GetGoImage are *image.RGBA, which image/color defines as
+// premultiplied too, so they can be drawn with image/draw directly. Straight
+// alpha appears only at the boundaries: PNG files, color.NRGBA values, and
+// images other libraries hand over as straight RGBA bytes. The helpers
+// below convert pixels in place at those boundaries.
+
+// PremultiplyAlpha converts img in place from straight to premultiplied
+// alpha, treating its bytes as non-premultiplied R, G, B, A values.
+func PremultiplyAlpha(img *image.RGBA) {
+	if img == nil {
+		return
+	}
+	b := img.Rect
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):][:b.Dx()*4]
+		for i := 0; i < len(row); i += 4 {
+			a := uint32(row[i+3])
+			if a == 0xff {
+				continue
+			}
+			row[i+0] = uint8((uint32(row[i+0])*a + 127) / 255)
+			row[i+1] = uint8((uint32(row[i+1])*a + 127) / 255)
+			row[i+2] = uint8((uint32(row[i+2])*a + 127) / 255)
+		}
+	}
+}
+
+// UnpremultiplyAlpha converts img in place from premultiplied to straight
+// alpha, leaving non-premultiplied R, G, B, A values in its bytes. Fully
+// transparent pixels become zero. Converting back with PremultiplyAlpha
+// restores the original pixels exactly.
+func UnpremultiplyAlpha(img *image.RGBA) {
+	if img == nil {
+		return
+	}
+	b := img.Rect
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(b.Min.X, y):][:b.Dx()*4]
+		for i := 0; i < len(row); i += 4 {
+			a := uint32(row[i+3])
+			switch a {
+			case 0xff:
+				continue
+			case 0:
+				row[i+0], row[i+1], row[i+2] = 0, 0, 0
+				continue
+			}
+			row[i+0] = unpremultiply(row[i+0], a)
+			row[i+1] = unpremultiply(row[i+1], a)
+			row[i+2] = unpremultiply(row[i+2], a)
+		}
+	}
+}
+
+// unpremultiply returns the straight value of a channel premultiplied by
+// a, rounded to nearest. Channels above alpha are invalid and clamp to 255.
+func unpremultiply(c uint8, a uint32) uint8 {
+	return uint8(min((uint32(c)*255+a/2)/a, 255))
+}
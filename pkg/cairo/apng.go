@@ -0,0 +1,220 @@
+package cairo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"io"
+)
+
+// pngSignature starts every PNG file.
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// APNGWriter assembles image surfaces into an animated PNG. Frames are
+// compressed as they are added and the file is written by Finish, since
+// the frame count comes first. Every frame covers the whole animation and
+// replaces the previous one. All frames are stored as 8-bit RGBA with
+// straight alpha, so a viewer without APNG support shows the first frame.
+type APNGWriter struct {
+	w             io.Writer
+	width, height int
+	frames        []apngFrame
+	status        Status
+}
+
+// apngFrame is a frame's compressed image data and its delay.
+type apngFrame struct {
+	data    []byte
+	delayMs int
+}
+
+// NewAPNGWriter returns a writer of a width by height animated PNG to w.
+func NewAPNGWriter(w io.Writer, width, height int) *APNGWriter {
+	a := &APNGWriter{w: w, width: width, height: height}
+	if w == nil {
+		a.status = StatusNullPointer
+	} else if width <= 0 || height <= 0 || width > 1<<31-1 || height > 1<<31-1 {
+		a.status = StatusInvalidSize
+	}
+	return a
+}
+
+// Status returns the writer's error status. Once an operation fails, the
+// writer stays in error and does nothing more.
+func (a *APNGWriter) Status() Status {
+	return a.status
+}
+
+// AddFrame appends the surface's current contents as the next frame, shown
+// for delayMs milliseconds. The surface must have the animation's size.
+func (a *APNGWriter) AddFrame(surface ImageSurface, delayMs int) Status {
+	if a.status != StatusSuccess {
+		return a.status
+	}
+	if surface == nil {
+		return a.setError(StatusNullPointer)
+	}
+	if status := surface.Status(); status != StatusSuccess {
+		return a.setError(status)
+	}
+	if surface.GetWidth() != a.width || surface.GetHeight() != a.height {
+		return a.setError(StatusInvalidSize)
+	}
+	if delayMs < 0 {
+		return a.setError(StatusInvalidSize)
+	}
+
+	// PNG stores straight alpha
+	nrgba := straightAlphaImage(surface)
+	if nrgba == nil {
+		return a.setError(StatusSurfaceTypeMismatch)
+	}
+
+	var data bytes.Buffer
+	zw := zlib.NewWriter(&data)
+	row := make([]byte, 1+4*a.width)
+	for y := 0; y < a.height; y++ {
+		// Each scanline starts with its filter type, none here
+		copy(row[1:], nrgba.Pix[y*nrgba.Stride:])
+		if _, err := zw.Write(row); err != nil {
+			return a.setError(StatusPngError)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return a.setError(StatusPngError)
+	}
+
+	a.frames = append(a.frames, apngFrame{data: data.Bytes(), delayMs: delayMs})
+	return StatusSuccess
+}
+
+// Finish writes the animation, looping forever, to the writer. At least
+// one frame must have been added. The writer can't be used afterwards.
+func (a *APNGWriter) Finish() Status {
+	if a.status != StatusSuccess {
+		return a.status
+	}
+	if len(a.frames) == 0 {
+		return a.setError(StatusPngError)
+	}
+	defer a.setError(StatusSurfaceFinished)
+
+	cw := &pngChunkWriter{w: a.w}
+	cw.write(pngSignature)
+
+	var ihdr [13]byte
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(a.width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(a.height))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // color type: RGBA
+	cw.chunk("IHDR", ihdr[:])
+
+	var actl [8]byte
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(a.frames)))
+	// A play count of 0 loops forever
+	cw.chunk("acTL", actl[:])
+
+	// fcTL and fdAT chunks share one sequence
+	var seq uint32
+	for i, frame := range a.frames {
+		num, den := apngDelay(frame.delayMs)
+		var fctl [26]byte
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(a.width))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(a.height))
+		// The frame sits at the origin, is disposed of by doing nothing
+		// and replaces the previous frame's pixels
+		binary.BigEndian.PutUint16(fctl[20:], num)
+		binary.BigEndian.PutUint16(fctl[22:], den)
+		cw.chunk("fcTL", fctl[:])
+		seq++
+
+		// The first frame is the default image
+		if i == 0 {
+			cw.chunk("IDAT", frame.data)
+			continue
+		}
+		fdat := make([]byte, 4+len(frame.data))
+		binary.BigEndian.PutUint32(fdat, seq)
+		copy(fdat[4:], frame.data)
+		cw.chunk("fdAT", fdat)
+		seq++
+	}
+	cw.chunk("IEND", nil)
+
+	if cw.err != nil {
+		a.status = StatusWriteError
+		return StatusWriteError
+	}
+	return StatusSuccess
+}
+
+func (a *APNGWriter) setError(status Status) Status {
+	if a.status == StatusSuccess {
+		a.status = status
+	}
+	return status
+}
+
+// straightAlphaImage returns a copy of the surface's pixels with straight
+// alpha, as image formats store them, or nil when it has no pixels.
+func straightAlphaImage(surface ImageSurface) *image.NRGBA {
+	var img image.Image
+	if s, ok := surface.(*imageSurface); ok {
+		img = s.pngImage()
+	} else {
+		img = surface.GetGoImage()
+	}
+	if img == nil {
+		return nil
+	}
+	// Drawing into NRGBA unpremultiplies
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+	return nrgba
+}
+
+// apngDelay returns a delay in milliseconds as the 16-bit fraction of a
+// second fcTL stores, losing precision only for delays over a minute.
+func apngDelay(delayMs int) (num, den uint16) {
+	n, d := delayMs, 1000
+	for n > 0xffff && d > 1 {
+		n, d = (n+5)/10, d/10
+	}
+	if n > 0xffff {
+		n = 0xffff
+	}
+	return uint16(n), uint16(d)
+}
+
+// pngChunkWriter writes PNG chunks, keeping the first error.
+type pngChunkWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (cw *pngChunkWriter) write(b []byte) {
+	if cw.err == nil {
+		_, cw.err = cw.w.Write(b)
+	}
+}
+
+// chunk writes a chunk with its length and CRC.
+func (cw *pngChunkWriter) chunk(name string, data []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], name)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	var footer [4]byte
+	binary.BigEndian.PutUint32(footer[:], crc.Sum32())
+
+	cw.write(header[:])
+	cw.write(data)
+	cw.write(footer[:])
+}
@@ -2,6 +2,7 @@ package cairo
 
 import (
 	"image/color"
+	"math"
 )
 
 // cairoBlendColor applies a simplified blend operation to a solid color.
@@ -9,16 +10,12 @@ import (
 // manipulation of the destination surface, which is not exposed by Pango.
 // This function only handles the source color's alpha based on the operator.
 func cairoBlendColor(src color.Color, op Operator) color.Color {
-	r, g, b, a := src.RGBA()
+	// Convert to non-premultiplied alpha for easier logic; RGBA() would
+	// return premultiplied components and darken translucent colors
+	n := color.NRGBAModel.Convert(src).(color.NRGBA)
+	alpha := float64(n.A) / 0xFF
 
-	// Convert to non-premultiplied alpha for easier logic
-	alpha := float64(a) / 0xFFFF
-
-	// Convert RGBA values to 8-bit
-	r8 := uint8(r >> 8)
-	g8 := uint8(g >> 8)
-	b8 := uint8(b >> 8)
-	a8 := uint8(a >> 8)
+	r8, g8, b8, a8 := n.R, n.G, n.B, n.A
 
 	switch op {
 	case OperatorClear:
@@ -81,3 +78,70 @@ func cairoBlendColor(src color.Color, op Operator) color.Color {
 
 // TODO: Implement full pixel-level blending by replacing Pango's drawing mechanism
 // with a custom one that uses image/draw.Drawer and applies the blend function.
+
+// isSeparableBlendMode reports whether op is one of the separable PDF blend
+// modes, OperatorMultiply through OperatorExclusion, which mix each color
+// channel independently.
+func isSeparableBlendMode(op Operator) bool {
+	return op >= OperatorMultiply && op <= OperatorExclusion
+}
+
+// blendChannel returns the blend function B(cs, cd) of a separable blend
+// mode for one channel of non-premultiplied source and destination colors in
+// [0, 1], following the PDF and W3C compositing definitions. The result is
+// composited with source-over alpha:
+//
+//	co = cs*as*(1-ad) + cd*ad*(1-as) + as*ad*B(cs, cd)
+//
+// Operators that are not separable blend modes return cs, which reduces the
+// formula to plain "over".
+func blendChannel(op Operator, cs, cd float64) float64 {
+	switch op {
+	case OperatorMultiply:
+		return cs * cd
+	case OperatorScreen:
+		return cs + cd - cs*cd
+	case OperatorOverlay:
+		return blendChannel(OperatorHardLight, cd, cs)
+	case OperatorDarken:
+		return math.Min(cs, cd)
+	case OperatorLighten:
+		return math.Max(cs, cd)
+	case OperatorColorDodge:
+		if cd == 0 {
+			return 0
+		}
+		if cs >= 1 {
+			return 1
+		}
+		return math.Min(1, cd/(1-cs))
+	case OperatorColorBurn:
+		if cd >= 1 {
+			return 1
+		}
+		if cs <= 0 {
+			return 0
+		}
+		return 1 - math.Min(1, (1-cd)/cs)
+	case OperatorHardLight:
+		if cs <= 0.5 {
+			return 2 * cs * cd
+		}
+		return blendChannel(OperatorScreen, 2*cs-1, cd)
+	case OperatorSoftLight:
+		if cs <= 0.5 {
+			return cd - (1-2*cs)*cd*(1-cd)
+		}
+		d := math.Sqrt(cd)
+		if cd <= 0.25 {
+			d = ((16*cd-12)*cd + 4) * cd
+		}
+		return cd + (2*cs-1)*(d-cd)
+	case OperatorDifference:
+		return math.Abs(cs - cd)
+	case OperatorExclusion:
+		return cs + cd - 2*cs*cd
+	default:
+		return cs
+	}
+}
@@ -0,0 +1,111 @@
+package cairo
+
+import (
+	"encoding/binary"
+	"os"
+)
+
+// WriteToBMP writes the surface to an uncompressed BMP file. See
+// WriteToBMPStream.
+func (s *imageSurface) WriteToBMP(filename string) Status {
+	if s.status != StatusSuccess {
+		return s.status
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return StatusWriteError
+	}
+
+	status := s.WriteToBMPStream(func(closure interface{}, data []byte) error {
+		_, err := closure.(*os.File).Write(data)
+		return err
+	}, file)
+	if err := file.Close(); err != nil && status == StatusSuccess {
+		status = StatusWriteError
+	}
+	return status
+}
+
+// WriteToBMPStream encodes the surface as an uncompressed bottom-up BMP and
+// passes the bytes to write, like WriteToPNGStream. Formats without alpha
+// give 24-bit pixels with the plain BITMAPINFOHEADER every reader takes;
+// the others give 32-bit BGRA with straight alpha, described by a
+// BITMAPV4HEADER with an alpha mask.
+func (s *imageSurface) WriteToBMPStream(write WriteFunc, closure interface{}) Status {
+	if s.status != StatusSuccess {
+		return s.status
+	}
+	if s.goImage == nil {
+		return StatusSurfaceTypeMismatch
+	}
+
+	img := straightAlphaImage(s)
+	w, h := s.width, s.height
+	alpha := !opaqueFormat(s.format)
+
+	infoLen, bpp := 40, 24
+	if alpha {
+		infoLen, bpp = 108, 32
+	}
+	// Rows are padded to a multiple of 4 bytes
+	rowLen := (w*bpp/8 + 3) &^ 3
+	offset := 14 + infoLen
+	header := make([]byte, offset)
+
+	// BITMAPFILEHEADER
+	header[0], header[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(header[2:], uint32(offset+rowLen*h))
+	binary.LittleEndian.PutUint32(header[10:], uint32(offset))
+
+	// BITMAPINFOHEADER; a positive height stores the rows bottom-up
+	info := header[14:]
+	binary.LittleEndian.PutUint32(info[0:], uint32(infoLen))
+	binary.LittleEndian.PutUint32(info[4:], uint32(w))
+	binary.LittleEndian.PutUint32(info[8:], uint32(h))
+	binary.LittleEndian.PutUint16(info[12:], 1) // planes
+	binary.LittleEndian.PutUint16(info[14:], uint16(bpp))
+	binary.LittleEndian.PutUint32(info[20:], uint32(rowLen*h))
+	// 72 DPI in pixels per meter
+	binary.LittleEndian.PutUint32(info[24:], 2835)
+	binary.LittleEndian.PutUint32(info[28:], 2835)
+	if alpha {
+		// BITMAPV4HEADER: BI_BITFIELDS with the BGRA channel masks
+		binary.LittleEndian.PutUint32(info[16:], 3)
+		binary.LittleEndian.PutUint32(info[40:], 0x00ff0000)
+		binary.LittleEndian.PutUint32(info[44:], 0x0000ff00)
+		binary.LittleEndian.PutUint32(info[48:], 0x000000ff)
+		binary.LittleEndian.PutUint32(info[52:], 0xff000000)
+		binary.LittleEndian.PutUint32(info[56:], 0x73524742) // LCS_sRGB
+	}
+
+	out := &funcWriter{write, closure}
+	if _, err := out.Write(header); err != nil {
+		return StatusWriteError
+	}
+	row := make([]byte, rowLen)
+	for y := h - 1; y >= 0; y-- {
+		src := img.Pix[y*img.Stride:]
+		for x := 0; x < w; x++ {
+			p := src[x*4:]
+			if alpha {
+				row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = p[2], p[1], p[0], p[3]
+			} else {
+				row[x*3], row[x*3+1], row[x*3+2] = p[2], p[1], p[0]
+			}
+		}
+		if _, err := out.Write(row); err != nil {
+			return StatusWriteError
+		}
+	}
+	return StatusSuccess
+}
+
+// opaqueFormat reports whether pixels of the format have no alpha channel.
+func opaqueFormat(format Format) bool {
+	switch format {
+	case FormatRGB24, FormatRGB16565, FormatRGB30, FormatRGB96F:
+		return true
+	}
+	return false
+}
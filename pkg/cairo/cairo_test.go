@@ -1,6 +1,8 @@
 package cairo
 
 import (
+	"fmt"
+	"image"
 	"math"
 	"testing"
 )
@@ -379,3 +381,261 @@ func TestMatrixTransformations(t *testing.T) {
 		t.Errorf("IdentityMatrix failed, got %+v", matrix)
 	}
 }
+
+func TestGlyphCache(t *testing.T) {
+	face := NewPangoCairoFont("sans", FontSlantNormal, FontWeightNormal)
+	defer face.Destroy()
+	fontMatrix := NewMatrix()
+	fontMatrix.InitScale(24, 24)
+	sf := NewPangoCairoScaledFont(face, fontMatrix, NewMatrix(), nil)
+	defer sf.Destroy()
+
+	gid, _ := face.realFace.NominalGlyph('a')
+	first, err := sf.GlyphPath(uint64(gid))
+	if err != nil {
+		t.Fatalf("GlyphPath failed: %v", err)
+	}
+	// Mutating a returned path must not affect the cached copy
+	first.Data[0].Points[0].X += 100
+
+	second, err := sf.GlyphPath(uint64(gid))
+	if err != nil {
+		t.Fatalf("GlyphPath failed: %v", err)
+	}
+	if second.Data[0].Points[0].X == first.Data[0].Points[0].X {
+		t.Error("Cached glyph path was modified through a returned copy")
+	}
+
+	// A different scale must not reuse the cached path
+	fontMatrix.InitScale(48, 48)
+	larger := NewPangoCairoScaledFont(face, fontMatrix, NewMatrix(), nil)
+	defer larger.Destroy()
+	scaled, _ := larger.GlyphPath(uint64(gid))
+	if math.Abs(scaled.Data[0].Points[0].X-2*second.Data[0].Points[0].X) > 1e-9 {
+		t.Errorf("Expected path scaled by 2, got %f vs %f", scaled.Data[0].Points[0].X, second.Data[0].Points[0].X)
+	}
+}
+
+// BenchmarkShowTextGlyphCache draws the same string again and again, with
+// the glyph cache emptied before each draw and with it kept warm.
+func BenchmarkShowTextGlyphCache(b *testing.B) {
+	surface := NewImageSurface(FormatARGB32, 600, 100)
+	defer surface.Destroy()
+	ctx := NewContext(surface)
+	defer ctx.Destroy()
+
+	layout := PangoCairoCreateLayout(ctx)
+	fontDesc := NewPangoFontDescription()
+	fontDesc.SetFamily("sans")
+	fontDesc.SetSize(24)
+	layout.SetFontDescription(fontDesc)
+	layout.SetText("the quick brown fox jumps over the lazy dog")
+	ctx.SetSourceRGB(0, 0, 0)
+
+	show := func() {
+		ctx.MoveTo(10, 50)
+		PangoCairoShowText(ctx, layout)
+	}
+	b.Run("Uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sharedGlyphCache.mu.Lock()
+			sharedGlyphCache.entries = make(map[glyphCacheKey]interface{})
+			sharedGlyphCache.mu.Unlock()
+			show()
+		}
+	})
+	b.Run("Cached", func(b *testing.B) {
+		show()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			show()
+		}
+	})
+}
+
+func TestGradientPaintCachesInverse(t *testing.T) {
+	const size = 100
+	surface := NewImageSurface(FormatARGB32, size, size)
+	defer surface.Destroy()
+	ctx := NewContext(surface)
+	defer ctx.Destroy()
+	ctx.Rotate(0.3)
+
+	gradient := NewPatternLinear(0, 0, size, 0)
+	defer gradient.Destroy()
+	gradient.(LinearGradientPattern).AddColorStopRGB(0, 1, 0, 0)
+	gradient.(LinearGradientPattern).AddColorStopRGB(1, 0, 0, 1)
+	ctx.SetSource(gradient)
+
+	// Before the inverse CTM was cached, sampling inverted the matrix at
+	// every pixel
+	before := matrixInversions.Load()
+	ctx.Paint()
+	ctx.Paint()
+	if n := matrixInversions.Load() - before; n > 10 {
+		t.Errorf("Expected a few matrix inversions for two paints of %d pixels, got %d", size*size, n)
+	}
+}
+
+func TestRectangleClipScanBounds(t *testing.T) {
+	surface := NewImageSurface(FormatARGB32, 1000, 1000)
+	defer surface.Destroy()
+	ctx := NewContext(surface).(*context)
+	defer ctx.Destroy()
+
+	ctx.Rectangle(100, 100, 300, 300)
+	ctx.Clip()
+	ctx.Rectangle(200, 150, 10, 20)
+	ctx.Clip()
+
+	cov := ctx.clipCoverage()
+	if cov == nil || !cov.rectangular {
+		t.Fatal("Expected nested rectangle clips to resolve to a rectangular clip")
+	}
+	want := image.Rect(200, 150, 210, 170)
+	if cov.extents != want {
+		t.Errorf("Expected clip extents %v, got %v", want, cov.extents)
+	}
+
+	// A fill covering the whole surface only scans the clip box
+	ctx.applyStateToPango()
+	if got := ctx.gc.scanBounds(0, 0, 1000, 1000); got != want {
+		t.Errorf("Expected scan bounds %v, got %v", want, got)
+	}
+
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Paint()
+	img := surface.(*imageSurface).GetGoImage()
+	for _, p := range []image.Point{{200, 150}, {209, 169}} {
+		if _, _, _, a := img.At(p.X, p.Y).RGBA(); a != 0xffff {
+			t.Errorf("Expected pixel %v inside the clip to be painted", p)
+		}
+	}
+	for _, p := range []image.Point{{199, 150}, {210, 169}, {205, 170}, {50, 50}} {
+		if _, _, _, a := img.At(p.X, p.Y).RGBA(); a != 0 {
+			t.Errorf("Expected pixel %v outside the clip to be untouched", p)
+		}
+	}
+}
+
+func TestScanFillMatchesSampling(t *testing.T) {
+	r := newRasterContext(image.NewRGBA(image.Rect(0, 0, 64, 64)))
+	// A self-intersecting star and a curved subpath overlapping it
+	r.MoveTo(32, 2)
+	r.LineTo(50.3, 60)
+	r.LineTo(2.5, 23.7)
+	r.LineTo(61.5, 23.7)
+	r.LineTo(13.7, 60)
+	r.Close()
+	r.MoveTo(10, 40)
+	r.CubicCurveTo(20, 10, 50, 70, 58, 35.5)
+	r.LineTo(40, 62)
+	r.Close()
+
+	path := make([]transformedPoint, len(r.path))
+	for i, pt := range r.path {
+		path[i] = transformedPoint(pt)
+	}
+	const samples = 4
+	filler := newScanFiller(path, r.tolerance, samples, FillRuleWinding, 0, 64)
+	cover := make([]float64, 64)
+	for y := 0; y < 64; y++ {
+		filler.rowCoverage(y, 0, 0, cover)
+		for x := 0; x < 64; x++ {
+			want := 0
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					if r.pointInPath(float64(x)+(float64(sx)+0.5)/samples, float64(y)+(float64(sy)+0.5)/samples) {
+						want++
+					}
+				}
+			}
+			if got := int(math.Round(cover[x] * samples * samples)); got != want {
+				t.Errorf("Pixel (%d, %d): expected %d samples inside, got %d", x, y, want, got)
+			}
+		}
+	}
+}
+
+func BenchmarkRectangleClippedFill(b *testing.B) {
+	surface := NewImageSurface(FormatARGB32, 2000, 2000)
+	defer surface.Destroy()
+	ctx := NewContext(surface).(*context)
+	defer ctx.Destroy()
+
+	ctx.Rectangle(1000, 1000, 20, 20)
+	ctx.Clip()
+	ctx.SetSourceRGB(0, 0, 1)
+
+	for i := 0; i < b.N; i++ {
+		ctx.Arc(1000, 1000, 900, 0, 2*math.Pi)
+		ctx.Fill()
+	}
+
+	// Pixels the fill visits, the clip box rather than the circle's bounds
+	scan := ctx.gc.scanBounds(100, 100, 1900, 1900)
+	b.ReportMetric(float64(scan.Dx()*scan.Dy()), "scanned-px/op")
+}
+
+func ExampleMatrix_TransformPoint() {
+	m := NewMatrix()
+	m.Translate(10, 20)
+	m.Scale(2, 2)
+
+	x, y := m.TransformPoint(1, 1)
+	dx, dy := m.TransformDistance(1, 1)
+	fmt.Println(x, y)
+	fmt.Println(dx, dy)
+	fmt.Println(m)
+	// Output:
+	// 12 22
+	// 2 2
+	// Matrix(2, 0, 0, 2, 10, 20)
+}
+
+func TestShapingCache(t *testing.T) {
+	face := NewPangoCairoFont("sans", FontSlantNormal, FontWeightNormal)
+	defer face.Destroy()
+	fontMatrix := NewMatrix()
+	fontMatrix.InitScale(16, 16)
+	sf := NewPangoCairoScaledFont(face, fontMatrix, NewMatrix(), nil)
+	defer sf.Destroy()
+
+	ClearShapingCache()
+	defer ClearShapingCache()
+	uncached, _, _, status := sf.TextToGlyphs(0, 0, "cache me")
+	if status != StatusSuccess {
+		t.Fatalf("TextToGlyphs failed: %v", status)
+	}
+	if n := len(sharedShapingCache.entries); n != 1 {
+		t.Fatalf("Expected the run to be cached once, got %d entries", n)
+	}
+	cached, _, _, _ := sf.TextToGlyphs(5, 0, "cache me")
+	if n := len(sharedShapingCache.entries); n != 1 {
+		t.Errorf("Expected the second call to reuse the cached run, got %d entries", n)
+	}
+	for i := range uncached {
+		if cached[i].Index != uncached[i].Index || cached[i].X != uncached[i].X+5 {
+			t.Errorf("Glyph %d differs when cached: %+v vs %+v", i, cached[i], uncached[i])
+		}
+	}
+
+	// 字号和方向都是键的一部分
+	fontMatrix.InitScale(32, 32)
+	larger := NewPangoCairoScaledFont(face, fontMatrix, NewMatrix(), nil)
+	defer larger.Destroy()
+	larger.TextToGlyphs(0, 0, "cache me")
+	if n := len(sharedShapingCache.entries); n != 2 {
+		t.Errorf("Expected another size to be cached separately, got %d entries", n)
+	}
+
+	SetShapingCacheLimit(0)
+	defer SetShapingCacheLimit(DefaultShapingCacheLimit)
+	if n := len(sharedShapingCache.entries); n != 0 {
+		t.Errorf("Expected disabling the cache to clear it, got %d entries", n)
+	}
+	sf.TextToGlyphs(0, 0, "not cached")
+	if n := len(sharedShapingCache.entries); n != 0 {
+		t.Errorf("Expected nothing cached while disabled, got %d entries", n)
+	}
+}
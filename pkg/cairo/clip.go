@@ -0,0 +1,195 @@
+package cairo
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Clips are kept in user space in the graphics state and resolved against a
+// raster target on use. Resolving a clip intersects it with every clip
+// pushed before it and caches the result in the clipRegion, so nested clips
+// cost one intersection each, and InClip, ClipExtents and
+// CopyClipRectangleList all read the same coverage the rasterizer uses.
+
+// clipCoverage is a clip stack resolved for one raster target.
+type clipCoverage struct {
+	// Target the coverage was resolved for
+	bounds image.Rectangle
+	device Matrix
+
+	// mask holds the coverage of the intersected clips for every pixel of
+	// bounds
+	mask *image.Alpha
+
+	// extents bounds the pixels with nonzero coverage
+	extents image.Rectangle
+
+	// rectangular is set when every clip in the stack is a pixel-aligned
+	// rectangle, so the clip is exactly extents
+	rectangular bool
+}
+
+// resolve returns the coverage of the clip stack ending at cr on a target of
+// the given bounds, device being the target's device transform.
+func (cr *clipRegion) resolve(bounds image.Rectangle, device Matrix) *clipCoverage {
+	if cov := cr.coverage; cov != nil && cov.bounds == bounds && cov.device == device {
+		return cov
+	}
+
+	m := cr.matrix
+	MatrixMultiply(&m, &m, &device)
+
+	cov := &clipCoverage{
+		bounds: bounds,
+		device: device,
+		mask:   image.NewAlpha(bounds),
+	}
+	rect, isRect := pixelAlignedRect(cr.path, &m)
+	var prev *clipCoverage
+	if cr.prev != nil {
+		prev = cr.prev.resolve(bounds, device)
+	}
+	cov.rectangular = isRect && (prev == nil || prev.rectangular)
+
+	if cov.rectangular {
+		// The intersection of rectangles is a rectangle, exact without
+		// rasterizing the path
+		cov.extents = rect.Intersect(bounds)
+		if prev != nil {
+			cov.extents = cov.extents.Intersect(prev.extents)
+		}
+		draw.Draw(cov.mask, cov.extents, image.Opaque, image.Point{}, draw.Src)
+		cr.coverage = cov
+		return cov
+	}
+
+	// Rasterize this clip's path in device space
+	rc := newRasterContext(image.NewRGBA(bounds))
+	rc.SetMatrixTransform([6]float64{m.XX, m.YX, m.XY, m.YY, m.X0, m.Y0})
+	rc.SetAntialias(cr.antialias)
+	rc.SetTolerance(cr.tolerance)
+	rc.SetFillRule(cr.fillRule)
+	rc.SetFillColor(color.White)
+	cr.path.forEach(func(op PathDataType, p []point) {
+		switch op {
+		case PathMoveTo:
+			rc.MoveTo(p[0].x, p[0].y)
+		case PathLineTo:
+			rc.LineTo(p[0].x, p[0].y)
+		case PathCurveTo:
+			rc.CubicCurveTo(p[0].x, p[0].y, p[1].x, p[1].y, p[2].x, p[2].y)
+		case PathClosePath:
+			rc.Close()
+		}
+	})
+	rc.Fill()
+
+	// Intersect with the previous clips by multiplying coverage
+	cov.extents = image.Rectangle{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			a := uint32(rc.img.RGBAAt(x, y).A)
+			if prev != nil {
+				a = a * uint32(prev.mask.AlphaAt(x, y).A) / 0xff
+			}
+			if a == 0 {
+				continue
+			}
+			cov.mask.SetAlpha(x, y, color.Alpha{A: uint8(a)})
+			cov.extents = cov.extents.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+
+	cr.coverage = cov
+	return cov
+}
+
+// pixelAlignedRect reports whether p, transformed by m, is a single
+// axis-aligned rectangle with integer corners, and returns it.
+func pixelAlignedRect(p *path, m *Matrix) (image.Rectangle, bool) {
+	var pts [][2]float64
+	for i, op := range p.ops {
+		switch op {
+		case PathMoveTo:
+			if i != 0 {
+				return image.Rectangle{}, false
+			}
+			fallthrough
+		case PathLineTo:
+			// Only moves and lines come first, so point i is this segment's
+			x, y := MatrixTransformPoint(m, p.pts[i].x, p.pts[i].y)
+			pts = append(pts, [2]float64{x, y})
+		case PathClosePath:
+			if i != len(p.ops)-1 {
+				return image.Rectangle{}, false
+			}
+		default:
+			return image.Rectangle{}, false
+		}
+	}
+	// An explicit closing line back to the start is allowed
+	if len(pts) == 5 && pts[4] == pts[0] {
+		pts = pts[:4]
+	}
+	if len(pts) != 4 {
+		return image.Rectangle{}, false
+	}
+
+	for _, pt := range pts {
+		if pt[0] != math.Round(pt[0]) || pt[1] != math.Round(pt[1]) {
+			return image.Rectangle{}, false
+		}
+	}
+	// Edges must alternate between horizontal and vertical
+	for i := range pts {
+		a, b, c := pts[i], pts[(i+1)%4], pts[(i+2)%4]
+		h1, v1 := a[1] == b[1], a[0] == b[0]
+		h2, v2 := b[1] == c[1], b[0] == c[0]
+		if !(h1 && v2 || v1 && h2) {
+			return image.Rectangle{}, false
+		}
+	}
+	return image.Rect(int(pts[0][0]), int(pts[0][1]), int(pts[2][0]), int(pts[2][1])), true
+}
+
+// pushClip intersects the clip with the current path.
+func (c *context) pushClip() {
+	clipPath := c.path.clone()
+
+	c.gstate.clip = &clipRegion{
+		path:      clipPath,
+		matrix:    c.gstate.matrix,
+		fillRule:  c.gstate.fillRule,
+		tolerance: c.gstate.tolerance,
+		antialias: c.gstate.antialias,
+		prev:      c.gstate.clip, // Push current clip onto stack
+	}
+}
+
+// clipCoverage resolves the current clip against the raster target, or
+// returns nil when there is no clip.
+func (c *context) clipCoverage() *clipCoverage {
+	if c.gstate.clip == nil || c.gc == nil {
+		return nil
+	}
+	return c.gstate.clip.resolve(c.gc.bounds(), c.deviceTransform())
+}
+
+// deviceToUserRect maps a device-space rectangle to the user-space bounding
+// box of its corners.
+func (c *context) deviceToUserRect(r image.Rectangle) (x1, y1, x2, y2 float64) {
+	inv := c.deviceMatrix()
+	if MatrixInvert(&inv) != StatusSuccess {
+		return 0, 0, 0, 0
+	}
+	x1, y1 = math.Inf(1), math.Inf(1)
+	x2, y2 = math.Inf(-1), math.Inf(-1)
+	for _, pt := range [][2]int{{r.Min.X, r.Min.Y}, {r.Max.X, r.Min.Y}, {r.Max.X, r.Max.Y}, {r.Min.X, r.Max.Y}} {
+		x, y := MatrixTransformPoint(&inv, float64(pt[0]), float64(pt[1]))
+		x1, y1 = math.Min(x1, x), math.Min(y1, y)
+		x2, y2 = math.Max(x2, x), math.Max(y2, y)
+	}
+	return x1, y1, x2, y2
+}
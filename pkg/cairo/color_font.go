@@ -0,0 +1,332 @@
+package cairo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"math"
+
+	"github.com/go-text/typesetting/opentype/api"
+	"golang.org/x/image/draw"
+)
+
+// colorForegroundIndex is the CPAL palette index meaning "use the text color".
+const colorForegroundIndex = 0xFFFF
+
+// colorGlyphLayer is one flat-colored layer of a color glyph: the outline of
+// glyphID filled with palette entry paletteIndex (scaled by alpha).
+type colorGlyphLayer struct {
+	glyphID      uint16
+	paletteIndex uint16
+	alpha        float64
+}
+
+// colorFontTables holds the color glyph data of a font: COLR layers with
+// their CPAL palettes, and whether embedded color bitmaps are present.
+type colorFontTables struct {
+	glyphs   map[uint16][]colorGlyphLayer
+	palettes [][]Color
+
+	// bitmaps is set when the font has CBDT or sbix color bitmap strikes
+	bitmaps bool
+}
+
+// parseColorFontTables extracts the color tables from raw sfnt data.
+// It returns nil if the font has no usable color glyphs.
+//
+// COLRv0 layer records are supported fully. For COLRv1 paint graphs only the
+// flat subset is understood (PaintColrLayers, PaintGlyph and PaintSolid);
+// glyphs using gradients, transforms or composites keep their monochrome
+// rendering.
+func parseColorFontTables(data []byte) *colorFontTables {
+	t := &colorFontTables{
+		glyphs:  make(map[uint16][]colorGlyphLayer),
+		bitmaps: findSfntTable(data, "CBDT") != nil || findSfntTable(data, "sbix") != nil,
+	}
+
+	colr := findSfntTable(data, "COLR")
+	cpal := findSfntTable(data, "CPAL")
+	if colr != nil && cpal != nil {
+		t.palettes = parseCPAL(cpal)
+	}
+	if len(t.palettes) > 0 {
+		parseCOLRv0(colr, t.glyphs)
+		if len(colr) >= 34 && binary.BigEndian.Uint16(colr) >= 1 {
+			parseCOLRv1(colr, t.glyphs)
+		}
+	}
+
+	if len(t.glyphs) == 0 && !t.bitmaps {
+		return nil
+	}
+	return t
+}
+
+// findSfntTable returns the bytes of the named table, or nil if missing.
+func findSfntTable(data []byte, tag string) []byte {
+	if len(data) < 12 {
+		return nil
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil
+		}
+		if string(data[rec:rec+4]) != tag {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil
+		}
+		return data[offset : offset+length]
+	}
+	return nil
+}
+
+// parseCPAL decodes every palette of a CPAL table.
+func parseCPAL(cpal []byte) [][]Color {
+	if len(cpal) < 12 {
+		return nil
+	}
+	numEntries := int(binary.BigEndian.Uint16(cpal[2:]))
+	numPalettes := int(binary.BigEndian.Uint16(cpal[4:]))
+	numRecords := int(binary.BigEndian.Uint16(cpal[6:]))
+	recordsOffset := int(binary.BigEndian.Uint32(cpal[8:]))
+	if 12+2*numPalettes > len(cpal) || recordsOffset+4*numRecords > len(cpal) {
+		return nil
+	}
+
+	palettes := make([][]Color, 0, numPalettes)
+	for p := 0; p < numPalettes; p++ {
+		first := int(binary.BigEndian.Uint16(cpal[12+2*p:]))
+		if first+numEntries > numRecords {
+			return palettes
+		}
+		palette := make([]Color, numEntries)
+		for e := range palette {
+			rec := cpal[recordsOffset+4*(first+e):]
+			// Color records are stored as BGRA
+			palette[e] = Color{
+				R: float64(rec[2]) / 255,
+				G: float64(rec[1]) / 255,
+				B: float64(rec[0]) / 255,
+				A: float64(rec[3]) / 255,
+			}
+		}
+		palettes = append(palettes, palette)
+	}
+	return palettes
+}
+
+// parseCOLRv0 reads the base glyph and layer records shared by all versions.
+func parseCOLRv0(colr []byte, glyphs map[uint16][]colorGlyphLayer) {
+	if len(colr) < 14 {
+		return
+	}
+	numBase := int(binary.BigEndian.Uint16(colr[2:]))
+	baseOffset := int(binary.BigEndian.Uint32(colr[4:]))
+	layerOffset := int(binary.BigEndian.Uint32(colr[8:]))
+	numLayers := int(binary.BigEndian.Uint16(colr[12:]))
+	if baseOffset+6*numBase > len(colr) || layerOffset+4*numLayers > len(colr) {
+		return
+	}
+
+	for i := 0; i < numBase; i++ {
+		rec := colr[baseOffset+6*i:]
+		gid := binary.BigEndian.Uint16(rec)
+		first := int(binary.BigEndian.Uint16(rec[2:]))
+		count := int(binary.BigEndian.Uint16(rec[4:]))
+		if first+count > numLayers {
+			continue
+		}
+		layers := make([]colorGlyphLayer, count)
+		for j := range layers {
+			l := colr[layerOffset+4*(first+j):]
+			layers[j] = colorGlyphLayer{
+				glyphID:      binary.BigEndian.Uint16(l),
+				paletteIndex: binary.BigEndian.Uint16(l[2:]),
+				alpha:        1,
+			}
+		}
+		glyphs[gid] = layers
+	}
+}
+
+// parseCOLRv1 reads the flat subset of the COLRv1 BaseGlyphList.
+func parseCOLRv1(colr []byte, glyphs map[uint16][]colorGlyphLayer) {
+	baseListOffset := int(binary.BigEndian.Uint32(colr[14:]))
+	layerListOffset := int(binary.BigEndian.Uint32(colr[18:]))
+	if baseListOffset == 0 || baseListOffset+4 > len(colr) {
+		return
+	}
+
+	var layerPaints []int
+	if layerListOffset != 0 && layerListOffset+4 <= len(colr) {
+		n := int(binary.BigEndian.Uint32(colr[layerListOffset:]))
+		if layerListOffset+4+4*n <= len(colr) {
+			layerPaints = make([]int, n)
+			for i := range layerPaints {
+				layerPaints[i] = layerListOffset + int(binary.BigEndian.Uint32(colr[layerListOffset+4+4*i:]))
+			}
+		}
+	}
+
+	n := int(binary.BigEndian.Uint32(colr[baseListOffset:]))
+	if baseListOffset+4+6*n > len(colr) {
+		return
+	}
+	for i := 0; i < n; i++ {
+		rec := colr[baseListOffset+4+6*i:]
+		gid := binary.BigEndian.Uint16(rec)
+		paint := baseListOffset + int(binary.BigEndian.Uint32(rec[2:]))
+
+		var layers []colorGlyphLayer
+		budget := maxCOLRPaints
+		if !flattenCOLRPaint(colr, paint, layerPaints, &layers, 0, &budget) || len(layers) == 0 {
+			continue
+		}
+		glyphs[gid] = layers
+	}
+}
+
+// maxCOLRPaintDepth bounds the nesting of paints, as FreeType does, so that
+// a malformed font whose paints refer back to themselves is rejected.
+// maxCOLRPaints bounds the paints visited for a glyph, since layer lists
+// referring to themselves several times multiply the work at every level.
+const (
+	maxCOLRPaintDepth = 64
+	maxCOLRPaints     = 1 << 16
+)
+
+// flattenCOLRPaint appends the layers described by the paint at offset and
+// reports whether the paint graph only used supported paint formats. depth
+// is the number of paints above this one and budget the number of paints
+// still allowed to be visited.
+func flattenCOLRPaint(colr []byte, offset int, layerPaints []int, layers *[]colorGlyphLayer, depth int, budget *int) bool {
+	if offset <= 0 || offset >= len(colr) || depth >= maxCOLRPaintDepth || *budget <= 0 {
+		return false
+	}
+	*budget--
+	switch colr[offset] {
+	case 1: // PaintColrLayers
+		if offset+6 > len(colr) {
+			return false
+		}
+		count := int(colr[offset+1])
+		first := int(binary.BigEndian.Uint32(colr[offset+2:]))
+		if first+count > len(layerPaints) {
+			return false
+		}
+		for _, p := range layerPaints[first : first+count] {
+			if !flattenCOLRPaint(colr, p, layerPaints, layers, depth+1, budget) {
+				return false
+			}
+		}
+		return true
+	case 10: // PaintGlyph
+		if offset+6 > len(colr) {
+			return false
+		}
+		child := offset + (int(colr[offset+1])<<16 | int(colr[offset+2])<<8 | int(colr[offset+3]))
+		gid := binary.BigEndian.Uint16(colr[offset+4:])
+		// Only solid fills are supported as the glyph's paint
+		if child >= len(colr) || child+5 > len(colr) || colr[child] != 2 {
+			return false
+		}
+		alpha := float64(int16(binary.BigEndian.Uint16(colr[child+3:]))) / (1 << 14)
+		*layers = append(*layers, colorGlyphLayer{
+			glyphID:      gid,
+			paletteIndex: binary.BigEndian.Uint16(colr[child+1:]),
+			alpha:        alpha,
+		})
+		return true
+	}
+	return false
+}
+
+// layerColor resolves the color of a layer for the selected palette, honouring
+// custom palette overrides. ok is false for the foreground (text color) entry.
+func (t *colorFontTables) layerColor(layer colorGlyphLayer, options *FontOptions) (color Color, ok bool) {
+	if layer.paletteIndex == colorForegroundIndex {
+		return Color{}, false
+	}
+
+	var paletteIdx uint
+	if options != nil {
+		if c, found := options.CustomPalette[uint(layer.paletteIndex)]; found {
+			c.A *= layer.alpha
+			return c, true
+		}
+		paletteIdx = options.ColorPalette
+	}
+	if paletteIdx >= uint(len(t.palettes)) {
+		paletteIdx = 0
+	}
+	palette := t.palettes[paletteIdx]
+	if int(layer.paletteIndex) >= len(palette) {
+		return Color{}, false
+	}
+	color = palette[layer.paletteIndex]
+	color.A *= layer.alpha
+	return color, true
+}
+
+// renderBitmapGlyph draws an embedded PNG bitmap glyph (CBDT or sbix) at the
+// glyph position, scaled bilinearly from the strike nearest to the font size.
+// It reports false if the glyph has no color bitmap.
+func renderBitmapGlyph(c *context, sf *PangoCairoScaledFont, glyph Glyph) bool {
+	realFace, status := sf.getRealFace()
+	if status != StatusSuccess {
+		return false
+	}
+
+	fontSize := math.Hypot(sf.fontMatrix.XX, sf.fontMatrix.YX)
+	if fontSize == 0 {
+		fontSize = 12.0
+	}
+
+	// Setting the ppem on a copy makes go-text pick the nearest strike
+	ppem := uint16(math.Max(1, math.Min(math.Round(fontSize), math.MaxUint16)))
+	face := *realFace
+	face.XPpem, face.YPpem = ppem, ppem
+
+	gid := api.GID(glyph.Index)
+	bitmap, ok := face.GlyphData(gid).(api.GlyphBitmap)
+	if !ok || bitmap.Format != api.PNG {
+		return false
+	}
+	src, err := png.Decode(bytes.NewReader(bitmap.Data))
+	if err != nil {
+		return false
+	}
+	extents, ok := face.GlyphExtents(gid)
+	if !ok {
+		return false
+	}
+
+	// Extents are in font units with y up
+	scale := fontSize / float64(face.Upem())
+	left := glyph.X + float64(extents.XBearing)*scale
+	top := glyph.Y - float64(extents.YBearing)*scale
+	width := int(math.Ceil(float64(extents.Width) * scale))
+	height := int(math.Ceil(-float64(extents.Height) * scale))
+	if width <= 0 || height <= 0 {
+		return true
+	}
+
+	scaled := NewImageSurface(FormatARGB32, width, height)
+	defer scaled.Destroy()
+	dst := scaled.(*imageSurface).rgbaImage
+	draw.BiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	c.Save()
+	c.SetSourceSurface(scaled, left, top)
+	c.NewPath()
+	c.Rectangle(left, top, float64(width), float64(height))
+	c.Fill()
+	c.Restore()
+	return true
+}
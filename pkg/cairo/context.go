@@ -1,13 +1,13 @@
 package cairo
 
 import (
-	"fmt"
 	"image"
 	"image/color"
 	"math"
 	"runtime"
-	"sync"
+	"slices"
 	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
@@ -18,11 +18,9 @@ type GroupSurface struct {
 	originalGC     *rasterContext
 }
 
-// context implements the Context interface
+// context implements the Context interface. It holds no lock: callers must
+// confine each context to one goroutine at a time (see Context).
 type context struct {
-	// Mutex for concurrency safety
-	mu sync.Mutex
-
 	// Reference counting
 	refCount int32
 
@@ -45,10 +43,21 @@ type context struct {
 	currentPoint struct {
 		x, y     float64
 		hasPoint bool
+		// pending is set by SetCurrentPoint; the MoveTo is only emitted
+		// once a drawing operation continues from the point.
+		pending bool
 	}
 
 	// Drawing context for backend
 	gc *rasterContext
+
+	// Contexts for the targets of a tee or observer surface, which drawing
+	// operations are replayed to
+	forwarded map[Surface]*context
+
+	// textSubpixelOrder is set while text is rendered with subpixel
+	// antialiasing; other drawing always uses grayscale coverage
+	textSubpixelOrder SubpixelOrder
 }
 
 // graphicsState represents the graphics state that can be saved/restored
@@ -67,9 +76,14 @@ type graphicsState struct {
 	miterLimit float64
 	dash       []float64
 	dashOffset float64
+	// dashContinuous carries the dash phase from one subpath into the
+	// next; by default it restarts at each
+	dashContinuous bool
 
-	// Transformation matrix
-	matrix Matrix
+	// Transformation matrix, and its inverse kept in step with it for
+	// mapping device coordinates back to user space
+	matrix        Matrix
+	matrixInverse Matrix
 
 	// Font properties
 	fontFace    FontFace
@@ -77,6 +91,9 @@ type graphicsState struct {
 	fontOptions *FontOptions
 	scaledFont  ScaledFont
 
+	// How codepoints missing from the font are rendered
+	missingGlyphStyle MissingGlyphStyle
+
 	// Clip region
 	clip *clipRegion
 
@@ -89,36 +106,76 @@ type graphicsState struct {
 
 // clipRegion represents clipping information
 type clipRegion struct {
-	// Clipping path
+	// Clipping path, in the user space of matrix
 	path      *path
+	matrix    Matrix
 	fillRule  FillRule
 	tolerance float64
 	antialias Antialias
 
+	// Intersection with the previous clips, cached by resolve
+	coverage *clipCoverage
+
 	// Previous clip in stack
 	prev *clipRegion
 }
 
 // path represents the current path
 type path struct {
-	// Path data
-	data []pathOp
+	// Segment types in order. Their points are stored back to back in pts:
+	// one for a move or line, three for a curve and none for a close, so
+	// adding segments allocates nothing once the slices have grown.
+	ops []PathDataType
+	pts []point
 
 	// Current subpath starting point
 	subpathStartX, subpathStartY float64
 }
 
-// pathOp represents a path operation
-type pathOp struct {
-	op     PathDataType
-	points []point
-}
-
 type point struct {
 	x, y float64
 }
 
-// NewContext creates a new drawing context for the given surface
+// segmentPoints returns how many points a segment of type op has.
+func segmentPoints(op PathDataType) int {
+	switch op {
+	case PathMoveTo, PathLineTo:
+		return 1
+	case PathCurveTo:
+		return 3
+	}
+	return 0
+}
+
+// add appends a segment with its points.
+func (p *path) add(op PathDataType, pts ...point) {
+	p.ops = append(p.ops, op)
+	p.pts = append(p.pts, pts...)
+}
+
+// forEach calls fn for every segment with its points. fn must not keep pts.
+func (p *path) forEach(fn func(op PathDataType, pts []point)) {
+	i := 0
+	for _, op := range p.ops {
+		n := segmentPoints(op)
+		fn(op, p.pts[i:i+n:i+n])
+		i += n
+	}
+}
+
+// clone returns a copy of p that shares no storage with it.
+func (p *path) clone() *path {
+	return &path{
+		ops:           append([]PathDataType(nil), p.ops...),
+		pts:           append([]point(nil), p.pts...),
+		subpathStartX: p.subpathStartX,
+		subpathStartY: p.subpathStartY,
+	}
+}
+
+// NewContext creates a new drawing context for the given surface. The
+// context must not be used from several goroutines at once; create one
+// context per goroutine, each drawing to its own surface.
 func NewContext(target Surface) Context {
 	if target == nil {
 		return newContextInError(StatusNullPointer)
@@ -129,7 +186,7 @@ func NewContext(target Surface) Context {
 		target:   target.Reference(),
 		userData: make(map[*UserDataKey]interface{}),
 		gstate:   newGraphicsState(),
-		path:     &path{data: make([]pathOp, 0)},
+		path:     &path{},
 	}
 
 	runtime.SetFinalizer(ctx, (*context).destroyConcrete)
@@ -138,9 +195,12 @@ func NewContext(target Surface) Context {
 	case ImageSurface:
 		imgSurf := target.(ImageSurface)
 		goImage := imgSurf.GetGoImage()
-		if goImage != nil {
-			ctx.gc = newRasterContext(goImage.(*image.RGBA))
-		} else {
+		switch img := goImage.(type) {
+		case *image.RGBA:
+			ctx.gc = newRasterContext(img)
+		case *floatImage:
+			ctx.gc = newFloatRasterContext(img)
+		default:
 			dummyImage := image.NewRGBA(image.Rect(0, 0, imgSurf.GetWidth(), imgSurf.GetHeight()))
 			ctx.gc = newRasterContext(dummyImage)
 		}
@@ -150,15 +210,26 @@ func NewContext(target Surface) Context {
 		// with circles and other shapes when using negative Y scaling.
 		ctx.gstate.matrix.InitIdentity()
 	case *pdfSurface:
-		// Create a raster context for PDF
-		dummyImage := image.NewRGBA(image.Rect(0, 0, int(s.width), int(s.height)))
-		ctx.gc = newRasterContext(dummyImage)
-		// Store a reference in the surface for Finish()
+		// Draw into the surface's current page, emitted by ShowPage
+		ctx.gc = newRasterContext(s.page)
 	case *svgSurface:
 		// Create a raster context for SVG
 		dummyImage := image.NewRGBA(image.Rect(0, 0, int(s.width), int(s.height)))
 		ctx.gc = newRasterContext(dummyImage)
 		// Store a reference in the surface for Finish()
+	case *scriptSurface:
+		// Drawing is recorded; the raster context only sizes groups
+		ctx.gc = newRasterContext(image.NewRGBA(s.rasterBounds()))
+	case forwardingSurface:
+		// Drawing is replayed to a context per target; the raster context
+		// only sizes groups, which match the primary target
+		var bounds image.Rectangle
+		if targets := s.forwardTargets(); len(targets) > 0 {
+			if img, ok := targets[0].(ImageSurface); ok {
+				bounds = image.Rect(0, 0, img.GetWidth(), img.GetHeight())
+			}
+		}
+		ctx.gc = newRasterContext(image.NewRGBA(bounds))
 	}
 
 	// Initialize default state
@@ -190,8 +261,10 @@ func newContextInError(status Status) Context {
 
 func newGraphicsState() *graphicsState {
 	return &graphicsState{
-		fontOptions: &FontOptions{},
-		fontMatrix:  Matrix{XX: 1, YY: 1}, // Identity matrix
+		fontOptions:   &FontOptions{},
+		fontMatrix:    Matrix{XX: 1, YY: 1}, // Identity matrix
+		matrix:        Matrix{XX: 1, YY: 1},
+		matrixInverse: Matrix{XX: 1, YY: 1},
 	}
 }
 
@@ -203,14 +276,25 @@ func (c *context) Reference() Context {
 
 func (c *context) Destroy() {
 	if atomic.AddInt32(&c.refCount, -1) == 0 {
+		// Released now, so the finalizer must not release it again
+		runtime.SetFinalizer(c, nil)
 		c.destroyConcrete()
 	}
 }
 
+// destroyConcrete releases the context's references, once: calling it
+// again does nothing.
 func (c *context) destroyConcrete() {
+	destroyUserData(c.userData)
+
 	if c.target != nil {
 		c.target.Destroy()
+		c.target = nil
+	}
+	for _, tc := range c.forwarded {
+		tc.Destroy()
 	}
+	c.forwarded = nil
 
 	// Clean up graphics state stack
 	for c.gstate != nil {
@@ -237,12 +321,16 @@ func (c *context) Status() Status {
 }
 
 // Target surface
+// GetTarget returns the surface the context was created for, even while a
+// group is pushed.
 func (c *context) GetTarget() Surface {
-	return c.target
+	target, _ := c.baseTarget()
+	return target
 }
 
+// GetGroupTarget returns the surface drawing currently goes to: the
+// innermost pushed group's surface, or the target when no group is pushed.
 func (c *context) GetGroupTarget() Surface {
-	// TODO: Implement group target tracking
 	return c.target
 }
 
@@ -252,16 +340,39 @@ func (c *context) SetUserData(key *UserDataKey, userData unsafe.Pointer, destroy
 		return c.status
 	}
 
-	c.userData[key] = userData
-	// TODO: Store destroy function and call it when appropriate
+	setUserData(c.userData, key, userData, destroy)
 	return StatusSuccess
 }
 
 func (c *context) GetUserData(key *UserDataKey) unsafe.Pointer {
-	if data, exists := c.userData[key]; exists {
-		return data.(unsafe.Pointer)
+	return getUserData(c.userData, key)
+}
+
+// SetUserDataAny attaches an arbitrary Go value to the context under key,
+// replacing data set with either SetUserData or SetUserDataAny. Storing nil
+// removes the key.
+func (c *context) SetUserDataAny(key *UserDataKey, v any) Status {
+	if c.status != StatusSuccess {
+		return c.status
 	}
-	return nil
+
+	setUserDataAny(c.userData, key, v)
+	return StatusSuccess
+}
+
+// GetUserDataAny returns the value set with SetUserDataAny, or nil.
+func (c *context) GetUserDataAny(key *UserDataKey) any {
+	return getUserDataAny(c.userData, key)
+}
+
+// setError puts the context in an error state, reported by Status. As in
+// cairo the first error sticks: later operations do nothing, and those
+// returning an error return one carrying the status.
+func (c *context) setError(status Status) error {
+	if c.status == StatusSuccess {
+		c.status = status
+	}
+	return newError(c.status, "")
 }
 
 // State management
@@ -272,21 +383,23 @@ func (c *context) Save() error {
 
 	// Create a copy of current state
 	newState := &graphicsState{
-		source:       c.gstate.source.Reference(),
-		operator:     c.gstate.operator,
-		tolerance:    c.gstate.tolerance,
-		antialias:    c.gstate.antialias,
-		fillRule:     c.gstate.fillRule,
-		lineWidth:    c.gstate.lineWidth,
-		lineCap:      c.gstate.lineCap,
-		lineJoin:     c.gstate.lineJoin,
-		miterLimit:   c.gstate.miterLimit,
-		matrix:       c.gstate.matrix,
-		fontMatrix:   c.gstate.fontMatrix,
-		fontOptions:  c.gstate.fontOptions, // TODO: Copy font options
-		clip:         c.gstate.clip,        // Clip is part of the graphics state
-		next:         c.gstate,
-		groupSurface: c.gstate.groupSurface, // Copy group surface reference
+		source:      c.gstate.source.Reference(),
+		operator:    c.gstate.operator,
+		tolerance:   c.gstate.tolerance,
+		antialias:   c.gstate.antialias,
+		fillRule:    c.gstate.fillRule,
+		lineWidth:   c.gstate.lineWidth,
+		lineCap:     c.gstate.lineCap,
+		lineJoin:    c.gstate.lineJoin,
+		miterLimit:  c.gstate.miterLimit,
+		matrix:      c.gstate.matrix,
+		fontMatrix:  c.gstate.fontMatrix,
+		fontOptions: c.gstate.fontOptions, // TODO: Copy font options
+		clip:        c.gstate.clip,        // Clip is part of the graphics state
+		next:        c.gstate,
+
+		matrixInverse:     c.gstate.matrixInverse,
+		missingGlyphStyle: c.gstate.missingGlyphStyle,
 	}
 
 	// Copy dash array
@@ -295,6 +408,7 @@ func (c *context) Save() error {
 		copy(newState.dash, c.gstate.dash)
 	}
 	newState.dashOffset = c.gstate.dashOffset
+	newState.dashContinuous = c.gstate.dashContinuous
 
 	// Reference font objects
 	if c.gstate.fontFace != nil {
@@ -314,8 +428,7 @@ func (c *context) Restore() error {
 	}
 
 	if c.gstate.next == nil {
-		c.status = StatusInvalidRestore
-		return newError(StatusInvalidRestore, "")
+		return c.setError(StatusInvalidRestore)
 	}
 
 	// Release current state resources
@@ -333,6 +446,7 @@ func (c *context) Restore() error {
 	oldState := c.gstate
 	c.gstate = oldState.next
 	oldState.next = nil
+	c.rebasePath(&oldState.matrix)
 
 	// If the old state was a group, restore the target and gc
 	if oldState.groupSurface != nil {
@@ -355,6 +469,14 @@ func (c *context) SetSource(source Pattern) {
 	if c.status != StatusSuccess {
 		return
 	}
+	if source == nil {
+		c.setError(StatusNullPointer)
+		return
+	}
+	if source.Status() != StatusSuccess {
+		c.setError(source.Status())
+		return
+	}
 
 	if c.gstate.source != nil {
 		c.gstate.source.Destroy()
@@ -372,7 +494,16 @@ func (c *context) SetSourceRGBA(red, green, blue, alpha float64) {
 	pattern.Destroy()
 }
 
+// SetSourceSurface sets the source to surface with its origin at (x, y).
+// The source is a snapshot of an image surface's current contents, so later
+// drawing to the surface, for example when it is the target as well, does
+// not change the source.
 func (c *context) SetSourceSurface(surface Surface, x, y float64) {
+	if img, ok := surface.(*imageSurface); ok && img.status == StatusSuccess && !img.finished {
+		snapshot := img.sourceSnapshot()
+		defer snapshot.Destroy()
+		surface = snapshot
+	}
 	pattern := NewPatternForSurface(surface)
 	matrix := NewMatrix()
 	// Pattern 矩阵是从用户空间到 pattern 空间的变换
@@ -483,11 +614,27 @@ func (c *context) GetLineJoin() LineJoin {
 	return c.gstate.lineJoin
 }
 
+// SetDash sets the dash pattern used by Stroke. Like cairo_set_dash, a
+// negative dash length or a pattern of only zero lengths puts the context
+// in error with StatusInvalidDash.
 func (c *context) SetDash(dashes []float64, offset float64) {
 	if c.status != StatusSuccess {
 		return
 	}
 
+	total := 0.0
+	for _, d := range dashes {
+		if d < 0 {
+			c.setError(StatusInvalidDash)
+			return
+		}
+		total += d
+	}
+	if len(dashes) > 0 && total == 0 {
+		c.setError(StatusInvalidDash)
+		return
+	}
+
 	c.gstate.dash = make([]float64, len(dashes))
 	copy(c.gstate.dash, dashes)
 	c.gstate.dashOffset = offset
@@ -504,6 +651,23 @@ func (c *context) GetDash() (dashes []float64, offset float64) {
 	return
 }
 
+// SetDashResetPerSubpath sets whether Stroke restarts the dash pattern, at
+// the dash offset, at the start of each subpath. That is the default, as
+// in cairo; with false the dashes run on from the end of one subpath into
+// the next as if the subpaths were joined, keeping e.g. the lines of a
+// dashed grid in phase with each other.
+func (c *context) SetDashResetPerSubpath(reset bool) {
+	if c.status != StatusSuccess {
+		return
+	}
+	c.gstate.dashContinuous = !reset
+}
+
+// GetDashResetPerSubpath returns the setting of SetDashResetPerSubpath.
+func (c *context) GetDashResetPerSubpath() bool {
+	return !c.gstate.dashContinuous
+}
+
 func (c *context) SetMiterLimit(limit float64) {
 	if c.status != StatusSuccess {
 		return
@@ -546,20 +710,59 @@ func (c *context) Rotate(angle float64) {
 	c.Transform(matrix)
 }
 
+// Transform modifies the CTM by applying matrix in user space, as
+// cairo_transform does: matrix is applied to user coordinates first, then
+// the existing CTM (CTM' = matrix * CTM).
 func (c *context) Transform(matrix *Matrix) {
 	if c.status != StatusSuccess {
 		return
 	}
 
 	// Multiply current matrix with the transformation matrix
-	MatrixMultiply(&c.gstate.matrix, matrix, &c.gstate.matrix)
+	var m Matrix
+	MatrixMultiply(&m, matrix, &c.gstate.matrix)
+	c.setMatrix(m)
+}
+
+// TransformPre modifies the CTM by applying matrix in device space: the
+// existing CTM is applied first, then matrix (CTM' = CTM * matrix). This is
+// the pre-multiply convention used by some other graphics APIs.
+func (c *context) TransformPre(matrix *Matrix) {
+	if c.status != StatusSuccess {
+		return
+	}
+
+	var m Matrix
+	MatrixMultiply(&m, &c.gstate.matrix, matrix)
+	c.setMatrix(m)
 }
 
 func (c *context) SetMatrix(matrix *Matrix) {
 	if c.status != StatusSuccess {
 		return
 	}
-	c.gstate.matrix = *matrix
+	c.setMatrix(*matrix)
+}
+
+// setMatrix replaces the CTM, rebasing the current path. A CTM that cannot
+// be inverted puts the context in error with StatusInvalidMatrix, as in
+// cairo, and leaves the CTM unchanged.
+func (c *context) setMatrix(m Matrix) {
+	inv := m
+	if MatrixInvert(&inv) != StatusSuccess {
+		c.setError(StatusInvalidMatrix)
+		return
+	}
+	old := c.gstate.matrix
+	c.gstate.matrix = m
+	c.gstate.matrixInverse = inv
+	c.rebasePath(&old)
+}
+
+// matrixInvertible reports whether MatrixInvert would succeed on m.
+func matrixInvertible(m *Matrix) bool {
+	inv := *m
+	return MatrixInvert(&inv) == StatusSuccess
 }
 
 func (c *context) GetMatrix() *Matrix {
@@ -572,40 +775,65 @@ func (c *context) IdentityMatrix() {
 	if c.status != StatusSuccess {
 		return
 	}
+	old := c.gstate.matrix
 	c.gstate.matrix.InitIdentity()
+	c.gstate.matrixInverse.InitIdentity()
+	c.rebasePath(&old)
+}
+
+// rebasePath keeps the current path and current point fixed in device space
+// when the CTM changes from old to the current matrix. The path is stored in
+// user coordinates and transformed at fill time, so without this a path built
+// before Translate/Scale/Rotate would move with the new CTM, whereas cairo
+// fixes path points in device space as they are added.
+func (c *context) rebasePath(old *Matrix) {
+	if len(c.path.ops) == 0 && !c.currentPoint.hasPoint {
+		return
+	}
+	if *old == c.gstate.matrix {
+		return
+	}
+
+	var m Matrix
+	MatrixMultiply(&m, old, &c.gstate.matrixInverse)
+
+	// Clip regions keep copies of their paths, so the points can be
+	// transformed in place
+	for i, p := range c.path.pts {
+		c.path.pts[i].x, c.path.pts[i].y = MatrixTransformPoint(&m, p.x, p.y)
+	}
+	c.path.subpathStartX, c.path.subpathStartY = MatrixTransformPoint(&m, c.path.subpathStartX, c.path.subpathStartY)
+	c.currentPoint.x, c.currentPoint.y = MatrixTransformPoint(&m, c.currentPoint.x, c.currentPoint.y)
 }
 
 // Coordinate transformations
+
+// UserToDevice maps a point from user space to device space, through the
+// CTM and the target surface's device scale and offset.
 func (c *context) UserToDevice(x, y float64) (float64, float64) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	return MatrixTransformPoint(&c.gstate.matrix, x, y)
+	m := c.deviceMatrix()
+	return MatrixTransformPoint(&m, x, y)
 }
 
+// UserToDeviceDistance is UserToDevice for a distance, which the device
+// offset does not change.
 func (c *context) UserToDeviceDistance(dx, dy float64) (float64, float64) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	return MatrixTransformDistance(&c.gstate.matrix, dx, dy)
+	m := c.deviceMatrix()
+	return MatrixTransformDistance(&m, dx, dy)
 }
 
+// DeviceToUser maps a point from device space to user space, undoing the
+// target surface's device scale and offset and then the CTM.
 func (c *context) DeviceToUser(x, y float64) (float64, float64) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	matrix := c.gstate.matrix
-	if MatrixInvert(&matrix) != StatusSuccess {
-		return x, y
-	}
-	return MatrixTransformPoint(&matrix, x, y)
+	m := c.deviceMatrixInverse()
+	return MatrixTransformPoint(&m, x, y)
 }
 
+// DeviceToUserDistance is DeviceToUser for a distance, which the device
+// offset does not change.
 func (c *context) DeviceToUserDistance(dx, dy float64) (float64, float64) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	matrix := c.gstate.matrix
-	if MatrixInvert(&matrix) != StatusSuccess {
-		return dx, dy
-	}
-	return MatrixTransformDistance(&matrix, dx, dy)
+	m := c.deviceMatrixInverse()
+	return MatrixTransformDistance(&m, dx, dy)
 }
 
 // Current point
@@ -623,14 +851,38 @@ func (c *context) GetCurrentPoint() (x, y float64) {
 	return 0, 0
 }
 
+// SetCurrentPoint sets the current point without emitting a path operation.
+// Unlike MoveTo, no new subpath is started in the path data, which makes it
+// useful for resuming relative drawing after an operation cleared the point.
+func (c *context) SetCurrentPoint(x, y float64) {
+	if c.status != StatusSuccess {
+		return
+	}
+	c.currentPoint.x = x
+	c.currentPoint.y = y
+	c.currentPoint.hasPoint = true
+	c.currentPoint.pending = true
+}
+
+// emitPendingMoveTo materializes a current point set by SetCurrentPoint as
+// the start of a new subpath.
+func (c *context) emitPendingMoveTo() {
+	if !c.currentPoint.pending {
+		return
+	}
+	c.MoveTo(c.currentPoint.x, c.currentPoint.y)
+}
+
 // Path creation
 func (c *context) NewPath() {
 	if c.status != StatusSuccess {
 		return
 	}
 
-	c.path.data = c.path.data[:0]
+	c.path.ops = c.path.ops[:0]
+	c.path.pts = c.path.pts[:0]
 	c.currentPoint.hasPoint = false
+	c.currentPoint.pending = false
 }
 
 func (c *context) MoveTo(x, y float64) {
@@ -638,14 +890,11 @@ func (c *context) MoveTo(x, y float64) {
 		return
 	}
 
-	op := pathOp{
-		op:     PathMoveTo,
-		points: []point{{x, y}},
-	}
-	c.path.data = append(c.path.data, op)
+	c.path.add(PathMoveTo, point{x, y})
 	c.currentPoint.x = x
 	c.currentPoint.y = y
 	c.currentPoint.hasPoint = true
+	c.currentPoint.pending = false
 	c.path.subpathStartX = x
 	c.path.subpathStartY = y
 }
@@ -653,6 +902,7 @@ func (c *context) MoveTo(x, y float64) {
 func (c *context) NewSubPath() {
 	// Just clear current point without adding to path
 	c.currentPoint.hasPoint = false
+	c.currentPoint.pending = false
 }
 
 func (c *context) LineTo(x, y float64) {
@@ -664,12 +914,9 @@ func (c *context) LineTo(x, y float64) {
 		c.MoveTo(x, y)
 		return
 	}
+	c.emitPendingMoveTo()
 
-	op := pathOp{
-		op:     PathLineTo,
-		points: []point{{x, y}},
-	}
-	c.path.data = append(c.path.data, op)
+	c.path.add(PathLineTo, point{x, y})
 	c.currentPoint.x = x
 	c.currentPoint.y = y
 }
@@ -682,30 +929,62 @@ func (c *context) CurveTo(x1, y1, x2, y2, x3, y3 float64) {
 	if !c.currentPoint.hasPoint {
 		c.MoveTo(x1, y1)
 	}
+	c.emitPendingMoveTo()
 
-	op := pathOp{
-		op:     PathCurveTo,
-		points: []point{{x1, y1}, {x2, y2}, {x3, y3}},
-	}
-	c.path.data = append(c.path.data, op)
+	c.path.add(PathCurveTo, point{x1, y1}, point{x2, y2}, point{x3, y3})
 	c.currentPoint.x = x3
 	c.currentPoint.y = y3
 }
 
+// PolyLine adds a line to each of pts in turn, as LineTo does, without the
+// per-call overhead, for plotting long series. Without a current point the
+// first point starts a new subpath.
+func (c *context) PolyLine(pts []Point) {
+	if c.status != StatusSuccess || len(pts) == 0 {
+		return
+	}
+
+	if !c.currentPoint.hasPoint {
+		c.MoveTo(pts[0].X, pts[0].Y)
+		pts = pts[1:]
+		if len(pts) == 0 {
+			return
+		}
+	}
+	c.emitPendingMoveTo()
+
+	c.path.ops = slices.Grow(c.path.ops, len(pts))
+	c.path.pts = slices.Grow(c.path.pts, len(pts))
+	for _, p := range pts {
+		c.path.ops = append(c.path.ops, PathLineTo)
+		c.path.pts = append(c.path.pts, point{p.X, p.Y})
+	}
+	last := pts[len(pts)-1]
+	c.currentPoint.x = last.X
+	c.currentPoint.y = last.Y
+}
+
+// Polygon adds a closed subpath through pts: a move to the first point,
+// lines to the others and a ClosePath.
+func (c *context) Polygon(pts []Point) {
+	if c.status != StatusSuccess || len(pts) == 0 {
+		return
+	}
+	c.MoveTo(pts[0].X, pts[0].Y)
+	c.PolyLine(pts[1:])
+	c.ClosePath()
+}
+
 func (c *context) ClosePath() {
 	if c.status != StatusSuccess {
 		return
 	}
 
-	if len(c.path.data) == 0 {
+	if len(c.path.ops) == 0 {
 		return
 	}
 
-	op := pathOp{
-		op:     PathClosePath,
-		points: []point{},
-	}
-	c.path.data = append(c.path.data, op)
+	c.path.add(PathClosePath)
 	c.currentPoint.x = c.path.subpathStartX
 	c.currentPoint.y = c.path.subpathStartY
 }
@@ -717,31 +996,55 @@ func (c *context) applyPathToPango() {
 	}
 
 	c.gc.BeginPath()
-	opCount := 0
-	for _, op := range c.path.data {
-		switch op.op {
+	c.path.forEach(func(op PathDataType, pts []point) {
+		switch op {
 		case PathMoveTo:
-			p := op.points[0]
+			p := pts[0]
 			c.gc.MoveTo(p.x, p.y)
-			opCount++
 		case PathLineTo:
-			p := op.points[0]
+			p := pts[0]
 			c.gc.LineTo(p.x, p.y)
-			opCount++
 		case PathCurveTo:
-			p1 := op.points[0]
-			p2 := op.points[1]
-			p3 := op.points[2]
+			p1 := pts[0]
+			p2 := pts[1]
+			p3 := pts[2]
 			c.gc.CubicCurveTo(p1.x, p1.y, p2.x, p2.y, p3.x, p3.y)
-			opCount++
 		case PathClosePath:
 			c.gc.Close()
-			opCount++
 		}
+	})
+}
+
+// deviceMatrix returns the CTM composed with the target surface's device
+// scale and offset, mapping user space to surface pixels.
+func (c *context) deviceMatrix() Matrix {
+	m := c.gstate.matrix
+	d := c.deviceTransform()
+	MatrixMultiply(&m, &m, &d)
+	return m
+}
+
+// deviceTransform returns the target surface's device scale and offset as
+// a matrix.
+func (c *context) deviceTransform() Matrix {
+	if c.target == nil {
+		return Matrix{XX: 1, YY: 1}
 	}
-	if opCount > 0 {
-		fmt.Printf("[DEBUG applyPathToPango] Applied %d path operations\n", opCount)
+	sx, sy := c.target.GetDeviceScale()
+	ox, oy := c.target.GetDeviceOffset()
+	return Matrix{XX: sx, YY: sy, X0: ox, Y0: oy}
+}
+
+// deviceMatrixInverse returns the inverse of deviceMatrix, mapping surface
+// pixels to user space. A zero device scale can't be undone and is skipped.
+func (c *context) deviceMatrixInverse() Matrix {
+	m := c.gstate.matrixInverse
+	d := c.deviceTransform()
+	if d.XX != 0 && d.YY != 0 {
+		inv := Matrix{XX: 1 / d.XX, YY: 1 / d.YY, X0: -d.X0 / d.XX, Y0: -d.Y0 / d.YY}
+		MatrixMultiply(&m, &inv, &m)
 	}
+	return m
 }
 
 // Helper to apply cairo state to raster context
@@ -750,14 +1053,30 @@ func (c *context) applyStateToPango() {
 		return
 	}
 
-	// Line properties
-	c.gc.SetLineWidth(c.gstate.lineWidth)
+	// Drawing follows: snapshots sharing the target's pixels get their own.
+	// A snapshot drawn to gets pixels of its own too, which the raster
+	// context must draw into.
+	if img, ok := c.target.(*imageSurface); ok {
+		img.detachSnapshots()
+		c.gc.setImage(img.goImage)
+	}
+
+	// Line properties. The line width is in user space, so it is scaled by
+	// the CTM; the raster strokes in device pixels.
+	m := c.deviceMatrix()
+	c.gc.SetLineWidth(c.gstate.lineWidth * math.Sqrt(math.Abs(m.XX*m.YY-m.XY*m.YX)))
 	c.gc.SetLineCap(c.gstate.lineCap)
 	c.gc.SetLineJoin(c.gstate.lineJoin)
 	c.gc.SetLineDash(c.gstate.dash, c.gstate.dashOffset)
+	c.gc.SetDashContinuous(c.gstate.dashContinuous)
+	c.gc.SetAntialias(c.gstate.antialias)
+	c.gc.SetTolerance(c.gstate.tolerance)
+	c.gc.SetFillRule(c.gstate.fillRule)
+	c.gc.SetOperator(c.gstate.operator)
+	c.gc.SetSubpixelOrder(c.textSubpixelOrder)
+	c.gc.SetClip(c.clipCoverage())
 
 	// Transformation matrix
-	m := c.gstate.matrix
 	c.gc.SetMatrixTransform([6]float64{
 		m.XX, m.YX,
 		m.XY, m.YY,
@@ -820,6 +1139,10 @@ func (c *context) applyStateToPango() {
 		}
 		// Apply the blend function to the source color before setting it
 		blendedColor := cairoBlendColor(fillColor, c.gstate.operator)
+		if c.gc.fimg != nil && blendedColor == color.Color(fillColor) {
+			// Float targets get the source color without 8-bit rounding
+			blendedColor = floatColor{R: r, G: g, B: b, A: a}
+		}
 		c.gc.SetFillColor(blendedColor)
 		c.gc.SetStrokeColor(blendedColor)
 
@@ -841,55 +1164,136 @@ func (c *context) PushGroupWithContent(content Content) {
 		return
 	}
 
-	// 1. Save current state
-	c.Save()
-
-	// 2. Create a new temporary ImageSurface as the new target
-	// We use the current target's dimensions for the temporary surface.
-	imgSurface, ok := c.target.(ImageSurface)
-	if !ok {
+	// Groups are rendered into a temporary ARGB32 image surface the size of
+	// the current raster target, so any backend with a raster context works.
+	if c.gc == nil {
 		c.status = StatusSurfaceTypeMismatch
 		return
 	}
+	bounds := c.gc.bounds()
+	newSurface := NewImageSurface(FormatARGB32, bounds.Dx(), bounds.Dy())
+	goImage, ok := newSurface.(ImageSurface).GetGoImage().(*image.RGBA)
+	if !ok {
+		newSurface.Destroy()
+		c.status = StatusNoMemory
+		return
+	}
 
-	newSurface := NewImageSurface(FormatARGB32, imgSurface.GetWidth(), imgSurface.GetHeight())
+	// Save the current state; the saved state remembers the original target
+	// and raster context so that Restore (via PopGroup) can switch back.
+	c.Save()
+	c.gstate.groupSurface = &GroupSurface{
+		Surface:        newSurface,
+		originalTarget: c.target,
+		originalGC:     c.gc,
+	}
 
-	// 3. Create a new context for the new surface
-	newCtx := NewContext(newSurface)
+	// Match the target's device transform so the group renders at full
+	// resolution and lines up with the target's pixels
+	newSurface.SetDeviceScale(c.target.GetDeviceScale())
+	newSurface.SetDeviceOffset(c.target.GetDeviceOffset())
 
-	// 4. Replace current context's target and gc with the new one
 	c.target = newSurface
-	if ctxImpl, ok := newCtx.(*context); ok {
-		c.gc = ctxImpl.gc
-	}
-
-	// 5. Store the old target and gc in the saved state (for PopGroup)
-	// We'll use the gstate.next to store the old target/gc temporarily.
-	// This is a simplification and not a true cairo group implementation.
-	// A proper implementation would require a dedicated group stack.
-	// For now, we'll just rely on the Save/Restore mechanism.
+	c.gc = newRasterContext(goImage)
 }
 
 func (c *context) PopGroup() Pattern {
 	if c.status != StatusSuccess {
 		return newPatternInError(c.status)
 	}
+	if c.gstate.groupSurface == nil {
+		c.status = StatusInvalidPopGroup
+		return newPatternInError(c.status)
+	}
 
-	// 1. Get the current target (which is the group surface)
-	groupSurface := c.target
+	// 1. Keep the group surface alive across Restore
+	groupSurface := c.target.Reference()
 
 	// 2. Restore the previous state (which restores the old target and gc)
 	c.Restore()
 
-	// 3. Create a SurfacePattern from the group surface
+	// 3. Create a SurfacePattern from the group surface. The group was
+	// rendered in device pixels, so map user space back onto it with the CTM
+	// and device transform.
 	pattern := NewPatternForSurface(groupSurface)
+	m := c.deviceMatrix()
+	pattern.SetMatrix(&m)
 
-	// 4. Destroy the group surface (since the pattern holds a reference)
+	// 4. Drop our reference (the pattern holds its own)
 	groupSurface.Destroy()
 
 	return pattern
 }
 
+// PushOpacityGroup starts a transparency group. Everything drawn until the
+// matching PopOpacityGroup is composited as a single layer, so overlapping
+// shapes inside the group do not accumulate opacity.
+//
+// The group is rendered to an image, as PushGroup's are. The PDF surface
+// rasterizes its pages, so a PDF holds the composited pixels rather than a
+// PDF transparency group.
+func (c *context) PushOpacityGroup() {
+	c.PushGroup()
+}
+
+// PopOpacityGroup ends a group started with PushOpacityGroup and paints it
+// onto the previous target with the given group opacity.
+func (c *context) PopOpacityGroup(alpha float64) {
+	if c.status != StatusSuccess {
+		return
+	}
+
+	pattern := c.PopGroup()
+	defer pattern.Destroy()
+	if pattern.Status() != StatusSuccess {
+		return
+	}
+
+	c.Save()
+	c.SetSource(pattern)
+	c.PaintWithAlpha(alpha)
+	c.Restore()
+}
+
+// PaintGroupWithFilter ends a group started with PushGroup, runs filter on
+// the group's image surface and paints the result onto the previous target
+// with the current operator. This is the usual way to build effects such as
+// drop shadows and glows:
+//
+//	ctx.PushGroup()
+//	// draw the shape casting the shadow
+//	ctx.PaintGroupWithFilter(func(img ImageSurface) { img.BlurGaussian(4) })
+//	// draw the shape itself on top
+//
+// filter may modify the pixels in place; a nil filter paints the group as
+// is.
+func (c *context) PaintGroupWithFilter(filter func(ImageSurface)) {
+	if c.status != StatusSuccess {
+		return
+	}
+	if c.gstate.groupSurface == nil {
+		c.setError(StatusInvalidPopGroup)
+		return
+	}
+
+	group := c.target.Reference()
+	defer group.Destroy()
+	pattern := c.PopGroup()
+	defer pattern.Destroy()
+	if pattern.Status() != StatusSuccess {
+		return
+	}
+
+	if img, ok := group.(ImageSurface); ok && filter != nil {
+		filter(img)
+	}
+
+	c.Save()
+	c.SetSource(pattern)
+	c.Paint()
+	c.Restore()
+}
+
 func (c *context) PopGroupToSource() {
 	if c.status != StatusSuccess {
 		return
@@ -900,40 +1304,109 @@ func (c *context) PopGroupToSource() {
 	pattern.Destroy()
 }
 
+// forwardingSurface is implemented by surfaces whose drawing is replayed
+// to other surfaces, the first of which is the primary target.
+type forwardingSurface interface {
+	Surface
+	forwardTargets() []Surface
+}
+
+// forwardDrawing runs a drawing operation on a context for each target when
+// the context draws to a tee or observer surface, sharing the current state
+// and path. It reports whether the operation was forwarded; the returned
+// error is the primary target's. Observers are notified of kind and the
+// time the operation took.
+func (c *context) forwardDrawing(kind ObserverCallbackType, op func(t *context) error) (bool, error) {
+	fs, ok := c.target.(forwardingSurface)
+	if !ok {
+		return false, nil
+	}
+
+	start := time.Now()
+	var err error
+	for i, tc := range c.forwardContexts(fs.forwardTargets()) {
+		gstate, path := tc.gstate, tc.path
+		tc.gstate, tc.path, tc.currentPoint = c.gstate, c.path, c.currentPoint
+		e := op(tc)
+		// The state stays owned by c
+		tc.gstate, tc.path = gstate, path
+		if i == 0 {
+			err = e
+			c.currentPoint = tc.currentPoint
+			if tc.status != StatusSuccess {
+				c.status = tc.status
+			}
+		}
+	}
+
+	if obs, ok := fs.(*observerSurface); ok {
+		obs.notify(kind, time.Since(start))
+	}
+	return true, err
+}
+
+// forwardContexts returns a context for each of targets, in order. Contexts
+// are created as targets are added and dropped once they are removed.
+func (c *context) forwardContexts(targets []Surface) []*context {
+	contexts := make([]*context, len(targets))
+	kept := make(map[Surface]*context, len(targets))
+	for i, target := range targets {
+		tc, ok := c.forwarded[target]
+		if !ok {
+			tc = NewContext(target).(*context)
+		}
+		contexts[i] = tc
+		kept[target] = tc
+	}
+	for target, tc := range c.forwarded {
+		if _, ok := kept[target]; !ok {
+			tc.Destroy()
+		}
+	}
+	c.forwarded = kept
+	return contexts
+}
+
 func (c *context) Paint() error {
 	if c.status != StatusSuccess || c.gc == nil {
 		return newError(c.status, "")
 	}
+	if forwarded, err := c.forwardDrawing(ObserverPaint, func(t *context) error { return t.Paint() }); forwarded {
+		return err
+	}
+	if traced, err := c.traceDrawing("paint", nil); traced {
+		return err
+	}
 
 	c.applyStateToPango()
 
-	// Cairo's paint is equivalent to filling the current clip region with the source pattern.
-	// If there's a clip region, use it; otherwise fill the entire surface.
-
-	if c.gstate.clip != nil && c.gstate.clip.path != nil {
-		// Use the clip path
-		fmt.Printf("[Paint] Using clip path, data length: %d\n", len(c.gstate.clip.path.data))
-		savedPath := c.path
-		c.path = c.gstate.clip.path
-		c.applyPathToPango()
-		c.gc.Fill()
-		c.path = savedPath
-	} else {
-		fmt.Println("[Paint] No clip path, filling entire surface")
-		// Fill the entire surface
-		if imgSurface, ok := c.target.(ImageSurface); ok {
-			width := float64(imgSurface.GetWidth())
-			height := float64(imgSurface.GetHeight())
-
-			c.gc.BeginPath()
-			c.gc.MoveTo(0, 0)
-			c.gc.LineTo(width, 0)
-			c.gc.LineTo(width, height)
-			c.gc.LineTo(0, height)
-			c.gc.Close()
-			c.gc.Fill()
+	// Cairo's paint is equivalent to filling the current clip region with
+	// the source pattern. The raster context applies the clip, so fill the
+	// entire raster target (vector backends paint into their raster context
+	// as well). The raster path is in user space, so map the target's
+	// corners back through the CTM.
+	inv := c.deviceMatrix()
+	if MatrixInvert(&inv) != StatusSuccess {
+		return nil
+	}
+	bounds := c.gc.bounds()
+	corners := [4][2]float64{
+		{float64(bounds.Min.X), float64(bounds.Min.Y)},
+		{float64(bounds.Max.X), float64(bounds.Min.Y)},
+		{float64(bounds.Max.X), float64(bounds.Max.Y)},
+		{float64(bounds.Min.X), float64(bounds.Max.Y)},
+	}
+	c.gc.BeginPath()
+	for i, pt := range corners {
+		x, y := MatrixTransformPoint(&inv, pt[0], pt[1])
+		if i == 0 {
+			c.gc.MoveTo(x, y)
+		} else {
+			c.gc.LineTo(x, y)
 		}
 	}
+	c.gc.Close()
+	c.gc.Fill()
 	return nil
 }
 
@@ -941,29 +1414,33 @@ func (c *context) PaintWithAlpha(alpha float64) error {
 	if c.status != StatusSuccess || c.gc == nil {
 		return newError(c.status, "")
 	}
-
-	// 1. Save current state
-	if err := c.Save(); err != nil {
+	if forwarded, err := c.forwardDrawing(ObserverPaint, func(t *context) error { return t.PaintWithAlpha(alpha) }); forwarded {
 		return err
 	}
-
-	// 2. Modify the source pattern's alpha (if possible)
-	// This is a simplification. Cairo creates a new pattern with the alpha applied.
-	// Note: Pango doesn't have SetGlobalAlpha method, so we skip this for now
-
-	// 3. Perform the paint operation
-	if err := c.Paint(); err != nil {
+	if traced, err := c.traceDrawing("paint-with-alpha "+formatScriptFloat(alpha), nil); traced {
 		return err
 	}
 
-	// 4. Restore the state (which restores the original alpha)
-	return c.Restore()
+	if alpha >= 1 {
+		return c.Paint()
+	}
+
+	// Scale the coverage of every composited pixel by alpha for this paint
+	c.gc.SetGlobalAlpha(math.Max(alpha, 0))
+	defer c.gc.SetGlobalAlpha(1)
+	return c.Paint()
 }
 
 func (c *context) Mask(pattern Pattern) {
 	if c.status != StatusSuccess {
 		return
 	}
+	if forwarded, _ := c.forwardDrawing(ObserverMask, func(t *context) error { t.Mask(pattern); return nil }); forwarded {
+		return
+	}
+	if traced, _ := c.traceDrawing("mask", pattern); traced {
+		return
+	}
 	// TODO: Implement mask operation
 }
 
@@ -984,11 +1461,39 @@ func (c *context) MaskSurface(surface Surface, surfaceX, surfaceY float64) {
 	pattern.Destroy()
 }
 
+// Page operations
+
+// ShowPage emits the current page of the target surface and starts a new,
+// blank one. It has no effect on surfaces without pages.
+func (c *context) ShowPage() {
+	if c.status != StatusSuccess {
+		return
+	}
+	c.target.ShowPage()
+}
+
+// CopyPage emits the current page of the target surface and keeps its
+// content for the next page. It has no effect on surfaces without pages.
+func (c *context) CopyPage() {
+	if c.status != StatusSuccess {
+		return
+	}
+	c.target.CopyPage()
+}
+
 // Path operations
 func (c *context) Stroke() error {
 	if c.status != StatusSuccess || c.gc == nil {
 		return newError(c.status, "")
 	}
+	if forwarded, err := c.forwardDrawing(ObserverStroke, func(t *context) error { return t.StrokePreserve() }); forwarded {
+		c.NewPath()
+		return err
+	}
+	if traced, err := c.traceDrawing("stroke", nil); traced {
+		c.NewPath()
+		return err
+	}
 
 	c.applyStateToPango()
 	c.applyPathToPango()
@@ -1001,6 +1506,12 @@ func (c *context) StrokePreserve() error {
 	if c.status != StatusSuccess || c.gc == nil {
 		return newError(c.status, "")
 	}
+	if forwarded, err := c.forwardDrawing(ObserverStroke, func(t *context) error { return t.StrokePreserve() }); forwarded {
+		return err
+	}
+	if traced, err := c.traceDrawing("stroke", nil); traced {
+		return err
+	}
 
 	c.applyStateToPango()
 	c.applyPathToPango()
@@ -1012,6 +1523,14 @@ func (c *context) Fill() error {
 	if c.status != StatusSuccess || c.gc == nil {
 		return newError(c.status, "")
 	}
+	if forwarded, err := c.forwardDrawing(ObserverFill, func(t *context) error { return t.FillPreserve() }); forwarded {
+		c.NewPath()
+		return err
+	}
+	if traced, err := c.traceDrawing("fill", nil); traced {
+		c.NewPath()
+		return err
+	}
 
 	c.applyStateToPango()
 	c.applyPathToPango()
@@ -1024,6 +1543,12 @@ func (c *context) FillPreserve() error {
 	if c.status != StatusSuccess || c.gc == nil {
 		return newError(c.status, "")
 	}
+	if forwarded, err := c.forwardDrawing(ObserverFill, func(t *context) error { return t.FillPreserve() }); forwarded {
+		return err
+	}
+	if traced, err := c.traceDrawing("fill", nil); traced {
+		return err
+	}
 
 	c.applyStateToPango()
 	c.applyPathToPango()
@@ -1031,6 +1556,54 @@ func (c *context) FillPreserve() error {
 	return nil
 }
 
+// ResetDamage empties the damage box returned by GetDamage.
+func (c *context) ResetDamage() {
+	if gc := c.damageGC(); gc != nil {
+		gc.resetDamage()
+	}
+}
+
+// GetDamage returns the device-space bounding box of the target pixels
+// changed by fills, strokes, paints and glyphs since the context was
+// created or ResetDamage was last called, for redrawing only that region.
+// Drawing inside a group counts once the group is painted to the target.
+func (c *context) GetDamage() RectangleInt {
+	gc := c.damageGC()
+	if gc == nil {
+		return RectangleInt{}
+	}
+	d := gc.damage
+	return RectangleInt{X: d.Min.X, Y: d.Min.Y, Width: d.Dx(), Height: d.Dy()}
+}
+
+// baseTarget returns the context's target and its raster context as they
+// were before any group was pushed.
+func (c *context) baseTarget() (Surface, *rasterContext) {
+	target, gc := c.target, c.gc
+	for gs := c.gstate; gs != nil; gs = gs.next {
+		if gs.groupSurface != nil {
+			target, gc = gs.groupSurface.originalTarget, gs.groupSurface.originalGC
+		}
+	}
+	return target, gc
+}
+
+// damageGC returns the raster context drawing to the context's target: the
+// one in use before any group was pushed, or for tee and observer surfaces
+// the one drawing to the primary target.
+func (c *context) damageGC() *rasterContext {
+	target, gc := c.baseTarget()
+	if fs, ok := target.(forwardingSurface); ok {
+		if targets := fs.forwardTargets(); len(targets) > 0 {
+			if tc, ok := c.forwarded[targets[0]]; ok {
+				return tc.damageGC()
+			}
+			return nil
+		}
+	}
+	return gc
+}
+
 // Arc implementation using Bezier curves
 func (c *context) Arc(xc, yc, radius, angle1, angle2 float64) {
 	if c.status != StatusSuccess {
@@ -1053,48 +1626,71 @@ func (c *context) Arc(xc, yc, radius, angle1, angle2 float64) {
 		return
 	}
 
-	// Calculate number of segments needed for smooth curve
-	dAngle := angle2 - angle1
-	segments := int(math.Ceil(math.Abs(dAngle) / (math.Pi / 2)))
+	c.ellipticalArc(xc, yc, radius, radius, 0, angle1, angle2-angle1)
+}
 
-	// Start point
-	x1 := xc + radius*math.Cos(angle1)
-	y1 := yc + radius*math.Sin(angle1)
+// arcSegments returns how many Bézier segments an arc of the given radius
+// and sweep needs so it deviates from the true circle by no more than the
+// tolerance in device space. Like cairo, it never uses segments longer than
+// a quarter circle.
+func (c *context) arcSegments(radius, dAngle float64) int {
+	segments := int(math.Ceil(math.Abs(dAngle) / (math.Pi / 2)))
 
-	// If no current point, move to start
-	if !c.currentPoint.hasPoint {
-		c.MoveTo(x1, y1)
-	} else {
-		// Otherwise line to start
-		c.LineTo(x1, y1)
+	// Radius of the circle along the major axis once in device space
+	major := radius * c.deviceScale()
+	if major <= 0 || c.gstate.tolerance <= 0 {
+		return segments
 	}
 
-	// Draw segments
-	for i := 1; i <= segments; i++ {
-		a1 := angle1 + float64(i-1)*dAngle/float64(segments)
-		a2 := angle1 + float64(i)*dAngle/float64(segments)
-
-		// Calculate control points for Bezier curve
-		ca := math.Cos(a1)
-		sa := math.Sin(a1)
-		cb := math.Cos(a2)
-		sb := math.Sin(a2)
+	// Find the largest angle π/n whose error is within the tolerance
+	tolerance := c.gstate.tolerance / major
+	maxAngle := math.Pi / 2
+	for n := 2; n < 1000; n++ {
+		maxAngle = math.Pi / float64(n)
+		if arcError(maxAngle) <= tolerance {
+			break
+		}
+	}
+	if n := int(math.Ceil(math.Abs(dAngle) / maxAngle)); n > segments {
+		segments = n
+	}
+	return segments
+}
 
-		// Calculate Bezier control points using the standard formula
-		// for approximating circular arcs with cubic Bezier curves
-		// The magic constant is (4/3) * tan(θ/4) where θ is the arc angle
-		alpha := math.Sin(a2-a1) * (math.Sqrt(4+3*math.Tan((a2-a1)/2)*math.Tan((a2-a1)/2)) - 1) / 3
+// deviceScale returns the most the device matrix stretches a length, the
+// major radius of the unit circle once in device space.
+func (c *context) deviceScale() float64 {
+	m := c.deviceMatrix()
+	i := m.XX*m.XX + m.YX*m.YX
+	j := m.XY*m.XY + m.YY*m.YY
+	f := (i + j) / 2
+	g := (i - j) / 2
+	h := m.XX*m.XY + m.YX*m.YY
+	return math.Sqrt(f + math.Hypot(g, h))
+}
 
-		x2 := xc + radius*(ca-alpha*sa)
-		y2 := yc + radius*(sa+alpha*ca)
-		x3 := xc + radius*(cb+alpha*sb)
-		y3 := yc + radius*(sb-alpha*cb)
-		x4 := xc + radius*cb
-		y4 := yc + radius*sb
+// arcError returns the maximum distance between a unit circle arc of the
+// given angle and its Bézier approximation.
+func arcError(angle float64) float64 {
+	return 2.0 / 27.0 * math.Pow(math.Sin(angle/4), 6) / math.Pow(math.Cos(angle/4), 2)
+}
 
-		// Add Bezier curve
-		c.CurveTo(x2, y2, x3, y3, x4, y4)
+// arcStart begins an arc at (x1, y1). As in cairo, a current point is
+// joined to the start with a line, except that a full circle drawn right
+// after a MoveTo replaces that move: the circle starts a subpath of its own
+// instead of drawing a spoke from, say, its center.
+func (c *context) arcStart(x1, y1 float64, fullCircle bool) {
+	if !c.currentPoint.hasPoint {
+		c.MoveTo(x1, y1)
+		return
 	}
+	if n := len(c.path.ops); fullCircle && n > 0 && c.path.ops[n-1] == PathMoveTo {
+		c.path.ops = c.path.ops[:n-1]
+		c.path.pts = c.path.pts[:len(c.path.pts)-1]
+		c.MoveTo(x1, y1)
+		return
+	}
+	c.LineTo(x1, y1)
 }
 
 func (c *context) ArcNegative(xc, yc, radius, angle1, angle2 float64) {
@@ -1118,49 +1714,87 @@ func (c *context) ArcNegative(xc, yc, radius, angle1, angle2 float64) {
 		return
 	}
 
-	// Calculate number of segments needed for smooth curve
-	dAngle := angle2 - angle1
-	segments := int(math.Ceil(math.Abs(dAngle) / (math.Pi / 2)))
+	c.ellipticalArc(xc, yc, radius, radius, 0, angle1, angle2-angle1)
+}
 
-	// Start point
-	x1 := xc + radius*math.Cos(angle1)
-	y1 := yc + radius*math.Sin(angle1)
+// EllipticalArc adds an arc of the ellipse centered on (cx, cy) with radii
+// rx and ry, its axes rotated by rotation radians, from angle1 increasing to
+// angle2 like Arc. The angles are parametric: angle t is the point
+// (rx·cos t, ry·sin t) before the rotation, which is where Arc puts it when
+// called under Translate, Rotate and Scale by the radii. With rx equal to ry
+// and no rotation the arc is the same as Arc's.
+func (c *context) EllipticalArc(cx, cy, rx, ry, rotation, angle1, angle2 float64) {
+	if c.status != StatusSuccess {
+		return
+	}
 
-	// If no current point, move to start
-	if !c.currentPoint.hasPoint {
-		c.MoveTo(x1, y1)
+	// Handle degenerate cases
+	if rx <= 0 || ry <= 0 {
+		c.LineTo(cx, cy)
+		return
+	}
+
+	for angle2 < angle1 {
+		angle2 += 2 * math.Pi
+	}
+	if angle2 == angle1 {
+		return
+	}
+	c.ellipticalArc(cx, cy, rx, ry, rotation, angle1, angle2-angle1)
+}
+
+// ellipticalArc adds the arc of EllipticalArc starting at angle1 and
+// sweeping dAngle, which is negative for an arc in the negative direction;
+// Arc and ArcNegative add its circular case. Each segment is approximated
+// on the unit circle by a cubic Bézier curve, whose points are then scaled
+// by the radii, rotated and moved to the center.
+func (c *context) ellipticalArc(cx, cy, rx, ry, rotation, angle1, dAngle float64) {
+	x1, y1 := ellipsePoint(cx, cy, rx, ry, rotation, math.Cos(angle1), math.Sin(angle1))
+	fullCircle := math.Remainder(dAngle, 2*math.Pi) == 0
+	c.arcStart(x1, y1, fullCircle)
+	if fullCircle {
+		// Close exactly on the start, which cos and sin of the end angle
+		// miss by rounding
+		c.arcCurves(cx, cy, rx, ry, rotation, angle1, dAngle, &point{x1, y1})
 	} else {
-		// Otherwise line to start
-		c.LineTo(x1, y1)
+		c.arcCurves(cx, cy, rx, ry, rotation, angle1, dAngle, nil)
 	}
+}
+
+// arcCurves adds the curves of an elliptical arc from the current point,
+// which should be its start. When end is not nil the last curve ends
+// exactly on it rather than on the computed end of the arc.
+func (c *context) arcCurves(cx, cy, rx, ry, rotation, angle1, dAngle float64, end *point) {
+	// The major radius bounds how far the ellipse strays from the curves
+	segments := c.arcSegments(math.Max(rx, ry), dAngle)
 
-	// Draw segments
 	for i := 1; i <= segments; i++ {
 		a1 := angle1 + float64(i-1)*dAngle/float64(segments)
 		a2 := angle1 + float64(i)*dAngle/float64(segments)
+		ca, sa := math.Cos(a1), math.Sin(a1)
+		cb, sb := math.Cos(a2), math.Sin(a2)
 
-		// Calculate control points for Bezier curve
-		ca := math.Cos(a1)
-		sa := math.Sin(a1)
-		cb := math.Cos(a2)
-		sb := math.Sin(a2)
-
-		// Calculate Bezier control points (negative direction)
-		// Using the standard formula for circular arcs
+		// Control point distance along the tangents, signed with the sweep
 		alpha := math.Sin(a2-a1) * (math.Sqrt(4+3*math.Tan((a2-a1)/2)*math.Tan((a2-a1)/2)) - 1) / 3
 
-		x2 := xc + radius*(ca+alpha*sa)
-		y2 := yc + radius*(sa-alpha*ca)
-		x3 := xc + radius*(cb-alpha*sb)
-		y3 := yc + radius*(sb+alpha*cb)
-		x4 := xc + radius*cb
-		y4 := yc + radius*sb
-
-		// Add Bezier curve
+		x2, y2 := ellipsePoint(cx, cy, rx, ry, rotation, ca-alpha*sa, sa+alpha*ca)
+		x3, y3 := ellipsePoint(cx, cy, rx, ry, rotation, cb+alpha*sb, sb-alpha*cb)
+		x4, y4 := ellipsePoint(cx, cy, rx, ry, rotation, cb, sb)
+		if end != nil && i == segments {
+			x4, y4 = end.x, end.y
+		}
 		c.CurveTo(x2, y2, x3, y3, x4, y4)
 	}
 }
 
+// ellipsePoint maps (u, v) on the unit circle to the ellipse centered on
+// (cx, cy) with radii rx and ry rotated by rotation.
+func ellipsePoint(cx, cy, rx, ry, rotation, u, v float64) (float64, float64) {
+	u, v = rx*u, ry*v
+	cosR, sinR := math.Cos(rotation), math.Sin(rotation)
+	return cx + u*cosR - v*sinR, cy + u*sinR + v*cosR
+}
+
 func (c *context) RelMoveTo(dx, dy float64) {
 	if c.currentPoint.hasPoint {
 		c.MoveTo(c.currentPoint.x+dx, c.currentPoint.y+dy)
@@ -1195,94 +1829,301 @@ func (c *context) Rectangle(x, y, width, height float64) {
 	c.LineTo(x+width, y+height)
 	c.LineTo(x, y+height)
 	c.ClosePath()
-	fmt.Printf("[Rectangle] Added rectangle, path.data length: %d\n", len(c.path.data))
 }
 
-// DrawCircle adds a circular path to the current path.
-// This is a convenience method that calls Arc with a full circle (0 to 2π).
-// It ensures the circle is drawn with optimal precision by using the Arc method
-// with proper angle normalization.
-func (c *context) DrawCircle(xc, yc, radius float64) {
+// RoundedRectangle adds a closed rectangle subpath whose corners are
+// quarter circles of the given radius. As with the usual cairo idiom of
+// four arcs, the subpath runs clockwise on screen from the end of the top
+// right corner. The radius is clamped to half the smaller side, which
+// turns a square into a circle.
+func (c *context) RoundedRectangle(x, y, width, height, radius float64) {
+	c.RoundedRectangleCorners(x, y, width, height, radius, radius, radius, radius)
+}
+
+// RoundedRectangleCorners is like RoundedRectangle with a radius for each
+// corner, in the order top left, top right, bottom right and bottom left as
+// in CSS. As CSS does, when the radii of adjacent corners add up to more
+// than their side, all of them are scaled down by the same factor until
+// they fit. A radius of 0 leaves a square corner.
+func (c *context) RoundedRectangleCorners(x, y, width, height, topLeft, topRight, bottomRight, bottomLeft float64) {
 	if c.status != StatusSuccess {
 		return
 	}
+	if width < 0 {
+		x, width = x+width, -width
+	}
+	if height < 0 {
+		y, height = y+height, -height
+	}
+	topLeft, topRight = max(topLeft, 0), max(topRight, 0)
+	bottomRight, bottomLeft = max(bottomRight, 0), max(bottomLeft, 0)
+
+	scale := 1.0
+	for _, side := range [][3]float64{
+		{width, topLeft, topRight},
+		{height, topRight, bottomRight},
+		{width, bottomRight, bottomLeft},
+		{height, bottomLeft, topLeft},
+	} {
+		if sum := side[1] + side[2]; sum > side[0] {
+			scale = min(scale, side[0]/sum)
+		}
+	}
+	topLeft, topRight = topLeft*scale, topRight*scale
+	bottomRight, bottomLeft = bottomRight*scale, bottomLeft*scale
 
-	// Draw a complete circle using Arc
-	// Start a new subpath to avoid connecting to previous path
 	c.NewSubPath()
-	c.Arc(xc, yc, radius, 0, 2*math.Pi)
+	c.Arc(x+width-topRight, y+topRight, topRight, -math.Pi/2, 0)
+	c.Arc(x+width-bottomRight, y+height-bottomRight, bottomRight, 0, math.Pi/2)
+	c.Arc(x+bottomLeft, y+height-bottomLeft, bottomLeft, math.Pi/2, math.Pi)
+	c.Arc(x+topLeft, y+topLeft, topLeft, math.Pi, 3*math.Pi/2)
 	c.ClosePath()
 }
 
-// More placeholder implementations
-func (c *context) PathExtents() (x1, y1, x2, y2 float64) { return 0, 0, 0, 0 }
-func (c *context) Clip() {
-	if c.status != StatusSuccess || c.gc == nil {
+// DrawCircle adds a circle as a new closed subpath. Unlike Arc from 0 to
+// 2π it does not connect to the current point, and the four quarter curves
+// start and end exactly on the axes, so the closing point meets the start
+// with no seam.
+func (c *context) DrawCircle(xc, yc, radius float64) {
+	c.DrawEllipse(xc, yc, radius, radius)
+}
+
+// DrawEllipse adds an axis-aligned ellipse with radii rx and ry as a new
+// closed subpath, built like DrawCircle from four cubic Bézier quarters.
+// The subpath starts at (xc+rx, yc) and runs clockwise on screen, in the
+// same direction as Arc.
+func (c *context) DrawEllipse(xc, yc, rx, ry float64) {
+	if c.status != StatusSuccess || rx <= 0 || ry <= 0 {
 		return
 	}
 
-	fmt.Printf("[Clip] Before copy, c.path.data length: %d\n", len(c.path.data))
+	// Control point distance for a quarter circle, 4/3·(√2−1)
+	const k = 0.5522847498307936
+	kx, ky := k*rx, k*ry
+
+	c.MoveTo(xc+rx, yc)
+	c.CurveTo(xc+rx, yc+ky, xc+kx, yc+ry, xc, yc+ry)
+	c.CurveTo(xc-kx, yc+ry, xc-rx, yc+ky, xc-rx, yc)
+	c.CurveTo(xc-rx, yc-ky, xc-kx, yc-ry, xc, yc-ry)
+	c.CurveTo(xc+kx, yc-ry, xc+rx, yc-ky, xc+rx, yc)
+	c.ClosePath()
+}
+
+// Superellipse adds the superellipse |x/rx|^n + |y/ry|^n = 1 centered on
+// (xc, yc) as a new closed subpath, flattened to lines within the
+// tolerance. An exponent of 2 gives an ellipse, larger ones approach the
+// rectangle of the radii, as with the squircle at 4, and ones below 1 pinch
+// the sides inwards. Like DrawEllipse, the subpath starts at (xc+rx, yc) and
+// runs clockwise on screen.
+func (c *context) Superellipse(xc, yc, rx, ry, n float64) {
+	if c.status != StatusSuccess || rx <= 0 || ry <= 0 || n <= 0 {
+		return
+	}
 
-	// Copy the current path for the clip region
-	// We need to copy the path data, not just the reference
-	clipPath := &path{
-		data:          make([]pathOp, len(c.path.data)),
-		subpathStartX: c.path.subpathStartX,
-		subpathStartY: c.path.subpathStartY,
+	// The tolerance in user space, along the most stretched direction
+	tolerance := c.gstate.tolerance
+	if scale := c.deviceScale(); scale > 0 {
+		tolerance /= scale
 	}
-	copy(clipPath.data, c.path.data)
 
-	fmt.Printf("[Clip] After copy, clipPath.data length: %d\n", len(clipPath.data))
+	// Quadrant by quadrant, from the parametric form
+	// (rx·cos^(2/n) t, ry·sin^(2/n) t) with signs restored
+	at := func(quadrant int, t float64) point {
+		cos := math.Cos(t)
+		if t == math.Pi/2 {
+			// cos leaves a rounding error that large exponents magnify
+			cos = 0
+		}
+		u := math.Pow(cos, 2/n)
+		v := math.Pow(math.Sin(t), 2/n)
+		switch quadrant {
+		case 1:
+			u, v = -v, u
+		case 2:
+			u, v = -u, -v
+		case 3:
+			u, v = v, -u
+		}
+		return point{xc + rx*u, yc + ry*v}
+	}
 
-	// Set the copied path as the new clip path
-	c.gstate.clip = &clipRegion{
-		path:      clipPath,
-		fillRule:  c.gstate.fillRule,
-		tolerance: c.gstate.tolerance,
-		antialias: c.gstate.antialias,
-		prev:      c.gstate.clip, // Push current clip onto stack
+	c.NewSubPath()
+	c.MoveTo(xc+rx, yc)
+	for q := 0; q < 4; q++ {
+		c.superellipseLines(func(t float64) point { return at(q, t) }, 0, math.Pi/2, at(q, 0), at(q, math.Pi/2), tolerance, 0)
 	}
+	c.ClosePath()
+}
 
-	// Apply the new clip path to Pango
-	c.applyPathToPango()
-	// Note: Pango doesn't have SetClipPath method, so we skip this for now
+// superellipseLines adds lines along curve from t0 to t1, between the
+// points p0 and p1 on it, bisecting until the midpoint of each piece is
+// within tolerance of its chord. Every quadrant is split at least four
+// times, so a midpoint that happens to lie on a long chord does not stop
+// the flattening early.
+func (c *context) superellipseLines(curve func(t float64) point, t0, t1 float64, p0, p1 point, tolerance float64, depth int) {
+	tm := (t0 + t1) / 2
+	pm := curve(tm)
+	dx, dy := p1.x-p0.x, p1.y-p0.y
+	dist := math.Abs(dx*(pm.y-p0.y) - dy*(pm.x-p0.x))
+	if length := math.Hypot(dx, dy); length > 0 {
+		dist /= length
+	} else {
+		dist = math.Hypot(pm.x-p0.x, pm.y-p0.y)
+	}
+	if depth >= 2 && (depth >= 16 || dist <= tolerance) {
+		c.LineTo(p1.x, p1.y)
+		return
+	}
+	c.superellipseLines(curve, t0, tm, p0, pm, tolerance, depth+1)
+	c.superellipseLines(curve, tm, t1, pm, p1, tolerance, depth+1)
+}
+
+// DashedArc strokes the arc from angle1 to angle2 (as drawn by Arc) with
+// dashes measured along its true arc length, starting with the first "on"
+// dash at angle1. The dash pattern follows SetDash semantics but replaces the
+// context's own dash for this stroke. Like Stroke, it clears the current path.
+func (c *context) DashedArc(xc, yc, radius, angle1, angle2 float64, dashes []float64) error {
+	if c.status != StatusSuccess {
+		return newError(c.status, "")
+	}
+	for angle2 < angle1 {
+		angle2 += 2 * math.Pi
+	}
+	if _, err := dashPeriod(dashes); err != nil {
+		return err
+	}
+	return c.strokeDashedArc(xc, yc, radius, angle1, angle2, dashes, 1)
+}
 
-	// Clear the current path
+// DashedCircle strokes a circle with dashes measured along its arc length.
+// The pattern is stretched or shrunk slightly so a whole number of periods
+// fits the circumference, keeping the dashes evenly spaced across the point
+// where the circle closes.
+func (c *context) DashedCircle(xc, yc, radius float64, dashes []float64) error {
+	if c.status != StatusSuccess {
+		return newError(c.status, "")
+	}
+	period, err := dashPeriod(dashes)
+	if err != nil {
+		return err
+	}
+
+	scale := 1.0
+	if period > 0 && radius > 0 {
+		circumference := 2 * math.Pi * radius
+		n := math.Max(1, math.Round(circumference/period))
+		scale = circumference / (n * period)
+	}
+	return c.strokeDashedArc(xc, yc, radius, 0, 2*math.Pi, dashes, scale)
+}
+
+// dashPeriod returns the length of one full on/off cycle of dashes. Odd
+// length patterns repeat with on and off swapped, doubling the period.
+func dashPeriod(dashes []float64) (float64, error) {
+	var sum float64
+	for _, d := range dashes {
+		if d < 0 {
+			return 0, newError(StatusInvalidDash, "negative dash length")
+		}
+		sum += d
+	}
+	if len(dashes) > 0 && sum == 0 {
+		return 0, newError(StatusInvalidDash, "dash lengths are all zero")
+	}
+	if len(dashes)%2 == 1 {
+		sum *= 2
+	}
+	return sum, nil
+}
+
+// strokeDashedArc strokes the "on" dashes of the arc as separate sub-arcs,
+// each dash length multiplied by scale.
+func (c *context) strokeDashedArc(xc, yc, radius, angle1, angle2 float64, dashes []float64, scale float64) error {
 	c.NewPath()
+	if radius <= 0 {
+		return nil
+	}
+
+	c.Save()
+	defer c.Restore()
+	c.SetDash(nil, 0)
+
+	length := radius * (angle2 - angle1)
+	if len(dashes) == 0 {
+		c.NewSubPath()
+		c.Arc(xc, yc, radius, angle1, angle2)
+		return c.Stroke()
+	}
+
+	on := true
+	for i, s := 0, 0.0; s < length; i++ {
+		end := math.Min(s+dashes[i%len(dashes)]*scale, length)
+		if on && end > s {
+			c.NewSubPath()
+			c.Arc(xc, yc, radius, angle1+s/radius, angle1+end/radius)
+		}
+		s = end
+		on = !on
+	}
+	return c.Stroke()
 }
 
-func (c *context) ClipPreserve() {
+// More placeholder implementations
+func (c *context) PathExtents() (x1, y1, x2, y2 float64) { return 0, 0, 0, 0 }
+func (c *context) Clip() {
 	if c.status != StatusSuccess || c.gc == nil {
 		return
 	}
 
-	// Set the current path as the new clip path, but don't clear the path
-	c.gstate.clip = &clipRegion{
-		path:      c.path,
-		fillRule:  c.gstate.fillRule,
-		tolerance: c.gstate.tolerance,
-		antialias: c.gstate.antialias,
-		prev:      c.gstate.clip, // Push current clip onto stack
+	c.pushClip()
+	c.NewPath()
+}
+
+func (c *context) ClipPreserve() {
+	if c.status != StatusSuccess || c.gc == nil {
+		return
 	}
 
-	// Apply the new clip path to Pango
-	c.applyPathToPango()
-	// Note: Pango doesn't have SetClipPath method, so we skip this for now
+	// Set the current path as the new clip path, but don't clear the path
+	c.pushClip()
 }
 
+// ClipExtents returns the user-space bounding box of the area the clip
+// leaves visible, the whole target when there is no clip. Nested clips are
+// intersected; a clip leaving nothing visible has empty extents.
 func (c *context) ClipExtents() (x1, y1, x2, y2 float64) {
-	if c.status != StatusSuccess || c.gstate.clip == nil {
+	if c.status != StatusSuccess || c.gc == nil {
 		return 0, 0, 0, 0
 	}
 
-	// For now, we'll return the extents of the clipping path.
-	// A proper implementation would consider the intersection of the path and the surface bounds.
-	// Note: path.extents() method doesn't exist, so we return default values
-	return 0, 0, 0, 0
+	extents := c.gc.bounds()
+	if cov := c.clipCoverage(); cov != nil {
+		extents = cov.extents
+	}
+	if extents.Empty() {
+		return 0, 0, 0, 0
+	}
+	return c.deviceToUserRect(extents)
 }
 
+// InClip reports whether the user-space point is in the area the clip
+// leaves visible. Points are tested against the same pixel coverage drawing
+// uses, so a point is in the clip when its pixel can be drawn to.
 func (c *context) InClip(x, y float64) Bool {
-	// TODO: Implement proper point-in-clip check
+	if c.status != StatusSuccess {
+		return False
+	}
+	cov := c.clipCoverage()
+	if cov == nil {
+		return True
+	}
+
+	m := c.deviceMatrix()
+	dx, dy := MatrixTransformPoint(&m, x, y)
+	pt := image.Pt(int(math.Floor(dx)), int(math.Floor(dy)))
+	if pt.In(cov.extents) && cov.mask.AlphaAt(pt.X, pt.Y).A > 0 {
+		return True
+	}
 	return False
 }
 
@@ -1297,7 +2138,38 @@ func (c *context) ResetClip() {
 	// Reset clip in Pango
 	// Note: Pango doesn't have SetClipPath method, so we skip this for now
 }
-func (c *context) CopyClipRectangleList() *RectangleList   { return nil }
+
+// CopyClipRectangleList returns the clip as a list of user-space
+// rectangles: the whole target without a clip, one rectangle for nested
+// pixel-aligned rectangular clips and none when they do not overlap. Other
+// clips give StatusClipNotRepresentable.
+func (c *context) CopyClipRectangleList() *RectangleList {
+	if c.status != StatusSuccess {
+		return &RectangleList{Status: c.status}
+	}
+	if c.gc == nil {
+		return &RectangleList{Status: StatusClipNotRepresentable}
+	}
+
+	extents := c.gc.bounds()
+	if cov := c.clipCoverage(); cov != nil {
+		if !cov.rectangular {
+			return &RectangleList{Status: StatusClipNotRepresentable}
+		}
+		extents = cov.extents
+	}
+	if m := c.deviceMatrix(); m.XY != 0 || m.YX != 0 {
+		return &RectangleList{Status: StatusClipNotRepresentable}
+	}
+
+	list := &RectangleList{Status: StatusSuccess}
+	if !extents.Empty() {
+		x1, y1, x2, y2 := c.deviceToUserRect(extents)
+		list.Rectangles = []*Rectangle{{X: x1, Y: y1, Width: x2 - x1, Height: y2 - y1}}
+		list.NumRectangles = 1
+	}
+	return list
+}
 func (c *context) InStroke(x, y float64) Bool              { return False }
 func (c *context) InFill(x, y float64) Bool                { return False }
 func (c *context) StrokeExtents() (x1, y1, x2, y2 float64) { return 0, 0, 0, 0 }
@@ -1309,19 +2181,19 @@ func (c *context) CopyPath() *Path {
 
 	newPath := &Path{
 		Status: StatusSuccess,
-		Data:   make([]PathData, len(c.path.data)),
+		Data:   make([]PathData, 0, len(c.path.ops)),
 	}
 
-	for i, op := range c.path.data {
+	c.path.forEach(func(op PathDataType, pts []point) {
 		data := PathData{
-			Type:   op.op,
-			Points: make([]Point, len(op.points)),
+			Type:   op,
+			Points: make([]Point, len(pts)),
 		}
-		for j, p := range op.points {
+		for j, p := range pts {
 			data.Points[j] = Point{X: p.x, Y: p.y}
 		}
-		newPath.Data[i] = data
-	}
+		newPath.Data = append(newPath.Data, data)
+	})
 
 	return newPath
 }
@@ -1338,30 +2210,41 @@ func (c *context) CopyPathFlat() *Path {
 }
 
 func (c *context) AppendPath(path *Path) {
-	if c.status != StatusSuccess || path.Status != StatusSuccess {
+	if c.status != StatusSuccess {
+		return
+	}
+	if path == nil {
+		c.setError(StatusNullPointer)
+		return
+	}
+	if path.Status != StatusSuccess {
+		c.setError(path.Status)
 		return
 	}
 
 	for _, data := range path.Data {
-		op := pathOp{
-			op:     data.Type,
-			points: make([]point, len(data.Points)),
+		if len(data.Points) < segmentPoints(data.Type) {
+			c.setError(StatusInvalidPathData)
+			return
 		}
-		for i, p := range data.Points {
-			op.points[i] = point{x: p.X, y: p.Y}
+	}
+
+	for _, data := range path.Data {
+		c.path.ops = append(c.path.ops, data.Type)
+		for _, p := range data.Points[:segmentPoints(data.Type)] {
+			c.path.pts = append(c.path.pts, point{x: p.X, y: p.Y})
 		}
-		c.path.data = append(c.path.data, op)
 
 		// Update current point
-		if len(op.points) > 0 {
-			lastPoint := op.points[len(op.points)-1]
-			c.currentPoint.x = lastPoint.x
-			c.currentPoint.y = lastPoint.y
+		if n := segmentPoints(data.Type); n > 0 {
+			lastPoint := data.Points[n-1]
+			c.currentPoint.x = lastPoint.X
+			c.currentPoint.y = lastPoint.Y
 			c.currentPoint.hasPoint = true
 		}
 
 		// Update subpath start point on MoveTo
-		if op.op == PathMoveTo {
+		if data.Type == PathMoveTo {
 			c.path.subpathStartX = c.currentPoint.x
 			c.path.subpathStartY = c.currentPoint.y
 		}
@@ -1420,8 +2303,13 @@ func MatrixTransformDistance(matrix *Matrix, dx, dy float64) (float64, float64)
 	return newDx, newDy
 }
 
+// matrixInversions counts MatrixInvert calls, for tests checking that
+// drawing reuses the inverses it caches
+var matrixInversions atomic.Int64
+
 // MatrixInvert inverts a matrix
 func MatrixInvert(matrix *Matrix) Status {
+	matrixInversions.Add(1)
 	det := matrix.XX*matrix.YY - matrix.YX*matrix.XY
 
 	if math.Abs(det) < 1e-10 {
@@ -1453,7 +2341,27 @@ func (c *context) SetFontMatrix(matrix *Matrix) {
 	if c.status != StatusSuccess {
 		return
 	}
+	if !matrixInvertible(matrix) {
+		c.setError(StatusInvalidMatrix)
+		return
+	}
 	c.gstate.fontMatrix = *matrix
+	c.dropScaledFont()
+}
+
+// SetFontSize sets the font matrix to a scale of size, the em size in user
+// space units, like cairo_set_font_size. A size that is not positive and
+// finite sets StatusInvalidSize and leaves the font matrix unchanged.
+func (c *context) SetFontSize(size float64) {
+	if c.status != StatusSuccess {
+		return
+	}
+	if size <= 0 || math.IsNaN(size) || math.IsInf(size, 0) {
+		c.setError(StatusInvalidSize)
+		return
+	}
+	c.gstate.fontMatrix = Matrix{XX: size, YY: size}
+	c.dropScaledFont()
 }
 
 func (c *context) GetFontMatrix() *Matrix {
@@ -1475,14 +2383,63 @@ func (c *context) GetFontOptions() *FontOptions {
 	return c.gstate.fontOptions.Copy()
 }
 
+// SetFontFace makes fontFace the current font face. A face in error puts
+// the context in the same error and leaves the current face unchanged.
 func (c *context) SetFontFace(fontFace FontFace) {
 	if c.status != StatusSuccess {
 		return
 	}
+	if fontFace == nil {
+		c.setError(StatusNullPointer)
+		return
+	}
+	if status := fontFace.Status(); status != StatusSuccess {
+		c.setError(status)
+		return
+	}
 	if c.gstate.fontFace != nil {
 		c.gstate.fontFace.Destroy()
 	}
 	c.gstate.fontFace = fontFace.Reference()
+	c.dropScaledFont()
+}
+
+// SelectFontFace makes a toy font face for family, slant and weight the
+// current font face, like cairo_select_font_face. An out of range slant or
+// weight sets StatusInvalidSlant or StatusInvalidWeight and leaves the
+// current face unchanged.
+func (c *context) SelectFontFace(family string, slant FontSlant, weight FontWeight) {
+	if c.status != StatusSuccess {
+		return
+	}
+	fontFace := NewToyFontFace(family, slant, weight)
+	c.SetFontFace(fontFace)
+	fontFace.Destroy()
+}
+
+// dropScaledFont releases the cached scaled font, which was built for the
+// previous font face or matrix.
+func (c *context) dropScaledFont() {
+	if c.gstate.scaledFont != nil {
+		c.gstate.scaledFont.Destroy()
+		c.gstate.scaledFont = nil
+	}
+}
+
+// SetFontFaceFromBytes parses an in-memory font with NewFontFaceFromBytes and
+// makes it the current font face, releasing the previous one. On error the
+// current font face is left unchanged.
+func (c *context) SetFontFaceFromBytes(data []byte) error {
+	if c.status != StatusSuccess {
+		return newError(c.status, "")
+	}
+	fontFace, err := NewFontFaceFromBytes(data)
+	if err != nil {
+		return err
+	}
+	c.SetFontFace(fontFace)
+	fontFace.Destroy()
+	return nil
 }
 
 func (c *context) GetFontFace() FontFace {
@@ -1529,6 +2486,19 @@ func (c *context) GetScaledFont() ScaledFont {
 	return c.gstate.scaledFont.Reference()
 }
 
+// SetMissingGlyphStyle selects how codepoints without a glyph in the
+// current font are drawn by PangoCairoShowText.
+func (c *context) SetMissingGlyphStyle(style MissingGlyphStyle) {
+	if c.status != StatusSuccess {
+		return
+	}
+	c.gstate.missingGlyphStyle = style
+}
+
+func (c *context) GetMissingGlyphStyle() MissingGlyphStyle {
+	return c.gstate.missingGlyphStyle
+}
+
 func (c *context) FontExtents() *FontExtents {
 	sf := c.GetScaledFont()
 	if sf == nil {
@@ -1539,6 +2509,10 @@ func (c *context) FontExtents() *FontExtents {
 }
 
 func (c *context) TextExtents(utf8 string) *TextExtents {
+	if !validText(utf8) {
+		c.setError(StatusInvalidString)
+		return &TextExtents{}
+	}
 	sf := c.GetScaledFont()
 	if sf == nil {
 		return &TextExtents{}
@@ -1547,6 +2521,50 @@ func (c *context) TextExtents(utf8 string) *TextExtents {
 	return sf.TextExtents(utf8)
 }
 
+// MeasureText returns the ink extents reported by TextExtents together with
+// the logical extents used for layout. The logical box starts at the origin,
+// spans the full advance (including leading and trailing bearings) and the
+// font's ascent and descent, growing if the ink reaches beyond them.
+func (c *context) MeasureText(utf8 string) (ink TextExtents, logical TextExtents) {
+	sf := c.GetScaledFont()
+	if sf == nil {
+		return TextExtents{}, TextExtents{}
+	}
+	defer sf.Destroy()
+
+	ink = *sf.TextExtents(utf8)
+	fe := sf.Extents()
+
+	ascent := math.Max(fe.Ascent, -ink.YBearing)
+	descent := math.Max(fe.Descent, ink.YBearing+ink.Height)
+	logical = TextExtents{
+		XBearing: 0,
+		YBearing: -ascent,
+		Width:    ink.XAdvance,
+		Height:   ascent + descent,
+		XAdvance: ink.XAdvance,
+		YAdvance: ink.YAdvance,
+	}
+	return ink, logical
+}
+
+// TextToGlyphs converts utf8 to positioned glyphs and the clusters mapping
+// them back to the text, like cairo_scaled_font_text_to_glyphs on the current
+// scaled font. Positions are in user space: the first glyph origin is at
+// (x, y) and later glyphs follow by their shaped advances, so the result can
+// be adjusted and passed on to ShowTextGlyphs or GlyphExtents.
+func (c *context) TextToGlyphs(x, y float64, utf8 string) ([]Glyph, []TextCluster, TextClusterFlags, Status) {
+	if c.status != StatusSuccess {
+		return nil, nil, 0, c.status
+	}
+	sf := c.GetScaledFont()
+	if sf == nil {
+		return nil, nil, 0, StatusNullPointer
+	}
+	defer sf.Destroy()
+	return sf.TextToGlyphs(x, y, utf8)
+}
+
 func (c *context) GlyphExtents(glyphs []Glyph) *TextExtents {
 	sf := c.GetScaledFont()
 	if sf == nil {
@@ -1556,11 +2574,67 @@ func (c *context) GlyphExtents(glyphs []Glyph) *TextExtents {
 	return sf.GlyphExtents(glyphs)
 }
 
-// ShowGlyphs is deprecated - use PangoCairoShowText instead
-// Deprecated: Use PangoCairoShowText for all text rendering
+// ShowGlyphs draws glyphs from the current scaled font, each at its own
+// user-space X, Y, without reshaping or re-measuring. The current point
+// ends up at the last glyph's origin plus its advance, which is what
+// callers laying out glyphs themselves (e.g. justified text) continue from.
 func (c *context) ShowGlyphs(glyphs []Glyph) {
-	// This method is deprecated and should not be called directly
-	c.status = StatusInvalidString
+	if c.status != StatusSuccess || len(glyphs) == 0 {
+		return
+	}
+	forwarded, _ := c.forwardDrawing(ObserverGlyphs, func(t *context) error {
+		t.ShowGlyphs(glyphs)
+		return nil
+	})
+	if forwarded {
+		return
+	}
+
+	sf := c.GetScaledFont()
+	if sf == nil {
+		c.setError(StatusNullPointer)
+		return
+	}
+	defer sf.Destroy()
+	if status := sf.Status(); status != StatusSuccess {
+		c.setError(status)
+		return
+	}
+
+	if psf, ok := sf.(*PangoCairoScaledFont); ok {
+		showGlyphRun(c, psf, glyphs, "")
+	} else {
+		// Color glyphs (COLR/CPAL) are painted layer by layer, as by
+		// showGlyphRun, unless color rendering is disabled
+		options := c.gstate.fontOptions
+		var colorTables *colorFontTables
+		if core, ok := sf.(*scaledFont); ok && options.GetColorMode() != ColorModeNoColor {
+			colorTables = core.colorGlyphs()
+		}
+
+		synthesis, size := glyphSynthesis(sf)
+		for _, glyph := range glyphs {
+			if colorTables != nil {
+				if layers := colorTables.glyphs[uint16(glyph.Index)]; len(layers) > 0 {
+					renderColorGlyph(c, sf, colorTables, layers, glyph, options)
+					continue
+				}
+			}
+			glyphPath, err := sf.GlyphPath(glyph.Index)
+			if err != nil || glyphPath == nil || len(glyphPath.Data) == 0 {
+				continue
+			}
+			c.Save()
+			fillGlyph(c, glyphPath, glyph.X, glyph.Y, synthesis, size)
+			c.Restore()
+		}
+	}
+
+	last := glyphs[len(glyphs)-1]
+	advance := sf.GlyphExtents([]Glyph{last})
+	c.currentPoint.x = last.X + advance.XAdvance
+	c.currentPoint.y = last.Y + advance.YAdvance
+	c.currentPoint.hasPoint = true
 }
 
 // TextPath is deprecated - use PangoCairoShowText instead
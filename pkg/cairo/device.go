@@ -0,0 +1,137 @@
+package cairo
+
+import (
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// imageDevice is a device shared by image surfaces. Image surfaces have no
+// backend state to guard, so the device only provides the locking callers
+// use to serialize access to the surfaces created against it, as they
+// would around a GL context.
+type imageDevice struct {
+	refCount int32
+	status   Status
+	userData map[*UserDataKey]interface{}
+
+	// mu is held between Acquire and Release; finished is guarded by it
+	mu       sync.Mutex
+	finished bool
+}
+
+// NewImageDevice creates a device for image surfaces, to be passed to
+// NewImageSurfaceForDevice. Its type is DeviceTypeImage.
+func NewImageDevice() Device {
+	return &imageDevice{
+		refCount: 1,
+		status:   StatusSuccess,
+		userData: make(map[*UserDataKey]interface{}),
+	}
+}
+
+// NewImageSurfaceForDevice creates an image surface like NewImageSurface
+// that belongs to device, which GetDevice then returns. Surfaces similar
+// to it and its subsurfaces belong to the device too. The device must be
+// an image device that is not finished.
+func NewImageSurfaceForDevice(device Device, format Format, width, height int) Surface {
+	d, ok := device.(*imageDevice)
+	if !ok {
+		return newSurfaceInError(StatusDeviceTypeMismatch)
+	}
+	if d.status != StatusSuccess {
+		return newSurfaceInError(d.status)
+	}
+	d.mu.Lock()
+	finished := d.finished
+	d.mu.Unlock()
+	if finished {
+		return newSurfaceInError(StatusDeviceFinished)
+	}
+
+	surface := NewImageSurface(format, width, height)
+	if s, ok := surface.(*imageSurface); ok && s.status == StatusSuccess {
+		s.device = d.Reference()
+	}
+	return surface
+}
+
+func (d *imageDevice) Reference() Device {
+	atomic.AddInt32(&d.refCount, 1)
+	return d
+}
+
+// Destroy drops a reference; the last one finishes the device.
+func (d *imageDevice) Destroy() {
+	if atomic.AddInt32(&d.refCount, -1) == 0 {
+		d.Finish()
+		destroyUserData(d.userData)
+	}
+}
+
+func (d *imageDevice) GetReferenceCount() int {
+	return int(atomic.LoadInt32(&d.refCount))
+}
+
+func (d *imageDevice) Status() Status {
+	return d.status
+}
+
+func (d *imageDevice) GetType() DeviceType {
+	return DeviceTypeImage
+}
+
+func (d *imageDevice) SetUserData(key *UserDataKey, userData unsafe.Pointer, destroy DestroyFunc) Status {
+	if d.status != StatusSuccess {
+		return d.status
+	}
+
+	setUserData(d.userData, key, userData, destroy)
+	return StatusSuccess
+}
+
+func (d *imageDevice) GetUserData(key *UserDataKey) unsafe.Pointer {
+	return getUserData(d.userData, key)
+}
+
+// Acquire gives the caller exclusive use of the device, blocking until
+// any other holder calls Release, as cairo_device_acquire does. Unlike in
+// cairo, the lock is not recursive. On a finished device it fails with
+// StatusDeviceFinished and the caller must not call Release.
+func (d *imageDevice) Acquire() Status {
+	if d.status != StatusSuccess {
+		return d.status
+	}
+	d.mu.Lock()
+	if d.finished {
+		d.mu.Unlock()
+		return StatusDeviceFinished
+	}
+	return StatusSuccess
+}
+
+// Release ends the exclusive use begun by a successful Acquire.
+func (d *imageDevice) Release() {
+	d.mu.Unlock()
+}
+
+// Flush completes pending operations; image devices have none.
+func (d *imageDevice) Flush() error {
+	if d.status != StatusSuccess {
+		return newError(d.status, "")
+	}
+	return nil
+}
+
+// Finish waits for the current holder to release the device and marks it
+// finished, so it can no longer be acquired nor get new surfaces. The
+// surfaces it already has keep working.
+func (d *imageDevice) Finish() error {
+	if err := d.Flush(); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.finished = true
+	d.mu.Unlock()
+	return nil
+}
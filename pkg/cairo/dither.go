@@ -0,0 +1,171 @@
+package cairo
+
+import (
+	"math"
+)
+
+// SetDither sets the dithering used when the surface's pixels are quantized
+// to a format with fewer levels per channel, such as by ConvertToFormat.
+// DitherFast and DitherGood use ordered (Bayer) dithering with a 4x4 and an
+// 8x8 matrix, DitherBest uses Floyd-Steinberg error diffusion, and
+// DitherNone and DitherDefault round each channel to the nearest level.
+func (s *imageSurface) SetDither(dither Dither) {
+	s.dither = dither
+}
+
+// GetDither returns the dithering set with SetDither; DitherNone by default.
+func (s *imageSurface) GetDither() Dither {
+	return s.dither
+}
+
+// ConvertToFormat returns a new surface of the given format holding the
+// surface's pixels, quantized with the surface's dither mode. Color formats
+// receive the premultiplied color (RGB24 and RGB16565 drop alpha, as if
+// composited over black); FormatA8 and FormatA1 receive the alpha channel.
+// RGB16565 pixels are stored as little-endian 16-bit words, and FormatA1
+// packs pixels least significant bit first. The returned surface keeps the
+// dither mode.
+//
+// The source must be ARGB32, RGB24 or RGB16565; other sources give a surface
+// in error with StatusInvalidFormat, as do the float and RGB30 target
+// formats.
+func (s *imageSurface) ConvertToFormat(format Format) ImageSurface {
+	if s.status != StatusSuccess {
+		return newSurfaceInError(s.status).(ImageSurface)
+	}
+	if s.rgbaImage == nil {
+		return newSurfaceInError(StatusInvalidFormat).(ImageSurface)
+	}
+	switch format {
+	case FormatARGB32, FormatRGB24, FormatA8, FormatA1, FormatRGB16565:
+	default:
+		return newSurfaceInError(StatusInvalidFormat).(ImageSurface)
+	}
+
+	dst := NewImageSurface(format, s.width, s.height).(*imageSurface)
+	if dst.status != StatusSuccess {
+		return dst
+	}
+	dst.dither = s.dither
+
+	w, h := s.width, s.height
+	src := s.rgbaImage
+	switch format {
+	case FormatARGB32, FormatRGB24, FormatRGB16565:
+		// RGB16565 is packed with the dither mode when its data is read
+		pix, stride := dst.rgbaImage.Pix, dst.rgbaImage.Stride
+		for y := 0; y < h; y++ {
+			copy(pix[y*stride:y*stride+w*4], src.Pix[y*src.Stride:])
+			if format != FormatARGB32 {
+				for x := 0; x < w; x++ {
+					pix[y*stride+x*4+3] = 0xff
+				}
+			}
+		}
+
+	case FormatA8:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				dst.data[y*dst.stride+x] = src.Pix[y*src.Stride+x*4+3]
+			}
+		}
+
+	case FormatA1:
+		alpha := ditherPlane(channelPlane(src.Pix, src.Stride, w, h, 3), w, h, 2, s.dither)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				if alpha[y*w+x] != 0 {
+					dst.data[y*dst.stride+x/8] |= 1 << (x % 8)
+				}
+			}
+		}
+	}
+	return dst
+}
+
+// channelPlane extracts channel c of 4-byte pixels as values in [0, 1].
+func channelPlane(pix []byte, stride, w, h, c int) []float64 {
+	plane := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			plane[y*w+x] = float64(pix[y*stride+x*4+c]) / 255
+		}
+	}
+	return plane
+}
+
+// ditherPlane quantizes a w x h plane of values in [0, 1] to the given
+// number of levels with the dither mode, returning level indices.
+func ditherPlane(plane []float64, w, h, levels int, dither Dither) []uint16 {
+	out := make([]uint16, len(plane))
+	scale := float64(levels - 1)
+	quantize := func(v float64) uint16 {
+		return uint16(math.Min(math.Max(math.Round(v*scale), 0), scale))
+	}
+
+	switch dither {
+	case DitherFast, DitherGood:
+		n := 4
+		if dither == DitherGood {
+			n = 8
+		}
+		matrix := bayerMatrix(n)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				// Offset by up to half a level either way
+				t := (float64(matrix[y%n][x%n])+0.5)/float64(n*n) - 0.5
+				out[y*w+x] = quantize(plane[y*w+x] + t/scale)
+			}
+		}
+
+	case DitherBest:
+		// Floyd-Steinberg: push each pixel's rounding error onto the
+		// unvisited neighbors with weights 7/16, 3/16, 5/16 and 1/16
+		errs := make([]float64, len(plane))
+		copy(errs, plane)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				i := y*w + x
+				q := quantize(errs[i])
+				out[i] = q
+				e := errs[i] - float64(q)/scale
+				if x+1 < w {
+					errs[i+1] += e * 7 / 16
+				}
+				if y+1 < h {
+					if x > 0 {
+						errs[i+w-1] += e * 3 / 16
+					}
+					errs[i+w] += e * 5 / 16
+					if x+1 < w {
+						errs[i+w+1] += e * 1 / 16
+					}
+				}
+			}
+		}
+
+	default:
+		for i, v := range plane {
+			out[i] = quantize(v)
+		}
+	}
+	return out
+}
+
+// bayerMatrix returns the n x n ordered dither matrix for n a power of two,
+// holding each of 0..n*n-1 once.
+func bayerMatrix(n int) [][]int {
+	if n == 1 {
+		return [][]int{{0}}
+	}
+	half := bayerMatrix(n / 2)
+	offsets := [2][2]int{{0, 2}, {3, 1}}
+	m := make([][]int, n)
+	for y := range m {
+		m[y] = make([]int, n)
+		for x := range m[y] {
+			m[y][x] = 4*half[y%(n/2)][x%(n/2)] + offsets[y/(n/2)][x/(n/2)]
+		}
+	}
+	return m
+}
@@ -0,0 +1,146 @@
+package cairo
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+)
+
+// floatImage is a view of FormatRGB96F or FormatRGBA128F surface data: each
+// pixel is 3 or 4 little-endian float32 channels in R, G, B(, A) order with
+// premultiplied alpha. Drawing composites in float directly into the surface
+// data, so no intermediate 8-bit quantization happens. As an image.Image it
+// clamps channels to [0, 1], which is what PNG export sees.
+type floatImage struct {
+	pix      []byte
+	stride   int
+	rect     image.Rectangle
+	channels int
+}
+
+// newFloatImage returns a view of data for a float format surface, or nil
+// for other formats.
+func newFloatImage(data []byte, format Format, width, height, stride int) *floatImage {
+	channels := 0
+	switch format {
+	case FormatRGB96F:
+		channels = 3
+	case FormatRGBA128F:
+		channels = 4
+	default:
+		return nil
+	}
+	return &floatImage{
+		pix:      data,
+		stride:   stride,
+		rect:     image.Rect(0, 0, width, height),
+		channels: channels,
+	}
+}
+
+func (f *floatImage) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+func (f *floatImage) Bounds() image.Rectangle {
+	return f.rect
+}
+
+func (f *floatImage) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(f.rect)) {
+		return color.RGBA64{}
+	}
+	p := f.pixel(x, y)
+	var c [4]uint16
+	for i, v := range p {
+		c[i] = uint16(math.Min(math.Max(v, 0), 1)*0xffff + 0.5)
+	}
+	// Keep the clamped color a valid premultiplied value
+	for i := 0; i < 3; i++ {
+		c[i] = min(c[i], c[3])
+	}
+	return color.RGBA64{R: c[0], G: c[1], B: c[2], A: c[3]}
+}
+
+// Set stores c, making floatImage a draw.Image.
+func (f *floatImage) Set(x, y int, c color.Color) {
+	if !(image.Point{x, y}.In(f.rect)) {
+		return
+	}
+	r, g, b, a := c.RGBA()
+	f.setPixel(x, y, [4]float64{
+		float64(r) / 0xffff,
+		float64(g) / 0xffff,
+		float64(b) / 0xffff,
+		float64(a) / 0xffff,
+	})
+}
+
+// pixel returns the premultiplied channels at (x, y); alpha is 1 for
+// FormatRGB96F.
+func (f *floatImage) pixel(x, y int) [4]float64 {
+	i := y*f.stride + x*f.channels*4
+	p := [4]float64{3: 1}
+	for ch := 0; ch < f.channels; ch++ {
+		p[ch] = float64(math.Float32frombits(binary.LittleEndian.Uint32(f.pix[i+ch*4:])))
+	}
+	return p
+}
+
+// setPixel stores premultiplied channels at (x, y); FormatRGB96F drops alpha.
+func (f *floatImage) setPixel(x, y int, p [4]float64) {
+	i := y*f.stride + x*f.channels*4
+	for ch := 0; ch < f.channels; ch++ {
+		binary.LittleEndian.PutUint32(f.pix[i+ch*4:], math.Float32bits(float32(p[ch])))
+	}
+}
+
+// colorAt returns the unclamped color at (x, y).
+func (f *floatImage) colorAt(x, y int) floatColor {
+	p := f.pixel(x, y)
+	if p[3] <= 0 {
+		return floatColor{}
+	}
+	return floatColor{R: p[0] / p[3], G: p[1] / p[3], B: p[2] / p[3], A: p[3]}
+}
+
+// rgba8 clamps the image to 8 bits per channel for PNG export.
+func (f *floatImage) rgba8() *image.RGBA {
+	img := image.NewRGBA(f.rect)
+	for y := f.rect.Min.Y; y < f.rect.Max.Y; y++ {
+		for x := f.rect.Min.X; x < f.rect.Max.X; x++ {
+			img.Set(x, y, f.At(x, y))
+		}
+	}
+	return img
+}
+
+// floatColor is a non-premultiplied color with float channels in [0, 1].
+// Contexts drawing to float surfaces use it as the source color so
+// translucent sources are not rounded to 8 bits.
+type floatColor struct {
+	R, G, B, A float64
+}
+
+func (c floatColor) RGBA() (r, g, b, a uint32) {
+	a = uint32(c.A*0xffff + 0.5)
+	r = uint32(c.R*c.A*0xffff + 0.5)
+	g = uint32(c.G*c.A*0xffff + 0.5)
+	b = uint32(c.B*c.A*0xffff + 0.5)
+	return
+}
+
+// toFloatColor converts c to a non-premultiplied floatColor.
+func toFloatColor(c color.Color) floatColor {
+	if fc, ok := c.(floatColor); ok {
+		return fc
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return floatColor{
+		R: float64(n.R) / 255,
+		G: float64(n.G) / 255,
+		B: float64(n.B) / 255,
+		A: float64(n.A) / 255,
+	}
+}
@@ -1,21 +1,39 @@
 package cairo
 
 import (
+	"bytes"
 	"math"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"unicode/utf8"
 	"unsafe"
 
 	"github.com/go-text/typesetting/di"
 	"github.com/go-text/typesetting/font"
 	"github.com/go-text/typesetting/opentype/api"
 	apifont "github.com/go-text/typesetting/opentype/api/font"
+	"github.com/go-text/typesetting/opentype/loader"
 	"github.com/go-text/typesetting/shaping"
 	"golang.org/x/image/math/fixed"
 )
 
+// validText reports whether text is valid UTF-8. Text functions take their
+// input in a parameter named utf8, which hides the package.
+func validText(text string) bool {
+	return utf8.ValidString(text)
+}
+
 // getFontKey creates a lookup key for font cache
 func getFontKey(family string, slant FontSlant, weight FontWeight) string {
+	// Fonts added with RegisterFont take precedence over built-in families;
+	// their slant and weight are synthesized where the font lacks them
+	if key, ok := registeredFontKey(family); ok {
+		return key
+	}
+
 	// Handle specific font families first
 	if family == "Go Regular" || family == "Go-Regular" || family == "Go" {
 		if weight == FontWeightBold && (slant == FontSlantItalic || slant == FontSlantOblique) {
@@ -164,7 +182,9 @@ func (o *FontOptions) Equal(other *FontOptions) bool {
 	return true
 }
 
-// Hash returns a stable hash value for the font options.
+// Hash returns a stable hash value for the font options. Options that are
+// Equal hash the same in every run, whatever order their custom palette
+// entries were set in.
 func (o *FontOptions) Hash() uint64 {
 	if o == nil {
 		return 0
@@ -182,7 +202,14 @@ func (o *FontOptions) Hash() uint64 {
 	add(uint64(o.HintMetrics))
 	add(uint64(o.ColorMode))
 	add(uint64(o.ColorPalette))
-	for k, v := range o.CustomPalette {
+	// Map iteration order is random; hash the entries by index
+	indices := make([]uint, 0, len(o.CustomPalette))
+	for k := range o.CustomPalette {
+		indices = append(indices, k)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	for _, k := range indices {
+		v := o.CustomPalette[k]
 		add(uint64(k))
 		add(math.Float64bits(v.R))
 		add(math.Float64bits(v.G))
@@ -337,12 +364,29 @@ type toyFontFace struct {
 	weight FontWeight
 
 	// Real font face from go-text/typesetting
-	realFace font.Face
-	fontData []byte
+	realFace   font.Face
+	fontData   []byte
+	variations map[string]float64
+
+	// Styles drawn synthetically because the loaded font lacks them
+	synthesis fontSynthesis
+
+	// Parsed COLR/CPAL tables, loaded on first use
+	colorOnce   sync.Once
+	colorTables *colorFontTables
 }
 
 // NewToyFontFace creates a toy font face similar to cairo_toy_font_face_create.
+// An out of range slant or weight gives a face in error with
+// StatusInvalidSlant or StatusInvalidWeight.
 func NewToyFontFace(family string, slant FontSlant, weight FontWeight) FontFace {
+	if slant < FontSlantNormal || slant > FontSlantOblique {
+		return newFontFaceInError(StatusInvalidSlant)
+	}
+	if weight < FontWeightNormal || weight > FontWeightBold {
+		return newFontFaceInError(StatusInvalidWeight)
+	}
+
 	ff := &toyFontFace{
 		baseFontFace: baseFontFace{
 			refCount: 1,
@@ -357,12 +401,16 @@ func NewToyFontFace(family string, slant FontSlant, weight FontWeight) FontFace
 
 	// Get font key and load font
 	fontKey := getFontKey(family, slant, weight)
-	face, data, err := LoadEmbeddedFont(fontKey)
-	if err != nil {
-		// Try loading from assets if family looks like a file
-		if strings.Contains(family, "/") || strings.Contains(family, "\\") {
-			face, data, err = LoadFontFromFile(family)
-		}
+	var face font.Face
+	var data []byte
+	var err error
+	// Try loading from file first if family looks like a path, since
+	// getFontKey maps unknown families to an embedded font
+	if strings.Contains(family, "/") || strings.Contains(family, "\\") {
+		face, data, err = LoadFontFromFile(family)
+	}
+	if face == nil || err != nil {
+		face, data, err = LoadEmbeddedFont(fontKey)
 		if err != nil {
 			// Final fallback to default font
 			face, data = GetDefaultFont()
@@ -374,10 +422,55 @@ func NewToyFontFace(family string, slant FontSlant, weight FontWeight) FontFace
 
 	if ff.realFace == nil {
 		ff.status = StatusFontTypeMismatch
+	} else {
+		ff.synthesis = synthesisFor(data, slant, weight)
 	}
 	return ff
 }
 
+// colorGlyphs returns the font's color glyph tables, or nil for plain fonts
+func (f *toyFontFace) colorGlyphs() *colorFontTables {
+	f.colorOnce.Do(func() {
+		f.colorTables = parseColorFontTables(f.fontData)
+	})
+	return f.colorTables
+}
+
+// newFontFaceInError returns a toy font face with no font and the given
+// error status.
+func newFontFaceInError(status Status) FontFace {
+	return &toyFontFace{
+		baseFontFace: baseFontFace{
+			refCount: 1,
+			status:   status,
+			fontType: FontTypeToy,
+			userData: make(map[*UserDataKey]interface{}),
+		},
+	}
+}
+
+// NewFontFaceFromBytes parses an in-memory TrueType/OpenType font, such as
+// one bundled with go:embed, and returns a font face for it. The data must
+// not be modified afterwards.
+func NewFontFaceFromBytes(data []byte) (FontFace, error) {
+	face, err := font.ParseTTF(bytes.NewReader(data))
+	if err != nil {
+		return nil, newError(StatusFontTypeMismatch, err.Error())
+	}
+
+	ff := &toyFontFace{
+		baseFontFace: baseFontFace{
+			refCount: 1,
+			status:   StatusSuccess,
+			fontType: FontTypeToy,
+			userData: make(map[*UserDataKey]interface{}),
+		},
+		realFace: face,
+		fontData: data,
+	}
+	return ff, nil
+}
+
 // FontFace interface implementation for toyFontFace.
 
 func (f *toyFontFace) Reference() FontFace {
@@ -387,7 +480,7 @@ func (f *toyFontFace) Reference() FontFace {
 
 func (f *toyFontFace) Destroy() {
 	if atomic.AddInt32(&f.refCount, -1) == 0 {
-		// nothing to free at the moment
+		destroyUserData(f.userData)
 	}
 }
 
@@ -410,20 +503,103 @@ func (f *toyFontFace) SetUserData(key *UserDataKey, userData unsafe.Pointer, des
 	if f.userData == nil {
 		f.userData = make(map[*UserDataKey]interface{})
 	}
-	f.userData[key] = userData
-	// destroy func is currently ignored, consistent with other parts of this package
-	_ = destroy
+	setUserData(f.userData, key, userData, destroy)
 	return StatusSuccess
 }
 
 func (f *toyFontFace) GetUserData(key *UserDataKey) unsafe.Pointer {
-	if f.userData == nil {
+	return getUserData(f.userData, key)
+}
+
+// SetVariations selects coordinates on the variation axes of the underlying
+// OpenType font. The shared cached face is copied so other font faces
+// loaded from the same font are unaffected.
+func (f *toyFontFace) SetVariations(variations map[string]float64) Status {
+	if f.status != StatusSuccess {
+		return f.status
+	}
+	face, status := applyFontVariations(f.realFace, variations)
+	if status != StatusSuccess {
+		return status
+	}
+	f.realFace = face
+	f.variations = copyFontVariations(variations)
+	return StatusSuccess
+}
+
+// SetVariationsString parses settings like "wght=550,wdth=75" and applies them.
+func (f *toyFontFace) SetVariationsString(settings string) Status {
+	variations, status := ParseFontVariations(settings)
+	if status != StatusSuccess {
+		return status
+	}
+	return f.SetVariations(variations)
+}
+
+// GetVariations returns a copy of the current axis coordinates.
+func (f *toyFontFace) GetVariations() map[string]float64 {
+	return copyFontVariations(f.variations)
+}
+
+// ParseFontVariations parses a comma separated list of tag=value pairs,
+// e.g. "wght=550,wdth=75", into a variations map.
+func ParseFontVariations(settings string) (map[string]float64, Status) {
+	variations := make(map[string]float64)
+	for _, item := range strings.Split(settings, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		tag, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, StatusInvalidString
+		}
+		tag = strings.Trim(strings.TrimSpace(tag), `"'`)
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || len(tag) != 4 {
+			return nil, StatusInvalidString
+		}
+		variations[tag] = v
+	}
+	return variations, StatusSuccess
+}
+
+// applyFontVariations returns a copy of face with the given axis coordinates set.
+func applyFontVariations(face font.Face, variations map[string]float64) (font.Face, Status) {
+	if face == nil {
+		return nil, StatusFontTypeMismatch
+	}
+	tags := make([]string, 0, len(variations))
+	for tag := range variations {
+		if len(tag) != 4 {
+			return nil, StatusInvalidString
+		}
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	vars := make([]apifont.Variation, 0, len(tags))
+	for _, tag := range tags {
+		vars = append(vars, apifont.Variation{
+			Tag:   loader.MustNewTag(tag),
+			Value: float32(variations[tag]),
+		})
+	}
+
+	varied := *face
+	varied.SetVariations(vars)
+	return &varied, StatusSuccess
+}
+
+func copyFontVariations(variations map[string]float64) map[string]float64 {
+	if variations == nil {
 		return nil
 	}
-	if data, ok := f.userData[key]; ok {
-		return data.(unsafe.Pointer)
+	out := make(map[string]float64, len(variations))
+	for tag, value := range variations {
+		out[tag] = value
 	}
-	return nil
+	return out
 }
 
 // ---------------- ScaledFont implementation (cairo_scaled_font_t) ----------------
@@ -561,6 +737,34 @@ func (s *scaledFont) getRealFace() (font.Face, Status) {
 	return toy.realFace, StatusSuccess
 }
 
+// colorGlyphs returns the color glyph tables of the scaled font's face, or
+// nil for plain fonts
+func (s *scaledFont) colorGlyphs() *colorFontTables {
+	switch f := s.fontFace.(type) {
+	case *toyFontFace:
+		return f.colorGlyphs()
+	case *PangoCairoFont:
+		return f.colorGlyphs()
+	}
+	return nil
+}
+
+// glyphOutline extracts the vector outline from glyph data. Bitmap and SVG
+// glyphs may carry an outline alongside their image data.
+func glyphOutline(data api.GlyphData) (api.GlyphOutline, bool) {
+	switch g := data.(type) {
+	case api.GlyphOutline:
+		return g, true
+	case api.GlyphBitmap:
+		if g.Outline != nil {
+			return *g.Outline, true
+		}
+	case api.GlyphSVG:
+		return g.Outline, len(g.Outline.Segments) > 0
+	}
+	return api.GlyphOutline{}, false
+}
+
 // Extents returns font extents using the real font face.
 func (s *scaledFont) Extents() *FontExtents {
 	fe := &FontExtents{}
@@ -604,17 +808,70 @@ func (s *scaledFont) Extents() *FontExtents {
 	fe.MaxXAdvance = fe.Ascent + fe.Descent
 	fe.MaxYAdvance = 0
 
-	// Calculate underline metrics
-	fe.UnderlinePosition = -fe.Descent * 0.5
-	fe.UnderlineThickness = (fe.Ascent + fe.Descent) * 0.05
+	// Underline and strikethrough as the font specifies them
+	underlinePos, underlineThick, strikePos, strikeThick := fontLineMetrics(realFace)
+	fe.UnderlinePosition = underlinePos / 64.0
+	fe.UnderlineThickness = underlineThick / 64.0
+	fe.StrikethroughPosition = strikePos / 64.0
+	fe.StrikethroughThickness = strikeThick / 64.0
 
-	// Approximate cap height and x-height
-	fe.CapHeight = fe.Ascent * 0.7 // Typical ratio
-	fe.XHeight = fe.Ascent * 0.5   // Typical ratio
+	// Cap height and x-height as the font declares or its glyphs measure
+	capHeight, xHeight := fontCapAndXHeight(realFace)
+	fe.CapHeight = capHeight / 64.0
+	fe.XHeight = xHeight / 64.0
 
 	return fe
 }
 
+// fontLineMetrics returns the underline and strikethrough of face in font
+// units with y up: the distance above the baseline of each line's top and
+// its thickness, as the post and OS/2 tables give them. Faces leaving them
+// out get the usual approximations, an underline half the descent below the
+// baseline and a strikethrough centered on half the x-height, both 5% of
+// the ascent plus descent thick.
+func fontLineMetrics(face font.Face) (underlinePos, underlineThick, strikePos, strikeThick float64) {
+	extents, _ := face.FontHExtents()
+	ascent, descent := float64(extents.Ascender), -float64(extents.Descender)
+
+	underlinePos = float64(face.LineMetric(api.UnderlinePosition))
+	underlineThick = float64(face.LineMetric(api.UnderlineThickness))
+	if underlineThick <= 0 {
+		underlinePos = -descent * 0.5
+		underlineThick = (ascent + descent) * 0.05
+	}
+
+	strikePos = float64(face.LineMetric(api.StrikethroughPosition))
+	strikeThick = float64(face.LineMetric(api.StrikethroughThickness))
+	if strikeThick <= 0 {
+		_, xHeight := fontCapAndXHeight(face)
+		strikeThick = underlineThick
+		strikePos = (xHeight + strikeThick) / 2
+	}
+	return underlinePos, underlineThick, strikePos, strikeThick
+}
+
+// fontCapAndXHeight returns the cap height and x-height of face in font
+// units, as the OS/2 table declares them. Older fonts without them are
+// measured from the tops of their 'H' and 'x' glyphs, and only fonts
+// lacking those too get the usual ratios of the ascent.
+func fontCapAndXHeight(face font.Face) (capHeight, xHeight float64) {
+	extents, _ := face.FontHExtents()
+	ascent := float64(extents.Ascender)
+
+	height := func(metric api.LineMetric, r rune, ratio float64) float64 {
+		if h := float64(face.LineMetric(metric)); h > 0 {
+			return h
+		}
+		if gid, ok := face.NominalGlyph(r); ok {
+			if ext, ok := face.GlyphExtents(gid); ok && ext.YBearing > 0 {
+				return float64(ext.YBearing)
+			}
+		}
+		return ascent * ratio
+	}
+	return height(api.CapHeight, 'H', 0.7), height(api.XHeight, 'x', 0.5)
+}
+
 // toyExtentsFallback returns toy font extents based on the derived font size.
 func (s *scaledFont) toyExtentsFallback() *FontExtents {
 	// Use average of xx and yy scale as size; fall back to 12 if zero.
@@ -635,12 +892,17 @@ func (s *scaledFont) toyExtentsFallback() *FontExtents {
 	fe.UnderlineThickness = size * 0.05
 	fe.CapHeight = fe.Ascent * 0.7 // Typical ratio
 	fe.XHeight = fe.Ascent * 0.5   // Typical ratio
+	fe.StrikethroughPosition = (fe.XHeight + fe.UnderlineThickness) / 2
+	fe.StrikethroughThickness = fe.UnderlineThickness
 	return fe
 }
 
 // TextExtents computes text extents using the real font face and shaping.
 func (s *scaledFont) TextExtents(utf8 string) *TextExtents {
 	ext := &TextExtents{}
+	if !validText(utf8) {
+		return ext
+	}
 
 	realFace, status := s.getRealFace()
 	if status != StatusSuccess {
@@ -657,7 +919,7 @@ func (s *scaledFont) TextExtents(utf8 string) *TextExtents {
 		Face:      realFace,
 		Size:      fixed.I(12), // Default size, will be scaled by font matrix
 	}
-	output := (&shaping.HarfbuzzShaper{}).Shape(input)
+	output := shapeText(input)
 
 	// 2. Calculate extents from shaped output
 	// Scale factor from font matrix
@@ -673,8 +935,7 @@ func (s *scaledFont) TextExtents(utf8 string) *TextExtents {
 		totalAdvance += g.XAdvance
 
 		// Get glyph outline for bounds calculation
-		glyphData := realFace.GlyphData(api.GID(g.GlyphID))
-		if outline, ok := glyphData.(api.GlyphOutline); ok {
+		if outline, ok := sharedGlyphCache.outline(realFace, api.GID(g.GlyphID)); ok {
 			// Convert outline points to user space and apply font matrix scaling
 			for _, seg := range outline.Segments {
 				for _, arg := range seg.Args {
@@ -743,20 +1004,75 @@ func (s *scaledFont) toyTextExtentsFallback(utf8 string) *TextExtents {
 	return ext
 }
 
-// GlyphExtents computes extents based on glyph positions.
+// GlyphExtents computes the ink extents and total advance of positioned
+// glyphs, as cairo_scaled_font_glyph_extents does. Bearings are relative to
+// the origin of the first glyph.
 func (s *scaledFont) GlyphExtents(glyphs []Glyph) *TextExtents {
-	if len(glyphs) == 0 {
+	realFace, status := s.getRealFace()
+	if status != StatusSuccess {
 		return &TextExtents{}
 	}
-	// Assume glyph positions are advances from origin.
-	last := glyphs[len(glyphs)-1]
+
+	// Metrics are in font units/64 with y up, while glyph positions from
+	// TextToGlyphs are in user space, so scale by font size per em and flip y.
+	upem := float64(realFace.Upem())
+	sx := math.Hypot(s.fontMatrix.XX, s.fontMatrix.YX) * 64 / upem
+	sy := math.Hypot(s.fontMatrix.XY, s.fontMatrix.YY) * 64 / upem
+	return glyphRunExtents(glyphs, func(gid api.GID) (*GlyphMetrics, bool) {
+		m, status := s.glyphMetrics(realFace, gid)
+		if status != StatusSuccess {
+			return nil, false
+		}
+		m.BoundingBox.XMin, m.BoundingBox.XMax = m.BoundingBox.XMin*sx, m.BoundingBox.XMax*sx
+		m.BoundingBox.YMin, m.BoundingBox.YMax = -m.BoundingBox.YMax*sy, -m.BoundingBox.YMin*sy
+		m.XAdvance *= sx
+		m.YAdvance *= sy
+		return m, true
+	})
+}
+
+// glyphRunExtents unions the ink boxes of glyphs, each offset by its
+// position, and sums their advances. metrics must return bounding boxes in
+// user space with y down, relative to the glyph origin; glyphs it cannot
+// measure contribute no ink and no advance.
+func glyphRunExtents(glyphs []Glyph, metrics func(gid api.GID) (*GlyphMetrics, bool)) *TextExtents {
 	ext := &TextExtents{}
-	ext.XAdvance = last.X
-	ext.YAdvance = last.Y
-	ext.Width = last.X
-	ext.Height = s.Extents().Height
-	ext.XBearing = 0
-	ext.YBearing = -s.Extents().Ascent
+	if len(glyphs) == 0 {
+		return ext
+	}
+
+	var x0, y0, x1, y1 float64
+	hasInk := false
+	for _, g := range glyphs {
+		m, ok := metrics(api.GID(g.Index))
+		if !ok {
+			continue
+		}
+		ext.XAdvance += m.XAdvance
+		ext.YAdvance += m.YAdvance
+
+		box := m.BoundingBox
+		if box.XMax <= box.XMin || box.YMax <= box.YMin {
+			// Blank glyphs such as spaces have no ink
+			continue
+		}
+		gx0, gy0 := g.X+box.XMin, g.Y+box.YMin
+		gx1, gy1 := g.X+box.XMax, g.Y+box.YMax
+		if !hasInk {
+			x0, y0, x1, y1 = gx0, gy0, gx1, gy1
+			hasInk = true
+			continue
+		}
+		x0, y0 = math.Min(x0, gx0), math.Min(y0, gy0)
+		x1, y1 = math.Max(x1, gx1), math.Max(y1, gy1)
+	}
+
+	if hasInk {
+		ext.XBearing = x0 - glyphs[0].X
+		ext.YBearing = y0 - glyphs[0].Y
+		ext.Width = x1 - x0
+		ext.Height = y1 - y0
+	}
 	return ext
 }
 
@@ -767,109 +1083,26 @@ func (s *scaledFont) GlyphPath(glyphID uint64) (*Path, error) {
 		return nil, newError(status, "failed to get real font face")
 	}
 
-	// Load the glyph outline from the font face
 	gid := api.GID(glyphID)
-	glyphData := realFace.GlyphData(gid)
+	key := newGlyphCacheKey(realFace, glyphEntryPath, gid, &s.fontMatrix, s.options)
+	if cached, ok := sharedGlyphCache.lookup(key); ok {
+		return copyPath(cached.(*Path)), nil
+	}
 
-	// Extract outline from glyph data
-	outline, ok := glyphData.(api.GlyphOutline)
+	// Load the glyph outline from the font face
+	outline, ok := sharedGlyphCache.outline(realFace, gid)
 	if !ok {
 		return nil, newError(StatusFontTypeMismatch, "glyph has no outline")
 	}
 
-	// Convert the outline to cairo.Path
-	cairoPath := &Path{
-		Status: StatusSuccess,
-		Data:   make([]PathData, 0),
-	}
-
-	// Scale factor from font matrix
+	// Outlines are in font units, like for PangoCairoScaledFont
+	unitsPerEm := float64(realFace.Upem())
 	sx := math.Hypot(s.fontMatrix.XX, s.fontMatrix.YX)
 	sy := math.Hypot(s.fontMatrix.XY, s.fontMatrix.YY)
+	cairoPath := outlinePath(outline, unitsPerEm, sx, sy)
 
-	// Check if we need to flip the Y axis based on the font matrix
-	// Font glyphs are designed for Y growing upward, but our coordinate system has Y growing downward.
-	// Since we now use positive Y scale in font matrix, we always need to flip.
-	flipY := true
-
-	// Iterate over the path segments
-	var pathPoints []Point
-	for _, seg := range outline.Segments {
-		switch seg.Op {
-		case api.SegmentOpMoveTo:
-			// Convert from fixed point and apply font matrix scaling
-			x := float64(seg.Args[0].X) / 64.0 * sx
-			y := float64(seg.Args[0].Y) / 64.0 * sy
-			// Apply Y flip if needed
-			if flipY {
-				y = -y
-			}
-			point := Point{X: x, Y: y}
-			pathPoints = append(pathPoints, point)
-		case api.SegmentOpLineTo:
-			// Convert from fixed point and apply font matrix scaling
-			x := float64(seg.Args[0].X) / 64.0 * sx
-			y := float64(seg.Args[0].Y) / 64.0 * sy
-			// Apply Y flip if needed
-			if flipY {
-				y = -y
-			}
-			point := Point{X: x, Y: y}
-			pathPoints = append(pathPoints, point)
-		case api.SegmentOpQuadTo:
-			// Convert quadratic to cubic Bezier
-			// Convert from fixed point and apply font matrix scaling
-			x1 := float64(seg.Args[0].X) / 64.0 * sx
-			y1 := float64(seg.Args[0].Y) / 64.0 * sy
-			x2 := float64(seg.Args[1].X) / 64.0 * sx
-			y2 := float64(seg.Args[1].Y) / 64.0 * sy
-			// Apply Y flip if needed
-			if flipY {
-				y1 = -y1
-				y2 = -y2
-			}
-			p1 := Point{X: x1, Y: y1}
-			p2 := Point{X: x2, Y: y2}
-			pathPoints = append(pathPoints, p1, p1, p2)
-		case api.SegmentOpCubeTo:
-			// Convert from fixed point and apply font matrix scaling
-			x1 := float64(seg.Args[0].X) / 64.0 * sx
-			y1 := float64(seg.Args[0].Y) / 64.0 * sy
-			x2 := float64(seg.Args[1].X) / 64.0 * sx
-			y2 := float64(seg.Args[1].Y) / 64.0 * sy
-			x3 := float64(seg.Args[2].X) / 64.0 * sx
-			y3 := float64(seg.Args[2].Y) / 64.0 * sy
-			// Apply Y flip if needed
-			if flipY {
-				y1 = -y1
-				y2 = -y2
-				y3 = -y3
-			}
-			p1 := Point{X: x1, Y: y1}
-			p2 := Point{X: x2, Y: y2}
-			p3 := Point{X: x3, Y: y3}
-			pathPoints = append(pathPoints, p1, p2, p3)
-		}
-	}
-
-	// Apply hinting to the path points
-	hintedPoints := s.applyHinting(pathPoints)
-
-	// Convert hinted points back to path data
-	// This is a simplified approach - in reality, we'd need to preserve
-	// the segment structure while applying hinting
-	for i, point := range hintedPoints {
-		var pd PathData
-		if i == 0 {
-			pd.Type = PathMoveTo
-		} else {
-			pd.Type = PathLineTo
-		}
-		pd.Points = []Point{point}
-		cairoPath.Data = append(cairoPath.Data, pd)
-	}
-
-	return cairoPath, nil
+	sharedGlyphCache.store(key, cairoPath)
+	return copyPath(cairoPath), nil
 }
 
 // GetTextBearingMetrics returns the bearing metrics for a text string
@@ -890,38 +1123,17 @@ func (s *scaledFont) GetTextAlignmentOffset(alignment TextAlignment) (float64, S
 	return GetAlignmentOffset(alignment, fontExtents), StatusSuccess
 }
 
-// GetKerning returns the kerning adjustment between two runes
+// GetKerning returns the kerning adjustment between two runes, as applied
+// by the shaper (GPOS, or the legacy kern/kerx tables).
 func (s *scaledFont) GetKerning(r1, r2 rune) (float64, Status) {
 	realFace, status := s.getRealFace()
 	if status != StatusSuccess {
 		return 0, status
 	}
 
-	// Get the glyph indices for the runes
-	gid1, ok1 := realFace.NominalGlyph(r1)
-	gid2, ok2 := realFace.NominalGlyph(r2)
-	if !ok1 || !ok2 {
-		return 0, StatusInvalidGlyph
-	}
-
-	// Check if we have kerning data
-	var kernValue int16
-	if len(realFace.Kern) > 0 {
-		// Try Kern tables first
-		for _, kernSubtable := range realFace.Kern {
-			if kd, ok := kernSubtable.Data.(apifont.Kern0); ok {
-				kernValue = kd.KernPair(gid1, gid2)
-				break
-			}
-		}
-	} else if len(realFace.Kerx) > 0 {
-		// Try Kerx tables if no Kern tables
-		for _, kerxSubtable := range realFace.Kerx {
-			if kd, ok := kerxSubtable.Data.(apifont.Kern0); ok {
-				kernValue = kd.KernPair(gid1, gid2)
-				break
-			}
-		}
+	kernValue, status := shapedPairKerning(realFace, r1, r2)
+	if status != StatusSuccess {
+		return 0, status
 	}
 
 	// Scale factor from font matrix
@@ -929,11 +1141,45 @@ func (s *scaledFont) GetKerning(r1, r2 rune) (float64, Status) {
 	unitsPerEm := float64(realFace.Upem())
 
 	// Convert kerning value to user space units
-	kerning := float64(kernValue) * sx / unitsPerEm
+	kerning := kernValue * sx / unitsPerEm
 
 	return kerning, StatusSuccess
 }
 
+// shapedPairKerning returns the kerning between r1 and r2 in font units. The
+// pair is shaped at one unit per em so the result matches the positioning
+// TextToGlyphs gets from the shaper, whichever table the font kerns with.
+func shapedPairKerning(face font.Face, r1, r2 rune) (float64, Status) {
+	gid1, ok1 := face.NominalGlyph(r1)
+	gid2, ok2 := face.NominalGlyph(r2)
+	if !ok1 || !ok2 {
+		return 0, StatusInvalidGlyph
+	}
+
+	text := string([]rune{r1, r2})
+	output := shapeText(shaping.Input{
+		Text:      []rune{r1, r2},
+		RunStart:  0,
+		RunEnd:    2,
+		Direction: di.DirectionLTR,
+		Face:      face,
+		Size:      fixed.I(int(face.Upem())),
+		Language:  convertLanguage(DetectLanguage(text)),
+		Script:    convertScript(DetectScript(text)),
+	})
+	// Ligatures and decompositions have no pair kerning
+	if len(output.Glyphs) != 2 {
+		return 0, StatusSuccess
+	}
+
+	var shaped fixed.Int26_6
+	for _, g := range output.Glyphs {
+		shaped += g.XAdvance
+	}
+	nominal := face.HorizontalAdvance(gid1) + face.HorizontalAdvance(gid2)
+	return float64(shaped)/64 - float64(nominal), StatusSuccess
+}
+
 // applyHinting applies font hinting based on the font options
 func (s *scaledFont) applyHinting(points []Point) []Point {
 	// If no options or hinting is disabled, return points as-is
@@ -969,10 +1215,19 @@ func (s *scaledFont) GetGlyphMetrics(r rune) (*GlyphMetrics, Status) {
 	if !ok || gid == 0 {
 		return nil, StatusInvalidGlyph
 	}
+	return s.glyphMetrics(realFace, gid)
+}
+
+// glyphMetrics returns the metrics of glyph gid in realFace.
+func (s *scaledFont) glyphMetrics(realFace font.Face, gid api.GID) (*GlyphMetrics, Status) {
+	key := newGlyphCacheKey(realFace, glyphEntryMetrics, gid, &s.fontMatrix, s.options)
+	if cached, ok := sharedGlyphCache.lookup(key); ok {
+		metrics := *cached.(*GlyphMetrics)
+		return &metrics, StatusSuccess
+	}
 
 	// Load glyph outline
-	glyphData := realFace.GlyphData(gid)
-	outline, ok := glyphData.(api.GlyphOutline)
+	outline, ok := sharedGlyphCache.outline(realFace, gid)
 	if !ok {
 		return nil, StatusFontTypeMismatch
 	}
@@ -1043,6 +1298,8 @@ func (s *scaledFont) GetGlyphMetrics(r rune) (*GlyphMetrics, Status) {
 	metrics.LSB = xmin
 	metrics.RSB = advanceWidth - xmax
 
+	cached := *metrics
+	sharedGlyphCache.store(key, &cached)
 	return metrics, StatusSuccess
 }
 
@@ -1064,7 +1321,7 @@ func (s *scaledFont) GetGlyphs(utf8 string) (glyphs []Glyph, status Status) {
 		Face:      realFace,
 		Size:      fixed.I(12),
 	}
-	output := (&shaping.HarfbuzzShaper{}).Shape(input)
+	output := shapeText(input)
 
 	// 2. Convert shaped output to cairo's Glyph structures
 	glyphs = make([]Glyph, len(output.Glyphs))
@@ -1076,8 +1333,6 @@ func (s *scaledFont) GetGlyphs(utf8 string) (glyphs []Glyph, status Status) {
 		}
 	}
 
-	// TODO: Integrate with color font (COLRv0/1) handling for 1.18+ compatibility.
-
 	return glyphs, StatusSuccess
 }
 
@@ -1088,6 +1343,9 @@ func (s *scaledFont) TextToGlyphs(x, y float64, utf8 string) (glyphs []Glyph, cl
 
 // TextToGlyphsWithOptions performs text shaping with advanced OpenType features
 func (s *scaledFont) TextToGlyphsWithOptions(x, y float64, utf8 string, options *ShapingOptions) (glyphs []Glyph, clusters []TextCluster, clusterFlags TextClusterFlags, status Status) {
+	if !validText(utf8) {
+		return nil, nil, 0, StatusInvalidString
+	}
 	realFace, status := s.getRealFace()
 	if status != StatusSuccess {
 		return s.toyTextToGlyphsFallback(x, y, utf8)
@@ -1111,13 +1369,6 @@ func (s *scaledFont) TextToGlyphsWithOptions(x, y float64, utf8 string, options
 		lineHeight = fontSize * 1.2 // Fallback to 120% of font size
 	}
 
-	// Get the CTM (Current Transformation Matrix)
-	ctm := s.GetCTM()
-
-	// Transform the initial position (x, y) by CTM
-	transformedX := ctm.XX*x + ctm.XY*y + ctm.X0
-	transformedY := ctm.YX*x + ctm.YY*y + ctm.Y0
-
 	// Use default options if not provided
 	if options == nil {
 		options = NewShapingOptions()
@@ -1162,35 +1413,26 @@ func (s *scaledFont) TextToGlyphsWithOptions(x, y float64, utf8 string, options
 			Language:  convertLanguage(options.Language),
 			Script:    convertScript(options.Script),
 		}
-		output := (&shaping.HarfbuzzShaper{}).Shape(input)
+		output := shapeText(input)
 
 		// 2. Convert shaped output to cairo's Glyph and TextCluster structures
 		var curX float64
 
 		// Process each glyph with proper spacing
-		for glyphIdx, g := range output.Glyphs {
+		for _, g := range output.Glyphs {
 			// Position is in user space, relative to the start point (x, y)
 			glyph := Glyph{
 				Index: uint64(g.GlyphID),
-				X:     transformedX + curX + float64(g.XOffset)/64.0,
-				Y:     transformedY + curY - float64(g.YOffset)/64.0, // Subtract because glyph offsets are in font coordinate system
+				X:     x + curX + float64(g.XOffset)/64.0,
+				Y:     y + curY - float64(g.YOffset)/64.0, // Subtract because glyph offsets are in font coordinate system
 			}
 			glyphs = append(glyphs, glyph)
 
-			// Add the advance width for the next glyph
+			// Add the advance width for the next glyph. The shaper has
+			// already applied kerning, so it must not be added again here.
 			advance := float64(g.XAdvance) / 64.0
 			curX += advance
 
-			// Add kerning between characters if this is not the last glyph
-			if glyphIdx < len(runes)-1 {
-				// Get kerning adjustment between current and next glyph
-				kerning, kernStatus := s.GetKerning(runes[glyphIdx], runes[glyphIdx+1])
-				// Only apply kerning if successfully obtained
-				if kernStatus == StatusSuccess {
-					curX += kerning
-				}
-			}
-
 			// Add vertical advance
 			curY += float64(g.YAdvance) / 64.0
 		}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/go-text/typesetting/font"
@@ -18,8 +19,59 @@ var (
 	fontCache     = make(map[string]font.Face)
 	fontDataCache = make(map[string][]byte)
 	fontCacheMu   sync.RWMutex
+
+	// Font data added with RegisterFont, by family name; guarded by
+	// fontCacheMu
+	registeredFonts = make(map[string][]byte)
 )
 
+// registeredFontPrefix starts the cache keys of registered fonts, keeping
+// them apart from embedded font names and file paths.
+const registeredFontPrefix = "registered:"
+
+// RegisterFont adds a TrueType/OpenType font under the family name name,
+// so that NewToyFontFace, NewPangoCairoFont and SelectFontFace find it
+// like a built-in family. Registered families take precedence over the
+// built-in ones, and registering a name again replaces its font. The font
+// is parsed once and shared by all faces created for it; the data must not
+// be modified afterwards. It is safe for concurrent use.
+func RegisterFont(name string, data []byte) error {
+	if name == "" {
+		return newError(StatusInvalidString, "empty font name")
+	}
+	face, err := font.ParseTTF(bytes.NewReader(data))
+	if err != nil {
+		return newError(StatusFontTypeMismatch, err.Error())
+	}
+
+	key := registeredFontPrefix + name
+	fontCacheMu.Lock()
+	registeredFonts[name] = data
+	fontCache[key] = face
+	fontDataCache[key] = data
+	fontCacheMu.Unlock()
+	return nil
+}
+
+// registeredFontKey returns the key LoadEmbeddedFont loads the font
+// registered as family with, and whether there is one.
+func registeredFontKey(family string) (string, bool) {
+	fontCacheMu.RLock()
+	_, ok := registeredFonts[family]
+	fontCacheMu.RUnlock()
+	return registeredFontPrefix + family, ok
+}
+
+// ClearFontCache drops the parsed fonts kept for reuse, so that they are
+// read and parsed again when next needed. Font faces already created keep
+// their fonts, and registered fonts stay registered.
+func ClearFontCache() {
+	fontCacheMu.Lock()
+	fontCache = make(map[string]font.Face)
+	fontDataCache = make(map[string][]byte)
+	fontCacheMu.Unlock()
+}
+
 // Internal font data storage
 var embeddedFonts = map[string][]byte{
 	"Go-Regular":       goregular.TTF,
@@ -127,8 +179,13 @@ func LoadEmbeddedFont(name string) (font.Face, []byte, error) {
 		}
 	}
 
-	// Try loading from embedded fonts
+	// Try loading from embedded or registered fonts
 	data, ok := embeddedFonts[name]
+	if family, isRegistered := strings.CutPrefix(name, registeredFontPrefix); isRegistered {
+		fontCacheMu.RLock()
+		data, ok = registeredFonts[family]
+		fontCacheMu.RUnlock()
+	}
 	if !ok {
 		// Try loading from assets directory
 		assetsPath := filepath.Join("assets", name+".ttf")
@@ -0,0 +1,363 @@
+package cairo
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// WriteToGIF writes the surface to a GIF file. See WriteToGIFStream.
+func (s *imageSurface) WriteToGIF(filename string) Status {
+	if s.status != StatusSuccess {
+		return s.status
+	}
+	// Check the format before creating the file, so it is left alone
+	if s.goImage == nil {
+		return StatusInvalidFormat
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return StatusWriteError
+	}
+
+	status := s.WriteToGIFStream(func(closure interface{}, data []byte) error {
+		_, err := closure.(*os.File).Write(data)
+		return err
+	}, file)
+	if err := file.Close(); err != nil && status == StatusSuccess {
+		status = StatusWriteError
+	}
+	return status
+}
+
+// WriteToGIFStream encodes the surface as a GIF and passes the bytes to
+// write, like WriteToPNGStream. The pixels are reduced to a palette of at
+// most 256 colors chosen by median cut and mapped to it with the surface's
+// dither mode. Pixels less than half opaque become transparent; the others
+// lose their alpha. A8, A1 and RGB30 surfaces give StatusInvalidFormat.
+func (s *imageSurface) WriteToGIFStream(write WriteFunc, closure interface{}) Status {
+	if s.status != StatusSuccess {
+		return s.status
+	}
+	if s.goImage == nil {
+		return StatusInvalidFormat
+	}
+
+	g := NewGIFWriter(&funcWriter{write, closure}, s.width, s.height)
+	if status := g.AddFrame(s, 0); status != StatusSuccess {
+		return status
+	}
+	return g.Finish()
+}
+
+// GIFWriter assembles image surfaces into an animated GIF. Each frame gets
+// its own palette, quantized as WriteToGIFStream does with the dither mode
+// of the surface it comes from. Frames are kept until Finish writes the
+// file, which loops forever.
+type GIFWriter struct {
+	w             io.Writer
+	width, height int
+	anim          gif.GIF
+	status        Status
+}
+
+// NewGIFWriter returns a writer of a width by height animated GIF to w.
+func NewGIFWriter(w io.Writer, width, height int) *GIFWriter {
+	g := &GIFWriter{w: w, width: width, height: height}
+	if w == nil {
+		g.status = StatusNullPointer
+	} else if width <= 0 || height <= 0 || width > 0xffff || height > 0xffff {
+		g.status = StatusInvalidSize
+	}
+	return g
+}
+
+// Status returns the writer's error status. Once an operation fails, the
+// writer stays in error and does nothing more.
+func (g *GIFWriter) Status() Status {
+	return g.status
+}
+
+// AddFrame appends the surface's current contents as the next frame, shown
+// for delayMs milliseconds, rounded to the hundredths of a second GIF
+// stores. The surface must have the animation's size.
+func (g *GIFWriter) AddFrame(surface ImageSurface, delayMs int) Status {
+	if g.status != StatusSuccess {
+		return g.status
+	}
+	if surface == nil {
+		return g.setError(StatusNullPointer)
+	}
+	if status := surface.Status(); status != StatusSuccess {
+		return g.setError(status)
+	}
+	if surface.GetWidth() != g.width || surface.GetHeight() != g.height {
+		return g.setError(StatusInvalidSize)
+	}
+	if delayMs < 0 {
+		return g.setError(StatusInvalidSize)
+	}
+
+	img := straightAlphaImage(surface)
+	if img == nil {
+		return g.setError(StatusSurfaceTypeMismatch)
+	}
+	frame, transparent := quantizeImage(img, surface.GetDither())
+
+	// Frames with transparent pixels are cleared before the next one, which
+	// would otherwise show through them
+	disposal := byte(gif.DisposalNone)
+	if transparent {
+		disposal = gif.DisposalBackground
+	}
+	g.anim.Image = append(g.anim.Image, frame)
+	g.anim.Delay = append(g.anim.Delay, (delayMs+5)/10)
+	g.anim.Disposal = append(g.anim.Disposal, disposal)
+	return StatusSuccess
+}
+
+// Finish writes the animation to the writer. At least one frame must have
+// been added. The writer can't be used afterwards.
+func (g *GIFWriter) Finish() Status {
+	if g.status != StatusSuccess {
+		return g.status
+	}
+	if len(g.anim.Image) == 0 {
+		return g.setError(StatusInvalidSize)
+	}
+	defer g.setError(StatusSurfaceFinished)
+
+	g.anim.Config = image.Config{Width: g.width, Height: g.height}
+	if err := gif.EncodeAll(g.w, &g.anim); err != nil {
+		g.status = StatusWriteError
+		return StatusWriteError
+	}
+	return StatusSuccess
+}
+
+func (g *GIFWriter) setError(status Status) Status {
+	if g.status == StatusSuccess {
+		g.status = status
+	}
+	return status
+}
+
+// quantizeImage maps img to a median-cut palette with the dither mode.
+// Pixels less than half opaque take a transparent palette entry, added
+// last, in which case transparent is true.
+func quantizeImage(img *image.NRGBA, dither Dither) (frame *image.Paletted, transparent bool) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	opaque := func(x, y int) bool {
+		return img.Pix[y*img.Stride+x*4+3] >= 0x80
+	}
+	for y := 0; y < h && !transparent; y++ {
+		for x := 0; x < w && !transparent; x++ {
+			transparent = !opaque(x, y)
+		}
+	}
+
+	maxColors := 256
+	if transparent {
+		maxColors--
+	}
+	palette := medianCutPalette(img, maxColors)
+	colors := len(palette)
+	if transparent {
+		palette = append(palette, color.NRGBA{})
+	}
+	frame = image.NewPaletted(image.Rect(0, 0, w, h), palette)
+	if colors == 0 {
+		// Nothing but transparent pixels, which index 0 already is
+		return frame, transparent
+	}
+
+	// Nearest palette entry by squared RGB distance, remembered per color
+	nearestCache := make(map[[3]uint8]uint8)
+	nearest := func(r, g, b float64) uint8 {
+		key := [3]uint8{clampByte(r), clampByte(g), clampByte(b)}
+		if i, ok := nearestCache[key]; ok {
+			return i
+		}
+		best, bestDist := 0, math.MaxInt
+		for i, c := range palette[:colors] {
+			pc := c.(color.NRGBA)
+			dr, dg, db := int(key[0])-int(pc.R), int(key[1])-int(pc.G), int(key[2])-int(pc.B)
+			if d := dr*dr + dg*dg + db*db; d < bestDist {
+				best, bestDist = i, d
+			}
+		}
+		nearestCache[key] = uint8(best)
+		return uint8(best)
+	}
+
+	var matrix [][]int
+	n := 0
+	switch dither {
+	case DitherFast:
+		n = 4
+	case DitherGood:
+		n = 8
+	}
+	if n > 0 {
+		matrix = bayerMatrix(n)
+	}
+	// Ordered dithering spreads each pixel by up to half the typical
+	// spacing between palette colors
+	spread := 255 / math.Cbrt(float64(colors))
+
+	// Floyd-Steinberg error carried to the pixels not visited yet
+	var errs []float64
+	if dither == DitherBest {
+		errs = make([]float64, w*h*3)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if !opaque(x, y) {
+				frame.Pix[y*frame.Stride+x] = uint8(colors)
+				continue
+			}
+			p := img.Pix[y*img.Stride+x*4:]
+			c := [3]float64{float64(p[0]), float64(p[1]), float64(p[2])}
+			switch {
+			case matrix != nil:
+				t := ((float64(matrix[y%n][x%n])+0.5)/float64(n*n) - 0.5) * spread
+				c[0], c[1], c[2] = c[0]+t, c[1]+t, c[2]+t
+			case errs != nil:
+				c[0], c[1], c[2] = c[0]+errs[i*3], c[1]+errs[i*3+1], c[2]+errs[i*3+2]
+			}
+			index := nearest(c[0], c[1], c[2])
+			frame.Pix[y*frame.Stride+x] = index
+
+			if errs != nil {
+				pc := palette[index].(color.NRGBA)
+				q := [3]float64{float64(pc.R), float64(pc.G), float64(pc.B)}
+				for ch := 0; ch < 3; ch++ {
+					e := c[ch] - q[ch]
+					if x+1 < w {
+						errs[(i+1)*3+ch] += e * 7 / 16
+					}
+					if y+1 < h {
+						if x > 0 {
+							errs[(i+w-1)*3+ch] += e * 3 / 16
+						}
+						errs[(i+w)*3+ch] += e * 5 / 16
+						if x+1 < w {
+							errs[(i+w+1)*3+ch] += e * 1 / 16
+						}
+					}
+				}
+			}
+		}
+	}
+	return frame, transparent
+}
+
+// colorCount is a color of an image and how many of its pixels have it.
+type colorCount struct {
+	c [3]uint8
+	n int
+}
+
+// medianCutPalette returns at most maxColors colors standing for the
+// pixels of img that are at least half opaque. Images with few enough
+// colors get them exactly. Otherwise the colors are split into boxes,
+// each time cutting the box with the widest channel range at the median
+// pixel along that channel, and each box gives the average of its pixels.
+func medianCutPalette(img *image.NRGBA, maxColors int) color.Palette {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	histogram := make(map[[3]uint8]int)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := img.Pix[y*img.Stride+x*4:]
+			if p[3] >= 0x80 {
+				histogram[[3]uint8{p[0], p[1], p[2]}]++
+			}
+		}
+	}
+	entries := make([]colorCount, 0, len(histogram))
+	for c, n := range histogram {
+		entries = append(entries, colorCount{c, n})
+	}
+	// Map order is random; sort so the palette is the same every time
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].c, entries[j].c
+		return a[0] < b[0] || a[0] == b[0] && (a[1] < b[1] || a[1] == b[1] && a[2] < b[2])
+	})
+
+	if len(entries) <= maxColors {
+		palette := make(color.Palette, len(entries))
+		for i, e := range entries {
+			palette[i] = color.NRGBA{e.c[0], e.c[1], e.c[2], 0xff}
+		}
+		return palette
+	}
+
+	boxes := [][]colorCount{entries}
+	for len(boxes) < maxColors {
+		// The box with the widest range in any channel is cut next
+		box, channel, widest := -1, 0, 0
+		for i, b := range boxes {
+			if len(b) < 2 {
+				continue
+			}
+			for ch := 0; ch < 3; ch++ {
+				lo, hi := b[0].c[ch], b[0].c[ch]
+				for _, e := range b[1:] {
+					lo, hi = min(lo, e.c[ch]), max(hi, e.c[ch])
+				}
+				if r := int(hi - lo); r > widest || box < 0 {
+					box, channel, widest = i, ch, r
+				}
+			}
+		}
+		if box < 0 {
+			break
+		}
+
+		b := boxes[box]
+		sort.SliceStable(b, func(i, j int) bool { return b[i].c[channel] < b[j].c[channel] })
+		total := 0
+		for _, e := range b {
+			total += e.n
+		}
+		// The cut falls at the median pixel but leaves both halves a color
+		cut, count := 1, b[0].n
+		for cut < len(b)-1 && count < total/2 {
+			count += b[cut].n
+			cut++
+		}
+		boxes[box] = b[:cut]
+		boxes = append(boxes, b[cut:])
+	}
+
+	palette := make(color.Palette, len(boxes))
+	for i, b := range boxes {
+		var sum [3]int
+		total := 0
+		for _, e := range b {
+			for ch := range sum {
+				sum[ch] += int(e.c[ch]) * e.n
+			}
+			total += e.n
+		}
+		palette[i] = color.NRGBA{
+			uint8((sum[0] + total/2) / total),
+			uint8((sum[1] + total/2) / total),
+			uint8((sum[2] + total/2) / total),
+			0xff,
+		}
+	}
+	return palette
+}
+
+// clampByte rounds v to the nearest byte value.
+func clampByte(v float64) uint8 {
+	return uint8(math.Min(math.Max(math.Round(v), 0), 255))
+}
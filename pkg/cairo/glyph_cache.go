@@ -0,0 +1,104 @@
+package cairo
+
+import (
+	"sync"
+
+	"github.com/go-text/typesetting/font"
+	"github.com/go-text/typesetting/opentype/api"
+)
+
+// glyphCacheLimit bounds the number of cached entries; the cache is
+// cleared when it fills up.
+const glyphCacheLimit = 8192
+
+// glyphEntryKind distinguishes the kinds of values stored for a glyph.
+type glyphEntryKind uint8
+
+const (
+	glyphEntryOutline glyphEntryKind = iota
+	glyphEntryPath
+	glyphEntryPangoPath
+	glyphEntryMetrics
+	glyphEntryPangoMetrics
+)
+
+// glyphCacheKey identifies a cached glyph value. Converted paths and metrics
+// depend on the font matrix scale and hint style; raw outlines only on the
+// face and glyph ID.
+type glyphCacheKey struct {
+	face  font.Face
+	gid   api.GID
+	kind  glyphEntryKind
+	scale [4]float64
+	hint  HintStyle
+}
+
+// glyphCache caches glyph outlines, converted paths and metrics. Faces are
+// shared through the font cache, so entries are reused by every scaled font
+// (and every PangoCairoShowText call) with the same face and scale.
+// It is safe for concurrent use.
+type glyphCache struct {
+	mu      sync.RWMutex
+	entries map[glyphCacheKey]interface{}
+}
+
+var sharedGlyphCache = &glyphCache{entries: make(map[glyphCacheKey]interface{})}
+
+// newGlyphCacheKey builds a key for a scaled glyph value.
+func newGlyphCacheKey(face font.Face, kind glyphEntryKind, gid api.GID, fontMatrix *Matrix, options *FontOptions) glyphCacheKey {
+	key := glyphCacheKey{
+		face:  face,
+		gid:   gid,
+		kind:  kind,
+		scale: [4]float64{fontMatrix.XX, fontMatrix.YX, fontMatrix.XY, fontMatrix.YY},
+	}
+	if options != nil {
+		key.hint = options.HintStyle
+	}
+	return key
+}
+
+func (c *glyphCache) lookup(key glyphCacheKey) (interface{}, bool) {
+	c.mu.RLock()
+	v, ok := c.entries[key]
+	c.mu.RUnlock()
+	return v, ok
+}
+
+func (c *glyphCache) store(key glyphCacheKey, v interface{}) {
+	c.mu.Lock()
+	if len(c.entries) >= glyphCacheLimit {
+		c.entries = make(map[glyphCacheKey]interface{})
+	}
+	c.entries[key] = v
+	c.mu.Unlock()
+}
+
+// outline returns the vector outline of gid, decoding it on first use.
+func (c *glyphCache) outline(face font.Face, gid api.GID) (api.GlyphOutline, bool) {
+	key := glyphCacheKey{face: face, gid: gid, kind: glyphEntryOutline}
+	if v, ok := c.lookup(key); ok {
+		outline, _ := v.(*api.GlyphOutline)
+		if outline == nil {
+			return api.GlyphOutline{}, false
+		}
+		return *outline, true
+	}
+
+	outline, ok := glyphOutline(face.GlyphData(gid))
+	if ok {
+		c.store(key, &outline)
+	} else {
+		c.store(key, (*api.GlyphOutline)(nil))
+	}
+	return outline, ok
+}
+
+// copyPath returns a deep copy so callers cannot modify cached paths.
+func copyPath(p *Path) *Path {
+	out := &Path{Status: p.Status, Data: make([]PathData, len(p.Data))}
+	for i, pd := range p.Data {
+		out.Data[i] = PathData{Type: pd.Type, Points: append([]Point(nil), pd.Points...)}
+	}
+	return out
+}
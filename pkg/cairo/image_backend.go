@@ -28,16 +28,19 @@ func (b *ImageBackend) GetImage() *image.RGBA {
 	return b.img
 }
 
-// Clear 清空图像
-func (b *ImageBackend) Clear(c color.Color) {
-	r, g, bl, a := c.RGBA()
-	fillColor := color.NRGBA{
-		R: uint8(r >> 8),
-		G: uint8(g >> 8),
-		B: uint8(bl >> 8),
-		A: uint8(a >> 8),
+// setImage 将滤波结果写回当前图像缓冲区
+// 原地复制而不是替换 b.img，这样通过 ImageSurface.Backend 获得的后端
+// 会直接修改表面的像素
+func (b *ImageBackend) setImage(img *image.RGBA) {
+	rowBytes := b.width * 4
+	for y := 0; y < b.height; y++ {
+		copy(b.img.Pix[y*b.img.Stride:y*b.img.Stride+rowBytes], img.Pix[y*img.Stride:])
 	}
+}
 
+// Clear 清空图像
+func (b *ImageBackend) Clear(c color.Color) {
+	fillColor := color.RGBAModel.Convert(c)
 	for y := 0; y < b.height; y++ {
 		for x := 0; x < b.width; x++ {
 			b.img.Set(x, y, fillColor)
@@ -121,18 +124,9 @@ func (b *ImageBackend) drawThickPixel(x, y int, c color.Color, width float64) {
 	}
 }
 
-// colorToNRGBA 转换颜色为 NRGBA
+// colorToNRGBA 转换颜色为 NRGBA（非预乘）。c.RGBA() 返回预乘值，需先除以 alpha
 func colorToNRGBA(c color.Color) color.NRGBA {
-	if nrgba, ok := c.(color.NRGBA); ok {
-		return nrgba
-	}
-	r, g, b, a := c.RGBA()
-	return color.NRGBA{
-		R: uint8(r >> 8),
-		G: uint8(g >> 8),
-		B: uint8(b >> 8),
-		A: uint8(a >> 8),
-	}
+	return color.NRGBAModel.Convert(c).(color.NRGBA)
 }
 
 // SmoothBilinear 双线性插值平滑
@@ -188,7 +182,7 @@ func (b *ImageBackend) SmoothBilinear() {
 	}
 
 	// 将结果复制回原图像
-	b.img = temp
+	b.setImage(temp)
 }
 
 // SmoothGaussian 高斯模糊平滑
@@ -246,7 +240,7 @@ func (b *ImageBackend) SmoothGaussian(radius int) {
 		}
 	}
 
-	b.img = temp
+	b.setImage(temp)
 }
 
 // SmoothMedian 中值滤波平滑（去噪效果好）
@@ -292,7 +286,7 @@ func (b *ImageBackend) SmoothMedian(windowSize int) {
 		}
 	}
 
-	b.img = temp
+	b.setImage(temp)
 }
 
 // generateGaussianKernel 生成高斯核
@@ -438,7 +432,7 @@ func (b *ImageBackend) SmoothBilateral(spatialSigma, colorSigma float64) {
 		}
 	}
 
-	b.img = temp
+	b.setImage(temp)
 }
 
 // SmoothWithEdgeDetection 基于边缘检测的选择性平滑（使用高斯模糊）
@@ -466,11 +460,11 @@ func (b *ImageBackend) SmoothBilateralWithEdgeDetection(spatialSigma, colorSigma
 
 	// 步骤3: 对原图应用双边滤波
 	tempBackend := &ImageBackend{
-		img:    image.NewRGBA(b.img.Bounds()),
+		img:    image.NewRGBA(image.Rect(0, 0, b.width, b.height)),
 		width:  b.width,
 		height: b.height,
 	}
-	copy(tempBackend.img.Pix, b.img.Pix)
+	tempBackend.setImage(b.img)
 	tempBackend.SmoothBilateral(spatialSigma, colorSigma)
 
 	// 步骤4: 根据羽化后的掩码混合原图和平滑图
@@ -493,7 +487,7 @@ func (b *ImageBackend) SmoothBilateralWithEdgeDetection(spatialSigma, colorSigma
 		}
 	}
 
-	b.img = smoothed
+	b.setImage(smoothed)
 }
 
 // smoothWithEdgeDetectionInternal 内部方法，支持不同的平滑算法
@@ -511,11 +505,11 @@ func (b *ImageBackend) smoothWithEdgeDetectionInternal(smoothRadius int, edgeThr
 	// 步骤3: 对原图应用平滑
 	smoothed := image.NewRGBA(image.Rect(0, 0, b.width, b.height))
 	tempBackend := &ImageBackend{
-		img:    image.NewRGBA(b.img.Bounds()),
+		img:    image.NewRGBA(image.Rect(0, 0, b.width, b.height)),
 		width:  b.width,
 		height: b.height,
 	}
-	copy(tempBackend.img.Pix, b.img.Pix)
+	tempBackend.setImage(b.img)
 
 	switch method {
 	case "gaussian":
@@ -549,7 +543,7 @@ func (b *ImageBackend) smoothWithEdgeDetectionInternal(smoothRadius int, edgeThr
 		}
 	}
 
-	b.img = smoothed
+	b.setImage(smoothed)
 }
 
 // detectEdgesSobel 使用 Sobel 算子检测边缘
@@ -751,7 +745,7 @@ func (b *ImageBackend) SmoothAnisotropicDiffusion(iterations int, kappa, lambda
 			temp.Set(b.width-1, y, b.img.At(b.width-1, y))
 		}
 
-		b.img = temp
+		b.setImage(temp)
 	}
 }
 
@@ -828,7 +822,7 @@ func (b *ImageBackend) SmoothGuidedFilter(radius int, epsilon float64) {
 		}
 	}
 
-	b.img = result
+	b.setImage(result)
 }
 
 // boxFilter 盒式滤波（快速均值滤波）
@@ -868,3 +862,84 @@ func (b *ImageBackend) boxFilter(img *image.RGBA, radius int) *image.RGBA {
 
 	return result
 }
+
+// Sharpen 锐化
+// 使用 3x3 十字形拉普拉斯核：中心权重 1+4*amount，上下左右各 -amount。
+// amount 为 0 时图像不变，通常取 0.2-1。
+// 直接在预乘像素上计算，结果按通道截断，颜色不超过 alpha
+func (b *ImageBackend) Sharpen(amount float64) {
+	if amount <= 0 || b.width < 2 || b.height < 2 {
+		return
+	}
+
+	// 上下左右四个邻居的均值（边缘复制）
+	orig := b.packedPixels()
+	mean := make([]uint8, len(orig))
+	w, h := b.width, b.height
+	for y := 0; y < h; y++ {
+		up, down := max(y-1, 0), min(y+1, h-1)
+		for x := 0; x < w; x++ {
+			left, right := max(x-1, 0), min(x+1, w-1)
+			for c := 0; c < 4; c++ {
+				sum := uint32(orig[(up*w+x)*4+c]) + uint32(orig[(down*w+x)*4+c]) +
+					uint32(orig[(y*w+left)*4+c]) + uint32(orig[(y*w+right)*4+c])
+				mean[(y*w+x)*4+c] = uint8((sum + 2) / 4)
+			}
+		}
+	}
+
+	b.addDetail(orig, mean, 4*amount, 0)
+}
+
+// UnsharpMask 反锐化掩模
+// 从原图减去半径为 radius 的高斯模糊副本得到细节，乘以 amount 后加回原图。
+// 细节绝对值小于 threshold（0-255）的通道保持不变，避免放大噪点。
+// 与 BlurGaussian 一样在预乘像素上模糊，透明区域的颜色不会渗入
+func (b *ImageBackend) UnsharpMask(radius, amount, threshold float64) {
+	if radius <= 0 || amount <= 0 || b.width < 1 || b.height < 1 {
+		return
+	}
+
+	orig := b.packedPixels()
+	blurred := image.NewRGBA(image.Rect(0, 0, b.width, b.height))
+	copy(blurred.Pix, orig)
+	blurSeparable(blurred, gaussianKernel(radius))
+
+	b.addDetail(orig, blurred.Pix, amount, threshold)
+}
+
+// packedPixels 复制图像像素为紧凑排列（stride = width*4）的切片
+func (b *ImageBackend) packedPixels() []uint8 {
+	rowBytes := b.width * 4
+	pix := make([]uint8, rowBytes*b.height)
+	for y := 0; y < b.height; y++ {
+		copy(pix[y*rowBytes:], b.img.Pix[y*b.img.Stride:y*b.img.Stride+rowBytes])
+	}
+	return pix
+}
+
+// addDetail 将 orig + amount*(orig-smooth) 写回图像
+// orig 和 smooth 均为紧凑排列的预乘像素。先计算 alpha，
+// 再把颜色截断到 [0, alpha]，保证结果仍是合法的预乘像素
+func (b *ImageBackend) addDetail(orig, smooth []uint8, amount, threshold float64) {
+	sharpen := func(o, s uint8) float64 {
+		d := float64(o) - float64(s)
+		if math.Abs(d) < threshold {
+			return float64(o)
+		}
+		return float64(o) + amount*d
+	}
+
+	for y := 0; y < b.height; y++ {
+		row := b.img.Pix[y*b.img.Stride:]
+		for x := 0; x < b.width; x++ {
+			i := (y*b.width + x) * 4
+			a := math.Round(math.Max(0, math.Min(255, sharpen(orig[i+3], smooth[i+3]))))
+			for c := 0; c < 3; c++ {
+				v := math.Round(math.Max(0, math.Min(a, sharpen(orig[i+c], smooth[i+c]))))
+				row[x*4+c] = uint8(v)
+			}
+			row[x*4+3] = uint8(a)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package cairo
+
+import (
+	"image"
+)
+
+// DrawImage draws img at its natural size with its top left corner at the
+// user-space point (x, y). See DrawImageScaled.
+func (c *context) DrawImage(img image.Image, x, y float64) {
+	if c.status != StatusSuccess {
+		return
+	}
+	if img == nil {
+		c.setError(StatusNullPointer)
+		return
+	}
+	bounds := img.Bounds()
+	c.DrawImageScaled(img, Rectangle{X: x, Y: y, Width: float64(bounds.Dx()), Height: float64(bounds.Dy())})
+}
+
+// DrawImageScaled draws img stretched over the user-space rectangle dst by
+// filling dst with it as a surface pattern, so the operator, clip and
+// transformation apply as for any fill. The image is sampled with the
+// filter of the current source pattern. Images of any color model are
+// converted; the current path, point and source are left as they were. An
+// empty image or rectangle draws nothing.
+func (c *context) DrawImageScaled(img image.Image, dst Rectangle) {
+	if c.status != StatusSuccess {
+		return
+	}
+	if img == nil {
+		c.setError(StatusNullPointer)
+		return
+	}
+	bounds := img.Bounds()
+	if bounds.Empty() || dst.Width == 0 || dst.Height == 0 {
+		return
+	}
+
+	format := FormatARGB32
+	if isOpaqueModel(img) {
+		format = FormatRGB24
+	}
+	surface := NewImageSurface(format, bounds.Dx(), bounds.Dy())
+	if status := surface.Status(); status != StatusSuccess {
+		c.setError(status)
+		return
+	}
+	importPremultiplied(surface.(*imageSurface).rgbaImage, img)
+	pattern := NewPatternForSurface(surface)
+	surface.Destroy()
+	defer pattern.Destroy()
+
+	// The pattern matrix maps dst to the image's pixels
+	sx, sy := float64(bounds.Dx())/dst.Width, float64(bounds.Dy())/dst.Height
+	pattern.SetMatrix(&Matrix{XX: sx, YY: sy, X0: -dst.X * sx, Y0: -dst.Y * sy})
+	if c.gstate.source != nil {
+		pattern.SetFilter(c.gstate.source.GetFilter())
+	}
+
+	path, currentPoint := c.path.clone(), c.currentPoint
+	c.Save()
+	c.SetSource(pattern)
+	c.NewPath()
+	c.Rectangle(dst.X, dst.Y, dst.Width, dst.Height)
+	c.Fill()
+	c.Restore()
+	c.path, c.currentPoint = path, currentPoint
+}
@@ -1,6 +1,7 @@
 package cairo
 
 import (
+	"image"
 	"unsafe"
 )
 
@@ -22,6 +23,8 @@ type Surface interface {
 	// User data management
 	SetUserData(key *UserDataKey, userData unsafe.Pointer, destroy DestroyFunc) Status
 	GetUserData(key *UserDataKey) unsafe.Pointer
+	SetUserDataAny(key *UserDataKey, v any) Status
+	GetUserDataAny(key *UserDataKey) any
 
 	// Surface operations
 	Flush() error
@@ -38,6 +41,7 @@ type Surface interface {
 	CreateSimilar(content Content, width, height int) Surface
 	CreateSimilarImage(format Format, width, height int) Surface
 	CreateForRectangle(x, y, width, height float64) Surface
+	Snapshot() Surface
 
 	// Transformations
 	SetDeviceScale(xScale, yScale float64)
@@ -54,7 +58,20 @@ type Surface interface {
 	ShowPage()
 }
 
-// Context represents cairo_t - drawing context interface
+// Context represents cairo_t - drawing context interface.
+//
+// Like cairo_t, a Context is not safe for concurrent use: its path, current
+// point and graphics state are unsynchronized, so all calls on one Context
+// must come from a single goroutine (or be serialized by the caller). Separate
+// contexts drawing to separate surfaces may run in parallel; the font and
+// glyph caches they share are synchronized internally.
+//
+// Errors follow cairo's model: a failed operation (an unbalanced Restore, a
+// singular matrix, an invalid dash pattern, a source in error, ...) puts the
+// context in an error state that Status reports. The first error sticks;
+// afterwards every operation is a no-op, and methods returning an error
+// return an Error carrying that status, so checking Status once after
+// drawing is enough.
 type Context interface {
 	// Reference management
 	Reference() Context
@@ -71,6 +88,8 @@ type Context interface {
 	// User data
 	SetUserData(key *UserDataKey, userData unsafe.Pointer, destroy DestroyFunc) Status
 	GetUserData(key *UserDataKey) unsafe.Pointer
+	SetUserDataAny(key *UserDataKey, v any) Status
+	GetUserDataAny(key *UserDataKey) any
 
 	// State management
 	Save() error
@@ -81,16 +100,31 @@ type Context interface {
 	PushGroupWithContent(content Content)
 	PopGroup() Pattern
 	PopGroupToSource()
+	PushOpacityGroup()
+	PopOpacityGroup(alpha float64)
+	PaintGroupWithFilter(filter func(ImageSurface))
 
 	// Drawing operations
 	Paint() error
 	PaintWithAlpha(alpha float64) error
 	Mask(pattern Pattern)
 	MaskSurface(surface Surface, surfaceX, surfaceY float64)
+	DrawImage(img image.Image, x, y float64)
+	DrawImageScaled(img image.Image, dst Rectangle)
+
+	// Damage tracking
+	ResetDamage()
+	GetDamage() RectangleInt
+
+	// Page operations
+	ShowPage()
+	CopyPage()
 
 	// Path operations
 	Stroke() error
 	StrokePreserve() error
+	DashedArc(xc, yc, radius, angle1, angle2 float64, dashes []float64) error
+	DashedCircle(xc, yc, radius float64, dashes []float64) error
 	Fill() error
 	FillPreserve() error
 
@@ -128,6 +162,10 @@ type Context interface {
 	SetDash(dashes []float64, offset float64)
 	GetDashCount() int
 	GetDash() (dashes []float64, offset float64)
+	// SetDashResetPerSubpath sets whether dashes restart at each subpath,
+	// the default, or run on across them.
+	SetDashResetPerSubpath(reset bool)
+	GetDashResetPerSubpath() bool
 
 	SetMiterLimit(limit float64)
 	GetMiterLimit() float64
@@ -137,6 +175,7 @@ type Context interface {
 	Scale(sx, sy float64)
 	Rotate(angle float64)
 	Transform(matrix *Matrix)
+	TransformPre(matrix *Matrix)
 	SetMatrix(matrix *Matrix)
 	GetMatrix() *Matrix
 	IdentityMatrix()
@@ -155,11 +194,18 @@ type Context interface {
 	CurveTo(x1, y1, x2, y2, x3, y3 float64)
 	Arc(xc, yc, radius, angle1, angle2 float64)
 	ArcNegative(xc, yc, radius, angle1, angle2 float64)
+	EllipticalArc(cx, cy, rx, ry, rotation, angle1, angle2 float64)
 	RelMoveTo(dx, dy float64)
 	RelLineTo(dx, dy float64)
 	RelCurveTo(dx1, dy1, dx2, dy2, dx3, dy3 float64)
 	Rectangle(x, y, width, height float64)
+	RoundedRectangle(x, y, width, height, radius float64)
+	RoundedRectangleCorners(x, y, width, height, topLeft, topRight, bottomRight, bottomLeft float64)
 	DrawCircle(xc, yc, radius float64)
+	DrawEllipse(xc, yc, rx, ry float64)
+	Superellipse(xc, yc, rx, ry, n float64)
+	PolyLine(pts []Point)
+	Polygon(pts []Point)
 	ClosePath()
 	PathExtents() (x1, y1, x2, y2 float64)
 
@@ -182,32 +228,56 @@ type Context interface {
 	// Current point
 	HasCurrentPoint() Bool
 	GetCurrentPoint() (x, y float64)
+	SetCurrentPoint(x, y float64)
 
 	// Path access
 	CopyPath() *Path
 	CopyPathFlat() *Path
 	AppendPath(path *Path)
+	// AppendSVGPath appends SVG path data, the d attribute of an SVG path.
+	AppendSVGPath(d string) error
 
 	// Text operations (use PangoCairo for text rendering)
-	// Deprecated: Use PangoCairoShowText instead
+	// ShowGlyphs draws pre-positioned glyphs; the current point follows the
+	// last glyph's advance.
 	ShowGlyphs(glyphs []Glyph)
+	// ShowTextDecorated draws utf8 from the current point like ShowGlyphs
+	// with TextToGlyphs, then the decorations spanning its advance.
+	ShowTextDecorated(utf8 string, decoration TextDecoration)
+	// ShowTextOnPath draws utf8 along the current path, each glyph rotated
+	// to the path's direction, and clears the path.
+	ShowTextOnPath(utf8 string)
+	// ShowTextMonospace draws text from the current point with each
+	// cluster centered in cells of a fixed width, wide characters taking two.
+	ShowTextMonospace(text string, cellWidth float64)
 	// Deprecated: Use PangoCairoShowText instead
 	ShowTextGlyphs(utf8 string, glyphs []Glyph, clusters []TextCluster, clusterFlags TextClusterFlags)
 	// Deprecated: Use PangoCairoShowText instead
 	GlyphPath(glyphs []Glyph)
 	TextExtents(utf8 string) *TextExtents
 	GlyphExtents(glyphs []Glyph) *TextExtents
+	// MeasureText returns both the ink (tight glyph bounds) and logical
+	// (ascent/descent line box with full advance) extents of utf8
+	MeasureText(utf8 string) (ink TextExtents, logical TextExtents)
+	// TextToGlyphs shapes utf8 with the current scaled font. Glyph positions
+	// are in user space, with the first glyph origin at (x, y)
+	TextToGlyphs(x, y float64, utf8 string) ([]Glyph, []TextCluster, TextClusterFlags, Status)
 
 	// Font operations
 	SetFontMatrix(matrix *Matrix)
+	SetFontSize(size float64)
 	GetFontMatrix() *Matrix
 	SetFontOptions(options *FontOptions)
 	GetFontOptions() *FontOptions
 	SetFontFace(fontFace FontFace)
+	SelectFontFace(family string, slant FontSlant, weight FontWeight)
+	SetFontFaceFromBytes(data []byte) error
 	GetFontFace() FontFace
 	SetScaledFont(scaledFont ScaledFont)
 	GetScaledFont() ScaledFont
 	FontExtents() *FontExtents
+	SetMissingGlyphStyle(style MissingGlyphStyle)
+	GetMissingGlyphStyle() MissingGlyphStyle
 
 	// PangoCairo functions (use these for text rendering)
 	PangoCairoCreateLayout() interface{}
@@ -229,6 +299,8 @@ type Pattern interface {
 	// User data
 	SetUserData(key *UserDataKey, userData unsafe.Pointer, destroy DestroyFunc) Status
 	GetUserData(key *UserDataKey) unsafe.Pointer
+	SetUserDataAny(key *UserDataKey, v any) Status
+	GetUserDataAny(key *UserDataKey) any
 
 	// Pattern matrix
 	SetMatrix(matrix *Matrix)
@@ -241,6 +313,10 @@ type Pattern interface {
 	// Filter mode
 	SetFilter(filter Filter)
 	GetFilter() Filter
+
+	// Phase offsets the pattern origin in user space
+	SetPhase(x, y float64)
+	GetPhase() (x, y float64)
 }
 
 // Device represents cairo_device_t - rendering backend interface
@@ -281,6 +357,19 @@ type FontFace interface {
 	GetUserData(key *UserDataKey) unsafe.Pointer
 }
 
+// VariableFontFace is implemented by font faces backed by an OpenType font
+// that can select coordinates on its variation axes (wght, wdth, slnt, ...).
+type VariableFontFace interface {
+	FontFace
+
+	// SetVariations sets the axis coordinates, keyed by 4-letter axis tag.
+	// Axes not listed use their default value; a nil map resets all axes.
+	SetVariations(variations map[string]float64) Status
+	// SetVariationsString accepts settings such as "wght=550,wdth=75".
+	SetVariationsString(settings string) Status
+	GetVariations() map[string]float64
+}
+
 // ScaledFont represents cairo_scaled_font_t - scaled font interface
 type ScaledFont interface {
 	// Reference management
@@ -310,6 +399,9 @@ type ScaledFont interface {
 	GlyphPath(glyphID uint64) (*Path, error)
 	TextToGlyphs(x, y float64, utf8 string) (glyphs []Glyph, clusters []TextCluster, clusterFlags TextClusterFlags, status Status)
 	GetGlyphs(utf8 string) (glyphs []Glyph, status Status)
+	// TextFitWidth returns how many leading bytes of utf8 fit within
+	// maxWidth, broken between clusters, and the advance of that prefix.
+	TextFitWidth(utf8 string, maxWidth float64) (nBytes int, width float64)
 
 	// Kerning
 	GetKerning(r1, r2 rune) (float64, Status)
@@ -320,63 +412,3 @@ type ScaledFont interface {
 	GetGlyphBearingMetrics(r rune) (xBearing, yBearing float64, status Status)
 	GetGlyphMetrics(r rune) (*GlyphMetrics, Status)
 }
-
-// Additional data structures
-
-// PathDataType represents cairo_path_data_type_t - path segment types
-type PathDataType int
-
-const (
-	PathMoveTo PathDataType = iota
-	PathLineTo
-	PathCurveTo
-	PathClosePath
-)
-
-// PathData represents cairo_path_data_t - path segment data
-type PathData struct {
-	Type   PathDataType
-	Points []Point
-}
-
-// Path represents cairo_path_t - path data structure
-type Path struct {
-	Status Status
-	Data   []PathData
-}
-
-// Additional enum types for interfaces
-
-// DeviceType represents cairo_device_type_t
-type DeviceType int
-
-const (
-	DeviceTypeDRM DeviceType = iota
-	DeviceTypeGL
-	DeviceTypeScript
-	DeviceTypeXcb
-	DeviceTypeXlib
-	DeviceTypeXML
-	DeviceTypeCogl
-	DeviceTypeWin32
-	DeviceTypeInvalid
-)
-
-// FontType represents cairo_font_type_t
-type FontType int
-
-const (
-	FontTypeToy FontType = iota
-	FontTypeFt
-	FontTypeWin32
-	FontTypeQuartz
-	FontTypeUser
-	FontTypeDwrite
-)
-
-// RectangleList represents cairo_rectangle_list_t
-type RectangleList struct {
-	Status        Status
-	Rectangles    []*Rectangle
-	NumRectangles int
-}
@@ -0,0 +1,80 @@
+package cairo
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+)
+
+// SetJPEGBackground sets the color JPEG output is flattened onto, since
+// JPEG has no alpha. It is black by default, as when ConvertToFormat drops
+// the alpha channel for FormatRGB24. Components are clamped to [0, 1].
+func (s *imageSurface) SetJPEGBackground(red, green, blue float64) {
+	s.jpegBackground = color.RGBA{clampUnitByte(red), clampUnitByte(green), clampUnitByte(blue), 0xff}
+}
+
+// GetJPEGBackground returns the color set with SetJPEGBackground.
+func (s *imageSurface) GetJPEGBackground() (red, green, blue float64) {
+	bg := s.jpegBackground
+	return float64(bg.R) / 255, float64(bg.G) / 255, float64(bg.B) / 255
+}
+
+// WriteToJPEG writes the surface to a JPEG file. See WriteToJPEGStream.
+func (s *imageSurface) WriteToJPEG(filename string, quality int) Status {
+	if s.status != StatusSuccess {
+		return s.status
+	}
+	if quality < 1 || quality > 100 {
+		return StatusInvalidSize
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return StatusWriteError
+	}
+
+	status := s.WriteToJPEGStream(func(closure interface{}, data []byte) error {
+		_, err := closure.(*os.File).Write(data)
+		return err
+	}, file, quality)
+	if err := file.Close(); err != nil && status == StatusSuccess {
+		status = StatusWriteError
+	}
+	return status
+}
+
+// WriteToJPEGStream encodes the surface as a JPEG of the given quality,
+// from 1 to 100, and passes the bytes to write, like WriteToPNGStream. The
+// surface is composited over the color set with SetJPEGBackground first;
+// an out of range quality gives StatusInvalidSize.
+func (s *imageSurface) WriteToJPEGStream(write WriteFunc, closure interface{}, quality int) Status {
+	if s.status != StatusSuccess {
+		return s.status
+	}
+	if quality < 1 || quality > 100 {
+		return StatusInvalidSize
+	}
+	if s.goImage == nil {
+		return StatusSurfaceTypeMismatch
+	}
+
+	img := s.pngImage()
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	background := s.jpegBackground
+	background.A = 0xff
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+
+	if err := jpeg.Encode(&funcWriter{write, closure}, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return StatusWriteError
+	}
+	return StatusSuccess
+}
+
+// clampUnitByte converts a component in [0, 1] to a byte, clamping it.
+func clampUnitByte(v float64) uint8 {
+	return clampByte(v * 255)
+}
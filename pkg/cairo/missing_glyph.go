@@ -0,0 +1,102 @@
+package cairo
+
+import (
+	"math"
+)
+
+// hexDigitGlyphs is a 3x5 pixel font for the hex box digits. Each row is a
+// 3-bit mask with the most significant bit on the left.
+var hexDigitGlyphs = [16][5]uint8{
+	{7, 5, 5, 5, 7}, // 0
+	{2, 6, 2, 2, 7}, // 1
+	{7, 1, 7, 4, 7}, // 2
+	{7, 1, 7, 1, 7}, // 3
+	{5, 5, 7, 1, 1}, // 4
+	{7, 4, 7, 1, 7}, // 5
+	{7, 4, 7, 5, 7}, // 6
+	{7, 1, 1, 1, 1}, // 7
+	{7, 5, 7, 5, 7}, // 8
+	{7, 5, 7, 1, 7}, // 9
+	{7, 5, 7, 5, 5}, // A
+	{6, 5, 6, 5, 6}, // B
+	{7, 4, 4, 4, 7}, // C
+	{6, 5, 5, 5, 6}, // D
+	{7, 4, 7, 4, 7}, // E
+	{7, 4, 7, 4, 4}, // F
+}
+
+// missingRunes returns, in order, the runes of text that the font cannot map.
+func missingRunes(sf *PangoCairoScaledFont, text string) []rune {
+	realFace, status := sf.getRealFace()
+	if status != StatusSuccess {
+		return nil
+	}
+	var missing []rune
+	for _, r := range text {
+		if _, ok := realFace.NominalGlyph(r); !ok {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// renderMissingGlyph draws the placeholder for codepoint r at the glyph
+// origin (x, y) according to style. The box sits on the baseline and is
+// sized relative to the font size; it is filled with the current source.
+func renderMissingGlyph(c *context, sf *PangoCairoScaledFont, style MissingGlyphStyle, r rune, x, y float64) {
+	if style != MissingGlyphStyleBox && style != MissingGlyphStyleHex {
+		return
+	}
+
+	fontSize := math.Hypot(sf.fontMatrix.XX, sf.fontMatrix.YX)
+	if fontSize == 0 {
+		fontSize = 12.0
+	}
+
+	// Layout is measured in "pixels" of the digit font: two rows of
+	// 3x5 digits separated and surrounded by one pixel, plus the border.
+	px := fontSize * 0.055
+	cols := 2
+	if r > 0xFFFF {
+		cols = 3
+	}
+	width := float64(4*cols+3) * px
+	height := 15 * px
+	left := x + px
+	top := y - height
+
+	c.Save()
+	defer c.Restore()
+
+	// Outline: the inner rectangle is wound in reverse to cut the hole
+	c.SetFillRule(FillRuleWinding)
+	c.NewPath()
+	c.Rectangle(left, top, width, height)
+	c.MoveTo(left+px, top+px)
+	c.LineTo(left+px, top+height-px)
+	c.LineTo(left+width-px, top+height-px)
+	c.LineTo(left+width-px, top+px)
+	c.ClosePath()
+	c.Fill()
+
+	if style != MissingGlyphStyleHex {
+		return
+	}
+
+	c.NewPath()
+	digits := 2 * cols
+	for i := 0; i < digits; i++ {
+		shift := uint(4 * (digits - 1 - i))
+		digit := hexDigitGlyphs[(uint32(r)>>shift)&0xF]
+		dx := left + 2*px + float64(4*(i%cols))*px
+		dy := top + 2*px + float64(6*(i/cols))*px
+		for row, bits := range digit {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>uint(col)) != 0 {
+					c.Rectangle(dx+float64(col)*px, dy+float64(row)*px, px, px)
+				}
+			}
+		}
+	}
+	c.Fill()
+}
@@ -0,0 +1,201 @@
+package cairo
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// ObserverCallbackType identifies the operations an observer surface
+// reports, mirroring the cairo_surface_observer_add_*_callback functions.
+type ObserverCallbackType int
+
+const (
+	ObserverPaint ObserverCallbackType = iota
+	ObserverMask
+	ObserverFill
+	ObserverStroke
+	ObserverGlyphs
+	ObserverFlush
+	ObserverFinish
+)
+
+// ObserverCallback is called after an observed operation has been forwarded
+// to the target, with the operation type and the time it took.
+type ObserverCallback func(observer ObserverSurface, target Surface, op ObserverCallbackType, elapsed time.Duration)
+
+// ObserverSurface is a surface that forwards drawing to a target surface and
+// reports every operation to registered callbacks.
+type ObserverSurface interface {
+	Surface
+	AddCallback(op ObserverCallbackType, callback ObserverCallback) error
+	// Elapsed returns the total time spent in observed operations.
+	Elapsed() time.Duration
+	// Count returns how many operations of the type were observed.
+	Count(op ObserverCallbackType) int
+	Target() Surface
+}
+
+// observerSurface implements the ObserverSurface interface.
+type observerSurface struct {
+	baseSurface
+
+	target    Surface
+	callbacks map[ObserverCallbackType][]ObserverCallback
+	counts    [ObserverFinish + 1]int
+	elapsed   time.Duration
+}
+
+// NewObserverSurface creates a surface that wraps target, like
+// cairo_surface_create_observer. Contexts created for the observer draw to
+// the target, timing each paint, mask, fill, stroke and glyph operation.
+func NewObserverSurface(target Surface) ObserverSurface {
+	if target == nil {
+		return &observerSurface{
+			baseSurface: baseSurface{
+				refCount:    1,
+				status:      StatusNullPointer,
+				surfaceType: SurfaceTypeObserver,
+				userData:    make(map[*UserDataKey]interface{}),
+			},
+		}
+	}
+
+	surface := &observerSurface{
+		baseSurface: baseSurface{
+			refCount:            1,
+			status:              StatusSuccess,
+			surfaceType:         SurfaceTypeObserver,
+			content:             target.GetContent(),
+			userData:            make(map[*UserDataKey]interface{}),
+			fontOptions:         &FontOptions{},
+			deviceScaleX:        1.0,
+			deviceScaleY:        1.0,
+			fallbackResolutionX: 72.0,
+			fallbackResolutionY: 72.0,
+		},
+		target:    target.Reference(),
+		callbacks: make(map[ObserverCallbackType][]ObserverCallback),
+	}
+
+	runtime.SetFinalizer(surface, (*observerSurface).Destroy)
+	return surface
+}
+
+func (s *observerSurface) Reference() Surface {
+	atomic.AddInt32(&s.refCount, 1)
+	return s
+}
+
+func (s *observerSurface) Destroy() {
+	if atomic.AddInt32(&s.refCount, -1) == 0 {
+		if s.target != nil {
+			s.target.Destroy()
+			s.target = nil
+		}
+		s.cleanup()
+	}
+}
+
+// Status returns the status of the target surface.
+func (s *observerSurface) Status() Status {
+	if s.status != StatusSuccess || s.target == nil {
+		return s.status
+	}
+	return s.target.Status()
+}
+
+// AddCallback registers callback for operations of type op. Callbacks run in
+// the order they were added.
+func (s *observerSurface) AddCallback(op ObserverCallbackType, callback ObserverCallback) error {
+	if s.status != StatusSuccess {
+		return newError(s.status, "")
+	}
+	if callback == nil {
+		return newError(StatusNullPointer, "observer callback is nil")
+	}
+	if op < ObserverPaint || op > ObserverFinish {
+		return newError(StatusInvalidIndex, "unknown observer callback type")
+	}
+	s.callbacks[op] = append(s.callbacks[op], callback)
+	return nil
+}
+
+func (s *observerSurface) Elapsed() time.Duration {
+	return s.elapsed
+}
+
+func (s *observerSurface) Count(op ObserverCallbackType) int {
+	if op < ObserverPaint || op > ObserverFinish {
+		return 0
+	}
+	return s.counts[op]
+}
+
+func (s *observerSurface) Target() Surface {
+	return s.target
+}
+
+func (s *observerSurface) forwardTargets() []Surface {
+	if s.target == nil {
+		return nil
+	}
+	return []Surface{s.target}
+}
+
+// notify records an operation and runs its callbacks.
+func (s *observerSurface) notify(op ObserverCallbackType, elapsed time.Duration) {
+	s.counts[op]++
+	s.elapsed += elapsed
+	for _, callback := range s.callbacks[op] {
+		callback(s, s.target, op, elapsed)
+	}
+}
+
+func (s *observerSurface) Flush() error {
+	if s.target == nil {
+		return nil
+	}
+	start := time.Now()
+	err := s.target.Flush()
+	s.notify(ObserverFlush, time.Since(start))
+	return err
+}
+
+func (s *observerSurface) MarkDirty() {
+	if s.target != nil {
+		s.target.MarkDirty()
+	}
+}
+
+func (s *observerSurface) MarkDirtyRectangle(x, y, width, height int) {
+	if s.target != nil {
+		s.target.MarkDirtyRectangle(x, y, width, height)
+	}
+}
+
+func (s *observerSurface) ShowPage() {
+	if s.target != nil {
+		s.target.ShowPage()
+	}
+}
+
+func (s *observerSurface) CopyPage() {
+	if s.target != nil {
+		s.target.CopyPage()
+	}
+}
+
+func (s *observerSurface) Finish() error {
+	if s.finished {
+		return nil
+	}
+	var err error
+	if s.target != nil {
+		start := time.Now()
+		err = s.target.Finish()
+		s.notify(ObserverFinish, time.Since(start))
+	}
+	s.baseSurface.Finish()
+	return err
+}
@@ -4,13 +4,13 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"unsafe"
 
 	"github.com/go-text/typesetting/di"
 	"github.com/go-text/typesetting/font"
 	"github.com/go-text/typesetting/opentype/api"
-	apifont "github.com/go-text/typesetting/opentype/api/font"
 	"github.com/go-text/typesetting/shaping"
 	"golang.org/x/image/math/fixed"
 )
@@ -25,11 +25,19 @@ type PangoCairoFontMap struct {
 // PangoCairoFont represents a Pango font integrated with Cairo
 type PangoCairoFont struct {
 	baseFontFace
-	family   string
-	slant    FontSlant
-	weight   FontWeight
-	realFace font.Face
-	fontData []byte
+	family     string
+	slant      FontSlant
+	weight     FontWeight
+	realFace   font.Face
+	fontData   []byte
+	variations map[string]float64
+
+	// Styles drawn synthetically because the loaded font lacks them
+	synthesis fontSynthesis
+
+	// Parsed COLR/CPAL tables, loaded on first use
+	colorOnce   sync.Once
+	colorTables *colorFontTables
 }
 
 // PangoCairoFontMetrics represents font metrics in PangoCairo
@@ -42,9 +50,10 @@ type PangoCairoFontMetrics struct {
 	lineGap        float64
 	underlinePos   float64
 	underlineThick float64
-	// strikethroughPos and strikethroughThick are reserved for future use
-	_ float64 // strikethroughPos
-	_ float64 // strikethroughThick
+	// Like the underline, the distance above the baseline of the top of
+	// the strikethrough
+	strikethroughPos   float64
+	strikethroughThick float64
 }
 
 // PangoCairoLayout represents a Pango layout for text arrangement
@@ -61,6 +70,8 @@ type PangoCairoLayout struct {
 	wrap        PangoWrapMode
 	ellipsize   PangoEllipsizeMode
 	align       PangoAlignment
+	justify     bool
+	tabs        []float64
 	spacing     float64
 	lineSpacing float64
 	userData    map[*UserDataKey]interface{}
@@ -230,7 +241,7 @@ func (fm *PangoCairoFontMap) Reference() *PangoCairoFontMap {
 
 func (fm *PangoCairoFontMap) Destroy() {
 	if atomic.AddInt32(&fm.refCount, -1) == 0 {
-		// Cleanup resources if needed
+		destroyUserData(fm.userData)
 	}
 }
 
@@ -250,19 +261,12 @@ func (fm *PangoCairoFontMap) SetUserData(key *UserDataKey, userData unsafe.Point
 	if fm.userData == nil {
 		fm.userData = make(map[*UserDataKey]interface{})
 	}
-	fm.userData[key] = userData
-	_ = destroy // destroy func is currently ignored
+	setUserData(fm.userData, key, userData, destroy)
 	return StatusSuccess
 }
 
 func (fm *PangoCairoFontMap) GetUserData(key *UserDataKey) unsafe.Pointer {
-	if fm.userData == nil {
-		return nil
-	}
-	if data, ok := fm.userData[key]; ok {
-		return data.(unsafe.Pointer)
-	}
-	return nil
+	return getUserData(fm.userData, key)
 }
 
 // NewPangoCairoFont creates a new Pango font integrated with Cairo
@@ -281,12 +285,16 @@ func NewPangoCairoFont(family string, slant FontSlant, weight FontWeight) *Pango
 
 	// Get font key and load font
 	fontKey := getFontKey(family, slant, weight)
-	face, data, err := LoadEmbeddedFont(fontKey)
-	if err != nil {
-		// Try loading from assets if family looks like a file
-		if strings.Contains(family, "/") || strings.Contains(family, "\\") {
-			face, data, err = LoadFontFromFile(family)
-		}
+	var face font.Face
+	var data []byte
+	var err error
+	// Try loading from file first if family looks like a path, since
+	// getFontKey maps unknown families to an embedded font
+	if strings.Contains(family, "/") || strings.Contains(family, "\\") {
+		face, data, err = LoadFontFromFile(family)
+	}
+	if face == nil || err != nil {
+		face, data, err = LoadEmbeddedFont(fontKey)
 		if err != nil {
 			// Final fallback to default font
 			face, data = GetDefaultFont()
@@ -298,6 +306,8 @@ func NewPangoCairoFont(family string, slant FontSlant, weight FontWeight) *Pango
 
 	if pf.realFace == nil {
 		pf.status = StatusFontTypeMismatch
+	} else {
+		pf.synthesis = synthesisFor(data, slant, weight)
 	}
 	return pf
 }
@@ -310,7 +320,7 @@ func (f *PangoCairoFont) Reference() FontFace {
 
 func (f *PangoCairoFont) Destroy() {
 	if atomic.AddInt32(&f.refCount, -1) == 0 {
-		// nothing to free at the moment
+		destroyUserData(f.userData)
 	}
 }
 
@@ -333,19 +343,48 @@ func (f *PangoCairoFont) SetUserData(key *UserDataKey, userData unsafe.Pointer,
 	if f.userData == nil {
 		f.userData = make(map[*UserDataKey]interface{})
 	}
-	f.userData[key] = userData
-	_ = destroy // destroy func is currently ignored
+	setUserData(f.userData, key, userData, destroy)
 	return StatusSuccess
 }
 
 func (f *PangoCairoFont) GetUserData(key *UserDataKey) unsafe.Pointer {
-	if f.userData == nil {
-		return nil
+	return getUserData(f.userData, key)
+}
+
+// SetVariations selects coordinates on the font's variation axes (wght, wdth, slnt, ...)
+func (f *PangoCairoFont) SetVariations(variations map[string]float64) Status {
+	if f.status != StatusSuccess {
+		return f.status
+	}
+	face, status := applyFontVariations(f.realFace, variations)
+	if status != StatusSuccess {
+		return status
 	}
-	if data, ok := f.userData[key]; ok {
-		return data.(unsafe.Pointer)
+	f.realFace = face
+	f.variations = copyFontVariations(variations)
+	return StatusSuccess
+}
+
+// SetVariationsString parses settings like "wght=550,wdth=75" and applies them
+func (f *PangoCairoFont) SetVariationsString(settings string) Status {
+	variations, status := ParseFontVariations(settings)
+	if status != StatusSuccess {
+		return status
 	}
-	return nil
+	return f.SetVariations(variations)
+}
+
+// GetVariations returns a copy of the current axis coordinates
+func (f *PangoCairoFont) GetVariations() map[string]float64 {
+	return copyFontVariations(f.variations)
+}
+
+// colorGlyphs returns the font's color glyph tables, or nil for plain fonts
+func (f *PangoCairoFont) colorGlyphs() *colorFontTables {
+	f.colorOnce.Do(func() {
+		f.colorTables = parseColorFontTables(f.fontData)
+	})
+	return f.colorTables
 }
 
 // NewPangoCairoFontMetrics creates new font metrics
@@ -359,9 +398,36 @@ func NewPangoCairoFontMetrics(ascent, descent, height, lineGap float64) *PangoCa
 		lineGap:        lineGap,
 		underlinePos:   -descent * 0.5,
 		underlineThick: (ascent + descent) * 0.05,
+		// Centered on x-height/2, positions giving the top of the line
+		strikethroughPos:   ascent*0.25 + (ascent+descent)*0.025,
+		strikethroughThick: (ascent + descent) * 0.05,
 	}
 }
 
+// GetMetrics returns the font's metrics at size, in the units of the size.
+// Ascent, descent and line gap come from the hhea table, the underline
+// from the post table and the strikethrough from the OS/2 table, with the
+// approximations of NewPangoCairoFontMetrics for fonts leaving them out.
+func (f *PangoCairoFont) GetMetrics(size float64) *PangoCairoFontMetrics {
+	if f.realFace == nil || f.realFace.Upem() == 0 {
+		return NewPangoCairoFontMetrics(size*0.8, size*0.2, size, size*0.2)
+	}
+
+	scale := size / float64(f.realFace.Upem())
+	extents, _ := f.realFace.FontHExtents()
+	ascent := float64(extents.Ascender) * scale
+	descent := -float64(extents.Descender) * scale
+	lineGap := float64(extents.LineGap) * scale
+	fm := NewPangoCairoFontMetrics(ascent, descent, ascent+descent+lineGap, lineGap)
+
+	underlinePos, underlineThick, strikePos, strikeThick := fontLineMetrics(f.realFace)
+	fm.underlinePos = underlinePos * scale
+	fm.underlineThick = underlineThick * scale
+	fm.strikethroughPos = strikePos * scale
+	fm.strikethroughThick = strikeThick * scale
+	return fm
+}
+
 // Reference management for PangoCairoFontMetrics
 func (fm *PangoCairoFontMetrics) Reference() *PangoCairoFontMetrics {
 	atomic.AddInt32(&fm.refCount, 1)
@@ -407,6 +473,16 @@ func (fm *PangoCairoFontMetrics) GetUnderlineThickness() float64 {
 	return fm.underlineThick
 }
 
+// GetStrikethroughPosition returns the distance above the baseline of the
+// top of the strikethrough.
+func (fm *PangoCairoFontMetrics) GetStrikethroughPosition() float64 {
+	return fm.strikethroughPos
+}
+
+func (fm *PangoCairoFontMetrics) GetStrikethroughThickness() float64 {
+	return fm.strikethroughThick
+}
+
 // NewPangoCairoLayout creates a new Pango layout
 func NewPangoCairoLayout(context *PangoCairoContext) *PangoCairoLayout {
 	return &PangoCairoLayout{
@@ -429,6 +505,7 @@ func (l *PangoCairoLayout) Reference() *PangoCairoLayout {
 
 func (l *PangoCairoLayout) Destroy() {
 	if atomic.AddInt32(&l.refCount, -1) == 0 {
+		destroyUserData(l.userData)
 		if l.context != nil {
 			l.context.Destroy()
 		}
@@ -527,19 +604,12 @@ func (l *PangoCairoLayout) SetUserData(key *UserDataKey, userData unsafe.Pointer
 	if l.userData == nil {
 		l.userData = make(map[*UserDataKey]interface{})
 	}
-	l.userData[key] = userData
-	_ = destroy // destroy func is currently ignored
+	setUserData(l.userData, key, userData, destroy)
 	return StatusSuccess
 }
 
 func (l *PangoCairoLayout) GetUserData(key *UserDataKey) unsafe.Pointer {
-	if l.userData == nil {
-		return nil
-	}
-	if data, ok := l.userData[key]; ok {
-		return data.(unsafe.Pointer)
-	}
-	return nil
+	return getUserData(l.userData, key)
 }
 
 // NewPangoCairoContext creates a new Pango context integrated with Cairo
@@ -561,6 +631,7 @@ func (c *PangoCairoContext) Reference() *PangoCairoContext {
 
 func (c *PangoCairoContext) Destroy() {
 	if atomic.AddInt32(&c.refCount, -1) == 0 {
+		destroyUserData(c.userData)
 		if c.fontMap != nil {
 			c.fontMap.Destroy()
 		}
@@ -603,19 +674,12 @@ func (c *PangoCairoContext) SetUserData(key *UserDataKey, userData unsafe.Pointe
 	if c.userData == nil {
 		c.userData = make(map[*UserDataKey]interface{})
 	}
-	c.userData[key] = userData
-	_ = destroy // destroy func is currently ignored
+	setUserData(c.userData, key, userData, destroy)
 	return StatusSuccess
 }
 
 func (c *PangoCairoContext) GetUserData(key *UserDataKey) unsafe.Pointer {
-	if c.userData == nil {
-		return nil
-	}
-	if data, ok := c.userData[key]; ok {
-		return data.(unsafe.Pointer)
-	}
-	return nil
+	return getUserData(c.userData, key)
 }
 
 // NewPangoFontDescription creates a new font description
@@ -655,6 +719,22 @@ func (fd *PangoFontDescription) GetWeight() PangoWeight {
 	return fd.weight
 }
 
+// fontFace loads the description's family in its style and weight.
+func (fd *PangoFontDescription) fontFace() *PangoCairoFont {
+	slant := FontSlantNormal
+	switch fd.style {
+	case PangoStyleItalic:
+		slant = FontSlantItalic
+	case PangoStyleOblique:
+		slant = FontSlantOblique
+	}
+	weight := FontWeightNormal
+	if fd.weight >= PangoWeightBold {
+		weight = FontWeightBold
+	}
+	return NewPangoCairoFont(fd.family, slant, weight)
+}
+
 func (fd *PangoFontDescription) SetStretch(stretch PangoStretch) {
 	fd.stretch = stretch
 }
@@ -818,13 +898,17 @@ func (s *PangoCairoScaledFont) Extents() *FontExtents {
 	fe.MaxXAdvance = fe.Ascent + fe.Descent
 	fe.MaxYAdvance = 0
 
-	// Calculate underline metrics
-	fe.UnderlinePosition = -fe.Descent * 0.5
-	fe.UnderlineThickness = (fe.Ascent + fe.Descent) * 0.05
+	// Underline and strikethrough as the font specifies them
+	underlinePos, underlineThick, strikePos, strikeThick := fontLineMetrics(realFace)
+	fe.UnderlinePosition = underlinePos / 64.0
+	fe.UnderlineThickness = underlineThick / 64.0
+	fe.StrikethroughPosition = strikePos / 64.0
+	fe.StrikethroughThickness = strikeThick / 64.0
 
-	// Approximate cap height and x-height
-	fe.CapHeight = fe.Ascent * 0.7 // Typical ratio
-	fe.XHeight = fe.Ascent * 0.5   // Typical ratio
+	// Cap height and x-height as the font declares or its glyphs measure
+	capHeight, xHeight := fontCapAndXHeight(realFace)
+	fe.CapHeight = capHeight / 64.0
+	fe.XHeight = xHeight / 64.0
 
 	return fe
 }
@@ -849,12 +933,17 @@ func (s *PangoCairoScaledFont) toyExtentsFallback() *FontExtents {
 	fe.UnderlineThickness = size * 0.05
 	fe.CapHeight = fe.Ascent * 0.7 // Typical ratio
 	fe.XHeight = fe.Ascent * 0.5   // Typical ratio
+	fe.StrikethroughPosition = (fe.XHeight + fe.UnderlineThickness) / 2
+	fe.StrikethroughThickness = fe.UnderlineThickness
 	return fe
 }
 
 // TextExtents computes text extents using the real font face and shaping.
 func (s *PangoCairoScaledFont) TextExtents(utf8 string) *TextExtents {
 	ext := &TextExtents{}
+	if !validText(utf8) {
+		return ext
+	}
 
 	realFace, status := s.getRealFace()
 	if status != StatusSuccess {
@@ -877,7 +966,7 @@ func (s *PangoCairoScaledFont) TextExtents(utf8 string) *TextExtents {
 		Face:      realFace,
 		Size:      fixed.I(int(fontSize)), // Use actual font size
 	}
-	output := (&shaping.HarfbuzzShaper{}).Shape(input)
+	output := shapeText(input)
 
 	// Calculate total advance and bounds
 	var totalAdvance fixed.Int26_6
@@ -892,8 +981,7 @@ func (s *PangoCairoScaledFont) TextExtents(utf8 string) *TextExtents {
 
 	for _, g := range output.Glyphs {
 		// Get glyph outline for bounds calculation
-		glyphData := realFace.GlyphData(api.GID(g.GlyphID))
-		if outline, ok := glyphData.(api.GlyphOutline); ok {
+		if outline, ok := sharedGlyphCache.outline(realFace, api.GID(g.GlyphID)); ok {
 			// Convert outline points from font units to user space
 			for _, seg := range outline.Segments {
 				for _, arg := range seg.Args {
@@ -972,21 +1060,17 @@ func (s *PangoCairoScaledFont) toyTextExtentsFallback(utf8 string) *TextExtents
 	return ext
 }
 
-// GlyphExtents computes extents based on glyph positions.
+// GlyphExtents computes the ink extents and total advance of positioned
+// glyphs. Bearings are relative to the origin of the first glyph.
 func (s *PangoCairoScaledFont) GlyphExtents(glyphs []Glyph) *TextExtents {
-	if len(glyphs) == 0 {
+	realFace, status := s.getRealFace()
+	if status != StatusSuccess {
 		return &TextExtents{}
 	}
-	// Assume glyph positions are advances from origin.
-	last := glyphs[len(glyphs)-1]
-	ext := &TextExtents{}
-	ext.XAdvance = last.X
-	ext.YAdvance = last.Y
-	ext.Width = last.X
-	ext.Height = s.Extents().Height
-	ext.XBearing = 0
-	ext.YBearing = -s.Extents().Ascent
-	return ext
+	return glyphRunExtents(glyphs, func(gid api.GID) (*GlyphMetrics, bool) {
+		m, status := s.glyphMetrics(realFace, gid)
+		return m, status == StatusSuccess
+	})
 }
 
 // GlyphPath returns the path for a single glyph ID.
@@ -996,16 +1080,30 @@ func (s *PangoCairoScaledFont) GlyphPath(glyphID uint64) (*Path, error) {
 		return nil, newError(status, "failed to get real font face")
 	}
 
-	// Load the glyph outline from the font face
 	gid := api.GID(glyphID)
-	glyphData := realFace.GlyphData(gid)
+	key := newGlyphCacheKey(realFace, glyphEntryPangoPath, gid, &s.fontMatrix, s.options)
+	if cached, ok := sharedGlyphCache.lookup(key); ok {
+		return copyPath(cached.(*Path)), nil
+	}
 
-	// Extract outline from glyph data
-	outline, ok := glyphData.(api.GlyphOutline)
+	// Load the glyph outline from the font face
+	outline, ok := sharedGlyphCache.outline(realFace, gid)
 	if !ok {
 		return nil, newError(StatusFontTypeMismatch, "glyph has no outline")
 	}
 
+	unitsPerEm := float64(realFace.Upem())
+	scaleX := math.Hypot(s.fontMatrix.XX, s.fontMatrix.YX)
+	scaleY := math.Hypot(s.fontMatrix.XY, s.fontMatrix.YY)
+	cairoPath := outlinePath(outline, unitsPerEm, scaleX, scaleY)
+
+	sharedGlyphCache.store(key, cairoPath)
+	return copyPath(cairoPath), nil
+}
+
+// outlinePath converts a glyph outline in font units to a path in user space
+// with y down, scaled by the font size in x and y.
+func outlinePath(outline api.GlyphOutline, unitsPerEm, scaleX, scaleY float64) *Path {
 	// Convert the outline to cairo.Path
 	cairoPath := &Path{
 		Status: StatusSuccess,
@@ -1017,10 +1115,6 @@ func (s *PangoCairoScaledFont) GlyphPath(glyphID uint64) (*Path, error) {
 	// Since we now use positive Y scale in font matrix, we always need to flip.
 	flipY := true
 
-	// Get font units per em and scale factor for coordinate transformation
-	unitsPerEm := float64(realFace.Upem())
-	scaleX := math.Hypot(s.fontMatrix.XX, s.fontMatrix.YX)
-	scaleY := math.Hypot(s.fontMatrix.XY, s.fontMatrix.YY)
 	if scaleX == 0 {
 		scaleX = 1.0
 	}
@@ -1104,7 +1198,7 @@ func (s *PangoCairoScaledFont) GlyphPath(glyphID uint64) (*Path, error) {
 		cairoPath.Data = append(cairoPath.Data, pd)
 	}
 
-	return cairoPath, nil
+	return cairoPath
 }
 
 // GetTextBearingMetrics returns the bearing metrics for a text string
@@ -1125,38 +1219,17 @@ func (s *PangoCairoScaledFont) GetTextAlignmentOffset(alignment TextAlignment) (
 	return GetAlignmentOffset(alignment, fontExtents), StatusSuccess
 }
 
-// GetKerning returns the kerning adjustment between two runes
+// GetKerning returns the kerning adjustment between two runes, as applied
+// by the shaper (GPOS, or the legacy kern/kerx tables).
 func (s *PangoCairoScaledFont) GetKerning(r1, r2 rune) (float64, Status) {
 	realFace, status := s.getRealFace()
 	if status != StatusSuccess {
 		return 0, status
 	}
 
-	// Get the glyph indices for the runes
-	gid1, ok1 := realFace.NominalGlyph(r1)
-	gid2, ok2 := realFace.NominalGlyph(r2)
-	if !ok1 || !ok2 {
-		return 0, StatusInvalidGlyph
-	}
-
-	// Check if we have kerning data
-	var kernValue int16
-	if len(realFace.Kern) > 0 {
-		// Try Kern tables first
-		for _, kernSubtable := range realFace.Kern {
-			if kd, ok := kernSubtable.Data.(apifont.Kern0); ok {
-				kernValue = kd.KernPair(gid1, gid2)
-				break
-			}
-		}
-	} else if len(realFace.Kerx) > 0 {
-		// Try Kerx tables if no Kern tables
-		for _, kerxSubtable := range realFace.Kerx {
-			if kd, ok := kerxSubtable.Data.(apifont.Kern0); ok {
-				kernValue = kd.KernPair(gid1, gid2)
-				break
-			}
-		}
+	kernValue, status := shapedPairKerning(realFace, r1, r2)
+	if status != StatusSuccess {
+		return 0, status
 	}
 
 	// Scale factor from font matrix
@@ -1164,7 +1237,7 @@ func (s *PangoCairoScaledFont) GetKerning(r1, r2 rune) (float64, Status) {
 	unitsPerEm := float64(realFace.Upem())
 
 	// Convert kerning value to user space units
-	kerning := float64(kernValue) * sx / unitsPerEm
+	kerning := kernValue * sx / unitsPerEm
 
 	return kerning, StatusSuccess
 }
@@ -1205,9 +1278,20 @@ func (s *PangoCairoScaledFont) GetGlyphMetrics(r rune) (*GlyphMetrics, Status) {
 		return nil, StatusInvalidGlyph
 	}
 
+	return s.glyphMetrics(realFace, gid)
+}
+
+// glyphMetrics returns the metrics of glyph gid in realFace, in user space
+// with y down.
+func (s *PangoCairoScaledFont) glyphMetrics(realFace font.Face, gid api.GID) (*GlyphMetrics, Status) {
+	key := newGlyphCacheKey(realFace, glyphEntryPangoMetrics, gid, &s.fontMatrix, s.options)
+	if cached, ok := sharedGlyphCache.lookup(key); ok {
+		metrics := *cached.(*GlyphMetrics)
+		return &metrics, StatusSuccess
+	}
+
 	// Load glyph outline
-	glyphData := realFace.GlyphData(gid)
-	outline, ok := glyphData.(api.GlyphOutline)
+	outline, ok := sharedGlyphCache.outline(realFace, gid)
 	if !ok {
 		return nil, StatusFontTypeMismatch
 	}
@@ -1231,25 +1315,17 @@ func (s *PangoCairoScaledFont) GetGlyphMetrics(r rune) (*GlyphMetrics, Status) {
 	// We need to apply Y flip here to match the actual rendered path
 	flipY := true
 
-	pointCount := 0
 	for _, seg := range outline.Segments {
 		for _, arg := range seg.Args {
 			// Coordinates are already in font units (float32), just convert to float64
 			xInFontUnits := float64(arg.X)
 			yInFontUnits := float64(arg.Y)
 
-			// Debug: print first few points for 'M'
-			if r == 'M' && pointCount < 3 {
-				fmt.Printf("[DEBUG] 'M' point %d: raw X=%.2f, Y=%.2f\n", pointCount, xInFontUnits, yInFontUnits)
-			}
-
 			// Apply Y flip to match rendered coordinates
 			if flipY {
 				yInFontUnits = -yInFontUnits
 			}
 
-			pointCount++
-
 			if firstPoint {
 				xmin, xmax = xInFontUnits, xInFontUnits
 				ymin, ymax = yInFontUnits, yInFontUnits
@@ -1277,11 +1353,6 @@ func (s *PangoCairoScaledFont) GetGlyphMetrics(r rune) (*GlyphMetrics, Status) {
 	ymin = (ymin / unitsPerEm) * scaleY
 	ymax = (ymax / unitsPerEm) * scaleY
 
-	// Debug output for character 'M'
-	if r == 'M' {
-		fmt.Printf("[DEBUG GetGlyphMetrics] 'M': xmin=%.2f, xmax=%.2f, ymin=%.2f, ymax=%.2f\n", xmin, xmax, ymin, ymax)
-	}
-
 	// Get horizontal metrics from the font's hmtx table
 	// HorizontalAdvance returns the advance width in font units (not 26.6 format)
 	rawAdvance := realFace.HorizontalAdvance(gid)
@@ -1322,6 +1393,8 @@ func (s *PangoCairoScaledFont) GetGlyphMetrics(r rune) (*GlyphMetrics, Status) {
 	// Update XBearing to match the actual left edge of the glyph
 	metrics.XBearing = xmin
 
+	cached := *metrics
+	sharedGlyphCache.store(key, &cached)
 	return metrics, StatusSuccess
 }
 
@@ -1342,7 +1415,7 @@ func (s *PangoCairoScaledFont) GetGlyphs(utf8 string) (glyphs []Glyph, status St
 		Face:      realFace,
 		Size:      fixed.I(12),
 	}
-	output := (&shaping.HarfbuzzShaper{}).Shape(input)
+	output := shapeText(input)
 
 	// 2. Convert shaped output to cairo's Glyph structures
 	glyphs = make([]Glyph, len(output.Glyphs))
@@ -1364,6 +1437,9 @@ func (s *PangoCairoScaledFont) TextToGlyphs(x, y float64, utf8 string) (glyphs [
 
 // TextToGlyphsWithOptions performs text shaping with advanced OpenType features
 func (s *PangoCairoScaledFont) TextToGlyphsWithOptions(x, y float64, utf8 string, options *ShapingOptions) (glyphs []Glyph, clusters []TextCluster, clusterFlags TextClusterFlags, status Status) {
+	if !validText(utf8) {
+		return nil, nil, 0, StatusInvalidString
+	}
 	realFace, status := s.getRealFace()
 	if status != StatusSuccess {
 		return s.toyTextToGlyphsFallback(x, y, utf8)
@@ -1433,7 +1509,7 @@ func (s *PangoCairoScaledFont) TextToGlyphsWithOptions(x, y float64, utf8 string
 			Language:  convertLanguage(options.Language),
 			Script:    convertScript(options.Script),
 		}
-		output := (&shaping.HarfbuzzShaper{}).Shape(input)
+		output := shapeText(input)
 
 		// 2. Convert shaped output to cairo's Glyph and TextCluster structures
 		var curX float64
@@ -1520,6 +1596,23 @@ func PangoCairoShowText(ctx Context, layout *PangoCairoLayout) {
 	if ctx.Status() != StatusSuccess {
 		return
 	}
+	// Malformed text is an error rather than replacement characters
+	if !validText(layout.GetText()) {
+		if c, ok := ctx.(*context); ok {
+			c.setError(StatusInvalidString)
+		}
+		return
+	}
+	// Tee and observer targets render the whole text on each target
+	if c, ok := ctx.(*context); ok {
+		forwarded, _ := c.forwardDrawing(ObserverGlyphs, func(t *context) error {
+			PangoCairoShowText(t, layout)
+			return nil
+		})
+		if forwarded {
+			return
+		}
+	}
 
 	// Get current point or use (0, 0)
 	x, y := ctx.GetCurrentPoint()
@@ -1533,18 +1626,7 @@ func PangoCairoShowText(ctx Context, layout *PangoCairoLayout) {
 		return
 	}
 
-	fontFace := NewPangoCairoFont(layout.fontDesc.family, FontSlantNormal, FontWeightNormal)
-	defer fontFace.Destroy()
-
-	fontMatrix := NewMatrix()
-	// Use positive Y scale - our coordinate system has Y growing downward,
-	// and we'll handle the glyph flip in the rendering code
-	fontMatrix.InitScale(layout.fontDesc.size, layout.fontDesc.size)
-
-	ctm := NewMatrix()
-	ctm.InitIdentity()
-
-	sf := NewPangoCairoScaledFont(fontFace, fontMatrix, ctm, nil)
+	sf := layout.scaledFont()
 	defer sf.Destroy()
 
 	// Get font metrics for line spacing
@@ -1561,40 +1643,53 @@ func PangoCairoShowText(ctx Context, layout *PangoCairoLayout) {
 		lineHeight = layout.fontDesc.size * 1.2 // 120% of font size
 	}
 
-	// Split text into lines
-	text := layout.GetText()
-	lines := strings.Split(text, "\n")
+	// Split text into paragraphs, wrapped to the layout width
+	lines := layout.breakLines(sf)
 
 	// Render each line
 	currentY := y
-	for _, line := range lines {
+	for i := range lines {
+		line := &lines[i]
 		// Skip empty lines but still advance Y position
-		if line == "" {
+		if len(line.words) == 0 {
 			currentY += lineHeight
 			continue
 		}
 
-		// Perform text shaping to get glyphs for this line
-		glyphs, _, _, status := sf.TextToGlyphs(x, currentY, line)
+		// Perform text shaping to get glyphs for this line, with the space
+		// between words stretched when justifying
+		text := line.text()
+		var glyphs []Glyph
+		status := StatusSuccess
+		if layout.justify && layout.width > 0 && !line.endsSection && len(line.gaps) > 0 && !strings.Contains(text, "\t") {
+			glyphs, status = justifiedGlyphs(sf, line, x, currentY, float64(layout.width)/1024.0)
+			text = strings.Join(line.words, "")
+			if status == StatusSuccess {
+				showGlyphRun(ctx.(*context), sf, glyphs, text)
+			}
+		} else {
+			glyphs, status = layout.tabbedGlyphs(sf, x, currentY, text)
+			text = strings.ReplaceAll(text, "\t", "")
+			if status == StatusSuccess {
+				renderLineGlyphs(ctx, sf, glyphs, layout, x, text)
+			}
+		}
 		if status != StatusSuccess {
 			ctx.(*context).status = status
 			return
 		}
 
-		// Render this line's glyphs
-		renderLineGlyphs(ctx, sf, glyphs, layout, x, line)
-
 		// Move to next line
 		currentY += lineHeight
 	}
 
 	// Update current point to the position after the last line
 	if len(lines) > 0 {
-		lastLine := lines[len(lines)-1]
+		lastLine := lines[len(lines)-1].text()
 		if lastLine != "" {
 			extents := sf.TextExtents(lastLine)
 			c := ctx.(*context)
-			c.currentPoint.x = x + extents.XAdvance
+			c.currentPoint.x = x + layout.advanceAt(sf, 0, lastLine)
 			c.currentPoint.y = currentY - lineHeight + extents.YAdvance
 			c.currentPoint.hasPoint = true
 		}
@@ -1624,11 +1719,13 @@ func renderLineGlyphs(ctx Context, sf *PangoCairoScaledFont, glyphs []Glyph, lay
 		}
 	}
 
-	// Render glyphs directly to surface using PangoCairo
-	c := ctx.(*context)
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	showGlyphRun(ctx.(*context), sf, glyphs, lineText)
+}
 
+// showGlyphRun renders positioned glyphs with the current source. text is
+// the string the glyphs were shaped from and is only used to label missing
+// glyphs; with no text they are drawn as the font's .notdef.
+func showGlyphRun(c *context, sf *PangoCairoScaledFont, glyphs []Glyph, text string) {
 	// Get the current source pattern for text color
 	source := c.gstate.source
 	if source == nil {
@@ -1638,8 +1735,73 @@ func renderLineGlyphs(ctx Context, sf *PangoCairoScaledFont, glyphs []Glyph, lay
 	// Apply state once before rendering all glyphs to ensure gradient is set
 	c.applyStateToPango()
 
+	// Resolve color layers (COLR/CPAL) and color bitmaps (CBDT/sbix)
+	// unless color rendering is disabled
+	options := c.gstate.fontOptions
+	var colorTables *colorFontTables
+	if options.GetColorMode() != ColorModeNoColor {
+		if pf, ok := sf.fontFace.(*PangoCairoFont); ok {
+			colorTables = pf.colorGlyphs()
+		}
+	}
+
+	// Subpixel (LCD) antialiasing applies to text only. The font options
+	// take precedence over the context's antialias mode.
+	antialias := options.GetAntialias()
+	if antialias == AntialiasDefault {
+		antialias = c.gstate.antialias
+	}
+	hintMetrics := false
+	if antialias == AntialiasSubpixel {
+		order := options.GetSubpixelOrder()
+		if order == SubpixelOrderDefault {
+			order = SubpixelOrderRGB
+		}
+		c.textSubpixelOrder = order
+		defer func() { c.textSubpixelOrder = SubpixelOrderDefault }()
+
+		// With hinted metrics glyph origins snap to whole pixels, so every
+		// glyph sees the same alignment with the subpixel grid
+		hintMetrics = options.GetHintMetrics() == HintMetricsOn
+	}
+
+	// Codepoints the font cannot map shape to .notdef (glyph 0); pair them
+	// up in order so the placeholder can show the right codepoint
+	missingStyle := c.gstate.missingGlyphStyle
+	if text == "" {
+		missingStyle = MissingGlyphStyleNotdef
+	}
+	var missing []rune
+	if missingStyle != MissingGlyphStyleNotdef {
+		missing = missingRunes(sf, text)
+	}
+
+	synthesis, size := glyphSynthesis(sf)
+
 	// Render each glyph directly to the surface
 	for _, glyph := range glyphs {
+		if hintMetrics {
+			glyph.X, glyph.Y = math.Round(glyph.X), math.Round(glyph.Y)
+		}
+		if glyph.Index == 0 && missingStyle != MissingGlyphStyleNotdef {
+			var r rune
+			if len(missing) > 0 {
+				r, missing = missing[0], missing[1:]
+			}
+			renderMissingGlyph(c, sf, missingStyle, r, glyph.X, glyph.Y)
+			continue
+		}
+
+		if colorTables != nil {
+			if layers := colorTables.glyphs[uint16(glyph.Index)]; len(layers) > 0 {
+				renderColorGlyph(c, sf, colorTables, layers, glyph, options)
+				continue
+			}
+			if colorTables.bitmaps && renderBitmapGlyph(c, sf, glyph) {
+				continue
+			}
+		}
+
 		// Save context state before rendering each glyph
 		c.Save()
 
@@ -1655,50 +1817,61 @@ func renderLineGlyphs(ctx Context, sf *PangoCairoScaledFont, glyphs []Glyph, lay
 			continue
 		}
 
-		// Clear current path and create a new one for this glyph
-		c.NewPath()
+		// Fill the glyph, emboldened or slanted if the face lacks the style
+		fillGlyph(c, glyphPath, glyph.X, glyph.Y, synthesis, size)
 
-		// Translate the glyph path to the correct position and add to context
-		// The glyph path is in font space, we need to translate it to the glyph position
-		pathSegments := 0
-		for _, pathData := range glyphPath.Data {
-			switch pathData.Type {
-			case PathMoveTo:
-				if len(pathData.Points) > 0 {
-					c.MoveTo(pathData.Points[0].X+glyph.X, pathData.Points[0].Y+glyph.Y)
-					pathSegments++
-				}
-			case PathLineTo:
-				if len(pathData.Points) > 0 {
-					c.LineTo(pathData.Points[0].X+glyph.X, pathData.Points[0].Y+glyph.Y)
-					pathSegments++
-				}
-			case PathCurveTo:
-				if len(pathData.Points) >= 3 {
-					c.CurveTo(
-						pathData.Points[0].X+glyph.X, pathData.Points[0].Y+glyph.Y,
-						pathData.Points[1].X+glyph.X, pathData.Points[1].Y+glyph.Y,
-						pathData.Points[2].X+glyph.X, pathData.Points[2].Y+glyph.Y,
-					)
-					pathSegments++
-				}
-			case PathClosePath:
-				c.ClosePath()
-				pathSegments++
-			}
-		}
+		// Restore context state after rendering each glyph
+		c.Restore()
+	}
+}
 
-		// Debug: print glyph info (commented out for production)
-		// fmt.Printf("[DEBUG] Glyph %d at (%.2f, %.2f): added %d path segments\n", glyph.Index, glyph.X, glyph.Y, pathSegments)
+// renderColorGlyph paints each layer of a color glyph with its palette color.
+// Layers using the foreground entry are filled with the current source.
+func renderColorGlyph(c *context, sf ScaledFont, tables *colorFontTables, layers []colorGlyphLayer, glyph Glyph, options *FontOptions) {
+	for _, layer := range layers {
+		layerPath, err := sf.GlyphPath(uint64(layer.glyphID))
+		if err != nil || layerPath == nil || len(layerPath.Data) == 0 {
+			continue
+		}
 
-		// Fill the glyph
+		c.Save()
+		if color, ok := tables.layerColor(layer, options); ok {
+			c.SetSourceRGBA(color.R, color.G, color.B, color.A)
+		}
+		c.NewPath()
+		appendGlyphPath(c, layerPath, glyph.X, glyph.Y)
 		c.Fill()
-
-		// Restore context state after rendering each glyph
 		c.Restore()
 	}
 }
 
+// appendGlyphPath adds a glyph outline to the current path, translated to (x, y).
+// The glyph path is in font space, so it is offset to the glyph position.
+func appendGlyphPath(c *context, glyphPath *Path, x, y float64) {
+	for _, pathData := range glyphPath.Data {
+		switch pathData.Type {
+		case PathMoveTo:
+			if len(pathData.Points) > 0 {
+				c.MoveTo(pathData.Points[0].X+x, pathData.Points[0].Y+y)
+			}
+		case PathLineTo:
+			if len(pathData.Points) > 0 {
+				c.LineTo(pathData.Points[0].X+x, pathData.Points[0].Y+y)
+			}
+		case PathCurveTo:
+			if len(pathData.Points) >= 3 {
+				c.CurveTo(
+					pathData.Points[0].X+x, pathData.Points[0].Y+y,
+					pathData.Points[1].X+x, pathData.Points[1].Y+y,
+					pathData.Points[2].X+x, pathData.Points[2].Y+y,
+				)
+			}
+		case PathClosePath:
+			c.ClosePath()
+		}
+	}
+}
+
 // PangoCairoUpdateLayout updates a layout to match the current transformation matrix of a Cairo context
 func PangoCairoUpdateLayout(ctx Context, layout *PangoCairoLayout) {
 	// Implementation would synchronize the layout with the Cairo context transformation
@@ -1724,32 +1897,33 @@ type GlyphCornerCoordinates struct {
 	BottomRightX, BottomRightY float64
 }
 
-// GetGlyphCornerCoordinates calculates the four corner coordinates of a glyph
+// GetGlyphCornerCoordinates calculates the four corner coordinates of a
+// glyph's ink bounding box at its position. glyph.Index is a glyph ID, as
+// returned by TextToGlyphs.
 func (s *PangoCairoScaledFont) GetGlyphCornerCoordinates(glyph Glyph) (*GlyphCornerCoordinates, Status) {
-	// Get glyph metrics
-	metrics, status := s.GetGlyphMetrics(rune(glyph.Index))
+	realFace, status := s.getRealFace()
 	if status != StatusSuccess {
 		return nil, status
 	}
 
-	// Calculate the four corners based on glyph position and advance width
-	// The bounding box represents the visual bounds of the glyph
-	topRightX := glyph.X + metrics.BoundingBox.XMax
-
-	if glyph.Index == uint64('H') {
-		fmt.Printf("[DEBUG GetGlyphCornerCoordinates] 'H': glyph.X=%.2f, BBox.XMax=%.2f, TopRightX=%.2f\n",
-			glyph.X, metrics.BoundingBox.XMax, topRightX)
+	// Get glyph metrics
+	metrics, status := s.glyphMetrics(realFace, api.GID(glyph.Index))
+	if status != StatusSuccess {
+		return nil, status
 	}
 
+	// Calculate the four corners based on glyph position
+	// The bounding box represents the visual bounds of the glyph
+	box := metrics.BoundingBox
 	coords := &GlyphCornerCoordinates{
-		TopLeftX:     glyph.X + metrics.BoundingBox.XMin,
-		TopLeftY:     glyph.Y + metrics.BoundingBox.YMin,
-		TopRightX:    topRightX,
-		TopRightY:    glyph.Y + metrics.BoundingBox.YMin,
-		BottomLeftX:  glyph.X + metrics.BoundingBox.XMin,
-		BottomLeftY:  glyph.Y + metrics.BoundingBox.YMax,
-		BottomRightX: topRightX,
-		BottomRightY: glyph.Y + metrics.BoundingBox.YMax,
+		TopLeftX:     glyph.X + box.XMin,
+		TopLeftY:     glyph.Y + box.YMin,
+		TopRightX:    glyph.X + box.XMax,
+		TopRightY:    glyph.Y + box.YMin,
+		BottomLeftX:  glyph.X + box.XMin,
+		BottomLeftY:  glyph.Y + box.YMax,
+		BottomRightX: glyph.X + box.XMax,
+		BottomRightY: glyph.Y + box.YMax,
 	}
 
 	return coords, StatusSuccess
@@ -1876,7 +2050,7 @@ func (l *PangoCairoLayout) GetPixelExtents() *PangoRectangle {
 	}
 
 	// Create a temporary scaled font to get text extents
-	fontFace := NewPangoCairoFont(l.fontDesc.family, FontSlantNormal, FontWeightNormal)
+	fontFace := l.fontDesc.fontFace()
 	defer fontFace.Destroy()
 
 	fontMatrix := NewMatrix()
@@ -1906,7 +2080,7 @@ func (l *PangoCairoLayout) GetFontExtents() *FontExtents {
 	}
 
 	// Create a temporary scaled font to get font extents
-	fontFace := NewPangoCairoFont(l.fontDesc.family, FontSlantNormal, FontWeightNormal)
+	fontFace := l.fontDesc.fontFace()
 	defer fontFace.Destroy()
 
 	fontMatrix := NewMatrix()
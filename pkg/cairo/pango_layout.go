@@ -0,0 +1,254 @@
+package cairo
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// layoutLine is a line of a layout's text once paragraphs are wrapped to
+// the layout width: its words and the spaces between them, with their
+// advances. Spaces a line was broken at belong to neither line.
+type layoutLine struct {
+	words       []string
+	gaps        []string
+	wordWidths  []float64
+	gapWidths   []float64
+	width       float64
+	endsSection bool // the line ends its paragraph
+}
+
+// text returns the line's text as it appears in the layout.
+func (l *layoutLine) text() string {
+	var b strings.Builder
+	for i, w := range l.words {
+		if i > 0 {
+			b.WriteString(l.gaps[i-1])
+		}
+		b.WriteString(w)
+	}
+	return b.String()
+}
+
+// SetJustify sets whether wrapped lines are stretched to fill the layout
+// width, as pango_layout_set_justify does. The space left on a line is
+// shared out between its words; the last line of each paragraph, lines
+// holding a single word and lines with tabs, whose columns stay on their
+// tab stops, keep their natural width and follow the alignment.
+// Justification needs a width to wrap to.
+func (l *PangoCairoLayout) SetJustify(justify bool) {
+	l.justify = justify
+}
+
+func (l *PangoCairoLayout) GetJustify() bool {
+	return l.justify
+}
+
+// GetLineCount returns the number of lines of the layout once its
+// paragraphs are wrapped to the layout width.
+func (l *PangoCairoLayout) GetLineCount() int {
+	if l.fontDesc == nil {
+		return len(strings.Split(l.text, "\n"))
+	}
+	sf := l.scaledFont()
+	defer sf.Destroy()
+	return len(l.breakLines(sf))
+}
+
+// scaledFont returns the scaled font the layout's text is drawn with.
+func (l *PangoCairoLayout) scaledFont() *PangoCairoScaledFont {
+	fontFace := l.fontDesc.fontFace()
+	defer fontFace.Destroy()
+
+	// Use positive Y scale - our coordinate system has Y growing downward,
+	// and the glyph flip is handled in the rendering code
+	fontMatrix := NewMatrix()
+	fontMatrix.InitScale(l.fontDesc.size, l.fontDesc.size)
+	return NewPangoCairoScaledFont(fontFace, fontMatrix, NewMatrix(), nil)
+}
+
+// breakLines splits the layout's text into paragraphs at newlines and,
+// when the layout has a width, wraps each paragraph at spaces so its lines
+// fit the width. A word wider than the layout gets a line of its own.
+func (l *PangoCairoLayout) breakLines(sf *PangoCairoScaledFont) []layoutLine {
+	maxWidth := -1.0
+	if l.width > 0 {
+		maxWidth = float64(l.width) / 1024.0 // Convert from Pango units
+	}
+
+	var lines []layoutLine
+	for _, paragraph := range strings.Split(l.text, "\n") {
+		words, gaps := splitWords(paragraph)
+		line := layoutLine{}
+		for i, word := range words {
+			if len(line.words) == 0 {
+				w := l.advanceAt(sf, 0, word)
+				line.words, line.wordWidths, line.width = []string{word}, []float64{w}, w
+				continue
+			}
+			g := l.advanceAt(sf, line.width, gaps[i-1])
+			w := l.advanceAt(sf, line.width+g, word)
+			if maxWidth >= 0 && line.width+g+w > maxWidth {
+				lines = append(lines, line)
+				w = l.advanceAt(sf, 0, word)
+				line = layoutLine{words: []string{word}, wordWidths: []float64{w}, width: w}
+				continue
+			}
+			line.words = append(line.words, word)
+			line.wordWidths = append(line.wordWidths, w)
+			line.gaps = append(line.gaps, gaps[i-1])
+			line.gapWidths = append(line.gapWidths, g)
+			line.width += g + w
+		}
+		line.endsSection = true
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitWords splits a paragraph into words and the runs of spaces between
+// them. Leading spaces stay with the first word, so indentation is kept,
+// and trailing spaces are dropped.
+func splitWords(paragraph string) (words, gaps []string) {
+	start := 0
+	for start < len(paragraph) {
+		r, size := utf8.DecodeRuneInString(paragraph[start:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += size
+	}
+	wordStart, i := 0, start
+	for i < len(paragraph) {
+		r, size := utf8.DecodeRuneInString(paragraph[i:])
+		if !unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+		gapStart := i
+		for i < len(paragraph) {
+			r, size = utf8.DecodeRuneInString(paragraph[i:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+		words = append(words, paragraph[wordStart:gapStart])
+		if i < len(paragraph) {
+			gaps = append(gaps, paragraph[gapStart:i])
+		}
+		wordStart = i
+	}
+	if wordStart < len(paragraph) {
+		words = append(words, paragraph[wordStart:])
+	}
+	return words, gaps
+}
+
+// justifiedGlyphs shapes line word by word with the space between words
+// stretched so the line spans width, starting at (x, y).
+func justifiedGlyphs(sf *PangoCairoScaledFont, line *layoutLine, x, y, width float64) ([]Glyph, Status) {
+	extra := (width - line.width) / float64(len(line.gaps))
+	var glyphs []Glyph
+	for i, word := range line.words {
+		wordGlyphs, _, _, status := sf.TextToGlyphs(x, y, word)
+		if status != StatusSuccess {
+			return nil, status
+		}
+		glyphs = append(glyphs, wordGlyphs...)
+		x += line.wordWidths[i]
+		if i < len(line.gaps) {
+			x += line.gapWidths[i] + extra
+		}
+	}
+	return glyphs, StatusSuccess
+}
+
+// SetTabs sets the tab stops of the layout, in user units from the start of
+// each line. A tab moves the pen to the first stop past it; past the last
+// stop, further stops follow at the spacing of the last two, or of the
+// last stop alone. With no tab stops, the default, stops are every eight
+// spaces. Stops that are not positive are ignored.
+func (l *PangoCairoLayout) SetTabs(tabs []float64) {
+	l.tabs = nil
+	for _, tab := range tabs {
+		if tab > 0 {
+			l.tabs = append(l.tabs, tab)
+		}
+	}
+	sort.Float64s(l.tabs)
+}
+
+// GetTabs returns the tab stops set with SetTabs, in increasing order.
+func (l *PangoCairoLayout) GetTabs() []float64 {
+	return append([]float64(nil), l.tabs...)
+}
+
+// nextTabStop returns the first tab stop after pos, in user units from the
+// start of the line.
+func (l *PangoCairoLayout) nextTabStop(sf *PangoCairoScaledFont, pos float64) float64 {
+	for _, tab := range l.tabs {
+		if tab > pos {
+			return tab
+		}
+	}
+
+	var last, interval float64
+	switch n := len(l.tabs); n {
+	case 0:
+		interval = 8 * sf.TextExtents(" ").XAdvance
+		if interval <= 0 {
+			interval = 4 * l.fontDesc.size
+		}
+	case 1:
+		last, interval = l.tabs[0], l.tabs[0]
+	default:
+		last, interval = l.tabs[n-1], l.tabs[n-1]-l.tabs[n-2]
+		if interval <= 0 {
+			interval = last
+		}
+	}
+	return last + (math.Floor((pos-last)/interval)+1)*interval
+}
+
+// advanceAt returns the advance of s drawn with the pen at pos from the
+// start of the line, with each tab reaching to the next tab stop.
+func (l *PangoCairoLayout) advanceAt(sf *PangoCairoScaledFont, pos float64, s string) float64 {
+	end := pos
+	for i, segment := range strings.Split(s, "\t") {
+		if i > 0 {
+			end = l.nextTabStop(sf, end)
+		}
+		if segment != "" {
+			end += sf.TextExtents(segment).XAdvance
+		}
+	}
+	return end - pos
+}
+
+// tabbedGlyphs shapes s as a line starting at (x, y), moving the pen to the
+// next tab stop at each tab. Tabs get no glyphs.
+func (l *PangoCairoLayout) tabbedGlyphs(sf *PangoCairoScaledFont, x, y float64, s string) ([]Glyph, Status) {
+	segments := strings.Split(s, "\t")
+	var glyphs []Glyph
+	pos := 0.0
+	for i, segment := range segments {
+		if i > 0 {
+			pos = l.nextTabStop(sf, pos)
+		}
+		if segment == "" {
+			continue
+		}
+		segmentGlyphs, _, _, status := sf.TextToGlyphs(x+pos, y, segment)
+		if status != StatusSuccess {
+			return nil, status
+		}
+		glyphs = append(glyphs, segmentGlyphs...)
+		if i < len(segments)-1 {
+			pos += sf.TextExtents(segment).XAdvance
+		}
+	}
+	return glyphs, StatusSuccess
+}
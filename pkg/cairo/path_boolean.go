@@ -0,0 +1,305 @@
+package cairo
+
+import (
+	"math"
+	"sort"
+)
+
+// pathBooleanTolerance is the tolerance curves are flattened with for
+// boolean operations, a context's default tolerance.
+const pathBooleanTolerance = 0.1
+
+// pathBooleanGrid is the grid vertices and intersections are snapped to,
+// so that edges meeting at a point share it exactly.
+const pathBooleanGrid = 1 << 20
+
+// PathUnion returns a path covering the area covered by a or b. See
+// pathBoolean for how the operands are read and what the result is.
+func PathUnion(a, b *Path) *Path {
+	return pathBoolean(a, b, func(inA, inB bool) bool { return inA || inB })
+}
+
+// PathIntersect returns a path covering the area covered by both a and b.
+func PathIntersect(a, b *Path) *Path {
+	return pathBoolean(a, b, func(inA, inB bool) bool { return inA && inB })
+}
+
+// PathDifference returns a path covering the area covered by a but not by
+// b, such as a rectangle with a circular hole.
+func PathDifference(a, b *Path) *Path {
+	return pathBoolean(a, b, func(inA, inB bool) bool { return inA && !inB })
+}
+
+// PathXor returns a path covering the area covered by exactly one of a and
+// b.
+func PathXor(a, b *Path) *Path {
+	return pathBoolean(a, b, func(inA, inB bool) bool { return inA != inB })
+}
+
+// boolEdge is a line of an operand, from a flattened and closed subpath.
+type boolEdge struct {
+	p0, p1 Point
+	// splits holds the points other edges cross this one at
+	splits []Point
+}
+
+// pathBoolean combines the areas a and b cover when filled with
+// FillRuleWinding, keeping the points for which keep is true. Curves are
+// flattened with the default tolerance of a context, and open subpaths are
+// closed as Fill closes them. The result is made of closed polygons that
+// don't cross each other, wound so that it fills the same area with either
+// fill rule: holes run the other way round from the outlines around them.
+//
+// Every edge of both operands is split where any other edge crosses it.
+// The pieces then lie either on the result's boundary, with the result on
+// exactly one side, or not, and the boundary pieces are turned to keep the
+// result on the same side and chained into polygons.
+func pathBoolean(a, b *Path, keep func(inA, inB bool) bool) *Path {
+	for _, p := range []*Path{a, b} {
+		if p == nil {
+			return &Path{Status: StatusNullPointer}
+		}
+		if p.Status != StatusSuccess {
+			return &Path{Status: p.Status}
+		}
+		for _, data := range p.Data {
+			if len(data.Points) < segmentPoints(data.Type) {
+				return &Path{Status: StatusInvalidPathData}
+			}
+		}
+	}
+
+	edgesA, edgesB := polygonEdges(a), polygonEdges(b)
+	edges := make([]*boolEdge, 0, len(edgesA)+len(edgesB))
+	edges = append(append(edges, edgesA...), edgesB...)
+	for i, e := range edges {
+		for _, f := range edges[i+1:] {
+			intersectEdges(e, f)
+		}
+	}
+
+	type fragment struct{ p0, p1 Point }
+	var boundary []fragment
+	seen := make(map[fragment]bool)
+	for _, e := range edges {
+		for _, f := range splitEdge(e) {
+			// Sample each side a little way off the middle of the piece,
+			// where no other edge can pass
+			dx, dy := f[1].X-f[0].X, f[1].Y-f[0].Y
+			length := math.Hypot(dx, dy)
+			offset := math.Min(length*1e-3, 1e-4) / length
+			mx, my := (f[0].X+f[1].X)/2, (f[0].Y+f[1].Y)/2
+			nx, ny := -dy*offset, dx*offset
+			left := Point{X: mx + nx, Y: my + ny}
+			right := Point{X: mx - nx, Y: my - ny}
+			inLeft := keep(windingNumber(edgesA, left) != 0, windingNumber(edgesB, left) != 0)
+			inRight := keep(windingNumber(edgesA, right) != 0, windingNumber(edgesB, right) != 0)
+			if inLeft == inRight {
+				continue
+			}
+			frag := fragment{f[0], f[1]}
+			if inRight {
+				frag = fragment{f[1], f[0]}
+			}
+			// Edges the operands share give the same piece twice
+			if !seen[frag] {
+				seen[frag] = true
+				boundary = append(boundary, frag)
+			}
+		}
+	}
+
+	// Pieces turned this way meet head to tail, so they can be chained
+	// from any piece until it returns to its start
+	from := make(map[Point][]int)
+	for i, f := range boundary {
+		from[f.p0] = append(from[f.p0], i)
+	}
+	used := make([]bool, len(boundary))
+	result := &Path{Status: StatusSuccess}
+	for i := range boundary {
+		if used[i] {
+			continue
+		}
+		var polygon []Point
+		for j := i; j >= 0; {
+			used[j] = true
+			polygon = append(polygon, boundary[j].p0)
+			next := -1
+			for _, k := range from[boundary[j].p1] {
+				if !used[k] {
+					next = k
+					break
+				}
+			}
+			j = next
+		}
+		appendPolygon(result, polygon)
+	}
+	return result
+}
+
+// polygonEdges returns the edges of the closed polygons approximating the
+// subpaths of p.
+func polygonEdges(p *Path) []*boolEdge {
+	var edges []*boolEdge
+	var start, current Point
+	// open is set while a subpath has been started and not closed;
+	// after ClosePath the next segment starts a subpath at its start
+	open, hasPoint := false, false
+	lineTo := func(pt Point) {
+		pt = snapPoint(pt)
+		if pt != current {
+			edges = append(edges, &boolEdge{p0: current, p1: pt})
+		}
+		current = pt
+	}
+	closeSubpath := func() {
+		if open {
+			lineTo(start)
+		}
+		open = false
+	}
+	moveTo := func(pt Point) {
+		closeSubpath()
+		start, current, open, hasPoint = snapPoint(pt), snapPoint(pt), true, true
+	}
+	for _, data := range p.Data {
+		pts := data.Points
+		if data.Type == PathLineTo || data.Type == PathCurveTo {
+			if !hasPoint {
+				moveTo(pts[0])
+			} else if !open {
+				start, open = current, true
+			}
+		}
+		switch data.Type {
+		case PathMoveTo:
+			moveTo(pts[0])
+		case PathLineTo:
+			lineTo(pts[0])
+		case PathCurveTo:
+			flattenCubic(current.X, current.Y, pts[0].X, pts[0].Y, pts[1].X, pts[1].Y, pts[2].X, pts[2].Y,
+				pathBooleanTolerance, 0, func(x, y float64) { lineTo(Point{X: x, Y: y}) })
+		case PathClosePath:
+			closeSubpath()
+			current = start
+		}
+	}
+	closeSubpath()
+	return edges
+}
+
+// snapPoint rounds p to the grid of pathBooleanGrid.
+func snapPoint(p Point) Point {
+	return Point{
+		X: math.Round(p.X*pathBooleanGrid) / pathBooleanGrid,
+		Y: math.Round(p.Y*pathBooleanGrid) / pathBooleanGrid,
+	}
+}
+
+// intersectEdges records where e and f cross or overlap on both of them.
+func intersectEdges(e, f *boolEdge) {
+	if math.Max(e.p0.X, e.p1.X) < math.Min(f.p0.X, f.p1.X) || math.Max(f.p0.X, f.p1.X) < math.Min(e.p0.X, e.p1.X) ||
+		math.Max(e.p0.Y, e.p1.Y) < math.Min(f.p0.Y, f.p1.Y) || math.Max(f.p0.Y, f.p1.Y) < math.Min(e.p0.Y, e.p1.Y) {
+		return
+	}
+	ex, ey := e.p1.X-e.p0.X, e.p1.Y-e.p0.Y
+	fx, fy := f.p1.X-f.p0.X, f.p1.Y-f.p0.Y
+	gx, gy := f.p0.X-e.p0.X, f.p0.Y-e.p0.Y
+	denom := ex*fy - ey*fx
+
+	if denom == 0 {
+		if gx*ey-gy*ex != 0 {
+			return
+		}
+		// Collinear edges overlapping: each is split at the other's ends
+		// that lie within it
+		for _, pair := range [][2]*boolEdge{{e, f}, {f, e}} {
+			g, h := pair[0], pair[1]
+			for _, pt := range []Point{h.p0, h.p1} {
+				if t := edgeParameter(g, pt); t > 0 && t < 1 {
+					g.splits = append(g.splits, pt)
+				}
+			}
+		}
+		return
+	}
+
+	t := (gx*fy - gy*fx) / denom
+	u := (gx*ey - gy*ex) / denom
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return
+	}
+	pt := snapPoint(Point{X: e.p0.X + t*ex, Y: e.p0.Y + t*ey})
+	for _, g := range []*boolEdge{e, f} {
+		if pt != g.p0 && pt != g.p1 {
+			g.splits = append(g.splits, pt)
+		}
+	}
+}
+
+// edgeParameter returns where the projection of pt falls along e, 0 at its
+// start and 1 at its end.
+func edgeParameter(e *boolEdge, pt Point) float64 {
+	dx, dy := e.p1.X-e.p0.X, e.p1.Y-e.p0.Y
+	return ((pt.X-e.p0.X)*dx + (pt.Y-e.p0.Y)*dy) / (dx*dx + dy*dy)
+}
+
+// splitEdge returns the pieces e is cut into by its splits, in order.
+func splitEdge(e *boolEdge) [][2]Point {
+	pts := append([]Point{e.p0}, e.splits...)
+	sort.SliceStable(pts[1:], func(i, j int) bool {
+		return edgeParameter(e, pts[1+i]) < edgeParameter(e, pts[1+j])
+	})
+	pts = append(pts, e.p1)
+	var pieces [][2]Point
+	for i := 1; i < len(pts); i++ {
+		if pts[i] != pts[i-1] {
+			pieces = append(pieces, [2]Point{pts[i-1], pts[i]})
+		}
+	}
+	return pieces
+}
+
+// windingNumber returns the winding number of the polygons made of edges
+// around pt.
+func windingNumber(edges []*boolEdge, pt Point) int {
+	winding := 0
+	for _, e := range edges {
+		if (e.p0.Y <= pt.Y) == (e.p1.Y <= pt.Y) {
+			continue
+		}
+		// Which side of the edge pt lies on, for an edge crossing its row
+		side := (e.p1.X-e.p0.X)*(pt.Y-e.p0.Y) - (pt.X-e.p0.X)*(e.p1.Y-e.p0.Y)
+		if e.p1.Y > e.p0.Y && side > 0 {
+			winding++
+		} else if e.p1.Y < e.p0.Y && side < 0 {
+			winding--
+		}
+	}
+	return winding
+}
+
+// appendPolygon adds polygon to p as a closed subpath, leaving out
+// vertices in the middle of straight runs.
+func appendPolygon(p *Path, polygon []Point) {
+	n := len(polygon)
+	var kept []Point
+	for i, pt := range polygon {
+		prev, next := polygon[(i+n-1)%n], polygon[(i+1)%n]
+		cross := (pt.X-prev.X)*(next.Y-pt.Y) - (pt.Y-prev.Y)*(next.X-pt.X)
+		dot := (pt.X-prev.X)*(next.X-pt.X) + (pt.Y-prev.Y)*(next.Y-pt.Y)
+		if cross != 0 || dot <= 0 {
+			kept = append(kept, pt)
+		}
+	}
+	if len(kept) < 3 {
+		return
+	}
+	p.Data = append(p.Data, PathData{Type: PathMoveTo, Points: []Point{kept[0]}})
+	for _, pt := range kept[1:] {
+		p.Data = append(p.Data, PathData{Type: PathLineTo, Points: []Point{pt}})
+	}
+	p.Data = append(p.Data, PathData{Type: PathClosePath})
+}
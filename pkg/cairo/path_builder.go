@@ -0,0 +1,98 @@
+package cairo
+
+// ForEach calls fn for every segment of the path in order. pts holds one
+// point for PathMoveTo and PathLineTo, the two control points and the end
+// point for PathCurveTo, and none for PathClosePath. fn must not keep pts.
+func (p *Path) ForEach(fn func(op PathDataType, pts []Point)) {
+	if p == nil {
+		return
+	}
+	for _, data := range p.Data {
+		fn(data.Type, data.Points)
+	}
+}
+
+// Copy returns a deep copy of the path, so the copy can be modified without
+// affecting p.
+func (p *Path) Copy() *Path {
+	if p == nil {
+		return nil
+	}
+	return copyPath(p)
+}
+
+// PathBuilder constructs a Path without a context, with the same segment
+// semantics as the Context path methods. The result can be added to a
+// context with AppendPath.
+type PathBuilder struct {
+	data     []PathData
+	current  Point
+	start    Point
+	hasPoint bool
+}
+
+// NewPathBuilder returns an empty path builder.
+func NewPathBuilder() *PathBuilder {
+	return &PathBuilder{}
+}
+
+// MoveTo begins a new subpath at (x, y).
+func (b *PathBuilder) MoveTo(x, y float64) {
+	b.data = append(b.data, PathData{Type: PathMoveTo, Points: []Point{{X: x, Y: y}}})
+	b.current = Point{X: x, Y: y}
+	b.start = b.current
+	b.hasPoint = true
+}
+
+// LineTo adds a line to (x, y), or behaves as MoveTo if there is no current
+// point.
+func (b *PathBuilder) LineTo(x, y float64) {
+	if !b.hasPoint {
+		b.MoveTo(x, y)
+		return
+	}
+	b.data = append(b.data, PathData{Type: PathLineTo, Points: []Point{{X: x, Y: y}}})
+	b.current = Point{X: x, Y: y}
+}
+
+// CurveTo adds a cubic Bézier curve to (x3, y3) with control points (x1, y1)
+// and (x2, y2). Without a current point the curve starts at (x1, y1).
+func (b *PathBuilder) CurveTo(x1, y1, x2, y2, x3, y3 float64) {
+	if !b.hasPoint {
+		b.MoveTo(x1, y1)
+	}
+	b.data = append(b.data, PathData{
+		Type:   PathCurveTo,
+		Points: []Point{{X: x1, Y: y1}, {X: x2, Y: y2}, {X: x3, Y: y3}},
+	})
+	b.current = Point{X: x3, Y: y3}
+}
+
+// ClosePath closes the current subpath; the current point returns to its
+// start.
+func (b *PathBuilder) ClosePath() {
+	if !b.hasPoint {
+		return
+	}
+	b.data = append(b.data, PathData{Type: PathClosePath})
+	b.current = b.start
+}
+
+// Rectangle adds a closed rectangle subpath, like Context.Rectangle.
+func (b *PathBuilder) Rectangle(x, y, width, height float64) {
+	b.MoveTo(x, y)
+	b.LineTo(x+width, y)
+	b.LineTo(x+width, y+height)
+	b.LineTo(x, y+height)
+	b.ClosePath()
+}
+
+// CurrentPoint returns the current point and whether there is one.
+func (b *PathBuilder) CurrentPoint() (x, y float64, ok bool) {
+	return b.current.X, b.current.Y, b.hasPoint
+}
+
+// Path returns a copy of the segments built so far.
+func (b *PathBuilder) Path() *Path {
+	return copyPath(&Path{Status: StatusSuccess, Data: b.data})
+}
@@ -0,0 +1,144 @@
+package cairo
+
+import "math"
+
+// Simplify returns a copy of the path with fewer points, for exporting
+// dense polylines such as traced outlines. Each run of line segments is
+// reduced with the Ramer–Douglas–Peucker algorithm, which drops points
+// lying within tolerance of the segment between the points kept around
+// them. Curves are kept intact, as are the subpaths' moves and closes, so
+// closed subpaths stay closed. See SimplifyFlat to simplify curves too.
+func (p *Path) Simplify(tolerance float64) *Path {
+	return simplifyPath(p, tolerance, false)
+}
+
+// SimplifyFlat is Simplify with curves first flattened to lines within
+// tolerance, so the result has no curves left.
+func (p *Path) SimplifyFlat(tolerance float64) *Path {
+	return simplifyPath(p, tolerance, true)
+}
+
+func simplifyPath(p *Path, tolerance float64, flatten bool) *Path {
+	if p == nil {
+		return nil
+	}
+	if p.Status != StatusSuccess {
+		return &Path{Status: p.Status}
+	}
+	for _, data := range p.Data {
+		if len(data.Points) < segmentPoints(data.Type) {
+			return &Path{Status: StatusInvalidPathData}
+		}
+	}
+	tolerance = math.Max(tolerance, 0)
+
+	out := &Path{Status: StatusSuccess}
+	// run holds the points of the lines since the last point written out,
+	// which is run[0]
+	var run []Point
+	var start Point
+	flush := func() {
+		for _, pt := range simplifyPolyline(run, tolerance)[1:] {
+			out.Data = append(out.Data, PathData{Type: PathLineTo, Points: []Point{pt}})
+		}
+		run = run[len(run)-1:]
+	}
+	for _, data := range p.Data {
+		switch data.Type {
+		case PathMoveTo:
+			if len(run) > 0 {
+				flush()
+			}
+			start = data.Points[0]
+			run = []Point{start}
+			out.Data = append(out.Data, PathData{Type: PathMoveTo, Points: []Point{start}})
+		case PathLineTo:
+			if len(run) == 0 {
+				run = []Point{data.Points[0]}
+				start = data.Points[0]
+				out.Data = append(out.Data, PathData{Type: PathMoveTo, Points: []Point{start}})
+				continue
+			}
+			run = append(run, data.Points[0])
+		case PathCurveTo:
+			if len(run) == 0 {
+				start = data.Points[0]
+				run = []Point{start}
+				out.Data = append(out.Data, PathData{Type: PathMoveTo, Points: []Point{start}})
+			}
+			pts := data.Points
+			if flatten {
+				from := run[len(run)-1]
+				flattenCubic(from.X, from.Y, pts[0].X, pts[0].Y, pts[1].X, pts[1].Y, pts[2].X, pts[2].Y, tolerance, 0,
+					func(x, y float64) { run = append(run, Point{X: x, Y: y}) })
+				continue
+			}
+			flush()
+			out.Data = append(out.Data, PathData{Type: PathCurveTo, Points: append([]Point(nil), pts[:3]...)})
+			run = []Point{pts[2]}
+		case PathClosePath:
+			if len(run) == 0 {
+				continue
+			}
+			// The closing line is simplified with the others and then left
+			// to ClosePath to draw
+			run = append(run, start)
+			flush()
+			out.Data = out.Data[:len(out.Data)-1]
+			out.Data = append(out.Data, PathData{Type: PathClosePath})
+			run = []Point{start}
+		}
+	}
+	if len(run) > 0 {
+		flush()
+	}
+	return out
+}
+
+// simplifyPolyline returns the points of pts kept by the Ramer–Douglas–
+// Peucker algorithm: the first and last, and recursively the farthest point
+// from the segment between two kept points while it is over tolerance
+// away.
+func simplifyPolyline(pts []Point, tolerance float64) []Point {
+	if len(pts) < 3 {
+		return pts
+	}
+	keep := make([]bool, len(pts))
+	keep[0], keep[len(pts)-1] = true, true
+	// Spans between kept points still to check, without recursion since
+	// dense inputs can split many times
+	spans := [][2]int{{0, len(pts) - 1}}
+	for len(spans) > 0 {
+		span := spans[len(spans)-1]
+		spans = spans[:len(spans)-1]
+		a, b := pts[span[0]], pts[span[1]]
+		farthest, dist := -1, tolerance
+		for i := span[0] + 1; i < span[1]; i++ {
+			if d := segmentDistance(pts[i], a, b); d > dist {
+				farthest, dist = i, d
+			}
+		}
+		if farthest >= 0 {
+			keep[farthest] = true
+			spans = append(spans, [2]int{span[0], farthest}, [2]int{farthest, span[1]})
+		}
+	}
+
+	kept := make([]Point, 0, len(pts))
+	for i, pt := range pts {
+		if keep[i] {
+			kept = append(kept, pt)
+		}
+	}
+	return kept
+}
+
+// segmentDistance returns the distance from p to the segment from a to b.
+func segmentDistance(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	t := 0.0
+	if lengthSq := dx*dx + dy*dy; lengthSq > 0 {
+		t = math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/lengthSq))
+	}
+	return math.Hypot(p.X-a.X-t*dx, p.Y-a.Y-t*dy)
+}
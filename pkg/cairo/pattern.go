@@ -46,17 +46,34 @@ type meshPattern struct {
 	basePattern
 	patches      []*MeshPatch
 	currentPatch *MeshPatch
+
+	// Sides of the current patch drawn with MeshPatternLineTo and
+	// MeshPatternCurveTo, -1 before MeshPatternMoveTo, and which of its
+	// interior control points have been given
+	currentSide int
+	interiorSet [4]bool
 }
 
 // MeshPatch represents a single patch in the mesh pattern.
 type MeshPatch struct {
-	// 4 control points for a Coons patch
+	// The boundary of the patch: the 4 corners, then the 2 Bézier control
+	// points of each side, side i running from corner i to corner (i+1)%4
+	boundary [12]Point
+
+	// The 4 interior control points of the tensor-product patch, point i
+	// lying nearest corner i
 	controlPoints [4]Point
 
 	// 4 corner colors
 	cornerColors [4]Color
 }
 
+// meshSideControls returns the indices of the Bézier control points of
+// side, from the start corner's end.
+func meshSideControls(side int) (int, int) {
+	return 4 + 2*side, 5 + 2*side
+}
+
 // RasterSourceAcquireFunc is the callback function to acquire the surface for a raster source pattern.
 type RasterSourceAcquireFunc func(pattern Pattern, target Surface, extents *Rectangle) Surface
 
@@ -78,6 +95,8 @@ type basePattern struct {
 	matrix      Matrix
 	extend      Extend
 	filter      Filter
+	phaseX      float64
+	phaseY      float64
 	userData    map[*UserDataKey]interface{}
 }
 
@@ -127,7 +146,8 @@ func NewPatternForSurface(surface Surface) Pattern {
 	return pattern
 }
 
-// NewPatternLinear creates a linear gradient pattern
+// NewPatternLinear creates a linear gradient pattern. Like all gradients,
+// it extends with ExtendPad by default, as in cairo.
 func NewPatternLinear(x0, y0, x1, y1 float64) Pattern {
 	pattern := &linearGradient{
 		gradientPattern: gradientPattern{
@@ -135,7 +155,7 @@ func NewPatternLinear(x0, y0, x1, y1 float64) Pattern {
 				refCount:    1,
 				status:      StatusSuccess,
 				patternType: PatternTypeLinear,
-				extend:      ExtendNone,
+				extend:      ExtendPad,
 				filter:      FilterFast,
 				userData:    make(map[*UserDataKey]interface{}),
 			},
@@ -165,26 +185,145 @@ func NewPatternMesh() Pattern {
 	return pattern
 }
 
-// MeshPatternBeginPatch starts a new patch.
+// MeshPatternBeginPatch starts a new patch. Its boundary is built with
+// MeshPatternMoveTo followed by up to four MeshPatternLineTo and
+// MeshPatternCurveTo sides, like the C API; a patch whose four sides are
+// straight lines is bilinear.
 func (p *meshPattern) MeshPatternBeginPatch() error {
 	if p.currentPatch != nil {
 		return newError(StatusInvalidMeshConstruction, "patch already in progress")
 	}
 	p.currentPatch = &MeshPatch{}
+	p.currentSide = -1
+	p.interiorSet = [4]bool{}
 	return nil
 }
 
-// MeshPatternEndPatch ends the current patch and adds it to the pattern.
+// MeshPatternEndPatch ends the current patch and adds it to the pattern. A
+// boundary with fewer than four sides is closed with straight lines back
+// to its first corner. Interior control points that were never set are
+// placed so the patch is the Coons patch of its boundary.
 func (p *meshPattern) MeshPatternEndPatch() error {
 	if p.currentPatch == nil {
 		return newError(StatusInvalidMeshConstruction, "no patch in progress")
 	}
-	p.patches = append(p.patches, p.currentPatch)
+	if p.currentSide <= 0 {
+		// Without a move there is no boundary, and a lone starting point
+		// has none to close
+		return newError(StatusInvalidMeshConstruction, "patch has no sides")
+	}
+	start := p.currentPatch.boundary[0]
+	for p.currentSide < 4 {
+		p.MeshPatternLineTo(start.X, start.Y)
+	}
+
+	patch := p.currentPatch
+	for i := range patch.controlPoints {
+		if !p.interiorSet[i] {
+			patch.controlPoints[i] = patch.coonsControlPoint(i)
+		}
+	}
+	p.patches = append(p.patches, patch)
 	p.currentPatch = nil
 	return nil
 }
 
-// MeshPatternSetControlPoint sets a control point for the current patch.
+// meshGrid returns the boundary of patch as the border of the 4x4 grid of
+// a tensor-product patch, with corner i of the boundary at grid[0][0],
+// grid[0][3], grid[3][3] and grid[3][0] for i from 0 to 3. The interior
+// entries are left zero.
+func (patch *MeshPatch) meshGrid() [4][4]Point {
+	b := patch.boundary
+	return [4][4]Point{
+		{b[0], b[4], b[5], b[1]},
+		{b[11], {}, {}, b[6]},
+		{b[10], {}, {}, b[7]},
+		{b[3], b[9], b[8], b[2]},
+	}
+}
+
+// meshInteriorIndex holds the grid positions of the interior control
+// points, point i lying next to corner i.
+var meshInteriorIndex = [4][2]int{{1, 1}, {1, 2}, {2, 2}, {2, 1}}
+
+// coonsControlPoint returns interior control point i of the tensor-product
+// patch equal to the Coons patch of patch's boundary, as cairo computes it.
+func (patch *MeshPatch) coonsControlPoint(i int) Point {
+	g := patch.meshGrid()
+	r, c := meshInteriorIndex[i][0], meshInteriorIndex[i][1]
+	// The corner next to the point, and the row and column across from it
+	r0, c0 := 3*(r-1), 3*(c-1)
+	r3, c3 := 3-r0, 3-c0
+	coord := func(v func(Point) float64) float64 {
+		return (-4*v(g[r0][c0]) + 6*(v(g[r0][c])+v(g[r][c0])) - 2*(v(g[r0][c3])+v(g[r3][c0])) +
+			3*(v(g[r][c3])+v(g[r3][c])) - v(g[r3][c3])) / 9
+	}
+	return Point{
+		X: coord(func(p Point) float64 { return p.X }),
+		Y: coord(func(p Point) float64 { return p.Y }),
+	}
+}
+
+// MeshPatternMoveTo sets the first corner of the current patch's boundary.
+// It must come before any side is drawn.
+func (p *meshPattern) MeshPatternMoveTo(x, y float64) error {
+	if p.currentPatch == nil {
+		return newError(StatusInvalidMeshConstruction, "no patch in progress")
+	}
+	if p.currentSide > 0 {
+		return newError(StatusInvalidMeshConstruction, "move after the patch's sides")
+	}
+	p.currentPatch.boundary[0] = Point{X: x, Y: y}
+	p.currentSide = 0
+	return nil
+}
+
+// MeshPatternLineTo adds a straight side from the current corner to (x, y),
+// or starts the boundary there like MeshPatternMoveTo when it has no
+// corner yet.
+func (p *meshPattern) MeshPatternLineTo(x, y float64) error {
+	if p.currentPatch == nil {
+		return newError(StatusInvalidMeshConstruction, "no patch in progress")
+	}
+	if p.currentSide < 0 {
+		return p.MeshPatternMoveTo(x, y)
+	}
+	from := p.currentPatch.boundary[p.currentSide]
+	return p.MeshPatternCurveTo(
+		from.X+(x-from.X)/3, from.Y+(y-from.Y)/3,
+		from.X+(x-from.X)*2/3, from.Y+(y-from.Y)*2/3,
+		x, y)
+}
+
+// MeshPatternCurveTo adds a cubic Bézier side from the current corner to
+// (x3, y3), or starts the boundary at (x1, y1) first when it has no corner
+// yet. A patch has at most four sides; the fourth ends at the first
+// corner, whatever (x3, y3) is.
+func (p *meshPattern) MeshPatternCurveTo(x1, y1, x2, y2, x3, y3 float64) error {
+	if p.currentPatch == nil {
+		return newError(StatusInvalidMeshConstruction, "no patch in progress")
+	}
+	if p.currentSide < 0 {
+		p.MeshPatternMoveTo(x1, y1)
+	}
+	if p.currentSide >= 4 {
+		return newError(StatusInvalidMeshConstruction, "patch already has four sides")
+	}
+
+	side := p.currentSide
+	c1, c2 := meshSideControls(side)
+	p.currentPatch.boundary[c1] = Point{X: x1, Y: y1}
+	p.currentPatch.boundary[c2] = Point{X: x2, Y: y2}
+	if side < 3 {
+		p.currentPatch.boundary[side+1] = Point{X: x3, Y: y3}
+	}
+	p.currentSide++
+	return nil
+}
+
+// MeshPatternSetControlPoint sets interior control point pointNum, 0 to 3,
+// of the current patch, like cairo_mesh_pattern_set_control_point. Point i
+// lies nearest corner i.
 func (p *meshPattern) MeshPatternSetControlPoint(pointNum int, x, y float64) error {
 	if p.currentPatch == nil {
 		return newError(StatusInvalidMeshConstruction, "no patch in progress")
@@ -193,6 +332,7 @@ func (p *meshPattern) MeshPatternSetControlPoint(pointNum int, x, y float64) err
 		return newError(StatusInvalidIndex, "control point index out of range (0-3)")
 	}
 	p.currentPatch.controlPoints[pointNum] = Point{X: x, Y: y}
+	p.interiorSet[pointNum] = true
 	return nil
 }
 
@@ -226,7 +366,8 @@ func NewPatternRasterSource(acquireFunc RasterSourceAcquireFunc, releaseFunc Ras
 	return pattern
 }
 
-// radialGradient implements radial gradient patterns
+// NewPatternRadial creates a radial gradient pattern, extending with
+// ExtendPad by default.
 func NewPatternRadial(cx0, cy0, radius0, cx1, cy1, radius1 float64) Pattern {
 	pattern := &radialGradient{
 		gradientPattern: gradientPattern{
@@ -234,7 +375,7 @@ func NewPatternRadial(cx0, cy0, radius0, cx1, cy1, radius1 float64) Pattern {
 				refCount:    1,
 				status:      StatusSuccess,
 				patternType: PatternTypeRadial,
-				extend:      ExtendNone,
+				extend:      ExtendPad,
 				filter:      FilterFast,
 				userData:    make(map[*UserDataKey]interface{}),
 			},
@@ -281,7 +422,7 @@ func (p *basePattern) Destroy() {
 }
 
 func (p *basePattern) cleanup() {
-	// Base cleanup - overridden in concrete types
+	destroyUserData(p.userData)
 }
 
 func (p *basePattern) GetReferenceCount() int {
@@ -301,16 +442,29 @@ func (p *basePattern) SetUserData(key *UserDataKey, userData unsafe.Pointer, des
 		return p.status
 	}
 
-	p.userData[key] = userData
-	// TODO: Store destroy function and call it when appropriate
+	setUserData(p.userData, key, userData, destroy)
 	return StatusSuccess
 }
 
 func (p *basePattern) GetUserData(key *UserDataKey) unsafe.Pointer {
-	if data, exists := p.userData[key]; exists {
-		return data.(unsafe.Pointer)
+	return getUserData(p.userData, key)
+}
+
+// SetUserDataAny attaches an arbitrary Go value to the pattern under key,
+// replacing data set with either SetUserData or SetUserDataAny. Storing nil
+// removes the key.
+func (p *basePattern) SetUserDataAny(key *UserDataKey, v any) Status {
+	if p.status != StatusSuccess {
+		return p.status
 	}
-	return nil
+
+	setUserDataAny(p.userData, key, v)
+	return StatusSuccess
+}
+
+// GetUserDataAny returns the value set with SetUserDataAny, or nil.
+func (p *basePattern) GetUserDataAny(key *UserDataKey) any {
+	return getUserDataAny(p.userData, key)
 }
 
 func (p *basePattern) SetMatrix(matrix *Matrix) {
@@ -348,6 +502,20 @@ func (p *basePattern) GetFilter() Filter {
 	return p.filter
 }
 
+// SetPhase shifts the pattern origin to (x, y) in user space before the
+// pattern matrix is applied. Shapes filled with patterns of the same phase
+// share one continuous repeat, like CSS background-position.
+func (p *basePattern) SetPhase(x, y float64) {
+	if p.status != StatusSuccess {
+		return
+	}
+	p.phaseX, p.phaseY = x, y
+}
+
+func (p *basePattern) GetPhase() (x, y float64) {
+	return p.phaseX, p.phaseY
+}
+
 // Solid pattern implementation
 
 // (deleted unused getPattern)
@@ -482,6 +650,68 @@ func (p *gradientPattern) GetColorStop(index int) (offset, red, green, blue, alp
 	return stop.offset, stop.red, stop.green, stop.blue, stop.alpha, StatusSuccess
 }
 
+// Mesh pattern accessors
+
+// GetPatchCount returns the number of patches completed with
+// MeshPatternEndPatch.
+func (p *meshPattern) GetPatchCount() int {
+	return len(p.patches)
+}
+
+// GetControlPoint returns interior control point pointNum, 0 to 3, of a
+// completed patch, numbered as for MeshPatternSetControlPoint.
+func (p *meshPattern) GetControlPoint(patchNum, pointNum int) (x, y float64, status Status) {
+	if patchNum < 0 || patchNum >= len(p.patches) || pointNum < 0 || pointNum > 3 {
+		return 0, 0, StatusInvalidIndex
+	}
+	pt := p.patches[patchNum].controlPoints[pointNum]
+	return pt.X, pt.Y, StatusSuccess
+}
+
+// GetPath returns the boundary of a completed patch as a closed path of
+// four curves, like cairo_mesh_pattern_get_path.
+func (p *meshPattern) GetPath(patchNum int) (*Path, Status) {
+	if patchNum < 0 || patchNum >= len(p.patches) {
+		return nil, StatusInvalidIndex
+	}
+	pts := p.patches[patchNum].boundary
+	path := &Path{Status: StatusSuccess}
+	path.Data = append(path.Data, PathData{Type: PathMoveTo, Points: []Point{pts[0]}})
+	for side := 0; side < 4; side++ {
+		c1, c2 := meshSideControls(side)
+		path.Data = append(path.Data, PathData{Type: PathCurveTo, Points: []Point{pts[c1], pts[c2], pts[(side+1)%4]}})
+	}
+	path.Data = append(path.Data, PathData{Type: PathClosePath})
+	return path, StatusSuccess
+}
+
+// GetCornerColor returns the color of corner cornerNum, 0 to 3, of a
+// completed patch.
+func (p *meshPattern) GetCornerColor(patchNum, cornerNum int) (red, green, blue, alpha float64, status Status) {
+	if patchNum < 0 || patchNum >= len(p.patches) || cornerNum < 0 || cornerNum > 3 {
+		return 0, 0, 0, 0, StatusInvalidIndex
+	}
+	c := p.patches[patchNum].cornerColors[cornerNum]
+	return c.R, c.G, c.B, c.A, StatusSuccess
+}
+
+func (p *meshPattern) Reference() Pattern {
+	atomic.AddInt32(&p.refCount, 1)
+	return p
+}
+
+// Raster source pattern implementation
+
+// GetAcquire returns the callbacks the pattern was created with.
+func (p *rasterSourcePattern) GetAcquire() (acquire RasterSourceAcquireFunc, release RasterSourceReleaseFunc) {
+	return p.acquireFunc, p.releaseFunc
+}
+
+func (p *rasterSourcePattern) Reference() Pattern {
+	atomic.AddInt32(&p.refCount, 1)
+	return p
+}
+
 // Linear gradient implementation
 
 func (p *linearGradient) Reference() Pattern {
@@ -533,3 +763,170 @@ type RadialGradientPattern interface {
 	GradientPattern
 	GetRadialCircles() (cx0, cy0, radius0, cx1, cy1, radius1 float64)
 }
+
+type MeshPattern interface {
+	Pattern
+	MeshPatternBeginPatch() error
+	MeshPatternEndPatch() error
+	MeshPatternMoveTo(x, y float64) error
+	MeshPatternLineTo(x, y float64) error
+	MeshPatternCurveTo(x1, y1, x2, y2, x3, y3 float64) error
+	MeshPatternSetControlPoint(pointNum int, x, y float64) error
+	MeshPatternSetCornerColor(cornerNum int, red, green, blue, alpha float64) error
+	GetPatchCount() int
+	GetControlPoint(patchNum, pointNum int) (x, y float64, status Status)
+	GetPath(patchNum int) (*Path, Status)
+	GetCornerColor(patchNum, cornerNum int) (red, green, blue, alpha float64, status Status)
+}
+
+type RasterSourcePattern interface {
+	Pattern
+	GetAcquire() (acquire RasterSourceAcquireFunc, release RasterSourceReleaseFunc)
+}
+
+// Accessors taking any pattern, like the cairo_pattern_get_* functions.
+// They return the pattern's error status when it is in error, and
+// StatusPatternTypeMismatch when it is not of the type they read.
+
+// PatternGetRGBA returns the color of a solid pattern, like
+// cairo_pattern_get_rgba.
+func PatternGetRGBA(pattern Pattern) (red, green, blue, alpha float64, status Status) {
+	if status := patternStatus(pattern); status != StatusSuccess {
+		return 0, 0, 0, 0, status
+	}
+	solid, ok := pattern.(SolidPattern)
+	if !ok {
+		return 0, 0, 0, 0, StatusPatternTypeMismatch
+	}
+	red, green, blue, alpha = solid.GetRGBA()
+	return red, green, blue, alpha, StatusSuccess
+}
+
+// PatternGetSurface returns a new reference to the surface of a surface
+// pattern, like cairo_pattern_get_surface; the caller destroys it.
+func PatternGetSurface(pattern Pattern) (Surface, Status) {
+	if status := patternStatus(pattern); status != StatusSuccess {
+		return nil, status
+	}
+	sp, ok := pattern.(SurfacePattern)
+	if !ok {
+		return nil, StatusPatternTypeMismatch
+	}
+	return sp.GetSurface(), StatusSuccess
+}
+
+// PatternGetLinearPoints returns the end points of a linear gradient, like
+// cairo_pattern_get_linear_points.
+func PatternGetLinearPoints(pattern Pattern) (x0, y0, x1, y1 float64, status Status) {
+	if status := patternStatus(pattern); status != StatusSuccess {
+		return 0, 0, 0, 0, status
+	}
+	linear, ok := pattern.(LinearGradientPattern)
+	if !ok {
+		return 0, 0, 0, 0, StatusPatternTypeMismatch
+	}
+	x0, y0, x1, y1 = linear.GetLinearPoints()
+	return x0, y0, x1, y1, StatusSuccess
+}
+
+// PatternGetRadialCircles returns the circles of a radial gradient, like
+// cairo_pattern_get_radial_circles.
+func PatternGetRadialCircles(pattern Pattern) (cx0, cy0, radius0, cx1, cy1, radius1 float64, status Status) {
+	if status := patternStatus(pattern); status != StatusSuccess {
+		return 0, 0, 0, 0, 0, 0, status
+	}
+	radial, ok := pattern.(RadialGradientPattern)
+	if !ok {
+		return 0, 0, 0, 0, 0, 0, StatusPatternTypeMismatch
+	}
+	cx0, cy0, radius0, cx1, cy1, radius1 = radial.GetRadialCircles()
+	return cx0, cy0, radius0, cx1, cy1, radius1, StatusSuccess
+}
+
+// PatternGetColorStopCount returns the number of color stops of a gradient,
+// like cairo_pattern_get_color_stop_count.
+func PatternGetColorStopCount(pattern Pattern) (int, Status) {
+	if status := patternStatus(pattern); status != StatusSuccess {
+		return 0, status
+	}
+	gradient, ok := pattern.(GradientPattern)
+	if !ok {
+		return 0, StatusPatternTypeMismatch
+	}
+	return gradient.GetColorStopCount(), StatusSuccess
+}
+
+// PatternGetColorStopRGBA returns a color stop of a gradient, in offset
+// order, like cairo_pattern_get_color_stop_rgba. Out of range indices give
+// StatusInvalidIndex.
+func PatternGetColorStopRGBA(pattern Pattern, index int) (offset, red, green, blue, alpha float64, status Status) {
+	if status := patternStatus(pattern); status != StatusSuccess {
+		return 0, 0, 0, 0, 0, status
+	}
+	gradient, ok := pattern.(GradientPattern)
+	if !ok {
+		return 0, 0, 0, 0, 0, StatusPatternTypeMismatch
+	}
+	return gradient.GetColorStop(index)
+}
+
+// MeshPatternGetPatchCount returns the number of patches of a mesh pattern,
+// like cairo_mesh_pattern_get_patch_count.
+func MeshPatternGetPatchCount(pattern Pattern) (int, Status) {
+	if status := patternStatus(pattern); status != StatusSuccess {
+		return 0, status
+	}
+	mesh, ok := pattern.(MeshPattern)
+	if !ok {
+		return 0, StatusPatternTypeMismatch
+	}
+	return mesh.GetPatchCount(), StatusSuccess
+}
+
+// MeshPatternGetControlPoint returns a control point of a mesh patch, like
+// cairo_mesh_pattern_get_control_point.
+func MeshPatternGetControlPoint(pattern Pattern, patchNum, pointNum int) (x, y float64, status Status) {
+	if status := patternStatus(pattern); status != StatusSuccess {
+		return 0, 0, status
+	}
+	mesh, ok := pattern.(MeshPattern)
+	if !ok {
+		return 0, 0, StatusPatternTypeMismatch
+	}
+	return mesh.GetControlPoint(patchNum, pointNum)
+}
+
+// MeshPatternGetPath returns the boundary of a mesh patch, like
+// cairo_mesh_pattern_get_path.
+func MeshPatternGetPath(pattern Pattern, patchNum int) (*Path, Status) {
+	if status := patternStatus(pattern); status != StatusSuccess {
+		return nil, status
+	}
+	mesh, ok := pattern.(MeshPattern)
+	if !ok {
+		return nil, StatusPatternTypeMismatch
+	}
+	return mesh.GetPath(patchNum)
+}
+
+// MeshPatternGetCornerColorRGBA returns a corner color of a mesh patch,
+// like cairo_mesh_pattern_get_corner_color_rgba.
+func MeshPatternGetCornerColorRGBA(pattern Pattern, patchNum, cornerNum int) (red, green, blue, alpha float64, status Status) {
+	if status := patternStatus(pattern); status != StatusSuccess {
+		return 0, 0, 0, 0, status
+	}
+	mesh, ok := pattern.(MeshPattern)
+	if !ok {
+		return 0, 0, 0, 0, StatusPatternTypeMismatch
+	}
+	return mesh.GetCornerColor(patchNum, cornerNum)
+}
+
+// patternStatus returns the status the accessors report for a pattern that
+// can't be read.
+func patternStatus(pattern Pattern) Status {
+	if pattern == nil {
+		return StatusNullPointer
+	}
+	return pattern.Status()
+}
@@ -0,0 +1,180 @@
+package cairo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"os"
+	"sync/atomic"
+)
+
+// The PDF backend rasterizes each page at one pixel per point and embeds it
+// as an RGB image composited over white. Pages accumulate in memory and the
+// document is written when the surface is finished. Groups, including the
+// transparency groups of PushOpacityGroup, are composited into the page's
+// pixels like any other drawing; no PDF transparency groups are written.
+
+func (s *pdfSurface) Destroy() {
+	if atomic.AddInt32(&s.refCount, -1) == 0 {
+		s.Finish()
+		s.cleanup()
+	}
+}
+
+// ShowPage emits the current page and starts a new, blank one.
+func (s *pdfSurface) ShowPage() {
+	if s.status != StatusSuccess || s.finished {
+		return
+	}
+	s.CopyPage()
+	clear(s.page.Pix)
+}
+
+// CopyPage emits the current page and keeps its content as the start of
+// the next page.
+func (s *pdfSurface) CopyPage() {
+	if s.status != StatusSuccess || s.finished {
+		return
+	}
+	page := image.NewRGBA(s.page.Rect)
+	copy(page.Pix, s.page.Pix)
+	s.pages = append(s.pages, page)
+}
+
+// Flush writes the document as drawn so far to the surface's file: the
+// pages emitted, followed by the current page if it has content. The
+// surface stays open, and later flushes or Finish rewrite the file.
+func (s *pdfSurface) Flush() error {
+	if s.finished {
+		return nil
+	}
+	if s.status == StatusSuccess {
+		pages := s.pages
+		if len(pages) == 0 || !isBlank(s.page) {
+			page := image.NewRGBA(s.page.Rect)
+			copy(page.Pix, s.page.Pix)
+			pages = append(pages[:len(pages):len(pages)], page)
+		}
+		s.writeDocument(pages)
+	}
+	if s.status != StatusSuccess {
+		return newError(s.status, "failed to write PDF "+s.filename)
+	}
+	return nil
+}
+
+// Finish emits the current page if it has content, or if no page has been
+// emitted yet, and writes the document to the surface's file.
+func (s *pdfSurface) Finish() error {
+	if s.finished {
+		return nil
+	}
+	if s.status == StatusSuccess {
+		if len(s.pages) == 0 || !isBlank(s.page) {
+			s.CopyPage()
+		}
+		s.writeDocument(s.pages)
+	}
+	s.pages = nil
+	s.baseSurface.Finish()
+	if s.status != StatusSuccess {
+		return newError(s.status, "failed to write PDF "+s.filename)
+	}
+	return nil
+}
+
+// writeDocument writes a PDF of the given pages to the surface's file,
+// putting the surface in error if that fails.
+func (s *pdfSurface) writeDocument(pages []*image.RGBA) {
+	if err := os.WriteFile(s.filename, encodePDF(pages, s.width, s.height), 0o644); err != nil {
+		s.status = StatusWriteError
+	}
+}
+
+func isBlank(img *image.RGBA) bool {
+	for _, b := range img.Pix {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// encodePDF builds a PDF document with one page per image.
+func encodePDF(pages []*image.RGBA, width, height float64) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	beginObject := func() int {
+		offsets = append(offsets, buf.Len())
+		id := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n", id)
+		return id
+	}
+	writeStream := func(dict string, data []byte) {
+		fmt.Fprintf(&buf, "<< %s /Length %d >>\nstream\n", dict, len(data))
+		buf.Write(data)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 and 2 are the catalog and page tree; each page then takes
+	// three objects: the page, its content stream and its image.
+	beginObject()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	beginObject()
+	buf.WriteString("<< /Type /Pages /Kids [")
+	for i := range pages {
+		fmt.Fprintf(&buf, " %d 0 R", 3+3*i)
+	}
+	fmt.Fprintf(&buf, " ] /Count %d >>\nendobj\n", len(pages))
+
+	for _, page := range pages {
+		id := beginObject()
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] "+
+			"/Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>\nendobj\n",
+			width, height, id+2, id+1)
+
+		beginObject()
+		writeStream("", []byte(fmt.Sprintf("q %g 0 0 %g 0 0 cm /Im0 Do Q", width, height)))
+
+		beginObject()
+		bounds := page.Bounds()
+		writeStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d "+
+			"/ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode",
+			bounds.Dx(), bounds.Dy()), deflateRGBOverWhite(page))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// deflateRGBOverWhite composites the premultiplied page over white and
+// returns the compressed RGB samples.
+func deflateRGBOverWhite(img *image.RGBA) []byte {
+	var out bytes.Buffer
+	zw := zlib.NewWriter(&out)
+	bounds := img.Bounds()
+	row := make([]byte, bounds.Dx()*3)
+	for y := 0; y < bounds.Dy(); y++ {
+		pix := img.Pix[y*img.Stride:]
+		for x := 0; x < bounds.Dx(); x++ {
+			p := pix[x*4 : x*4+4]
+			white := 255 - p[3]
+			row[x*3+0] = p[0] + white
+			row[x*3+1] = p[1] + white
+			row[x*3+2] = p[2] + white
+		}
+		zw.Write(row)
+	}
+	zw.Close()
+	return out.Bytes()
+}
@@ -10,16 +10,16 @@ import (
 
 // PorterDuffBlend 执行 Porter-Duff 混合
 func PorterDuffBlend(src, dst color.NRGBA, op Operator) color.NRGBA {
-	// 转换为预乘 alpha
-	srcR := float64(src.R) * float64(src.A) / 255.0
-	srcG := float64(src.G) * float64(src.A) / 255.0
-	srcB := float64(src.B) * float64(src.A) / 255.0
+	// 转换为 [0, 1] 范围内的预乘 alpha
 	srcA := float64(src.A) / 255.0
+	srcR := float64(src.R) / 255.0 * srcA
+	srcG := float64(src.G) / 255.0 * srcA
+	srcB := float64(src.B) / 255.0 * srcA
 
-	dstR := float64(dst.R) * float64(dst.A) / 255.0
-	dstG := float64(dst.G) * float64(dst.A) / 255.0
-	dstB := float64(dst.B) * float64(dst.A) / 255.0
 	dstA := float64(dst.A) / 255.0
+	dstR := float64(dst.R) / 255.0 * dstA
+	dstG := float64(dst.G) / 255.0 * dstA
+	dstB := float64(dst.B) / 255.0 * dstA
 
 	var outR, outG, outB, outA float64
 
@@ -14,23 +14,65 @@ type rasterContext struct {
 	stroke color.Color
 	width  float64
 
+	// fimg replaces img as the target for float format surfaces
+	fimg *floatImage
+
 	// Current path
 	path []pathPoint
 
-	// Transform matrix
-	matrix Matrix
+	// Transform matrix, and its inverse for sampling patterns at device
+	// pixels; inverseOK is false when the matrix is singular
+	matrix    Matrix
+	inverse   Matrix
+	inverseOK bool
+
+	// Mapping from device pixels to the space of the gradient or surface
+	// pattern: the inverse matrix, then the pattern's phase and matrix
+	patternMatrix Matrix
 
 	// Line properties
 	lineCap    LineCap
 	lineJoin   LineJoin
 	lineDash   []float64
 	dashOffset float64
+	// dashContinuous carries the dash phase from one subpath into the
+	// next instead of restarting it at each MoveTo
+	dashContinuous bool
 
 	// Gradient pattern (if set)
 	gradientPattern Pattern
 
 	// Surface pattern (if set)
 	surfacePattern SurfacePattern
+
+	// Global alpha applied to every composited pixel (PaintWithAlpha)
+	globalAlpha float64
+
+	// Compositing operator; the separable blend modes mix source and
+	// destination colors, anything else composites with "over"
+	operator Operator
+
+	// Antialiasing mode; selects between exact area and center sampled fill
+	// coverage, and whether stroke coverage is thresholded
+	antialias Antialias
+
+	// Rule deciding which parts of a self-intersecting path Fill covers
+	fillRule FillRule
+
+	// Subpixel layout for LCD text fills; SubpixelOrderDefault fills with
+	// grayscale coverage
+	subpixelOrder SubpixelOrder
+
+	// Maximum distance in device pixels between a curve and the line
+	// segments it is flattened to
+	tolerance float64
+
+	// Clip coverage in device pixels, scaling every composited pixel;
+	// nil when unclipped
+	clip *clipCoverage
+
+	// Bounding box of the pixels composited since the last resetDamage
+	damage image.Rectangle
 }
 
 type pathPoint struct {
@@ -64,7 +106,40 @@ func newRasterContext(img *image.RGBA) *rasterContext {
 		stroke: color.Black,
 		width:  1.0,
 		path:   make([]pathPoint, 0),
+
+		globalAlpha: 1.0,
+		operator:    OperatorOver,
+		tolerance:   0.1,
+	}
+}
+
+// newFloatRasterContext creates a raster context compositing in float into
+// the data of a float format surface
+func newFloatRasterContext(img *floatImage) *rasterContext {
+	r := newRasterContext(nil)
+	r.fimg = img
+	return r
+}
+
+// setImage points r at img, the current pixels of the surface it draws to.
+// Images of other types are ignored.
+func (r *rasterContext) setImage(img image.Image) {
+	switch img := img.(type) {
+	case *image.RGBA:
+		if r.fimg == nil {
+			r.img = img
+		}
+	case *floatImage:
+		r.fimg = img
+	}
+}
+
+// bounds returns the bounds of the target image
+func (r *rasterContext) bounds() image.Rectangle {
+	if r.fimg != nil {
+		return r.fimg.Bounds()
 	}
+	return r.img.Bounds()
 }
 
 // BeginPath starts a new path
@@ -121,6 +196,54 @@ func (r *rasterContext) SetLineDash(dash []float64, offset float64) {
 	r.dashOffset = offset
 }
 
+// SetDashContinuous sets whether the dash phase runs on across subpaths.
+func (r *rasterContext) SetDashContinuous(continuous bool) {
+	r.dashContinuous = continuous
+}
+
+// SetAntialias sets the antialiasing mode
+func (r *rasterContext) SetAntialias(antialias Antialias) {
+	r.antialias = antialias
+}
+
+// SetFillRule sets the rule Fill uses for self-intersecting paths.
+func (r *rasterContext) SetFillRule(fillRule FillRule) {
+	r.fillRule = fillRule
+}
+
+// SetTolerance sets the curve flattening tolerance in device pixels.
+func (r *rasterContext) SetTolerance(tolerance float64) {
+	r.tolerance = tolerance
+}
+
+// SetSubpixelOrder selects per-channel coverage for fills. Any order other
+// than SubpixelOrderDefault renders with subpixel (LCD) antialiasing.
+func (r *rasterContext) SetSubpixelOrder(order SubpixelOrder) {
+	r.subpixelOrder = order
+}
+
+// fillSamples returns the side of the per-pixel sample grid used by Fill
+// for the current antialiasing mode, or 0 for exact area coverage. Only
+// AntialiasNone samples, once at the pixel center.
+func (r *rasterContext) fillSamples() int {
+	if r.antialias == AntialiasNone {
+		return 1
+	}
+	return 0
+}
+
+// edgeCoverage adjusts an analytic coverage value for the antialiasing
+// mode: with AntialiasNone pixels are either fully on or fully off.
+func (r *rasterContext) edgeCoverage(coverage float64) float64 {
+	if r.antialias == AntialiasNone {
+		if coverage >= 0.5 {
+			return 1
+		}
+		return 0
+	}
+	return coverage
+}
+
 // SetFillColor sets the fill color
 func (r *rasterContext) SetFillColor(c color.Color) {
 	r.color = c
@@ -138,6 +261,27 @@ func (r *rasterContext) SetMatrixTransform(m [6]float64) {
 		XY: m[2], YY: m[3],
 		X0: m[4], Y0: m[5],
 	}
+	r.inverse = r.matrix
+	r.inverseOK = MatrixInvert(&r.inverse) == StatusSuccess
+	r.updatePatternMatrix()
+}
+
+// updatePatternMatrix recomputes patternMatrix once the matrix or pattern
+// changes, so sampling a pattern costs one transform per pixel.
+func (r *rasterContext) updatePatternMatrix() {
+	var pattern Pattern
+	switch {
+	case r.surfacePattern != nil:
+		pattern = r.surfacePattern
+	case r.gradientPattern != nil:
+		pattern = r.gradientPattern
+	default:
+		return
+	}
+	phaseX, phaseY := pattern.GetPhase()
+	phase := Matrix{XX: 1, YY: 1, X0: -phaseX, Y0: -phaseY}
+	MatrixMultiply(&r.patternMatrix, &r.inverse, &phase)
+	MatrixMultiply(&r.patternMatrix, &r.patternMatrix, pattern.GetMatrix())
 }
 
 // SetFontSize sets the font size (placeholder)
@@ -145,9 +289,75 @@ func (r *rasterContext) SetFontSize(size float64) {
 	// Placeholder - font rendering is handled separately
 }
 
+// SetGlobalAlpha sets an alpha multiplier applied to everything drawn
+func (r *rasterContext) SetGlobalAlpha(alpha float64) {
+	r.globalAlpha = alpha
+}
+
+// SetOperator sets the compositing operator
+func (r *rasterContext) SetOperator(op Operator) {
+	r.operator = op
+}
+
 // SetGradientPattern sets a gradient pattern for filling
 func (r *rasterContext) SetGradientPattern(pattern Pattern) {
 	r.gradientPattern = pattern
+	r.updatePatternMatrix()
+}
+
+// SetClip sets the resolved clip, or removes the clip when cov is nil.
+func (r *rasterContext) SetClip(cov *clipCoverage) {
+	r.clip = cov
+}
+
+// addDamage adds a composited pixel to the damage box.
+func (r *rasterContext) addDamage(x, y int) {
+	d := &r.damage
+	if d.Empty() {
+		*d = image.Rect(x, y, x+1, y+1)
+		return
+	}
+	d.Min.X, d.Min.Y = min(d.Min.X, x), min(d.Min.Y, y)
+	d.Max.X, d.Max.Y = max(d.Max.X, x+1), max(d.Max.Y, y+1)
+}
+
+// resetDamage empties the damage box.
+func (r *rasterContext) resetDamage() {
+	r.damage = image.Rectangle{}
+}
+
+// clipCoverage returns the clip coverage of a pixel, 0 to 1. A rectangular
+// clip is exactly its extents, so its mask is not read.
+func (r *rasterContext) clipCoverage(x, y int) float64 {
+	if r.clip == nil {
+		return 1
+	}
+	if r.clip.rectangular {
+		if image.Pt(x, y).In(r.clip.extents) {
+			return 1
+		}
+		return 0
+	}
+	return float64(r.clip.mask.AlphaAt(x, y).A) / 255
+}
+
+// scanBounds returns the pixels a fill of a path with the given device
+// space bounding box has to visit: the box grown by a pixel for
+// antialiasing, limited to the target and to the clip extents, outside of
+// which nothing is composited.
+func (r *rasterContext) scanBounds(minX, minY, maxX, maxY float64) image.Rectangle {
+	bounds := r.bounds()
+	rect := image.Rectangle{
+		Min: image.Pt(int(math.Max(minX-1, float64(bounds.Min.X))), int(math.Max(minY-1, float64(bounds.Min.Y)))),
+		Max: image.Pt(int(math.Min(maxX+1, float64(bounds.Max.X))), int(math.Min(maxY+1, float64(bounds.Max.Y)))),
+	}
+	if rect.Empty() {
+		return image.Rectangle{}
+	}
+	if r.clip != nil {
+		rect = rect.Intersect(r.clip.extents)
+	}
+	return rect
 }
 
 // SetSurfacePattern sets a surface pattern for filling
@@ -157,14 +367,24 @@ func (r *rasterContext) SetSurfacePattern(pattern SurfacePattern) {
 	if pattern != nil {
 		r.gradientPattern = nil
 	}
+	r.updatePatternMatrix()
 }
 
-// Stroke strokes the current path
+// Stroke strokes the current path, in dashes when a dash pattern is set
 func (r *rasterContext) Stroke() {
 	if len(r.path) == 0 {
 		return
 	}
 
+	line := func(x0, y0, x1, y1 float64) { r.drawLine(x0, y0, x1, y1, r.stroke) }
+	var dasher *dashState
+	if len(r.lineDash) > 0 {
+		dasher = newDashState(r.lineDash, r.dashOffset)
+		line = func(x0, y0, x1, y1 float64) {
+			dasher.walk(x0, y0, x1, y1, func(x0, y0, x1, y1 float64) { r.drawLine(x0, y0, x1, y1, r.stroke) })
+		}
+	}
+
 	var lastX, lastY float64
 	var startX, startY float64
 	hasStart := false
@@ -175,47 +395,139 @@ func (r *rasterContext) Stroke() {
 			lastX, lastY = pt.x, pt.y
 			startX, startY = pt.x, pt.y
 			hasStart = true
+			if dasher != nil && !r.dashContinuous {
+				dasher.reset()
+			}
 		case opLineTo:
 			if hasStart {
-				r.drawLine(lastX, lastY, pt.x, pt.y, r.stroke)
+				line(lastX, lastY, pt.x, pt.y)
 			}
 			lastX, lastY = pt.x, pt.y
 		case opCurveTo:
 			if hasStart {
 				// Draw curve by flattening it with high quality
-				r.drawCurve(lastX, lastY, pt.cp1x, pt.cp1y, pt.cp2x, pt.cp2y, pt.x, pt.y, r.stroke)
+				r.drawCurve(lastX, lastY, pt.cp1x, pt.cp1y, pt.cp2x, pt.cp2y, pt.x, pt.y, line)
 			}
 			lastX, lastY = pt.x, pt.y
 		case opClose:
 			if hasStart {
-				r.drawLine(lastX, lastY, startX, startY, r.stroke)
+				line(lastX, lastY, startX, startY)
 			}
 		}
 	}
 }
 
-// drawCurve draws a cubic Bezier curve by flattening it adaptively
-func (r *rasterContext) drawCurve(x0, y0, x1, y1, x2, y2, x3, y3 float64, c color.Color) {
-	// Adaptive subdivision with high quality tolerance (smaller = smoother)
-	r.drawCurveRecursive(x0, y0, x1, y1, x2, y2, x3, y3, c, 0.05, 0)
+// dashState walks a dash pattern along a stroke, measuring in user space.
+type dashState struct {
+	dashes []float64
+	offset float64
+
+	// The dash being walked, how much of it is left and whether it is "on"
+	index     int
+	remaining float64
+	on        bool
 }
 
-// drawCurveRecursive recursively subdivides and draws a cubic Bezier curve
-func (r *rasterContext) drawCurveRecursive(x0, y0, x1, y1, x2, y2, x3, y3 float64, c color.Color, tolerance float64, depth int) {
-	// Limit recursion depth to prevent stack overflow
-	if depth > 12 {
-		r.drawLine(x0, y0, x3, y3, c)
+// newDashState returns the state at the start of a stroke: offset into the
+// pattern, an odd number of dashes repeating with "on" and "off" swapped.
+func newDashState(dashes []float64, offset float64) *dashState {
+	d := &dashState{dashes: dashes, offset: offset}
+	d.reset()
+	return d
+}
+
+// reset returns to the start of the pattern, offset into it.
+func (d *dashState) reset() {
+	period := 0.0
+	for _, dash := range d.dashes {
+		period += dash
+	}
+	if len(d.dashes)%2 == 1 {
+		period *= 2
+	}
+	offset := math.Mod(d.offset, period)
+	if offset < 0 {
+		offset += period
+	}
+
+	d.index, d.on = 0, true
+	for offset >= d.dashes[d.index] {
+		offset -= d.dashes[d.index]
+		d.advance()
+	}
+	d.remaining = d.dashes[d.index] - offset
+}
+
+// advance moves on to the next dash.
+func (d *dashState) advance() {
+	d.index = (d.index + 1) % len(d.dashes)
+	d.on = !d.on
+	d.remaining = d.dashes[d.index]
+}
+
+// walk follows the line from (x0, y0) to (x1, y1), calling draw with the
+// parts of it that fall in "on" dashes.
+func (d *dashState) walk(x0, y0, x1, y1 float64, draw func(x0, y0, x1, y1 float64)) {
+	length := math.Hypot(x1-x0, y1-y0)
+	if length == 0 {
 		return
 	}
+	for pos := 0.0; pos < length; {
+		step := math.Min(d.remaining, length-pos)
+		if d.on && step > 0 {
+			t0, t1 := pos/length, (pos+step)/length
+			draw(x0+(x1-x0)*t0, y0+(y1-y0)*t0, x0+(x1-x0)*t1, y0+(y1-y0)*t1)
+		}
+		pos += step
+		d.remaining -= step
+		if d.remaining <= 0 {
+			d.advance()
+		}
+	}
+}
 
-	// Check if curve is flat enough using distance from control points to line
+// drawCurve flattens a cubic Bezier curve adaptively to the raster's
+// tolerance, passing the lines to line
+func (r *rasterContext) drawCurve(x0, y0, x1, y1, x2, y2, x3, y3 float64, line func(x0, y0, x1, y1 float64)) {
+	r.drawCurveRecursive(x0, y0, x1, y1, x2, y2, x3, y3, line, r.tolerance, 0)
+}
+
+// curveFlat reports whether the cubic Bezier curve stays within tolerance of
+// the chord from (x0, y0) to (x3, y3). The curve deviates from the chord by
+// at most 3/4 of the larger control point distance, so comparing the sum of
+// both distances against 4/3 of the tolerance is conservative.
+func curveFlat(x0, y0, x1, y1, x2, y2, x3, y3, tolerance float64) bool {
 	dx := x3 - x0
 	dy := y3 - y0
+	lenSq := dx*dx + dy*dy
+	limit := tolerance * 4 / 3
+	if lenSq < 1e-12 {
+		// Closed loop or a point: use the control point distances
+		return math.Hypot(x1-x0, y1-y0) <= limit && math.Hypot(x2-x0, y2-y0) <= limit
+	}
 	d2 := math.Abs((x1-x3)*dy - (y1-y3)*dx)
 	d3 := math.Abs((x2-x3)*dy - (y2-y3)*dx)
+	return (d2+d3)*(d2+d3) <= limit*limit*lenSq
+}
+
+// maxCurveDepth limits how often a cubic Bezier curve is subdivided
+const maxCurveDepth = 12
+
+// drawCurveRecursive recursively subdivides and draws a cubic Bezier curve
+func (r *rasterContext) drawCurveRecursive(x0, y0, x1, y1, x2, y2, x3, y3 float64, line func(x0, y0, x1, y1 float64), tolerance float64, depth int) {
+	// Limit recursion depth to prevent stack overflow
+	if depth > maxCurveDepth {
+		line(x0, y0, x3, y3)
+		return
+	}
 
-	if (d2+d3)*(d2+d3) < tolerance*(dx*dx+dy*dy) {
-		r.drawLine(x0, y0, x3, y3, c)
+	// The path is in user space; measure flatness in device space
+	tx0, ty0 := MatrixTransformPoint(&r.matrix, x0, y0)
+	tx1, ty1 := MatrixTransformPoint(&r.matrix, x1, y1)
+	tx2, ty2 := MatrixTransformPoint(&r.matrix, x2, y2)
+	tx3, ty3 := MatrixTransformPoint(&r.matrix, x3, y3)
+	if curveFlat(tx0, ty0, tx1, ty1, tx2, ty2, tx3, ty3, tolerance) {
+		line(x0, y0, x3, y3)
 		return
 	}
 
@@ -234,8 +546,8 @@ func (r *rasterContext) drawCurveRecursive(x0, y0, x1, y1, x2, y2, x3, y3 float6
 	y0123 := (y012 + y123) / 2
 
 	// Recursively draw both halves
-	r.drawCurveRecursive(x0, y0, x01, y01, x012, y012, x0123, y0123, c, tolerance, depth+1)
-	r.drawCurveRecursive(x0123, y0123, x123, y123, x23, y23, x3, y3, c, tolerance, depth+1)
+	r.drawCurveRecursive(x0, y0, x01, y01, x012, y012, x0123, y0123, line, tolerance, depth+1)
+	r.drawCurveRecursive(x0123, y0123, x123, y123, x23, y23, x3, y3, line, tolerance, depth+1)
 }
 
 // Fill fills the current path with antialiasing
@@ -244,8 +556,6 @@ func (r *rasterContext) Fill() {
 		return
 	}
 
-	bounds := r.img.Bounds()
-
 	// Transform path points to device space and find bounding box
 	transformedPath := make([]transformedPoint, len(r.path))
 	minX, minY := math.MaxFloat64, math.MaxFloat64
@@ -309,81 +619,168 @@ func (r *rasterContext) Fill() {
 		}
 	}
 
-	// Clip to image bounds
-	x1 := int(math.Max(minX-1, float64(bounds.Min.X)))
-	y1 := int(math.Max(minY-1, float64(bounds.Min.Y)))
-	x2 := int(math.Min(maxX+1, float64(bounds.Max.X)))
-	y2 := int(math.Min(maxY+1, float64(bounds.Max.Y)))
+	// Clip to image bounds and the clip extents
+	scan := r.scanBounds(minX, minY, maxX, maxY)
+	x1, y1, x2, y2 := scan.Min.X, scan.Min.Y, scan.Max.X, scan.Max.Y
 
-	// Fill using supersampling antialiasing (4x4 grid per pixel)
-	const samples = 4
-	const invSamples = 1.0 / (samples * samples)
+	if scan.Empty() {
+		return
+	}
+
+	// Fill with exact area coverage, or center samples without antialiasing
+	filler := newScanFiller(transformedPath, r.tolerance, r.fillSamples(), r.fillRule, x1, x2)
+
+	if r.subpixelOrder != SubpixelOrderDefault {
+		r.fillSubpixel(y1, y2, filler)
+		return
+	}
 
-	pixelCount := 0
+	cover := make([]float64, x2-x1)
 	for y := y1; y < y2; y++ {
-		for x := x1; x < x2; x++ {
-			pixelCount++
-			// Count how many subpixel samples are inside the path
-			coverage := 0
-			for sy := 0; sy < samples; sy++ {
-				for sx := 0; sx < samples; sx++ {
-					// Sample at subpixel position
-					sampleX := float64(x) + (float64(sx)+0.5)/float64(samples)
-					sampleY := float64(y) + (float64(sy)+0.5)/float64(samples)
-					if r.pointInTransformedPath(sampleX, sampleY, transformedPath) {
-						coverage++
-					}
-				}
+		filler.rowCoverage(y, 0, 0, cover)
+		for i, alpha := range cover {
+			if alpha == 0 {
+				continue
 			}
+			x := x1 + i
+			// Use surface pattern, gradient, or solid color
+			pixelColor := r.color
+			if r.surfacePattern != nil {
+				pixelColor = r.getSurfacePatternColor(float64(x), float64(y))
+			} else if r.gradientPattern != nil {
+				pixelColor = r.getGradientColor(float64(x), float64(y))
+			}
+			r.blendPixel(x, y, pixelColor, alpha)
+		}
+	}
+}
 
-			// Apply antialiasing based on coverage
-			if coverage > 0 {
-				alpha := float64(coverage) * invSamples
-				// Use surface pattern, gradient, or solid color
-				pixelColor := r.color
-				if r.surfacePattern != nil {
-					pixelColor = r.getSurfacePatternColor(float64(x), float64(y))
-				} else if r.gradientPattern != nil {
-					pixelColor = r.getGradientColor(float64(x), float64(y))
-				}
-				r.blendPixel(x, y, pixelColor, alpha)
+// fillSubpixel fills the rows [y1,y2) of the filler's columns with
+// separate coverage for the red, green and blue channels. Each channel's
+// pixel grid is shifted by a third of a pixel toward its stripe on an LCD
+// panel: along x for RGB/BGR, along y for VRGB/VBGR.
+func (r *rasterContext) fillSubpixel(y1, y2 int, filler *scanFiller) {
+	// Offsets of the red, green and blue stripes
+	offsets := [3]float64{-1.0 / 3, 0, 1.0 / 3}
+	if r.subpixelOrder == SubpixelOrderBGR || r.subpixelOrder == SubpixelOrderVBGR {
+		offsets[0], offsets[2] = offsets[2], offsets[0]
+	}
+	vertical := r.subpixelOrder == SubpixelOrderVRGB || r.subpixelOrder == SubpixelOrderVBGR
+
+	var cover [3][]float64
+	for ch := range cover {
+		cover[ch] = make([]float64, filler.x2-filler.x1)
+	}
+	for y := y1; y < y2; y++ {
+		for ch, off := range offsets {
+			dx, dy := off, 0.0
+			if vertical {
+				dx, dy = 0, off
 			}
+			filler.rowCoverage(y, dx, dy, cover[ch])
+		}
+		for i := range cover[0] {
+			coverage := [3]float64{cover[0][i], cover[1][i], cover[2][i]}
+			if coverage == [3]float64{} {
+				continue
+			}
+
+			x := filler.x1 + i
+			pixelColor := r.color
+			if r.surfacePattern != nil {
+				pixelColor = r.getSurfacePatternColor(float64(x), float64(y))
+			} else if r.gradientPattern != nil {
+				pixelColor = r.getGradientColor(float64(x), float64(y))
+			}
+			r.blendPixelSubpixel(x, y, pixelColor, coverage)
 		}
 	}
 }
 
+// blendPixelSubpixel composites c onto the pixel with the current operator,
+// using a separate coverage for each color channel. Alpha uses the mean
+// coverage.
+func (r *rasterContext) blendPixelSubpixel(x, y int, c color.Color, coverage [3]float64) {
+	if clip := r.clipCoverage(x, y); clip < 1 {
+		if clip == 0 {
+			return
+		}
+		for ch := range coverage {
+			coverage[ch] *= clip
+		}
+	}
+	if r.fimg != nil {
+		r.blendPixelFloat(x, y, c, coverage)
+		return
+	}
+	if x < 0 || y < 0 || x >= r.img.Bounds().Dx() || y >= r.img.Bounds().Dy() {
+		return
+	}
+	r.addDamage(x, y)
+
+	src := color.NRGBAModel.Convert(c).(color.NRGBA)
+	srcA := float64(src.A) / 255.0 * r.globalAlpha
+	srcC := [3]float64{float64(src.R) / 255.0, float64(src.G) / 255.0, float64(src.B) / 255.0}
+
+	dst := r.img.RGBAAt(x, y)
+	dstC := [3]float64{float64(dst.R) / 255.0, float64(dst.G) / 255.0, float64(dst.B) / 255.0}
+	dstA := float64(dst.A) / 255.0
+
+	meanA := srcA * (coverage[0] + coverage[1] + coverage[2]) / 3
+	outA := meanA + dstA*(1-meanA)
+
+	var out [3]uint8
+	for ch := range out {
+		a := srcA * coverage[ch]
+		v := srcC[ch]*a + dstC[ch]*(1-a)
+		if isSeparableBlendMode(r.operator) && dstA > 0 {
+			v += a * dstA * (blendChannel(r.operator, srcC[ch], dstC[ch]/dstA) - srcC[ch])
+		}
+		out[ch] = uint8(math.Min(math.Max(math.Min(v, outA)*255+0.5, 0), 255))
+	}
+
+	r.img.SetRGBA(x, y, color.RGBA{
+		R: out[0],
+		G: out[1],
+		B: out[2],
+		A: uint8(math.Min(math.Max(outA*255+0.5, 0), 255)),
+	})
+}
+
 // blendPixel blends a color with the existing pixel using premultiplied alpha blending
 // This matches Cairo's blending behavior which uses premultiplied alpha
 func (r *rasterContext) blendPixel(x, y int, c color.Color, alpha float64) {
+	if alpha *= r.clipCoverage(x, y); alpha == 0 {
+		return
+	}
+	if r.fimg != nil {
+		r.blendPixelFloat(x, y, c, [3]float64{alpha, alpha, alpha})
+		return
+	}
 	if x < 0 || y < 0 || x >= r.img.Bounds().Dx() || y >= r.img.Bounds().Dy() {
 		return
 	}
+	r.addDamage(x, y)
 
 	// Get source color components (non-premultiplied)
-	sr, sg, sb, sa := c.RGBA()
-	srcR := float64(sr>>8) / 255.0
-	srcG := float64(sg>>8) / 255.0
-	srcB := float64(sb>>8) / 255.0
-	srcA := float64(sa>>8) / 255.0 * alpha
-
-	// Get destination color (RGBA format is non-premultiplied)
-	dst := r.img.At(x, y)
-	dr, dg, db, da := dst.RGBA()
-	dstR := float64(dr>>8) / 255.0
-	dstG := float64(dg>>8) / 255.0
-	dstB := float64(db>>8) / 255.0
-	dstA := float64(da>>8) / 255.0
+	src := color.NRGBAModel.Convert(c).(color.NRGBA)
+	srcR := float64(src.R) / 255.0
+	srcG := float64(src.G) / 255.0
+	srcB := float64(src.B) / 255.0
+	srcA := float64(src.A) / 255.0 * alpha * r.globalAlpha
+
+	// Get destination color (image.RGBA stores premultiplied alpha)
+	dst := r.img.RGBAAt(x, y)
+	dstRp := float64(dst.R) / 255.0
+	dstGp := float64(dst.G) / 255.0
+	dstBp := float64(dst.B) / 255.0
+	dstA := float64(dst.A) / 255.0
 
 	// Premultiply source color
 	srcRp := srcR * srcA
 	srcGp := srcG * srcA
 	srcBp := srcB * srcA
 
-	// Premultiply destination color
-	dstRp := dstR * dstA
-	dstGp := dstG * dstA
-	dstBp := dstB * dstA
-
 	// Porter-Duff "over" operator with premultiplied alpha:
 	// result = src + dst * (1 - srcA)
 	outA := srcA + dstA*(1-srcA)
@@ -391,6 +788,14 @@ func (r *rasterContext) blendPixel(x, y int, c color.Color, alpha float64) {
 	outGp := srcGp + dstGp*(1-srcA)
 	outBp := srcBp + dstBp*(1-srcA)
 
+	// Separable blend modes replace the source color with B(src, dst) where
+	// both are present: a*ad*B(cs, cd) instead of over's a*ad*cs
+	if isSeparableBlendMode(r.operator) && dstA > 0 {
+		outRp += srcA * dstA * (blendChannel(r.operator, srcR, dstRp/dstA) - srcR)
+		outGp += srcA * dstA * (blendChannel(r.operator, srcG, dstGp/dstA) - srcG)
+		outBp += srcA * dstA * (blendChannel(r.operator, srcB, dstBp/dstA) - srcB)
+	}
+
 	// Unpremultiply for RGBA output
 	var outR, outG, outB float64
 	if outA > 0.0001 {
@@ -410,50 +815,32 @@ func (r *rasterContext) blendPixel(x, y int, c color.Color, alpha float64) {
 	r.img.Set(x, y, result)
 }
 
-// pointInTransformedPath checks if a point is inside a transformed path
-func (r *rasterContext) pointInTransformedPath(x, y float64, path []transformedPoint) bool {
-	winding := 0
-	var lastX, lastY float64
-	var startX, startY float64
-	hasStart := false
-
-	for _, pt := range path {
-		switch pt.op {
-		case opMoveTo:
-			lastX, lastY = pt.x, pt.y
-			startX, startY = pt.x, pt.y
-			hasStart = true
-		case opLineTo:
-			if hasStart {
-				if crossesRay(lastX, lastY, pt.x, pt.y, x, y) {
-					if lastY <= y {
-						winding++
-					} else {
-						winding--
-					}
-				}
-			}
-			lastX, lastY = pt.x, pt.y
-		case opCurveTo:
-			if hasStart {
-				// For curves, check crossings along the curve
-				winding += curveCrossings(lastX, lastY, pt.cp1x, pt.cp1y, pt.cp2x, pt.cp2y, pt.x, pt.y, x, y)
-			}
-			lastX, lastY = pt.x, pt.y
-		case opClose:
-			if hasStart {
-				if crossesRay(lastX, lastY, startX, startY, x, y) {
-					if lastY <= y {
-						winding++
-					} else {
-						winding--
-					}
-				}
-			}
+// blendPixelFloat composites c onto a pixel of the float target with the
+// current operator, in float and without clamping. coverage is per color
+// channel as in blendPixelSubpixel; alpha uses the mean coverage.
+func (r *rasterContext) blendPixelFloat(x, y int, c color.Color, coverage [3]float64) {
+	if !(image.Point{x, y}.In(r.fimg.Bounds())) {
+		return
+	}
+	r.addDamage(x, y)
+
+	src := toFloatColor(c)
+	srcA := src.A * r.globalAlpha
+	srcC := [3]float64{src.R, src.G, src.B}
+
+	dst := r.fimg.pixel(x, y)
+	dstA := dst[3]
+
+	meanA := srcA * (coverage[0] + coverage[1] + coverage[2]) / 3
+	out := [4]float64{3: meanA + dstA*(1-meanA)}
+	for ch := 0; ch < 3; ch++ {
+		a := srcA * coverage[ch]
+		out[ch] = srcC[ch]*a + dst[ch]*(1-a)
+		if isSeparableBlendMode(r.operator) && dstA > 0 {
+			out[ch] += a * dstA * (blendChannel(r.operator, srcC[ch], dst[ch]/dstA) - srcC[ch])
 		}
 	}
-
-	return winding != 0
+	r.fimg.setPixel(x, y, out)
 }
 
 // drawLine draws an antialiased line with specified width
@@ -479,20 +866,14 @@ func (r *rasterContext) drawLine(x0, y0, x1, y1 float64, c color.Color) {
 
 	// Calculate bounding box
 	halfWidth := r.width / 2
-	minX := math.Min(x0t, x1t) - halfWidth - 1
-	maxX := math.Max(x0t, x1t) + halfWidth + 1
-	minY := math.Min(y0t, y1t) - halfWidth - 1
-	maxY := math.Max(y0t, y1t) + halfWidth + 1
-
-	bounds := r.img.Bounds()
-	x1i := int(math.Max(minX, float64(bounds.Min.X)))
-	y1i := int(math.Max(minY, float64(bounds.Min.Y)))
-	x2i := int(math.Min(maxX, float64(bounds.Max.X)))
-	y2i := int(math.Min(maxY, float64(bounds.Max.Y)))
+	scan := r.scanBounds(
+		math.Min(x0t, x1t)-halfWidth, math.Min(y0t, y1t)-halfWidth,
+		math.Max(x0t, x1t)+halfWidth, math.Max(y0t, y1t)+halfWidth,
+	)
 
 	// Draw antialiased line using distance field
-	for y := y1i; y < y2i; y++ {
-		for x := x1i; x < x2i; x++ {
+	for y := scan.Min.Y; y < scan.Max.Y; y++ {
+		for x := scan.Min.X; x < scan.Max.X; x++ {
 			// Calculate distance from pixel center to line segment
 			px_center := float64(x) + 0.5
 			py_center := float64(y) + 0.5
@@ -500,7 +881,7 @@ func (r *rasterContext) drawLine(x0, y0, x1, y1 float64, c color.Color) {
 			dist := r.pointToLineSegmentDistance(px_center, py_center, x0t, y0t, x1t, y1t)
 
 			// Calculate coverage based on distance
-			coverage := 1.0 - math.Max(0, math.Min(1, dist-halfWidth+0.5))
+			coverage := r.edgeCoverage(1.0 - math.Max(0, math.Min(1, dist-halfWidth+0.5)))
 
 			if coverage > 0 {
 				r.blendPixel(x, y, c, coverage)
@@ -511,14 +892,10 @@ func (r *rasterContext) drawLine(x0, y0, x1, y1 float64, c color.Color) {
 
 // drawAntialiasedCircle draws an antialiased circle (used for line caps)
 func (r *rasterContext) drawAntialiasedCircle(cx, cy, radius float64, c color.Color) {
-	bounds := r.img.Bounds()
-	x1 := int(math.Max(cx-radius-1, float64(bounds.Min.X)))
-	y1 := int(math.Max(cy-radius-1, float64(bounds.Min.Y)))
-	x2 := int(math.Min(cx+radius+1, float64(bounds.Max.X)))
-	y2 := int(math.Min(cy+radius+1, float64(bounds.Max.Y)))
+	scan := r.scanBounds(cx-radius, cy-radius, cx+radius, cy+radius)
 
-	for y := y1; y < y2; y++ {
-		for x := x1; x < x2; x++ {
+	for y := scan.Min.Y; y < scan.Max.Y; y++ {
+		for x := scan.Min.X; x < scan.Max.X; x++ {
 			px := float64(x) + 0.5
 			py := float64(y) + 0.5
 			dx := px - cx
@@ -526,7 +903,7 @@ func (r *rasterContext) drawAntialiasedCircle(cx, cy, radius float64, c color.Co
 			dist := math.Sqrt(dx*dx + dy*dy)
 
 			// Antialiased edge
-			coverage := 1.0 - math.Max(0, math.Min(1, dist-radius+0.5))
+			coverage := r.edgeCoverage(1.0 - math.Max(0, math.Min(1, dist-radius+0.5)))
 
 			if coverage > 0 {
 				r.blendPixel(x, y, c, coverage)
@@ -590,7 +967,7 @@ func (r *rasterContext) pointInPath(x, y float64) bool {
 		case opCurveTo:
 			if hasStart {
 				// For curves, we need to check crossings along the curve
-				winding += curveCrossings(lastX, lastY, pt.cp1x, pt.cp1y, pt.cp2x, pt.cp2y, pt.x, pt.y, x, y)
+				winding += curveCrossings(lastX, lastY, pt.cp1x, pt.cp1y, pt.cp2x, pt.cp2y, pt.x, pt.y, x, y, r.tolerance)
 			}
 			lastX, lastY = pt.x, pt.y
 		case opClose:
@@ -609,14 +986,23 @@ func (r *rasterContext) pointInPath(x, y float64) bool {
 	return winding != 0
 }
 
-// curveCrossings counts how many times a cubic Bezier curve crosses a horizontal ray
-func curveCrossings(x0, y0, x1, y1, x2, y2, x3, y3, px, py float64) int {
+// curveCrossings counts how many times a cubic Bezier curve, flattened to
+// tolerance, crosses a horizontal ray
+func curveCrossings(x0, y0, x1, y1, x2, y2, x3, y3, px, py, tolerance float64) int {
 	// Subdivide curve and count crossings
-	return curveCrossingsRecursive(x0, y0, x1, y1, x2, y2, x3, y3, px, py, 0)
+	return curveCrossingsRecursive(x0, y0, x1, y1, x2, y2, x3, y3, px, py, tolerance, 0)
 }
 
 // curveCrossingsRecursive recursively subdivides curve to count ray crossings
-func curveCrossingsRecursive(x0, y0, x1, y1, x2, y2, x3, y3, px, py float64, depth int) int {
+func curveCrossingsRecursive(x0, y0, x1, y1, x2, y2, x3, y3, px, py, tolerance float64, depth int) int {
+	// The curve and its flattening lie within the control polygon; skip it
+	// when the ray cannot reach the polygon
+	if math.Max(math.Max(y0, y1), math.Max(y2, y3)) <= py ||
+		math.Min(math.Min(y0, y1), math.Min(y2, y3)) > py ||
+		math.Max(math.Max(x0, x1), math.Max(x2, x3)) <= px {
+		return 0
+	}
+
 	// Limit recursion depth
 	if depth > 12 {
 		if crossesRay(x0, y0, x3, y3, px, py) {
@@ -628,13 +1014,7 @@ func curveCrossingsRecursive(x0, y0, x1, y1, x2, y2, x3, y3, px, py float64, dep
 		return 0
 	}
 
-	// Check if curve is flat enough
-	dx := x3 - x0
-	dy := y3 - y0
-	d2 := math.Abs((x1-x3)*dy - (y1-y3)*dx)
-	d3 := math.Abs((x2-x3)*dy - (y2-y3)*dx)
-
-	if (d2+d3)*(d2+d3) < 0.05*(dx*dx+dy*dy) {
+	if curveFlat(x0, y0, x1, y1, x2, y2, x3, y3, tolerance) {
 		if crossesRay(x0, y0, x3, y3, px, py) {
 			if y0 <= py {
 				return 1
@@ -659,8 +1039,8 @@ func curveCrossingsRecursive(x0, y0, x1, y1, x2, y2, x3, y3, px, py float64, dep
 	y0123 := (y012 + y123) / 2
 
 	// Count crossings in both halves
-	count := curveCrossingsRecursive(x0, y0, x01, y01, x012, y012, x0123, y0123, px, py, depth+1)
-	count += curveCrossingsRecursive(x0123, y0123, x123, y123, x23, y23, x3, y3, px, py, depth+1)
+	count := curveCrossingsRecursive(x0, y0, x01, y01, x012, y012, x0123, y0123, px, py, tolerance, depth+1)
+	count += curveCrossingsRecursive(x0123, y0123, x123, y123, x23, y23, x3, y3, px, py, tolerance, depth+1)
 	return count
 }
 
@@ -684,6 +1064,11 @@ func abs(x int) int {
 
 // Clear fills the image with a color
 func (r *rasterContext) Clear(c color.Color) {
+	r.damage = r.bounds()
+	if r.fimg != nil {
+		draw.Draw(r.fimg, r.fimg.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+		return
+	}
 	draw.Draw(r.img, r.img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
 }
 
@@ -693,17 +1078,13 @@ func (r *rasterContext) getGradientColor(x, y float64) color.Color {
 		return r.color
 	}
 
-	// First, transform from device space to user space using inverse of current matrix
-	invMatrix := r.matrix
-	if MatrixInvert(&invMatrix) != StatusSuccess {
+	// Transform from device space to user space using the inverse of the
+	// current matrix, then to pattern space: according to the Cairo spec the
+	// pattern matrix is the user-to-pattern transformation
+	if !r.inverseOK {
 		return r.color
 	}
-	ux, uy := MatrixTransformPoint(&invMatrix, x, y)
-
-	// Then apply pattern matrix directly (from user space to pattern space)
-	// According to Cairo spec, pattern matrix is user-to-pattern transformation
-	patternMatrix := r.gradientPattern.GetMatrix()
-	px, py := MatrixTransformPoint(patternMatrix, ux, uy)
+	px, py := MatrixTransformPoint(&r.patternMatrix, x, y)
 
 	switch pattern := r.gradientPattern.(type) {
 	case LinearGradientPattern:
@@ -746,8 +1127,10 @@ func (r *rasterContext) getLinearGradientColor(pattern LinearGradientPattern, x,
 	t := ((x-x0)*ndx + (y-y0)*ndy) / length
 
 	// Handle extend modes
-	extend := pattern.GetExtend()
-	t = r.applyExtendMode(t, extend)
+	t, ok := r.applyExtendMode(t, pattern.GetExtend())
+	if !ok {
+		return color.Transparent
+	}
 
 	// Interpolate color from stops
 	return r.interpolateColorStops(pattern, t)
@@ -785,44 +1168,47 @@ func (r *rasterContext) getRadialGradientColor(pattern RadialGradientPattern, x,
 	}
 
 	// Handle extend modes
-	extend := pattern.GetExtend()
-	t = r.applyExtendMode(t, extend)
+	t, ok := r.applyExtendMode(t, pattern.GetExtend())
+	if !ok {
+		return color.Transparent
+	}
 
 	// Interpolate color from stops
 	return r.interpolateColorStops(pattern, t)
 }
 
-// applyExtendMode applies the extend mode to a gradient parameter t
-func (r *rasterContext) applyExtendMode(t float64, extend Extend) float64 {
+// applyExtendMode applies the extend mode to a gradient parameter t. ok is
+// false outside [0, 1] with ExtendNone, where the gradient is transparent.
+func (r *rasterContext) applyExtendMode(t float64, extend Extend) (float64, bool) {
+	if extend == ExtendNone {
+		return t, t >= 0 && t <= 1
+	}
+	return extendPosition(t, 1, extend), true
+}
+
+// extendPosition maps a position v along a pattern of the given size into
+// [0, size] as extend dictates, for gradients and surface patterns alike:
+// ExtendRepeat wraps it, ExtendReflect mirrors every other period and
+// ExtendPad clamps it. ExtendNone leaves it as it is.
+func extendPosition(v, size float64, extend Extend) float64 {
 	switch extend {
-	case ExtendNone, ExtendPad:
-		// Clamp to [0, 1]
-		if t < 0 {
-			return 0
-		}
-		if t > 1 {
-			return 1
-		}
-		return t
+	case ExtendPad:
+		return math.Max(0, math.Min(size, v))
 	case ExtendRepeat:
-		// Repeat: t mod 1
-		t = math.Mod(t, 1.0)
-		if t < 0 {
-			t += 1.0
+		v -= math.Floor(v/size) * size
+		// Rounding can leave a tiny negative v at size instead of 0
+		if v >= size {
+			v = 0
 		}
-		return t
+		return v
 	case ExtendReflect:
-		// Reflect: bounce back and forth
-		t = math.Mod(t, 2.0)
-		if t < 0 {
-			t += 2.0
-		}
-		if t > 1.0 {
-			t = 2.0 - t
+		v = extendPosition(v, 2*size, ExtendRepeat)
+		if v > size {
+			v = 2*size - v
 		}
-		return t
+		return v
 	default:
-		return math.Max(0, math.Min(1, t))
+		return v
 	}
 }
 
@@ -833,16 +1219,25 @@ func (r *rasterContext) interpolateColorStops(pattern GradientPattern, t float64
 		return color.Black
 	}
 
-	if stopCount == 1 {
-		_, r, g, b, a, _ := pattern.GetColorStop(0)
+	// Float targets keep the exact stop colors
+	float := r.fimg != nil
+	stopColor := func(r, g, b, a float64) color.Color {
+		if float {
+			return floatColor{R: r, G: g, B: b, A: a}
+		}
 		return color.NRGBA{
-			R: uint8(r * 255),
-			G: uint8(g * 255),
-			B: uint8(b * 255),
-			A: uint8(a * 255),
+			R: uint8(math.Min(math.Max(r*255, 0), 255)),
+			G: uint8(math.Min(math.Max(g*255, 0), 255)),
+			B: uint8(math.Min(math.Max(b*255, 0), 255)),
+			A: uint8(math.Min(math.Max(a*255, 0), 255)),
 		}
 	}
 
+	if stopCount == 1 {
+		_, r, g, b, a, _ := pattern.GetColorStop(0)
+		return stopColor(r, g, b, a)
+	}
+
 	// Find the two stops to interpolate between
 	var stop1Offset, stop1R, stop1G, stop1B, stop1A float64
 	var stop2Offset, stop2R, stop2G, stop2B, stop2A float64
@@ -852,12 +1247,7 @@ func (r *rasterContext) interpolateColorStops(pattern GradientPattern, t float64
 
 	// If t is before first stop, use first stop color
 	if t <= stop1Offset {
-		return color.NRGBA{
-			R: uint8(stop1R * 255),
-			G: uint8(stop1G * 255),
-			B: uint8(stop1B * 255),
-			A: uint8(stop1A * 255),
-		}
+		return stopColor(stop1R, stop1G, stop1B, stop1A)
 	}
 
 	// Find the stops to interpolate between
@@ -868,12 +1258,7 @@ func (r *rasterContext) interpolateColorStops(pattern GradientPattern, t float64
 			// Interpolate between stop1 and stop2
 			if stop2Offset-stop1Offset < 0.0001 {
 				// Stops are at same position, use second stop
-				return color.NRGBA{
-					R: uint8(stop2R * 255),
-					G: uint8(stop2G * 255),
-					B: uint8(stop2B * 255),
-					A: uint8(stop2A * 255),
-				}
+				return stopColor(stop2R, stop2G, stop2B, stop2A)
 			}
 
 			// Linear interpolation
@@ -883,12 +1268,7 @@ func (r *rasterContext) interpolateColorStops(pattern GradientPattern, t float64
 			b := stop1B + (stop2B-stop1B)*factor
 			a := stop1A + (stop2A-stop1A)*factor
 
-			return color.NRGBA{
-				R: uint8(math.Min(math.Max(r*255, 0), 255)),
-				G: uint8(math.Min(math.Max(g*255, 0), 255)),
-				B: uint8(math.Min(math.Max(b*255, 0), 255)),
-				A: uint8(math.Min(math.Max(a*255, 0), 255)),
-			}
+			return stopColor(r, g, b, a)
 		}
 
 		// Move to next stop
@@ -896,12 +1276,7 @@ func (r *rasterContext) interpolateColorStops(pattern GradientPattern, t float64
 	}
 
 	// If t is after last stop, use last stop color
-	return color.NRGBA{
-		R: uint8(stop2R * 255),
-		G: uint8(stop2G * 255),
-		B: uint8(stop2B * 255),
-		A: uint8(stop2A * 255),
-	}
+	return stopColor(stop2R, stop2G, stop2B, stop2A)
 }
 
 // getSurfacePatternColor gets the color from a surface pattern at the given point
@@ -910,16 +1285,12 @@ func (r *rasterContext) getSurfacePatternColor(x, y float64) color.Color {
 		return r.color
 	}
 
-	// Transform from device space to user space
-	invMatrix := r.matrix
-	if MatrixInvert(&invMatrix) != StatusSuccess {
+	// Transform from device space to user space, then with the pattern
+	// matrix from user space to pattern space
+	if !r.inverseOK {
 		return r.color
 	}
-	ux, uy := MatrixTransformPoint(&invMatrix, x, y)
-
-	// Apply pattern matrix (user space to pattern space)
-	patternMatrix := r.surfacePattern.GetMatrix()
-	px, py := MatrixTransformPoint(patternMatrix, ux, uy)
+	px, py := MatrixTransformPoint(&r.patternMatrix, x, y)
 
 	// Get the surface from the pattern
 	surface := r.surfacePattern.GetSurface()
@@ -939,64 +1310,30 @@ func (r *rasterContext) getSurfacePatternColor(x, y float64) color.Color {
 	}
 
 	bounds := goImg.Bounds()
+	if bounds.Empty() {
+		return color.NRGBA{}
+	}
 
-	// Convert to integer coordinates
-	ix := int(math.Floor(px))
-	iy := int(math.Floor(py))
-
-	// Handle extend modes
+	// Extend the pattern about the center of the pixel px, py falls in, so
+	// mirrored pixels line up with the edge
 	extend := r.surfacePattern.GetExtend()
-	switch extend {
-	case ExtendRepeat:
-		// Wrap coordinates
-		if bounds.Dx() > 0 {
-			ix = ((ix % bounds.Dx()) + bounds.Dx()) % bounds.Dx()
-		}
-		if bounds.Dy() > 0 {
-			iy = ((iy % bounds.Dy()) + bounds.Dy()) % bounds.Dy()
-		}
-	case ExtendReflect:
-		// Mirror coordinates
-		if bounds.Dx() > 0 {
-			period := bounds.Dx() * 2
-			ix = ix % period
-			if ix < 0 {
-				ix += period
-			}
-			if ix >= bounds.Dx() {
-				ix = period - ix - 1
-			}
-		}
-		if bounds.Dy() > 0 {
-			period := bounds.Dy() * 2
-			iy = iy % period
-			if iy < 0 {
-				iy += period
-			}
-			if iy >= bounds.Dy() {
-				iy = period - iy - 1
-			}
-		}
-	case ExtendPad:
-		// Clamp to edges
-		if ix < bounds.Min.X {
-			ix = bounds.Min.X
-		}
-		if ix >= bounds.Max.X {
-			ix = bounds.Max.X - 1
-		}
-		if iy < bounds.Min.Y {
-			iy = bounds.Min.Y
-		}
-		if iy >= bounds.Max.Y {
-			iy = bounds.Max.Y - 1
-		}
-	default: // ExtendNone
-		// Return transparent for out-of-bounds
-		if ix < bounds.Min.X || ix >= bounds.Max.X || iy < bounds.Min.Y || iy >= bounds.Max.Y {
-			return color.NRGBA{R: 0, G: 0, B: 0, A: 0}
+	if extend == ExtendNone {
+		ix, iy := int(math.Floor(px)), int(math.Floor(py))
+		if !(image.Point{ix, iy}).In(bounds) {
+			return color.NRGBA{}
 		}
 	}
+	extendIndex := func(v float64, origin, size int) int {
+		v = extendPosition(math.Floor(v)-float64(origin)+0.5, float64(size), extend)
+		return origin + max(0, min(size-1, int(v)))
+	}
+	ix := extendIndex(px, bounds.Min.X, bounds.Dx())
+	iy := extendIndex(py, bounds.Min.Y, bounds.Dy())
+
+	// Float sources keep full precision when drawn to float targets
+	if fi, ok := goImg.(*floatImage); ok && r.fimg != nil {
+		return fi.colorAt(ix, iy)
+	}
 
 	// Get the color at the calculated position
 	return goImg.At(ix, iy)
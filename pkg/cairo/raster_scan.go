@@ -0,0 +1,320 @@
+package cairo
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// Fills are rasterized scanline by scanline with an active edge table: the
+// path is flattened once into device-space edges sorted by their top, and
+// each row of pixels only intersects the edges spanning it. Antialiased
+// fills get the exact area of each pixel covered by the path, accumulated
+// cell by cell as the edges cross the row, as cairo's and FreeType's
+// rasterizers do. Fills without antialiasing sample each pixel center.
+
+// scanEdge is a non-horizontal line segment of a flattened path.
+type scanEdge struct {
+	// End points in path order; crossings are interpolated from them
+	x0, y0, x1, y1 float64
+
+	// Vertical extent; the edge crosses sample rows in [ymin, ymax)
+	ymin, ymax float64
+
+	// +1 for edges going down, -1 for edges going up
+	dir int
+}
+
+// scanCrossing is where an edge crosses a sample row.
+type scanCrossing struct {
+	x   float64
+	dir int
+}
+
+// scanFiller computes the sample coverage of a flattened path for the
+// pixels of rows visited in increasing order.
+type scanFiller struct {
+	edges     []scanEdge
+	next      int
+	active    []*scanEdge
+	crossings []scanCrossing
+
+	// Samples per pixel axis, or 0 for exact area coverage
+	samples int
+
+	// Whether the even-odd rule decides what is inside rather than nonzero
+	// winding
+	evenOdd bool
+
+	// Signed area accumulated per column for exact coverage, with a last
+	// cell collecting what lies right of the columns
+	acc []float64
+
+	// Columns of the pixels being filled, [x1, x2)
+	x1, x2 int
+}
+
+// newScanFiller flattens a device-space path into the edges of a filler.
+// Every subpath is closed, as cairo closes subpaths when filling.
+func newScanFiller(path []transformedPoint, tolerance float64, samples int, fillRule FillRule, x1, x2 int) *scanFiller {
+	f := &scanFiller{samples: samples, evenOdd: fillRule == FillRuleEvenOdd, x1: x1, x2: x2}
+
+	var lastX, lastY, startX, startY float64
+	hasStart := false
+	for _, pt := range path {
+		switch pt.op {
+		case opMoveTo:
+			if hasStart {
+				f.addEdge(lastX, lastY, startX, startY)
+			}
+			lastX, lastY = pt.x, pt.y
+			startX, startY = pt.x, pt.y
+			hasStart = true
+		case opLineTo:
+			if hasStart {
+				f.addEdge(lastX, lastY, pt.x, pt.y)
+			}
+			lastX, lastY = pt.x, pt.y
+		case opCurveTo:
+			if hasStart {
+				f.addCurve(lastX, lastY, pt.cp1x, pt.cp1y, pt.cp2x, pt.cp2y, pt.x, pt.y, tolerance, 0)
+			}
+			lastX, lastY = pt.x, pt.y
+		case opClose:
+			if hasStart {
+				f.addEdge(lastX, lastY, startX, startY)
+				lastX, lastY = startX, startY
+			}
+		}
+	}
+	if hasStart {
+		f.addEdge(lastX, lastY, startX, startY)
+	}
+
+	slices.SortFunc(f.edges, func(a, b scanEdge) int {
+		return cmp.Compare(a.ymin, b.ymin)
+	})
+	return f
+}
+
+// addEdge adds the segment from (x0, y0) to (x1, y1). Horizontal segments
+// cross no sample row and are dropped.
+func (f *scanFiller) addEdge(x0, y0, x1, y1 float64) {
+	e := scanEdge{x0: x0, y0: y0, x1: x1, y1: y1, ymin: y0, ymax: y1, dir: 1}
+	switch {
+	case y0 == y1:
+		return
+	case y0 > y1:
+		e.ymin, e.ymax, e.dir = y1, y0, -1
+	}
+	f.edges = append(f.edges, e)
+}
+
+// addCurve flattens a cubic Bézier curve to tolerance, subdividing as
+// curveCrossings does.
+func (f *scanFiller) addCurve(x0, y0, x1, y1, x2, y2, x3, y3, tolerance float64, depth int) {
+	if depth > 12 || curveFlat(x0, y0, x1, y1, x2, y2, x3, y3, tolerance) {
+		f.addEdge(x0, y0, x3, y3)
+		return
+	}
+
+	x01 := (x0 + x1) / 2
+	y01 := (y0 + y1) / 2
+	x12 := (x1 + x2) / 2
+	y12 := (y1 + y2) / 2
+	x23 := (x2 + x3) / 2
+	y23 := (y2 + y3) / 2
+	x012 := (x01 + x12) / 2
+	y012 := (y01 + y12) / 2
+	x123 := (x12 + x23) / 2
+	y123 := (y12 + y23) / 2
+	x0123 := (x012 + x123) / 2
+	y0123 := (y012 + y123) / 2
+
+	f.addCurve(x0, y0, x01, y01, x012, y012, x0123, y0123, tolerance, depth+1)
+	f.addCurve(x0123, y0123, x123, y123, x23, y23, x3, y3, tolerance, depth+1)
+}
+
+// advance updates the active edges for pixel row y. Edges are kept for a
+// pixel beyond the row on either side, so samples offset by less than a
+// pixel still find them.
+func (f *scanFiller) advance(y int) {
+	top, bottom := float64(y-1), float64(y+2)
+	kept := f.active[:0]
+	for _, e := range f.active {
+		if e.ymax > top {
+			kept = append(kept, e)
+		}
+	}
+	f.active = kept
+	for f.next < len(f.edges) && f.edges[f.next].ymin < bottom {
+		if e := &f.edges[f.next]; e.ymax > top {
+			f.active = append(f.active, e)
+		}
+		f.next++
+	}
+}
+
+// rowCoverage sets cover, indexed by column from x1, to the coverage of
+// each pixel of row y, 0 to 1. The pixel grid is shifted by (dx, dy)
+// pixels. Rows must be visited in increasing order.
+func (f *scanFiller) rowCoverage(y int, dx, dy float64, cover []float64) {
+	clear(cover)
+	f.advance(y)
+	if len(f.active) == 0 {
+		return
+	}
+	if f.samples > 0 {
+		f.sampleRow(y, dx, dy, cover)
+	} else {
+		f.accumulateRow(y, dx, dy, cover)
+	}
+}
+
+// inside reports whether a winding number is inside under the fill rule.
+func (f *scanFiller) inside(winding int) bool {
+	if f.evenOdd {
+		return winding&1 != 0
+	}
+	return winding != 0
+}
+
+// sampleRow computes coverage as the fraction of a grid of samples inside
+// the path.
+func (f *scanFiller) sampleRow(y int, dx, dy float64, cover []float64) {
+	n := f.samples
+	scale := float64(n)
+	weight := 1 / float64(n*n)
+	kMin, kMax := f.x1*n, f.x2*n
+	for sy := 0; sy < n; sy++ {
+		py := float64(y) + (float64(sy)+0.5)/scale + dy
+
+		f.crossings = f.crossings[:0]
+		for _, e := range f.active {
+			if e.ymin <= py && py < e.ymax {
+				t := (py - e.y0) / (e.y1 - e.y0)
+				f.crossings = append(f.crossings, scanCrossing{x: e.x0 + t*(e.x1-e.x0), dir: e.dir})
+			}
+		}
+		slices.SortFunc(f.crossings, func(a, b scanCrossing) int {
+			return cmp.Compare(a.x, b.x)
+		})
+
+		// A sample is inside when the crossings left of it, at or before
+		// its position, wind around it
+		winding := 0
+		for i := 0; i+1 < len(f.crossings); i++ {
+			winding += f.crossings[i].dir
+			if !f.inside(winding) {
+				continue
+			}
+			kStart := sampleColumn((f.crossings[i].x-dx)*scale, kMin, kMax)
+			kEnd := sampleColumn((f.crossings[i+1].x-dx)*scale, kMin, kMax)
+			for k := kStart; k < kEnd; {
+				col := (k - kMin) / n
+				end := min(kMin+(col+1)*n, kEnd)
+				cover[col] += float64(end-k) * weight
+				k = end
+			}
+		}
+	}
+}
+
+// accumulateRow computes the exact area of each pixel covered by the path.
+// Every edge adds the signed area between it and the right end of the row
+// to the cells it crosses, so the running sum over a row's cells is the
+// winding number of each pixel weighted by area.
+func (f *scanFiller) accumulateRow(y int, dx, dy float64, cover []float64) {
+	w := len(cover)
+	if cap(f.acc) < w+1 {
+		f.acc = make([]float64, w+1)
+	}
+	f.acc = f.acc[:w+1]
+	clear(f.acc)
+
+	top := float64(y) + dy
+	bottom := top + 1
+	left := float64(f.x1) + dx
+	for _, e := range f.active {
+		ya, yb := max(e.ymin, top), min(e.ymax, bottom)
+		if ya >= yb {
+			continue
+		}
+		xa := e.x0 + (ya-e.y0)/(e.y1-e.y0)*(e.x1-e.x0)
+		xb := e.x0 + (yb-e.y0)/(e.y1-e.y0)*(e.x1-e.x0)
+		f.accumulate(xa-left, xb-left, (yb-ya)*float64(e.dir))
+	}
+
+	area := 0.0
+	for i := range cover {
+		area += f.acc[i]
+		a := math.Abs(area)
+		if f.evenOdd {
+			if a = math.Mod(a, 2); a > 1 {
+				a = 2 - a
+			}
+		}
+		cover[i] = min(a, 1)
+	}
+}
+
+// accumulate adds a segment crossing the row from column position xa to
+// xb, with signed height d, to the area cells. Cells left of the row fold
+// into the first one and cells right of it into the spare last one.
+func (f *scanFiller) accumulate(xa, xb, d float64) {
+	w := float64(len(f.acc) - 1)
+	add := func(i, v float64) {
+		f.acc[int(min(max(i, 0), w))] += v
+	}
+
+	x0, x1 := min(xa, xb), max(xa, xb)
+	x0i, x1i := math.Floor(x0), math.Ceil(x1)
+	if x1i <= x0i+1 {
+		// Within a single cell: split by the segment's mean position
+		xm := 0.5*(xa+xb) - x0i
+		add(x0i, d*(1-xm))
+		add(x0i+1, d*xm)
+		return
+	}
+
+	// Across cells: a triangle in the first and last cell, and equal
+	// parallelogram strips in between
+	s := 1 / (x1 - x0)
+	x0f := x0 - x0i
+	a0 := 0.5 * s * (1 - x0f) * (1 - x0f)
+	x1f := x1 - x1i + 1
+	am := 0.5 * s * x1f * x1f
+	add(x0i, d*a0)
+	if x1i == x0i+2 {
+		add(x0i+1, d*(1-a0-am))
+	} else {
+		a1 := s * (1.5 - x0f)
+		add(x0i+1, d*(a1-a0))
+		// Strips left of the row all fold into the first cell; those right
+		// of it are dropped with the spare cell
+		lo, hi := x0i+2, x1i-1
+		if left := min(hi, 0) - lo; left > 0 {
+			add(0, d*s*left)
+			lo = 0
+		}
+		for xi := lo; xi < min(hi, w); xi++ {
+			add(xi, d*s)
+		}
+		a2 := a1 + s*(x1i-x0i-3)
+		add(x1i-1, d*(1-a2-am))
+	}
+	add(x1i, d*am)
+}
+
+// sampleColumn returns the first sample column at or right of x, in units
+// of samples, limited to [lo, hi]. Sample k sits at k+0.5.
+func sampleColumn(x float64, lo, hi int) int {
+	k := math.Ceil(x - 0.5)
+	if k < float64(lo) {
+		return lo
+	}
+	if k > float64(hi) {
+		return hi
+	}
+	return int(k)
+}
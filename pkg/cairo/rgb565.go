@@ -0,0 +1,51 @@
+package cairo
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// FormatRGB16565 surfaces draw into the same opaque RGBA buffer as RGB24
+// surfaces. The 16-bit data returned by GetData is packed from that buffer
+// on demand, quantized with the surface's dither mode, so the drawing
+// pipeline itself is shared with the 32-bit formats.
+
+// packRGB565 quantizes the surface's RGBA buffer into its data buffer as
+// little-endian 5-6-5 words.
+func (s *imageSurface) packRGB565() {
+	if s.format != FormatRGB16565 || s.rgbaImage == nil {
+		return
+	}
+
+	w, h := s.width, s.height
+	src := s.rgbaImage
+	r := ditherPlane(channelPlane(src.Pix, src.Stride, w, h, 0), w, h, 32, s.dither)
+	g := ditherPlane(channelPlane(src.Pix, src.Stride, w, h, 1), w, h, 64, s.dither)
+	b := ditherPlane(channelPlane(src.Pix, src.Stride, w, h, 2), w, h, 32, s.dither)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			binary.LittleEndian.PutUint16(s.data[y*s.stride+x*2:], r[i]<<11|g[i]<<5|b[i])
+		}
+	}
+}
+
+// rgb565Image packs the surface and expands the 5-6-5 words back to an
+// opaque 8-bit image, showing the colors the packed data holds.
+func (s *imageSurface) rgb565Image() *image.RGBA {
+	s.packRGB565()
+
+	img := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			v := binary.LittleEndian.Uint16(s.data[y*s.stride+x*2:])
+			r, g, b := uint8(v>>11), uint8(v>>5&0x3f), uint8(v&0x1f)
+			i := y*img.Stride + x*4
+			img.Pix[i+0] = r<<3 | r>>2
+			img.Pix[i+1] = g<<2 | g>>4
+			img.Pix[i+2] = b<<3 | b>>2
+			img.Pix[i+3] = 0xff
+		}
+	}
+	return img
+}
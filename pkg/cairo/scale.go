@@ -0,0 +1,99 @@
+package cairo
+
+import (
+	"image"
+	"math"
+)
+
+// ScaledTo returns a new surface of the same format holding the surface
+// resampled to width x height. Each destination pixel is the area-weighted
+// average of the source pixels it covers, computed on the premultiplied
+// pixels so transparent areas do not darken their neighbors. Downscaling by
+// an integer factor therefore averages whole n x n blocks, which makes
+// rendering at 2x-4x and scaling down a form of supersampling.
+//
+// The returned surface is in error with StatusInvalidSize for a non-positive
+// size and with StatusInvalidFormat for formats without an RGBA buffer
+// (anything other than ARGB32 and RGB24).
+func (s *imageSurface) ScaledTo(width, height int) ImageSurface {
+	if s.status != StatusSuccess {
+		return newSurfaceInError(s.status).(ImageSurface)
+	}
+	if width <= 0 || height <= 0 {
+		return newSurfaceInError(StatusInvalidSize).(ImageSurface)
+	}
+	if s.rgbaImage == nil {
+		return newSurfaceInError(StatusInvalidFormat).(ImageSurface)
+	}
+
+	dst := NewImageSurface(s.format, width, height).(*imageSurface)
+	if dst.status != StatusSuccess {
+		return dst
+	}
+	resampleArea(dst.rgbaImage, s.rgbaImage)
+	return dst
+}
+
+// areaTap is one source pixel contributing weight to a destination pixel.
+type areaTap struct {
+	index  int
+	weight float64
+}
+
+// areaTaps returns, for each of the dstLen destination pixels, the source
+// pixels it overlaps when srcLen pixels are stretched onto dstLen, weighted by
+// the overlap and normalized to sum to 1.
+func areaTaps(srcLen, dstLen int) [][]areaTap {
+	scale := float64(srcLen) / float64(dstLen)
+	taps := make([][]areaTap, dstLen)
+	for d := range taps {
+		start, end := float64(d)*scale, float64(d+1)*scale
+		for i := int(start); i < srcLen && float64(i) < end; i++ {
+			overlap := math.Min(end, float64(i+1)) - math.Max(start, float64(i))
+			if overlap > 0 {
+				taps[d] = append(taps[d], areaTap{index: i, weight: overlap / scale})
+			}
+		}
+	}
+	return taps
+}
+
+// resampleArea fills dst with src resampled by area averaging, first along
+// rows and then along columns.
+func resampleArea(dst, src *image.RGBA) {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := dst.Rect.Dx(), dst.Rect.Dy()
+	xTaps, yTaps := areaTaps(sw, dw), areaTaps(sh, dh)
+
+	// Horizontal pass: src -> tmp (dw x sh)
+	tmp := make([]float64, dw*sh*4)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[y*src.Stride:]
+		out := tmp[y*dw*4:]
+		for x, taps := range xTaps {
+			for _, t := range taps {
+				for c := 0; c < 4; c++ {
+					out[x*4+c] += float64(row[t.index*4+c]) * t.weight
+				}
+			}
+		}
+	}
+
+	// Vertical pass: tmp -> dst
+	for y, taps := range yTaps {
+		out := dst.Pix[y*dst.Stride:]
+		for x := 0; x < dw; x++ {
+			var sum [4]float64
+			for _, t := range taps {
+				for c := 0; c < 4; c++ {
+					sum[c] += tmp[(t.index*dw+x)*4+c] * t.weight
+				}
+			}
+			a := math.Min(math.Round(sum[3]), 255)
+			for c := 0; c < 3; c++ {
+				out[x*4+c] = uint8(math.Min(math.Round(sum[c]), a))
+			}
+			out[x*4+3] = uint8(a)
+		}
+	}
+}
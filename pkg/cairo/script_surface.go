@@ -0,0 +1,646 @@
+package cairo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// scriptHeader starts every script written by a script surface.
+const scriptHeader = "%!CairoScript"
+
+// scriptSurface records drawing as a script of text lines that
+// ReplayScript plays back onto another context. Each paint, mask, fill and
+// stroke is preceded by the state it uses, written only when it differs
+// from what the script already set, and fills and strokes carry their
+// whole path. Text is drawn as the fills of its glyph outlines, so it is
+// recorded as paths too.
+type scriptSurface struct {
+	baseSurface
+	width, height float64
+	writer        *bufio.Writer
+
+	// last holds the most recent line written for each kind of state
+	last map[string]string
+}
+
+// NewScriptSurface creates a surface of the given content and size whose
+// drawing is written to w as a script, like cairo_script_surface_create.
+// The script starts with a "%!CairoScript" line and a surface line giving
+// the content and size, followed by one line per state change, path
+// segment and drawing operation:
+//
+//	operator 2
+//	source rgba 1 0 0 1
+//	m 10 10
+//	l 90 10
+//	l 50 80
+//	h
+//	fill
+//
+// Numbers are written so they parse back exactly and enums as their values.
+// Output is buffered until Flush or Finish. Mesh and raster source
+// patterns, and surface patterns that don't draw from an image surface,
+// are recorded as transparent sources with a comment.
+func NewScriptSurface(w io.Writer, content Content, width, height float64) Surface {
+	if w == nil {
+		return newSurfaceInError(StatusNullPointer)
+	}
+	if width <= 0 || height <= 0 {
+		return newSurfaceInError(StatusInvalidSize)
+	}
+	switch content {
+	case ContentColor, ContentAlpha, ContentColorAlpha:
+	default:
+		return newSurfaceInError(StatusInvalidContent)
+	}
+
+	surface := &scriptSurface{
+		baseSurface: baseSurface{
+			refCount:            1,
+			status:              StatusSuccess,
+			surfaceType:         SurfaceTypeScript,
+			content:             content,
+			userData:            make(map[*UserDataKey]interface{}),
+			fontOptions:         NewFontOptions(),
+			deviceScaleX:        1.0,
+			deviceScaleY:        1.0,
+			fallbackResolutionX: 72.0,
+			fallbackResolutionY: 72.0,
+		},
+		width:  width,
+		height: height,
+		writer: bufio.NewWriter(w),
+		last:   make(map[string]string),
+	}
+
+	surface.deviceTransform.InitIdentity()
+	surface.deviceTransformInverse.InitIdentity()
+
+	surface.line(scriptHeader)
+	surface.line(fmt.Sprintf("surface %d %s %s", content, formatScriptFloat(width), formatScriptFloat(height)))
+
+	runtime.SetFinalizer(surface, (*scriptSurface).Destroy)
+
+	return surface
+}
+
+func (s *scriptSurface) Reference() Surface {
+	atomic.AddInt32(&s.refCount, 1)
+	return s
+}
+
+func (s *scriptSurface) Destroy() {
+	if atomic.AddInt32(&s.refCount, -1) == 0 {
+		s.Finish()
+		s.cleanup()
+	}
+}
+
+func (s *scriptSurface) GetWidth() float64 {
+	return s.width
+}
+
+func (s *scriptSurface) GetHeight() float64 {
+	return s.height
+}
+
+// Flush writes the buffered script to the writer.
+func (s *scriptSurface) Flush() error {
+	if s.status != StatusSuccess {
+		return newError(s.status, "")
+	}
+	if s.finished {
+		return nil
+	}
+	if err := s.writer.Flush(); err != nil {
+		s.status = StatusWriteError
+		return newError(StatusWriteError, err.Error())
+	}
+	return nil
+}
+
+// Finish flushes the script. Nothing more is written afterwards.
+func (s *scriptSurface) Finish() error {
+	if s.finished {
+		return nil
+	}
+	err := s.Flush()
+	s.baseSurface.Finish()
+	return err
+}
+
+// ShowPage records a page break, replayed with ShowPage.
+func (s *scriptSurface) ShowPage() {
+	s.line("show-page")
+}
+
+// CopyPage records a page copy, replayed with CopyPage.
+func (s *scriptSurface) CopyPage() {
+	s.line("copy-page")
+}
+
+// line writes a line of the script, setting the write error status when
+// the writer fails.
+func (s *scriptSurface) line(text string) {
+	if s.status != StatusSuccess || s.finished {
+		return
+	}
+	if _, err := s.writer.WriteString(text + "\n"); err != nil {
+		s.status = StatusWriteError
+	}
+}
+
+// state writes a state line unless the last one of its kind was the same.
+func (s *scriptSurface) state(kind, text string) {
+	if s.last[kind] == text {
+		return
+	}
+	s.last[kind] = text
+	s.line(text)
+}
+
+// record writes a drawing operation with the state of c it depends on.
+// Fills and strokes are preceded by the current path; a mask operation
+// is followed by its pattern.
+func (s *scriptSurface) record(c *context, op string, mask Pattern) error {
+	if s.finished {
+		return newError(StatusSurfaceFinished, "")
+	}
+	gs := c.gstate
+
+	// Clips go first: replaying them sets the matrix each was made with
+	var clips []*clipRegion
+	for clip := gs.clip; clip != nil; clip = clip.prev {
+		clips = append([]*clipRegion{clip}, clips...)
+	}
+	var key strings.Builder
+	for _, clip := range clips {
+		key.WriteString(scriptMatrix(&clip.matrix))
+		key.WriteString(scriptPath(clip.path))
+		key.WriteString(scriptClip(clip) + "\n")
+	}
+	if s.last["clip"] != key.String() {
+		s.last["clip"] = key.String()
+		s.line("reset-clip")
+		for _, clip := range clips {
+			s.state("matrix", scriptMatrix(&clip.matrix))
+			s.lines(scriptPath(clip.path))
+			s.line(scriptClip(clip))
+		}
+	}
+
+	s.state("operator", fmt.Sprintf("operator %d", gs.operator))
+	s.state("tolerance", "tolerance "+formatScriptFloat(gs.tolerance))
+	s.state("antialias", fmt.Sprintf("antialias %d", gs.antialias))
+	s.state("fill-rule", fmt.Sprintf("fill-rule %d", gs.fillRule))
+	s.state("line-width", "line-width "+formatScriptFloat(gs.lineWidth))
+	s.state("line-cap", fmt.Sprintf("line-cap %d", gs.lineCap))
+	s.state("line-join", fmt.Sprintf("line-join %d", gs.lineJoin))
+	s.state("miter-limit", "miter-limit "+formatScriptFloat(gs.miterLimit))
+	dash := "dash " + formatScriptFloat(gs.dashOffset) + " " + strconv.Itoa(len(gs.dash))
+	for _, d := range gs.dash {
+		dash += " " + formatScriptFloat(d)
+	}
+	s.state("dash", dash)
+	s.state("dash-reset", fmt.Sprintf("dash-reset %t", !gs.dashContinuous))
+	s.state("matrix", scriptMatrix(&gs.matrix))
+	s.state("source", "source "+s.pattern(gs.source))
+
+	switch op {
+	case "fill", "stroke":
+		s.lines(scriptPath(c.path))
+	case "mask":
+		op += " " + s.pattern(mask)
+	}
+	s.line(op)
+	return newError(s.status, "")
+}
+
+// lines writes text, which holds whole lines, to the script.
+func (s *scriptSurface) lines(text string) {
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if line != "" {
+			s.line(line)
+		}
+	}
+}
+
+// pattern returns the script form of a pattern. Patterns that can't be
+// recorded are written as a comment and become transparent.
+func (s *scriptSurface) pattern(p Pattern) string {
+	if p == nil {
+		return "rgba 0 0 0 0"
+	}
+	common := func() string {
+		px, py := p.GetPhase()
+		m := p.GetMatrix()
+		return fmt.Sprintf(" %d %d %s %s %s %s %s %s %s %s",
+			p.GetExtend(), p.GetFilter(),
+			formatScriptFloat(px), formatScriptFloat(py),
+			formatScriptFloat(m.XX), formatScriptFloat(m.YX),
+			formatScriptFloat(m.XY), formatScriptFloat(m.YY),
+			formatScriptFloat(m.X0), formatScriptFloat(m.Y0))
+	}
+	stops := func(g GradientPattern) string {
+		n := g.GetColorStopCount()
+		text := " " + strconv.Itoa(n)
+		for i := 0; i < n; i++ {
+			offset, r, gr, b, a, _ := g.GetColorStop(i)
+			text += " " + formatScriptFloats(offset, r, gr, b, a)
+		}
+		return text
+	}
+
+	switch p := p.(type) {
+	case SolidPattern:
+		return "rgba " + formatScriptFloats(p.GetRGBA())
+	case LinearGradientPattern:
+		x0, y0, x1, y1 := p.GetLinearPoints()
+		return "linear " + formatScriptFloats(x0, y0, x1, y1) + common() + stops(p)
+	case RadialGradientPattern:
+		cx0, cy0, r0, cx1, cy1, r1 := p.GetRadialCircles()
+		return "radial " + formatScriptFloats(cx0, cy0, r0, cx1, cy1, r1) + common() + stops(p)
+	case SurfacePattern:
+		surface := p.GetSurface()
+		defer surface.Destroy()
+		if img, ok := surface.(ImageSurface); ok {
+			var png bytes.Buffer
+			status := img.WriteToPNGStream(func(closure interface{}, data []byte) error {
+				_, err := closure.(*bytes.Buffer).Write(data)
+				return err
+			}, &png)
+			if status == StatusSuccess {
+				return "surface" + common() + " " + base64.StdEncoding.EncodeToString(png.Bytes())
+			}
+		}
+	}
+	s.line(fmt.Sprintf("# pattern type %d is not recorded", p.GetType()))
+	return "rgba 0 0 0 0"
+}
+
+// scriptMatrix returns the matrix line for m.
+func scriptMatrix(m *Matrix) string {
+	return "matrix " + formatScriptFloats(m.XX, m.YX, m.XY, m.YY, m.X0, m.Y0)
+}
+
+// scriptPath returns the lines building p, one per segment.
+func scriptPath(p *path) string {
+	var b strings.Builder
+	if p == nil {
+		return ""
+	}
+	p.forEach(func(op PathDataType, pts []point) {
+		switch op {
+		case PathMoveTo:
+			b.WriteString("m " + formatScriptFloats(pts[0].x, pts[0].y))
+		case PathLineTo:
+			b.WriteString("l " + formatScriptFloats(pts[0].x, pts[0].y))
+		case PathCurveTo:
+			b.WriteString("c " + formatScriptFloats(pts[0].x, pts[0].y, pts[1].x, pts[1].y, pts[2].x, pts[2].y))
+		case PathClosePath:
+			b.WriteString("h")
+		}
+		b.WriteByte('\n')
+	})
+	return b.String()
+}
+
+// scriptClip returns the line intersecting the clip with the path before
+// it, with the clip's own fill rule, tolerance and antialias.
+func scriptClip(clip *clipRegion) string {
+	return fmt.Sprintf("clip %d %s %d", clip.fillRule, formatScriptFloat(clip.tolerance), clip.antialias)
+}
+
+// formatScriptFloat formats v with as few digits as parse back exactly.
+func formatScriptFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// formatScriptFloats formats values separated by spaces.
+func formatScriptFloats(values ...float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatScriptFloat(v)
+	}
+	return strings.Join(parts, " ")
+}
+
+// traceDrawing records a drawing operation, with the mask for a mask
+// operation, when the context draws to a script surface. It reports
+// whether it did.
+func (c *context) traceDrawing(op string, mask Pattern) (bool, error) {
+	s, ok := c.target.(*scriptSurface)
+	if !ok {
+		return false, nil
+	}
+	err := s.record(c, op, mask)
+	if s.status != StatusSuccess {
+		c.setError(s.status)
+	}
+	return true, err
+}
+
+// ReplayScript plays a script written by a script surface back onto
+// target, between a Save and a Restore so that the target's state is left
+// as it was. Unknown lines and malformed arguments stop the replay with
+// StatusInvalidFormat, reporting the line; drawing already replayed stays.
+func ReplayScript(r io.Reader, target Context) error {
+	if r == nil || target == nil {
+		return newError(StatusNullPointer, "")
+	}
+	if status := target.Status(); status != StatusSuccess {
+		return newError(status, "")
+	}
+
+	target.Save()
+	target.NewPath()
+	err := replayScript(bufio.NewReader(r), target)
+	target.Restore()
+	if err != nil {
+		return err
+	}
+	return newError(target.Status(), "")
+}
+
+func replayScript(r *bufio.Reader, target Context) error {
+	for n := 1; ; n++ {
+		line, readErr := r.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return newError(StatusReadError, readErr.Error())
+		}
+		line = strings.TrimSpace(line)
+		if n == 1 && line != scriptHeader {
+			return newError(StatusInvalidFormat, "missing "+scriptHeader+" header")
+		}
+		if n > 1 && line != "" && !strings.HasPrefix(line, "#") {
+			if err := replayLine(line, target); err != nil {
+				return newError(StatusInvalidFormat, fmt.Sprintf("line %d: %v", n, err))
+			}
+			if status := target.Status(); status != StatusSuccess {
+				return newError(status, fmt.Sprintf("line %d", n))
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// scriptArgs reads the arguments of a script line in order, keeping the
+// first error.
+type scriptArgs struct {
+	fields []string
+	err    error
+}
+
+func (a *scriptArgs) next() string {
+	if a.err != nil {
+		return ""
+	}
+	if len(a.fields) == 0 {
+		a.err = fmt.Errorf("missing argument")
+		return ""
+	}
+	f := a.fields[0]
+	a.fields = a.fields[1:]
+	return f
+}
+
+func (a *scriptArgs) float() float64 {
+	f := a.next()
+	if a.err != nil {
+		return 0
+	}
+	v, err := strconv.ParseFloat(f, 64)
+	if err != nil {
+		a.err = err
+	}
+	return v
+}
+
+func (a *scriptArgs) int() int {
+	f := a.next()
+	if a.err != nil {
+		return 0
+	}
+	v, err := strconv.Atoi(f)
+	if err != nil {
+		a.err = err
+	}
+	return v
+}
+
+func (a *scriptArgs) bool() bool {
+	f := a.next()
+	if a.err != nil {
+		return false
+	}
+	v, err := strconv.ParseBool(f)
+	if err != nil {
+		a.err = err
+	}
+	return v
+}
+
+// count reads a count of items that each take size more arguments.
+func (a *scriptArgs) count(size int) int {
+	n := a.int()
+	if a.err == nil && (n < 0 || n*size > len(a.fields)) {
+		a.err = fmt.Errorf("bad count %d", n)
+	}
+	return n
+}
+
+func (a *scriptArgs) matrix() *Matrix {
+	return &Matrix{XX: a.float(), YX: a.float(), XY: a.float(), YY: a.float(), X0: a.float(), Y0: a.float()}
+}
+
+// done reports the first error, or an error when arguments are left over.
+func (a *scriptArgs) done() error {
+	if a.err == nil && len(a.fields) > 0 {
+		a.err = fmt.Errorf("unexpected argument %q", a.fields[0])
+	}
+	return a.err
+}
+
+// replayLine applies one line of a script to target.
+func replayLine(line string, target Context) error {
+	fields := strings.Fields(line)
+	a := &scriptArgs{fields: fields[1:]}
+	switch fields[0] {
+	case "surface":
+		a.int()
+		a.float()
+		a.float()
+	case "operator":
+		target.SetOperator(Operator(a.int()))
+	case "tolerance":
+		target.SetTolerance(a.float())
+	case "antialias":
+		target.SetAntialias(Antialias(a.int()))
+	case "fill-rule":
+		target.SetFillRule(FillRule(a.int()))
+	case "line-width":
+		target.SetLineWidth(a.float())
+	case "line-cap":
+		target.SetLineCap(LineCap(a.int()))
+	case "line-join":
+		target.SetLineJoin(LineJoin(a.int()))
+	case "miter-limit":
+		target.SetMiterLimit(a.float())
+	case "dash":
+		offset := a.float()
+		dashes := make([]float64, a.count(1))
+		for i := range dashes {
+			dashes[i] = a.float()
+		}
+		if a.err == nil {
+			target.SetDash(dashes, offset)
+		}
+	case "dash-reset":
+		target.SetDashResetPerSubpath(a.bool())
+	case "matrix":
+		if m := a.matrix(); a.err == nil {
+			target.SetMatrix(m)
+		}
+	case "source", "mask":
+		pattern := replayPattern(a)
+		if a.err != nil {
+			break
+		}
+		if fields[0] == "source" {
+			target.SetSource(pattern)
+		} else {
+			target.Mask(pattern)
+		}
+		pattern.Destroy()
+	case "m":
+		target.MoveTo(a.float(), a.float())
+	case "l":
+		target.LineTo(a.float(), a.float())
+	case "c":
+		target.CurveTo(a.float(), a.float(), a.float(), a.float(), a.float(), a.float())
+	case "h":
+		target.ClosePath()
+	case "reset-clip":
+		target.ResetClip()
+	case "clip":
+		fillRule, tolerance, antialias := FillRule(a.int()), a.float(), Antialias(a.int())
+		if a.err != nil {
+			break
+		}
+		// The clip's settings don't carry over to drawing
+		oldFillRule, oldTolerance, oldAntialias := target.GetFillRule(), target.GetTolerance(), target.GetAntialias()
+		target.SetFillRule(fillRule)
+		target.SetTolerance(tolerance)
+		target.SetAntialias(antialias)
+		target.Clip()
+		target.SetFillRule(oldFillRule)
+		target.SetTolerance(oldTolerance)
+		target.SetAntialias(oldAntialias)
+	case "fill":
+		if err := a.done(); err != nil {
+			return err
+		}
+		return target.Fill()
+	case "stroke":
+		if err := a.done(); err != nil {
+			return err
+		}
+		return target.Stroke()
+	case "paint":
+		if err := a.done(); err != nil {
+			return err
+		}
+		return target.Paint()
+	case "paint-with-alpha":
+		alpha := a.float()
+		if err := a.done(); err != nil {
+			return err
+		}
+		return target.PaintWithAlpha(alpha)
+	case "show-page":
+		target.ShowPage()
+	case "copy-page":
+		target.CopyPage()
+	default:
+		return fmt.Errorf("unknown operation %q", fields[0])
+	}
+	return a.done()
+}
+
+// replayPattern reads a pattern in the form scriptSurface.pattern writes.
+func replayPattern(a *scriptArgs) Pattern {
+	kind := a.next()
+	var pattern Pattern
+	switch kind {
+	case "rgba":
+		return NewPatternRGBA(a.float(), a.float(), a.float(), a.float())
+	case "linear":
+		pattern = NewPatternLinear(a.float(), a.float(), a.float(), a.float())
+	case "radial":
+		pattern = NewPatternRadial(a.float(), a.float(), a.float(), a.float(), a.float(), a.float())
+	case "surface":
+		// The image follows the arguments common to all patterns
+	default:
+		if a.err == nil {
+			a.err = fmt.Errorf("unknown pattern %q", kind)
+		}
+		return NewPatternRGBA(0, 0, 0, 0)
+	}
+
+	extend, filter := Extend(a.int()), Filter(a.int())
+	px, py := a.float(), a.float()
+	matrix := a.matrix()
+
+	if kind == "surface" {
+		surface := replayImage(a)
+		pattern = NewPatternForSurface(surface)
+		surface.Destroy()
+	} else if g, ok := pattern.(GradientPattern); ok {
+		for i, n := 0, a.count(5); i < n; i++ {
+			g.AddColorStopRGBA(a.float(), a.float(), a.float(), a.float(), a.float())
+		}
+	}
+
+	pattern.SetExtend(extend)
+	pattern.SetFilter(filter)
+	pattern.SetPhase(px, py)
+	if a.err == nil {
+		pattern.SetMatrix(matrix)
+	}
+	return pattern
+}
+
+// replayImage decodes a base64 PNG argument into an image surface.
+func replayImage(a *scriptArgs) Surface {
+	data, err := base64.StdEncoding.DecodeString(a.next())
+	if a.err != nil {
+		return NewImageSurface(FormatARGB32, 1, 1)
+	}
+	if err != nil {
+		a.err = err
+		return NewImageSurface(FormatARGB32, 1, 1)
+	}
+	surface, status := ReadPNGSurface(bytes.NewReader(data))
+	if status != StatusSuccess {
+		a.err = fmt.Errorf("bad image: %v", status)
+		return NewImageSurface(FormatARGB32, 1, 1)
+	}
+	return surface
+}
+
+// rasterBounds returns the pixel area of the surface, which contexts
+// drawing to it size groups by.
+func (s *scriptSurface) rasterBounds() image.Rectangle {
+	return image.Rect(0, 0, int(math.Ceil(s.width)), int(math.Ceil(s.height)))
+}
@@ -0,0 +1,114 @@
+package cairo
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-text/typesetting/di"
+	"github.com/go-text/typesetting/font"
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/math/fixed"
+)
+
+// DefaultShapingCacheLimit is the number of shaped runs kept by default.
+const DefaultShapingCacheLimit = 1024
+
+// shapingCacheKey identifies a shaped run by everything the shaper's
+// result depends on. The face is compared by identity; faces are shared
+// through the font cache.
+type shapingCacheKey struct {
+	text             string
+	runStart, runEnd int
+	face             font.Face
+	size             fixed.Int26_6
+	direction        di.Direction
+	script           language.Script
+	language         language.Language
+	features         string
+}
+
+// shapingCache caches shaper output, so text measured or drawn again, such
+// as labels redrawn every frame, is not shaped again. Like the glyph cache
+// it is cleared when it fills up. It is safe for concurrent use.
+type shapingCache struct {
+	mu      sync.RWMutex
+	limit   int
+	entries map[shapingCacheKey]shaping.Output
+}
+
+var sharedShapingCache = &shapingCache{
+	limit:   DefaultShapingCacheLimit,
+	entries: make(map[shapingCacheKey]shaping.Output),
+}
+
+// SetShapingCacheLimit bounds the number of shaped runs kept for reuse by
+// TextExtents, TextToGlyphs and the other text functions. Runs are shaped
+// again once the cache has been cleared on reaching the limit. A limit of 0
+// or less disables the cache. Lowering the limit clears the cache.
+func SetShapingCacheLimit(limit int) {
+	c := sharedShapingCache
+	c.mu.Lock()
+	if limit < c.limit {
+		c.entries = make(map[shapingCacheKey]shaping.Output)
+	}
+	c.limit = limit
+	c.mu.Unlock()
+}
+
+// ClearShapingCache drops all shaped runs, for instance after a frame
+// whose text will not be drawn again.
+func ClearShapingCache() {
+	c := sharedShapingCache
+	c.mu.Lock()
+	c.entries = make(map[shapingCacheKey]shaping.Output)
+	c.mu.Unlock()
+}
+
+// shapeText shapes input with HarfBuzz, reusing the output of an identical
+// earlier input. The returned glyphs are shared and must not be modified.
+func shapeText(input shaping.Input) shaping.Output {
+	return sharedShapingCache.shape(input)
+}
+
+func (c *shapingCache) shape(input shaping.Input) shaping.Output {
+	key := shapingCacheKey{
+		text:      string(input.Text),
+		runStart:  input.RunStart,
+		runEnd:    input.RunEnd,
+		face:      input.Face,
+		size:      input.Size,
+		direction: input.Direction,
+		script:    input.Script,
+		language:  input.Language,
+	}
+	if len(input.FontFeatures) > 0 {
+		var features strings.Builder
+		for _, f := range input.FontFeatures {
+			features.WriteString(f.Tag.String())
+			features.WriteByte('=')
+			features.WriteString(strconv.FormatUint(uint64(f.Value), 10))
+			features.WriteByte(',')
+		}
+		key.features = features.String()
+	}
+
+	c.mu.RLock()
+	output, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok {
+		return output
+	}
+
+	output = (&shaping.HarfbuzzShaper{}).Shape(input)
+	c.mu.Lock()
+	if c.limit > 0 {
+		if len(c.entries) >= c.limit {
+			c.entries = make(map[shapingCacheKey]shaping.Output)
+		}
+		c.entries[key] = output
+	}
+	c.mu.Unlock()
+	return output
+}
@@ -2,9 +2,14 @@ package cairo
 
 import (
 	"bufio"
+	"encoding/binary"
 	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/png"
+	"io"
+	"math"
 	"os"
 	"runtime" // Added for SetFinalizer
 	"sync"
@@ -35,6 +40,22 @@ type imageSurface struct {
 	rgbaData  []byte
 	rgbaImage *image.RGBA
 	goImage   image.Image
+
+	// parent is the surface a subsurface views into; nil otherwise
+	parent Surface
+
+	// snapshotOf is the surface a snapshot shares its pixels with, until
+	// one of them is modified; nil otherwise
+	snapshotOf *imageSurface
+	// forSource marks a snapshot taken by SetSourceSurface, which later
+	// calls reuse while the pixels are unmodified
+	forSource bool
+
+	// dither selects how pixels are quantized to low-bit formats
+	dither Dither
+
+	// jpegBackground is what JPEG output is flattened onto
+	jpegBackground color.RGBA
 }
 
 // baseSurface provides common surface functionality
@@ -68,7 +89,8 @@ type baseSurface struct {
 	// Surface state
 	finished bool
 
-	// Snapshots
+	// Snapshots sharing the surface's pixels, referenced until the surface
+	// is modified
 	snapshots []Surface
 }
 
@@ -163,6 +185,7 @@ func NewImageSurfaceForData(data []byte, format Format, width, height, stride in
 	surface.deviceTransform.InitIdentity()
 	surface.deviceTransformInverse.InitIdentity()
 	surface.createGoImage()
+	surface.loadData(0, 0, width, height)
 
 	runtime.SetFinalizer(surface, (*imageSurface).Destroy)
 	return surface
@@ -204,6 +227,24 @@ func formatStrideForWidth(format Format, width int) int {
 	}
 }
 
+// formatBitsPerPixel returns the size of one pixel of format in bits
+func formatBitsPerPixel(format Format) int {
+	switch format {
+	case FormatA1:
+		return 1
+	case FormatA8:
+		return 8
+	case FormatRGB16565:
+		return 16
+	case FormatRGB96F:
+		return 96
+	case FormatRGBA128F:
+		return 128
+	default:
+		return 32
+	}
+}
+
 func formatToContent(format Format) Content {
 	switch format {
 	case FormatARGB32, FormatRGBA128F:
@@ -218,57 +259,59 @@ func formatToContent(format Format) Content {
 }
 
 func (s *imageSurface) createGoImage() {
-	if s.format != FormatARGB32 {
+	// Float formats composite directly into their data
+	if fi := newFloatImage(s.data, s.format, s.width, s.height, s.stride); fi != nil {
+		s.goImage = fi
+		return
+	}
+	if s.format != FormatARGB32 && s.format != FormatRGB24 && s.format != FormatRGB16565 {
 		return
 	}
 
-	size := s.stride * s.height
+	// RGB16565 draws into a 32-bit buffer and packs it on demand
+	stride := s.stride
+	if s.format == FormatRGB16565 {
+		stride = s.width * 4
+	}
+
+	size := stride * s.height
 	s.rgbaData = make([]byte, size)
 	s.rgbaImage = &image.RGBA{
 		Pix:    s.rgbaData,
-		Stride: s.stride,
+		Stride: stride,
 		Rect:   image.Rect(0, 0, s.width, s.height),
 	}
 	s.goImage = s.rgbaImage
+
+	// RGB24 and RGB16565 have no alpha channel; their pixels are always opaque
+	if s.format == FormatRGB24 || s.format == FormatRGB16565 {
+		for i := 3; i < size; i += 4 {
+			s.rgbaData[i] = 0xff
+		}
+	}
 }
 
-// syncARGBData synchronizes RGBA data back to ARGB format with premultiplied alpha
-// This is used when the RGBA buffer has been modified and needs to be synced back
+// syncARGBData copies the drawing buffer into the data buffer in cairo's
+// pixel layout: four bytes A, R, G, B per pixel with the color premultiplied
+// by alpha. The drawing buffer is premultiplied already. RGB24 leaves the
+// first byte of each pixel unused, as zero.
 func (s *imageSurface) syncARGBData() {
-	if s.rgbaImage == nil || s.format != FormatARGB32 {
+	if s.rgbaImage == nil || (s.format != FormatARGB32 && s.format != FormatRGB24) {
 		return
 	}
-	stride := s.stride
 	for y := 0; y < s.height; y++ {
-		rgbaOff := y * stride
-		argbOff := y * stride
-		rgbaPtr := s.rgbaData[rgbaOff:]
-		argbPtr := s.data[argbOff:]
+		src := s.rgbaData[y*s.rgbaImage.Stride:]
+		dst := s.data[y*s.stride:]
 		for x := 0; x < s.width; x++ {
 			i := x * 4
-			r := rgbaPtr[i+0]
-			g := rgbaPtr[i+1]
-			b := rgbaPtr[i+2]
-			a := rgbaPtr[i+3]
-			
-			// Convert to premultiplied alpha (Cairo's native format)
-			if a == 0 {
-				argbPtr[i+0] = 0
-				argbPtr[i+1] = 0
-				argbPtr[i+2] = 0
-				argbPtr[i+3] = 0
-			} else if a == 255 {
-				argbPtr[i+0] = a
-				argbPtr[i+1] = r
-				argbPtr[i+2] = g
-				argbPtr[i+3] = b
-			} else {
-				// Premultiply: color = color * alpha / 255
-				argbPtr[i+0] = a
-				argbPtr[i+1] = uint8((uint32(r) * uint32(a)) / 255)
-				argbPtr[i+2] = uint8((uint32(g) * uint32(a)) / 255)
-				argbPtr[i+3] = uint8((uint32(b) * uint32(a)) / 255)
+			a := src[i+3]
+			if s.format == FormatRGB24 {
+				a = 0
 			}
+			dst[i+0] = a
+			dst[i+1] = src[i+0]
+			dst[i+2] = src[i+1]
+			dst[i+3] = src[i+2]
 		}
 	}
 }
@@ -287,6 +330,7 @@ func (s *baseSurface) Destroy() {
 }
 
 func (s *baseSurface) cleanup() {
+	destroyUserData(s.userData)
 	if s.device != nil {
 		s.device.Destroy()
 	}
@@ -317,16 +361,29 @@ func (s *baseSurface) SetUserData(key *UserDataKey, userData unsafe.Pointer, des
 		return s.status
 	}
 
-	s.userData[key] = userData
-	// TODO: Store destroy function and call it when appropriate
+	setUserData(s.userData, key, userData, destroy)
 	return StatusSuccess
 }
 
 func (s *baseSurface) GetUserData(key *UserDataKey) unsafe.Pointer {
-	if data, exists := s.userData[key]; exists {
-		return data.(unsafe.Pointer)
+	return getUserData(s.userData, key)
+}
+
+// SetUserDataAny attaches an arbitrary Go value to the surface under key,
+// replacing data set with either SetUserData or SetUserDataAny. Storing nil
+// removes the key.
+func (s *baseSurface) SetUserDataAny(key *UserDataKey, v any) Status {
+	if s.status != StatusSuccess {
+		return s.status
 	}
-	return nil
+
+	setUserDataAny(s.userData, key, v)
+	return StatusSuccess
+}
+
+// GetUserDataAny returns the value set with SetUserDataAny, or nil.
+func (s *baseSurface) GetUserDataAny(key *UserDataKey) any {
+	return getUserDataAny(s.userData, key)
 }
 
 func (s *baseSurface) Flush() error {
@@ -354,11 +411,8 @@ func (s *baseSurface) Finish() error {
 	}
 	s.finished = true
 
-	// Clean up snapshots
-	for _, snapshot := range s.snapshots {
-		snapshot.Destroy()
-	}
-	s.snapshots = nil
+	// Clean up snapshots; they keep any pixels they share
+	s.releaseSnapshots()
 
 	// Call concrete surface finish
 	return s.finishConcrete()
@@ -386,7 +440,18 @@ func (s *baseSurface) CreateSimilar(content Content, width, height int) Surface
 		return newSurfaceInError(StatusInvalidContent)
 	}
 
-	return NewImageSurface(format, width, height)
+	// width and height are in the source's logical units, as in cairo: the
+	// similar surface inherits the device scale and is sized in pixels
+	// accordingly, so groups on a HiDPI surface keep their resolution.
+	sx, sy := s.deviceScaleX, s.deviceScaleY
+	similar := NewImageSurface(format, int(math.Ceil(float64(width)*sx)), int(math.Ceil(float64(height)*sy)))
+	if similar.Status() == StatusSuccess && (sx != 1 || sy != 1) {
+		similar.SetDeviceScale(sx, sy)
+	}
+	if s.device != nil && similar.Status() == StatusSuccess {
+		similar.(*imageSurface).device = s.device.Reference()
+	}
+	return similar
 }
 
 func (s *baseSurface) CreateSimilarImage(format Format, width, height int) Surface {
@@ -394,17 +459,31 @@ func (s *baseSurface) CreateSimilarImage(format Format, width, height int) Surfa
 }
 
 func (s *baseSurface) CreateForRectangle(x, y, width, height float64) Surface {
-	// TODO: Implement subsurface creation
-	return s.CreateSimilar(s.content, int(width), int(height))
+	// Subsurfaces need direct pixel access, which only image surfaces provide
+	return newSurfaceInError(StatusSurfaceTypeMismatch)
+}
+
+func (s *baseSurface) Snapshot() Surface {
+	// Snapshots share pixels, which only image surfaces have
+	return newSurfaceInError(StatusSurfaceTypeMismatch)
 }
 
 func (s *baseSurface) SetDeviceScale(xScale, yScale float64) {
 	s.deviceScaleX = xScale
 	s.deviceScaleY = yScale
+	s.updateDeviceTransform()
+}
 
-	// Update transform matrices
-	s.deviceTransform.InitScale(xScale, yScale)
-	s.deviceTransformInverse.InitScale(1.0/xScale, 1.0/yScale)
+// updateDeviceTransform recomputes the device transform from the device
+// scale and offset: user coordinates are scaled, then translated by the
+// offset, which is in device units.
+func (s *baseSurface) updateDeviceTransform() {
+	s.deviceTransform = Matrix{
+		XX: s.deviceScaleX, YY: s.deviceScaleY,
+		X0: s.deviceOffsetX, Y0: s.deviceOffsetY,
+	}
+	s.deviceTransformInverse = s.deviceTransform
+	MatrixInvert(&s.deviceTransformInverse)
 }
 
 func (s *baseSurface) GetDeviceScale() (xScale, yScale float64) {
@@ -414,10 +493,7 @@ func (s *baseSurface) GetDeviceScale() (xScale, yScale float64) {
 func (s *baseSurface) SetDeviceOffset(xOffset, yOffset float64) {
 	s.deviceOffsetX = xOffset
 	s.deviceOffsetY = yOffset
-
-	// Update transform matrices
-	s.deviceTransform.InitTranslate(xOffset, yOffset)
-	s.deviceTransformInverse.InitTranslate(-xOffset, -yOffset)
+	s.updateDeviceTransform()
 }
 
 func (s *baseSurface) GetDeviceOffset() (xOffset, yOffset float64) {
@@ -448,19 +524,172 @@ func (s *imageSurface) Reference() Surface {
 	return s
 }
 
-// MarkDirty converts from premultiplied to non-premultiplied alpha
+func (s *imageSurface) Destroy() {
+	if atomic.AddInt32(&s.refCount, -1) == 0 {
+		s.releaseSnapshots()
+		s.cleanup()
+		if s.parent != nil {
+			s.parent.Destroy()
+			s.parent = nil
+		}
+	}
+}
+
+// CreateForRectangle creates a subsurface viewing the (x, y, width, height)
+// region of s. The subsurface shares s's pixel data: drawing into it writes
+// to that region of s and is clipped to it, with the subsurface's origin at
+// (x, y). The rectangle is rounded out to whole pixels and clamped to the
+// bounds of s; if nothing remains the returned surface is in error with
+// StatusInvalidSize.
+func (s *imageSurface) CreateForRectangle(x, y, width, height float64) Surface {
+	if s.status != StatusSuccess {
+		return newSurfaceInError(s.status)
+	}
+	if s.finished {
+		return newSurfaceInError(StatusSurfaceFinished)
+	}
+
+	x0 := max(int(math.Floor(x)), 0)
+	y0 := max(int(math.Floor(y)), 0)
+	x1 := min(int(math.Ceil(x+width)), s.width)
+	y1 := min(int(math.Ceil(y+height)), s.height)
+	if width <= 0 || height <= 0 || x1 <= x0 || y1 <= y0 {
+		return newSurfaceInError(StatusInvalidSize)
+	}
+	w, h := x1-x0, y1-y0
+
+	bpp := formatBitsPerPixel(s.format)
+	if bpp < 8 && x0*bpp%8 != 0 {
+		// Sub-byte formats can only be viewed from a byte boundary
+		return newSurfaceInError(StatusInvalidStride)
+	}
+
+	// The subsurface views the pixels of s for good, so a snapshot still
+	// sharing its original's pixels gets its own first
+	snapshotMu.Lock()
+	released := s.unshare()
+	snapshotMu.Unlock()
+	if released != nil {
+		released.Destroy()
+	}
+
+	start := y0*s.stride + x0*bpp/8
+	end := (y1-1)*s.stride + (x1*bpp+7)/8
+
+	sub := &imageSurface{
+		baseSurface: baseSurface{
+			refCount:            1,
+			status:              StatusSuccess,
+			surfaceType:         SurfaceTypeSubsurface,
+			content:             s.content,
+			userData:            make(map[*UserDataKey]interface{}),
+			fontOptions:         &FontOptions{},
+			deviceScaleX:        1.0,
+			deviceScaleY:        1.0,
+			fallbackResolutionX: s.fallbackResolutionX,
+			fallbackResolutionY: s.fallbackResolutionY,
+		},
+		data:   s.data[start:end:end],
+		width:  w,
+		height: h,
+		stride: s.stride,
+		format: s.format,
+		parent: s.Reference(),
+		dither: s.dither,
+
+		jpegBackground: s.jpegBackground,
+	}
+	if s.device != nil {
+		sub.device = s.device.Reference()
+	}
+	sub.deviceTransform.InitIdentity()
+	sub.deviceTransformInverse.InitIdentity()
+
+	if s.rgbaImage != nil {
+		stride := s.rgbaImage.Stride
+		start, end := y0*stride+x0*4, (y1-1)*stride+x1*4
+		sub.rgbaData = s.rgbaData[start:end:end]
+		sub.rgbaImage = &image.RGBA{
+			Pix:    sub.rgbaData,
+			Stride: stride,
+			Rect:   image.Rect(0, 0, w, h),
+		}
+		sub.goImage = sub.rgbaImage
+	} else if fi, ok := s.goImage.(*floatImage); ok {
+		sub.goImage = &floatImage{
+			pix:      sub.data,
+			stride:   s.stride,
+			rect:     image.Rect(0, 0, w, h),
+			channels: fi.channels,
+		}
+	}
+
+	runtime.SetFinalizer(sub, (*imageSurface).Destroy)
+	return sub
+}
+
+// Backend returns an ImageBackend that aliases the surface's RGBA buffer, the
+// one drawing renders into and WriteToPNG encodes. Filters run through the
+// backend modify the surface in place, so no copying back is needed.
+//
+// Do not call MarkDirty after editing through the backend: MarkDirty reloads
+// the RGBA buffer from the ARGB data returned by GetData, discarding the
+// edits. Backend returns nil for formats without an RGBA buffer (anything
+// other than ARGB32 and RGB24).
+func (s *imageSurface) Backend() *ImageBackend {
+	if s.status != StatusSuccess || s.rgbaImage == nil {
+		return nil
+	}
+	s.detachSnapshots()
+	return &ImageBackend{
+		img:    s.rgbaImage,
+		width:  s.width,
+		height: s.height,
+	}
+}
+
+// Flush completes drawing to the surface: afterwards the data returned by
+// GetData holds everything drawn so far, showing the same pixels as
+// GetGoImage. Drawing renders into the Go image, so Flush must be called
+// before reading raw pixels through GetData. It also gives snapshots of the
+// surface their own copy of its pixels, so the pixels can then be modified
+// directly.
+func (s *imageSurface) Flush() error {
+	if s.status != StatusSuccess {
+		return newError(s.status, "")
+	}
+	s.detachSnapshots()
+	s.syncARGBData()
+	s.packRGB565()
+	return nil
+}
+
+// MarkDirty tells the surface its data was modified directly: the pixels
+// drawing renders into, which GetGoImage returns, are reloaded from the
+// data returned by GetData. To edit pixels through GetData, call Flush, make
+// the edits and then call MarkDirty or MarkDirtyRectangle.
 func (s *imageSurface) MarkDirty() {
-	s.unpremultiplyAlpha()
+	s.MarkDirtyRectangle(0, 0, s.width, s.height)
 }
 
-// MarkDirtyRectangle converts a rectangle from premultiplied to non-premultiplied alpha
+// MarkDirtyRectangle is MarkDirty for the pixels in a rectangle only.
 func (s *imageSurface) MarkDirtyRectangle(x, y, width, height int) {
-	s.unpremultiplyAlphaRect(x, y, width, height)
+	if s.status != StatusSuccess {
+		return
+	}
+	s.detachSnapshots()
+	s.loadData(x, y, width, height)
 }
 
 // Image surface specific methods
 
+// GetData returns the surface's pixel data. For FormatARGB32 and
+// FormatRGB24 each pixel is four bytes A, R, G, B with premultiplied color
+// (the A byte unused for RGB24); call Flush first so the data holds what has
+// been drawn. For FormatRGB16565 the data is packed from the drawing buffer
+// on each call.
 func (s *imageSurface) GetData() []byte {
+	s.packRGB565()
 	return s.data
 }
 
@@ -480,116 +709,111 @@ func (s *imageSurface) GetFormat() Format {
 	return s.format
 }
 
+// GetGoImage returns the image drawing renders into. For FormatARGB32 and
+// FormatRGB24 it is an *image.RGBA holding premultiplied alpha; see
+// UnpremultiplyAlpha for handing its pixels to code expecting straight alpha.
 func (s *imageSurface) GetGoImage() image.Image {
 	return s.goImage
 }
 
-// unpremultiplyAlpha converts the entire surface from premultiplied to non-premultiplied alpha
-func (s *imageSurface) unpremultiplyAlpha() {
-	if s.format != FormatARGB32 {
-		return
-	}
-	s.unpremultiplyAlphaRect(0, 0, s.width, s.height)
-}
-
-// unpremultiplyAlphaRect converts a rectangle from premultiplied to non-premultiplied alpha
-func (s *imageSurface) unpremultiplyAlphaRect(x, y, width, height int) {
-	if s.format != FormatARGB32 || s.rgbaImage == nil {
-		return
-	}
-	
-	// Clamp to surface bounds
-	if x < 0 {
-		width += x
-		x = 0
+// ToGoImage returns a copy of the surface's pixels as an *image.NRGBA with
+// straight alpha, for any format. Unlike the image from GetGoImage it
+// doesn't share memory with the surface, so later drawing doesn't change
+// it. Color formats without alpha are opaque; FormatA8 and FormatA1 give
+// black with the surface's alpha, and FormatRGB30 is rounded to 8 bits per
+// channel. A surface in error gives nil.
+func (s *imageSurface) ToGoImage() image.Image {
+	if s.status != StatusSuccess {
+		return nil
 	}
-	if y < 0 {
-		height += y
-		y = 0
+	if s.goImage != nil {
+		return straightAlphaImage(s)
 	}
-	if x+width > s.width {
-		width = s.width - x
+
+	img := image.NewNRGBA(image.Rect(0, 0, s.width, s.height))
+	for y := 0; y < s.height; y++ {
+		row := s.data[y*s.stride:]
+		pix := img.Pix[y*img.Stride:]
+		for x := 0; x < s.width; x++ {
+			p := pix[x*4 : x*4+4]
+			switch s.format {
+			case FormatA8:
+				p[3] = row[x]
+			case FormatA1:
+				if row[x/8]&(1<<(x%8)) != 0 {
+					p[3] = 0xff
+				}
+			case FormatRGB30:
+				// x2r10g10b10 in a native-endian 32-bit word
+				v := binary.LittleEndian.Uint32(row[x*4:])
+				p[0], p[1], p[2], p[3] = uint8(v>>22), uint8(v>>12), uint8(v>>2), 0xff
+			}
+		}
 	}
-	if y+height > s.height {
-		height = s.height - y
+	return img
+}
+
+// RegionData returns a view, without copying, of the pixels in r together
+// with the row stride. The rectangle is clamped to the surface bounds and
+// (nil, 0) is returned if nothing remains.
+//
+// The slice starts at the region's top-left pixel; row i begins at
+// i*stride and the slice ends right after the last pixel of the region.
+// For FormatARGB32 and FormatRGB24 surfaces the view is of the premultiplied
+// RGBA image that drawing renders into (byte order R, G, B, A); for other
+// formats it is the raw data buffer, and for FormatA1 rows start at the byte
+// holding x.
+// Writes through the slice modify the surface, except for FormatRGB16565,
+// whose data is packed from the drawing buffer on each call.
+func (s *imageSurface) RegionData(r RectangleInt) ([]byte, int) {
+	if s.status != StatusSuccess {
+		return nil, 0
 	}
-	if width <= 0 || height <= 0 {
-		return
+
+	x0, y0 := max(r.X, 0), max(r.Y, 0)
+	x1, y1 := min(r.X+r.Width, s.width), min(r.Y+r.Height, s.height)
+	if x1 <= x0 || y1 <= y0 {
+		return nil, 0
 	}
-	
-	stride := s.stride
-	for row := y; row < y+height; row++ {
-		argbOff := row*stride + x*4
-		rgbaOff := row*stride + x*4
-		argbPtr := s.data[argbOff:]
-		rgbaPtr := s.rgbaData[rgbaOff:]
-		
-		for col := 0; col < width; col++ {
-			i := col * 4
-			a := argbPtr[i+0]
-			r := argbPtr[i+1]
-			g := argbPtr[i+2]
-			b := argbPtr[i+3]
-			
-			// Convert from premultiplied to non-premultiplied alpha
-			if a == 0 {
-				rgbaPtr[i+0] = 0
-				rgbaPtr[i+1] = 0
-				rgbaPtr[i+2] = 0
-				rgbaPtr[i+3] = 0
-			} else if a == 255 {
-				rgbaPtr[i+0] = r
-				rgbaPtr[i+1] = g
-				rgbaPtr[i+2] = b
-				rgbaPtr[i+3] = a
-			} else {
-				// Unpremultiply: color = color * 255 / alpha
-				rgbaPtr[i+0] = uint8((uint32(r) * 255) / uint32(a))
-				rgbaPtr[i+1] = uint8((uint32(g) * 255) / uint32(a))
-				rgbaPtr[i+2] = uint8((uint32(b) * 255) / uint32(a))
-				rgbaPtr[i+3] = a
-			}
-		}
+
+	pix := s.data
+	if s.format == FormatRGB16565 {
+		s.packRGB565()
+	} else if s.rgbaImage != nil {
+		pix = s.rgbaData
 	}
+
+	bpp := formatBitsPerPixel(s.format)
+	start := y0*s.stride + x0*bpp/8
+	end := (y1-1)*s.stride + (x1*bpp+7)/8
+	return pix[start:end:end], s.stride
 }
 
-// premultiplyAlpha converts the entire surface from non-premultiplied to premultiplied alpha
-func (s *imageSurface) premultiplyAlpha() {
-	if s.format != FormatARGB32 || s.rgbaImage == nil {
+// loadData copies the pixels in a rectangle from the data buffer into the
+// drawing buffer, the reverse of syncARGBData and packRGB565. Float formats
+// draw into their data, so they need no copy.
+func (s *imageSurface) loadData(x, y, width, height int) {
+	if s.rgbaImage == nil {
 		return
 	}
-	
-	stride := s.stride
-	for y := 0; y < s.height; y++ {
-		rgbaOff := y * stride
-		argbOff := y * stride
-		rgbaPtr := s.rgbaData[rgbaOff:]
-		argbPtr := s.data[argbOff:]
-		
-		for x := 0; x < s.width; x++ {
-			i := x * 4
-			r := rgbaPtr[i+0]
-			g := rgbaPtr[i+1]
-			b := rgbaPtr[i+2]
-			a := rgbaPtr[i+3]
-			
-			// Convert to premultiplied alpha
-			if a == 0 {
-				argbPtr[i+0] = 0
-				argbPtr[i+1] = 0
-				argbPtr[i+2] = 0
-				argbPtr[i+3] = 0
-			} else if a == 255 {
-				argbPtr[i+0] = a
-				argbPtr[i+1] = r
-				argbPtr[i+2] = g
-				argbPtr[i+3] = b
-			} else {
-				// Premultiply: color = color * alpha / 255
-				argbPtr[i+0] = a
-				argbPtr[i+1] = uint8((uint32(r) * uint32(a)) / 255)
-				argbPtr[i+2] = uint8((uint32(g) * uint32(a)) / 255)
-				argbPtr[i+3] = uint8((uint32(b) * uint32(a)) / 255)
+	x0, y0 := max(x, 0), max(y, 0)
+	x1, y1 := min(x+width, s.width), min(y+height, s.height)
+	for row := y0; row < y1; row++ {
+		src := s.data[row*s.stride:]
+		dst := s.rgbaData[row*s.rgbaImage.Stride:]
+		for col := x0; col < x1; col++ {
+			d := dst[col*4 : col*4+4]
+			switch s.format {
+			case FormatARGB32:
+				p := src[col*4:]
+				d[0], d[1], d[2], d[3] = p[1], p[2], p[3], p[0]
+			case FormatRGB24:
+				p := src[col*4:]
+				d[0], d[1], d[2], d[3] = p[1], p[2], p[3], 0xff
+			case FormatRGB16565:
+				v := binary.LittleEndian.Uint16(src[col*2:])
+				r, g, b := uint8(v>>11), uint8(v>>5&0x3f), uint8(v&0x1f)
+				d[0], d[1], d[2], d[3] = r<<3|r>>2, g<<2|g>>4, b<<3|b>>2, 0xff
 			}
 		}
 	}
@@ -609,16 +833,76 @@ func (s *imageSurface) WriteToPNG(filename string) Status {
 	if err != nil {
 		return StatusWriteError
 	}
-	defer file.Close()
 
-	err = png.Encode(file, s.goImage)
-	if err != nil {
+	status := s.WriteToPNGStream(func(closure interface{}, data []byte) error {
+		_, err := closure.(*os.File).Write(data)
+		return err
+	}, file)
+	if err := file.Close(); err != nil && status == StatusSuccess {
+		status = StatusWriteError
+	}
+	return status
+}
+
+// WriteToPNGStream encodes the surface as PNG and passes the bytes to write,
+// like cairo_surface_write_to_png_stream. closure is handed to every call.
+// PNG stores straight alpha; the encoder unpremultiplies the surface's
+// premultiplied pixels.
+func (s *imageSurface) WriteToPNGStream(write WriteFunc, closure interface{}) Status {
+	if s.status != StatusSuccess {
+		return s.status
+	}
+
+	if s.goImage == nil {
+		return StatusSurfaceTypeMismatch
+	}
+
+	if err := png.Encode(&funcWriter{write, closure}, s.pngImage()); err != nil {
 		return StatusWriteError
 	}
 
 	return StatusSuccess
 }
 
+// pngImage returns the surface's pixels as an image the PNG encoder takes.
+func (s *imageSurface) pngImage() image.Image {
+	img := s.goImage
+	if fi, ok := img.(*floatImage); ok {
+		// Float channels are clamped to [0, 1] and rounded to 8 bits
+		img = fi.rgba8()
+	} else if s.format == FormatRGB16565 {
+		img = s.rgb565Image()
+	}
+	return img
+}
+
+// funcWriter adapts a WriteFunc to io.Writer.
+type funcWriter struct {
+	write   WriteFunc
+	closure interface{}
+}
+
+func (w *funcWriter) Write(p []byte) (int, error) {
+	if err := w.write(w.closure, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// funcReader adapts a ReadFunc to io.Reader. As in cairo, read must fill
+// the whole buffer or fail; a failure is reported as the end of the data.
+type funcReader struct {
+	read    ReadFunc
+	closure interface{}
+}
+
+func (r *funcReader) Read(p []byte) (int, error) {
+	if err := r.read(r.closure, p); err != nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return len(p), nil
+}
+
 // Format utilities
 
 func FormatStrideForWidth(format Format, width int) int {
@@ -633,25 +917,139 @@ func LoadPNGSurface(filename string) (Surface, error) {
 	}
 	defer file.Close()
 
-	img, err := png.Decode(file)
+	surface, status := ReadPNGSurface(file)
+	if status != StatusSuccess {
+		return surface, newError(status, "failed to decode PNG "+filename)
+	}
+	return surface, nil
+}
+
+// ReadPNGSurface decodes a PNG image from r into a new image surface, like
+// cairo_image_surface_create_from_png_stream. PNGs without an alpha channel
+// produce FormatRGB24 surfaces, all others FormatARGB32 with premultiplied
+// alpha. On failure the returned surface is in error with the same status.
+func ReadPNGSurface(r io.Reader) (Surface, Status) {
+	img, err := png.Decode(r)
 	if err != nil {
-		return newSurfaceInError(StatusReadError), err
+		return newSurfaceInError(StatusReadError), StatusReadError
+	}
+
+	format := FormatARGB32
+	if isOpaqueModel(img) {
+		format = FormatRGB24
+	}
+
+	bounds := img.Bounds()
+	surface := NewImageSurface(format, bounds.Dx(), bounds.Dy())
+	if status := surface.Status(); status != StatusSuccess {
+		return surface, status
 	}
+	importPremultiplied(surface.(*imageSurface).rgbaImage, img)
+
+	return surface, StatusSuccess
+}
 
+// ReadPNGSurfaceStream decodes a PNG image supplied by read, like
+// cairo_image_surface_create_from_png_stream. closure is handed to every
+// call. See ReadPNGSurface for the resulting format and error reporting.
+func ReadPNGSurfaceStream(read ReadFunc, closure interface{}) (Surface, Status) {
+	return ReadPNGSurface(&funcReader{read, closure})
+}
+
+// isOpaqueModel reports whether img's color model cannot carry alpha, which
+// is how the PNG decoder represents color types without an alpha channel.
+func isOpaqueModel(img image.Image) bool {
+	switch img := img.(type) {
+	case *image.RGBA, *image.RGBA64, *image.Gray, *image.Gray16:
+		return true
+	case *image.Paletted:
+		for _, c := range img.Palette {
+			if _, _, _, a := c.RGBA(); a != 0xffff {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// importPremultiplied copies a decoded image into the surface's premultiplied
+// RGBA buffer. PNG stores straight alpha, which the decoder returns as NRGBA;
+// those pixels are premultiplied here. Opaque RGBA rows are copied as is.
+// Other color models are converted by image/draw, which also yields
+// premultiplied values.
+func importPremultiplied(dst *image.RGBA, img image.Image) {
 	bounds := img.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
+	rowBytes := bounds.Dx() * 4
+
+	switch src := img.(type) {
+	case *image.RGBA:
+		for y := 0; y < bounds.Dy(); y++ {
+			s := src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y+y):]
+			copy(dst.Pix[y*dst.Stride:y*dst.Stride+rowBytes], s[:rowBytes])
+		}
+	case *image.NRGBA:
+		for y := 0; y < bounds.Dy(); y++ {
+			s := src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y+y):]
+			copy(dst.Pix[y*dst.Stride:y*dst.Stride+rowBytes], s[:rowBytes])
+		}
+		PremultiplyAlpha(dst.SubImage(image.Rect(0, 0, bounds.Dx(), bounds.Dy())).(*image.RGBA))
+	default:
+		draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	}
+}
 
-	surface := NewImageSurface(FormatARGB32, width, height).(*imageSurface)
+// diffHighlight is the color DiffSurfaces paints over differing pixels.
+var diffHighlight = color.RGBA{R: 255, A: 255}
+
+// DiffSurfaces compares two image surfaces of the same size pixel by pixel
+// and returns a new opaque ARGB32 surface visualizing the result: pixels that
+// differ are painted pure red, matching pixels show a's content composited
+// over white and faded to a quarter of its contrast. It returns an error
+// surface if either input is not an image surface or the sizes differ.
+func DiffSurfaces(a, b Surface) Surface {
+	imgA, okA := a.(ImageSurface)
+	imgB, okB := b.(ImageSurface)
+	if !okA || !okB {
+		return newSurfaceInError(StatusSurfaceTypeMismatch)
+	}
+	if imgA.GetWidth() != imgB.GetWidth() || imgA.GetHeight() != imgB.GetHeight() {
+		return newSurfaceInError(StatusInvalidSize)
+	}
+	srcA, okA := imgA.GetGoImage().(*image.RGBA)
+	srcB, okB := imgB.GetGoImage().(*image.RGBA)
+	if !okA || !okB {
+		return newSurfaceInError(StatusSurfaceTypeMismatch)
+	}
 
-	// Copy image data to RGBA buffer
+	width, height := imgA.GetWidth(), imgA.GetHeight()
+	diff := NewImageSurface(FormatARGB32, width, height).(*imageSurface)
+	dst := diff.rgbaImage
 	for y := 0; y < height; y++ {
+		rowA := srcA.Pix[y*srcA.Stride : y*srcA.Stride+width*4]
+		rowB := srcB.Pix[y*srcB.Stride : y*srcB.Stride+width*4]
 		for x := 0; x < width; x++ {
-			surface.rgbaImage.Set(x, y, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+			i := x * 4
+			if rowA[i] != rowB[i] || rowA[i+1] != rowB[i+1] ||
+				rowA[i+2] != rowB[i+2] || rowA[i+3] != rowB[i+3] {
+				dst.SetRGBA(x, y, diffHighlight)
+				continue
+			}
+			// Premultiplied pixel over white, then fade toward white
+			transparent := 255 - uint32(rowA[i+3])
+			fade := func(v uint8) uint8 {
+				over := uint32(v) + transparent
+				return uint8(255 - (255-over)/4)
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: fade(rowA[i]),
+				G: fade(rowA[i+1]),
+				B: fade(rowA[i+2]),
+				A: 255,
+			})
 		}
 	}
-
-	return surface, nil
+	return diff
 }
 
 // Surface-specific interfaces for type assertions
@@ -664,7 +1062,27 @@ type ImageSurface interface {
 	GetStride() int
 	GetFormat() Format
 	GetGoImage() image.Image
+	ToGoImage() image.Image
 	WriteToPNG(filename string) Status
+	WriteToPNGStream(write WriteFunc, closure interface{}) Status
+	WriteToGIF(filename string) Status
+	WriteToGIFStream(write WriteFunc, closure interface{}) Status
+	WriteToJPEG(filename string, quality int) Status
+	WriteToJPEGStream(write WriteFunc, closure interface{}, quality int) Status
+	SetJPEGBackground(red, green, blue float64)
+	GetJPEGBackground() (red, green, blue float64)
+	WriteToBMP(filename string) Status
+	WriteToBMPStream(write WriteFunc, closure interface{}) Status
+	WriteToTGA(filename string) Status
+	WriteToTGAStream(write WriteFunc, closure interface{}) Status
+	BlurGaussian(radius float64)
+	BlurBox(radius int)
+	ScaledTo(width, height int) ImageSurface
+	SetDither(dither Dither)
+	GetDither() Dither
+	ConvertToFormat(format Format) ImageSurface
+	Backend() *ImageBackend
+	RegionData(r RectangleInt) ([]byte, int)
 }
 
 // pdfSurface implements PDF output surface
@@ -672,6 +1090,11 @@ type pdfSurface struct {
 	baseSurface
 	filename      string
 	width, height float64
+
+	// page is the raster contexts draw the current page into; pages holds
+	// the pages emitted so far by ShowPage and CopyPage
+	page  *image.RGBA
+	pages []*image.RGBA
 }
 
 // svgSurface implements SVG output surface
@@ -692,17 +1115,12 @@ type psSurface struct {
 	writer        *bufio.Writer
 }
 
-// scriptSurface implements Script surface (JSON serialization)
-type scriptSurface struct {
-	baseSurface
-	filename      string
-	width, height float64
-	file          *os.File
-	commands      []map[string]interface{}
-}
-
 // NewPDFSurface creates a new PDF surface
 func NewPDFSurface(filename string, widthInPoints, heightInPoints float64) Surface {
+	if widthInPoints <= 0 || heightInPoints <= 0 {
+		return newSurfaceInError(StatusInvalidSize)
+	}
+
 	surface := &pdfSurface{
 		baseSurface: baseSurface{
 			refCount:            1,
@@ -711,12 +1129,15 @@ func NewPDFSurface(filename string, widthInPoints, heightInPoints float64) Surfa
 			content:             ContentColorAlpha,
 			userData:            make(map[*UserDataKey]interface{}),
 			fontOptions:         &FontOptions{},
+			deviceScaleX:        1.0,
+			deviceScaleY:        1.0,
 			fallbackResolutionX: 72.0,
 			fallbackResolutionY: 72.0,
 		},
 		filename: filename,
 		width:    widthInPoints,
 		height:   heightInPoints,
+		page:     image.NewRGBA(image.Rect(0, 0, int(widthInPoints), int(heightInPoints))),
 	}
 	surface.deviceTransform.InitIdentity()
 	surface.deviceTransformInverse.InitIdentity()
@@ -733,6 +1154,8 @@ func NewSVGSurface(filename string, widthInPoints, heightInPoints float64) Surfa
 			content:             ContentColorAlpha,
 			userData:            make(map[*UserDataKey]interface{}),
 			fontOptions:         &FontOptions{},
+			deviceScaleX:        1.0,
+			deviceScaleY:        1.0,
 			fallbackResolutionX: 72.0,
 			fallbackResolutionY: 72.0,
 		},
@@ -912,66 +1335,3 @@ func (s *psSurface) finishConcrete() error {
 	}
 	return nil
 }
-
-// NewScriptSurface creates a new Script surface for JSON serialization
-func NewScriptSurface(filename string, width, height float64) Surface {
-	if width <= 0 || height <= 0 {
-		return newSurfaceInError(StatusInvalidSize)
-	}
-
-	file, err := os.Create(filename)
-	if err != nil {
-		return newSurfaceInError(StatusWriteError)
-	}
-
-	surface := &scriptSurface{
-		baseSurface: baseSurface{
-			refCount:            1,
-			status:              StatusSuccess,
-			surfaceType:         SurfaceTypeScript,
-			content:             ContentColorAlpha,
-			userData:            make(map[*UserDataKey]interface{}),
-			fontOptions:         NewFontOptions(),
-			deviceScaleX:        1.0,
-			deviceScaleY:        1.0,
-			fallbackResolutionX: 72.0,
-			fallbackResolutionY: 72.0,
-		},
-		filename: filename,
-		width:    width,
-		height:   height,
-		file:     file,
-		commands: make([]map[string]interface{}, 0),
-	}
-
-	surface.deviceTransform.InitIdentity()
-	surface.deviceTransformInverse.InitIdentity()
-
-	runtime.SetFinalizer(surface, (*scriptSurface).Destroy)
-
-	return surface
-}
-
-func (s *scriptSurface) Reference() Surface {
-	atomic.AddInt32(&s.refCount, 1)
-	return s
-}
-
-func (s *scriptSurface) Destroy() {
-	if atomic.AddInt32(&s.refCount, -1) == 0 {
-		s.finishConcrete()
-		s.cleanup()
-	}
-}
-
-func (s *scriptSurface) GetWidth() float64 {
-	return s.width
-}
-
-func (s *scriptSurface) GetHeight() float64 {
-	return s.height
-}
-
-func (s *scriptSurface) AddCommand(cmd map[string]interface{}) {
-	s.commands = append(s.commands, cmd)
-}
@@ -0,0 +1,211 @@
+package cairo
+
+import (
+	"bytes"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// snapshotMu guards the snapshot bookkeeping of image surfaces, the
+// snapshots lists and snapshotOf links, which contexts on other goroutines
+// and finalizers reach through the surfaces sharing pixels.
+var snapshotMu sync.Mutex
+
+// Snapshot returns an image surface holding the current contents of s. The
+// snapshot shares s's pixels until either surface is drawn to, at which
+// point the snapshot gets a copy of its own, as with cairo's surface
+// snapshots. Drawing to s through a context, Backend, MarkDirty or
+// MarkDirtyRectangle leaves the snapshot unchanged; call Flush before
+// modifying the pixels of s directly through GetData, RegionData or
+// GetGoImage.
+//
+// A snapshot of a subsurface covers only the subsurface's region.
+func (s *imageSurface) Snapshot() Surface {
+	if s.status != StatusSuccess {
+		return newSurfaceInError(s.status)
+	}
+	if s.finished {
+		return newSurfaceInError(StatusSurfaceFinished)
+	}
+
+	if s.format == FormatRGB16565 {
+		s.packRGB565()
+	}
+
+	snap := &imageSurface{
+		baseSurface: baseSurface{
+			refCount:               1,
+			status:                 StatusSuccess,
+			surfaceType:            SurfaceTypeImage,
+			content:                s.content,
+			userData:               make(map[*UserDataKey]interface{}),
+			fontOptions:            &FontOptions{},
+			deviceTransform:        s.deviceTransform,
+			deviceTransformInverse: s.deviceTransformInverse,
+			deviceOffsetX:          s.deviceOffsetX,
+			deviceOffsetY:          s.deviceOffsetY,
+			deviceScaleX:           s.deviceScaleX,
+			deviceScaleY:           s.deviceScaleY,
+			fallbackResolutionX:    s.fallbackResolutionX,
+			fallbackResolutionY:    s.fallbackResolutionY,
+		},
+		data:      s.data,
+		width:     s.width,
+		height:    s.height,
+		stride:    s.stride,
+		format:    s.format,
+		rgbaData:  s.rgbaData,
+		rgbaImage: s.rgbaImage,
+		goImage:   s.goImage,
+		dither:    s.dither,
+	}
+	// The surface owning the pixels keeps a reference to the snapshot until
+	// it is about to be modified
+	snapshotMu.Lock()
+	owner := s.snapshotOwner()
+	snap.snapshotOf = owner
+	owner.snapshots = append(owner.snapshots, snap.Reference())
+	snapshotMu.Unlock()
+
+	runtime.SetFinalizer(snap, (*imageSurface).Destroy)
+	return snap
+}
+
+// sourceSnapshot returns a snapshot of s for use as a source. A snapshot
+// taken for an earlier source that still shares the pixels of s is reused,
+// so a drawing loop setting the same surface as the source again and again
+// does not pile up snapshots until the next write.
+func (s *imageSurface) sourceSnapshot() Surface {
+	snapshotMu.Lock()
+	for _, snap := range s.snapshotOwner().snapshots {
+		if img, ok := snap.(*imageSurface); ok && img.forSource && img.sharesView(s) {
+			snapshotMu.Unlock()
+			return img.Reference()
+		}
+	}
+	snapshotMu.Unlock()
+	snap := s.Snapshot()
+	if img, ok := snap.(*imageSurface); ok {
+		img.forSource = true
+	}
+	return snap
+}
+
+// sharesView reports whether the snapshot snap shows the same pixels as s,
+// with the same layout and device transformation.
+func (snap *imageSurface) sharesView(s *imageSurface) bool {
+	return snap.status == StatusSuccess && !snap.finished &&
+		snap.goImage == s.goImage && len(snap.data) == len(s.data) &&
+		(len(s.data) == 0 || &snap.data[0] == &s.data[0]) &&
+		snap.width == s.width && snap.height == s.height && snap.stride == s.stride &&
+		snap.format == s.format && snap.content == s.content && snap.dither == s.dither &&
+		snap.deviceTransform == s.deviceTransform &&
+		snap.deviceOffsetX == s.deviceOffsetX && snap.deviceOffsetY == s.deviceOffsetY &&
+		snap.deviceScaleX == s.deviceScaleX && snap.deviceScaleY == s.deviceScaleY &&
+		snap.fallbackResolutionX == s.fallbackResolutionX && snap.fallbackResolutionY == s.fallbackResolutionY
+}
+
+// snapshotOwner returns the surface whose pixels s shares: the root of a
+// subsurface, or the original of a snapshot that still shares its pixels.
+// The caller holds snapshotMu.
+func (s *imageSurface) snapshotOwner() *imageSurface {
+	owner := s
+	for {
+		if owner.snapshotOf != nil {
+			owner = owner.snapshotOf
+		} else if parent, ok := owner.parent.(*imageSurface); ok {
+			owner = parent
+		} else {
+			return owner
+		}
+	}
+}
+
+// detachSnapshots gives every snapshot sharing the pixels of s a copy of
+// its own. It is called before the pixels of s are modified.
+func (s *imageSurface) detachSnapshots() {
+	owner := s
+	for {
+		parent, ok := owner.parent.(*imageSurface)
+		if !ok {
+			break
+		}
+		owner = parent
+	}
+
+	snapshotMu.Lock()
+	// A snapshot being drawn to stops sharing its original's pixels
+	released := owner.unshare()
+	snapshots := owner.snapshots
+	owner.snapshots = nil
+	for _, snap := range snapshots {
+		if img, ok := snap.(*imageSurface); ok {
+			img.copyPixels()
+			img.snapshotOf = nil
+		}
+	}
+	snapshotMu.Unlock()
+
+	if released != nil {
+		released.Destroy()
+	}
+	for _, snap := range snapshots {
+		snap.Destroy()
+	}
+}
+
+// unshare gives the snapshot s a copy of the pixels it shares with its
+// original, if any. It returns the original's reference to s, for the
+// caller to destroy once snapshotMu is unlocked, or nil. The caller holds
+// snapshotMu.
+func (s *imageSurface) unshare() Surface {
+	orig := s.snapshotOf
+	if orig == nil {
+		return nil
+	}
+	s.copyPixels()
+	s.snapshotOf = nil
+	for i, snap := range orig.snapshots {
+		if snap == Surface(s) {
+			orig.snapshots = slices.Delete(orig.snapshots, i, i+1)
+			return snap
+		}
+	}
+	return nil
+}
+
+// releaseSnapshots lets go of the snapshots of a surface being finished or
+// destroyed. They keep the pixels, which are no longer modified.
+func (s *baseSurface) releaseSnapshots() {
+	snapshotMu.Lock()
+	snapshots := s.snapshots
+	s.snapshots = nil
+	for _, snap := range snapshots {
+		if img, ok := snap.(*imageSurface); ok {
+			img.snapshotOf = nil
+		}
+	}
+	snapshotMu.Unlock()
+
+	for _, snap := range snapshots {
+		snap.Destroy()
+	}
+}
+
+// copyPixels replaces the pixel buffers of s with copies, keeping their
+// layout.
+func (s *imageSurface) copyPixels() {
+	s.data = bytes.Clone(s.data)
+	if s.rgbaImage != nil {
+		s.rgbaData = bytes.Clone(s.rgbaData)
+		img := *s.rgbaImage
+		img.Pix = s.rgbaData
+		s.rgbaImage = &img
+		s.goImage = s.rgbaImage
+	} else if fi, ok := s.goImage.(*floatImage); ok {
+		img := *fi
+		img.pix = s.data
+		s.goImage = &img
+	}
+}
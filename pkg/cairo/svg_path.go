@@ -0,0 +1,302 @@
+package cairo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// AppendSVGPath appends the path described by SVG path data d, the syntax
+// of the d attribute of an SVG path element, to the current path. All of
+// the commands are supported in absolute and relative form: moves, lines,
+// horizontal and vertical lines, cubic and quadratic Bézier curves with
+// their smooth variants, elliptical arcs and closepath. Quadratic curves
+// are converted to cubic ones and arcs to cubic curves within the
+// tolerance, as Arc does.
+//
+// Relative coordinates start from the current point, or from the origin
+// when there is none. As SVG renderers do, a malformed command stops the
+// parsing: the commands before it are appended and an error with
+// StatusInvalidPathData describing the problem is returned.
+func (c *context) AppendSVGPath(d string) error {
+	if c.status != StatusSuccess {
+		return newError(c.status, "")
+	}
+
+	p := &svgPathParser{d: d}
+	var cur, start point
+	if c.currentPoint.hasPoint {
+		cur = point{c.currentPoint.x, c.currentPoint.y}
+		start = point{c.path.subpathStartX, c.path.subpathStartY}
+	}
+	// Control points of the previous cubic and quadratic curve, reflected
+	// by the smooth curve commands
+	var lastCubic, lastQuad *point
+
+	var cmd byte
+	for {
+		p.skipSeparators()
+		if p.pos >= len(p.d) {
+			return nil
+		}
+		if next := p.d[p.pos]; isSVGCommand(next) {
+			cmd = next
+			p.pos++
+		} else if cmd == 0 || cmd == 'z' || cmd == 'Z' {
+			return p.errorf("expected a command")
+		}
+		// Otherwise the previous command repeats with new arguments
+
+		relative := cmd >= 'a'
+		abs := func(x, y float64) point {
+			if relative {
+				return point{cur.x + x, cur.y + y}
+			}
+			return point{x, y}
+		}
+
+		var args [7]float64
+		n := svgCommandArgs(cmd)
+		for i := 0; i < n; i++ {
+			var ok bool
+			if (cmd == 'a' || cmd == 'A') && (i == 3 || i == 4) {
+				var flag bool
+				if flag, ok = p.flag(); flag {
+					args[i] = 1
+				}
+			} else {
+				args[i], ok = p.number()
+			}
+			if !ok {
+				return p.errorf("expected %d numbers after %q", n, cmd)
+			}
+		}
+
+		var cubic, quad *point
+		switch cmd {
+		case 'M', 'm':
+			cur = abs(args[0], args[1])
+			start = cur
+			c.MoveTo(cur.x, cur.y)
+			// Further coordinate pairs are lines
+			if relative {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+		case 'L', 'l':
+			cur = abs(args[0], args[1])
+			c.LineTo(cur.x, cur.y)
+		case 'H':
+			cur.x = args[0]
+			c.LineTo(cur.x, cur.y)
+		case 'h':
+			cur.x += args[0]
+			c.LineTo(cur.x, cur.y)
+		case 'V':
+			cur.y = args[0]
+			c.LineTo(cur.x, cur.y)
+		case 'v':
+			cur.y += args[0]
+			c.LineTo(cur.x, cur.y)
+		case 'C', 'c', 'S', 's':
+			var p1 point
+			if cmd == 'C' || cmd == 'c' {
+				p1 = abs(args[0], args[1])
+				args[0], args[1], args[2], args[3] = args[2], args[3], args[4], args[5]
+			} else if p1 = cur; lastCubic != nil {
+				p1 = point{2*cur.x - lastCubic.x, 2*cur.y - lastCubic.y}
+			}
+			p2 := abs(args[0], args[1])
+			end := abs(args[2], args[3])
+			c.CurveTo(p1.x, p1.y, p2.x, p2.y, end.x, end.y)
+			cur, cubic = end, &p2
+		case 'Q', 'q', 'T', 't':
+			var q point
+			if cmd == 'Q' || cmd == 'q' {
+				q = abs(args[0], args[1])
+				args[0], args[1] = args[2], args[3]
+			} else if q = cur; lastQuad != nil {
+				q = point{2*cur.x - lastQuad.x, 2*cur.y - lastQuad.y}
+			}
+			end := abs(args[0], args[1])
+			// The cubic with control points two thirds of the way to the
+			// quadratic's control point traces the same curve
+			c.CurveTo(
+				cur.x+2.0/3.0*(q.x-cur.x), cur.y+2.0/3.0*(q.y-cur.y),
+				end.x+2.0/3.0*(q.x-end.x), end.y+2.0/3.0*(q.y-end.y),
+				end.x, end.y,
+			)
+			cur, quad = end, &q
+		case 'A', 'a':
+			end := abs(args[5], args[6])
+			c.svgArc(cur, end, args[0], args[1], args[2]*math.Pi/180, args[3] != 0, args[4] != 0)
+			cur = end
+		case 'Z', 'z':
+			c.ClosePath()
+			cur = start
+		}
+		lastCubic, lastQuad = cubic, quad
+	}
+}
+
+// svgArc adds an SVG elliptical arc from the current point from to to,
+// converting SVG's endpoint parameters to the center, radii and angles
+// EllipticalArc takes, as the SVG specification's implementation notes
+// describe.
+func (c *context) svgArc(from, to point, rx, ry, rotation float64, largeArc, sweep bool) {
+	if from == to {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		c.LineTo(to.x, to.y)
+		return
+	}
+
+	// The midpoint between the ends in the ellipse's unrotated frame
+	cosR, sinR := math.Cos(rotation), math.Sin(rotation)
+	dx, dy := (from.x-to.x)/2, (from.y-to.y)/2
+	x1 := cosR*dx + sinR*dy
+	y1 := -sinR*dx + cosR*dy
+
+	// Radii too small to reach between the ends are scaled up until they do
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx *= math.Sqrt(l)
+		ry *= math.Sqrt(l)
+	}
+
+	// The center, on the side the flags select
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(num, 0) / den)
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cx1 := coef * rx * y1 / ry
+	cy1 := -coef * ry * x1 / rx
+	cx := cosR*cx1 - sinR*cy1 + (from.x+to.x)/2
+	cy := sinR*cx1 + cosR*cy1 + (from.y+to.y)/2
+
+	angle1 := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	angle2 := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx)
+	dAngle := angle2 - angle1
+	if sweep && dAngle < 0 {
+		dAngle += 2 * math.Pi
+	} else if !sweep && dAngle > 0 {
+		dAngle -= 2 * math.Pi
+	}
+
+	c.arcCurves(cx, cy, rx, ry, rotation, angle1, dAngle, &to)
+}
+
+// svgPathParser scans the numbers and flags of SVG path data.
+type svgPathParser struct {
+	d   string
+	pos int
+}
+
+// errorf returns a path data error at the parser's position.
+func (p *svgPathParser) errorf(format string, args ...interface{}) error {
+	return newError(StatusInvalidPathData, fmt.Sprintf("svg path data at offset %d: ", p.pos)+fmt.Sprintf(format, args...))
+}
+
+// skipSeparators skips whitespace and at most one comma.
+func (p *svgPathParser) skipSeparators() {
+	comma := false
+	for p.pos < len(p.d) {
+		switch p.d[p.pos] {
+		case ' ', '\t', '\n', '\r', '\f':
+		case ',':
+			if comma {
+				return
+			}
+			comma = true
+		default:
+			return
+		}
+		p.pos++
+	}
+}
+
+// number scans a number, which may follow the previous one without a
+// separator when it starts with a sign or a second decimal point.
+func (p *svgPathParser) number() (float64, bool) {
+	p.skipSeparators()
+	begin := p.pos
+	i := p.pos
+	if i < len(p.d) && (p.d[i] == '+' || p.d[i] == '-') {
+		i++
+	}
+	digits := 0
+	for ; i < len(p.d) && isDigit(p.d[i]); i++ {
+		digits++
+	}
+	if i < len(p.d) && p.d[i] == '.' {
+		i++
+		for ; i < len(p.d) && isDigit(p.d[i]); i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0, false
+	}
+	if i < len(p.d) && (p.d[i] == 'e' || p.d[i] == 'E') {
+		j := i + 1
+		if j < len(p.d) && (p.d[j] == '+' || p.d[j] == '-') {
+			j++
+		}
+		if j < len(p.d) && isDigit(p.d[j]) {
+			for i = j; i < len(p.d) && isDigit(p.d[i]); i++ {
+			}
+		}
+	}
+	v, err := strconv.ParseFloat(p.d[begin:i], 64)
+	if err != nil {
+		return 0, false
+	}
+	p.pos = i
+	return v, true
+}
+
+// flag scans an arc flag, a single 0 or 1 that needs no separator from
+// what follows.
+func (p *svgPathParser) flag() (bool, bool) {
+	p.skipSeparators()
+	if p.pos >= len(p.d) || (p.d[p.pos] != '0' && p.d[p.pos] != '1') {
+		return false, false
+	}
+	p.pos++
+	return p.d[p.pos-1] == '1', true
+}
+
+// svgCommandArgs returns how many numbers an SVG path command takes.
+func svgCommandArgs(cmd byte) int {
+	switch cmd {
+	case 'M', 'm', 'L', 'l', 'T', 't':
+		return 2
+	case 'H', 'h', 'V', 'v':
+		return 1
+	case 'C', 'c':
+		return 6
+	case 'S', 's', 'Q', 'q':
+		return 4
+	case 'A', 'a':
+		return 7
+	}
+	return 0
+}
+
+// isSVGCommand reports whether b is an SVG path command letter.
+func isSVGCommand(b byte) bool {
+	switch b {
+	case 'M', 'm', 'L', 'l', 'H', 'h', 'V', 'v', 'C', 'c', 'S', 's',
+		'Q', 'q', 'T', 't', 'A', 'a', 'Z', 'z':
+		return true
+	}
+	return false
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
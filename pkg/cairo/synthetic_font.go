@@ -0,0 +1,100 @@
+package cairo
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/go-text/typesetting/opentype/api/metadata"
+	"github.com/go-text/typesetting/opentype/loader"
+)
+
+// When a bold or italic face is requested but the font that gets loaded is
+// a regular one (the embedded set or a system fallback lacks the style),
+// glyphs are emboldened by stroking their outlines and slanted by shearing
+// them, so the requested style still shows.
+
+const (
+	// syntheticObliqueShear is the horizontal shift per unit of height,
+	// about 11 degrees, as FreeType's FT_GlyphSlot_Oblique
+	syntheticObliqueShear = 0.2
+
+	// syntheticBoldStrength is the outline stroke width relative to the em
+	// size, as FreeType's FT_GlyphSlot_Embolden
+	syntheticBoldStrength = 1.0 / 24
+)
+
+// fontSynthesis records the styles a face draws synthetically.
+type fontSynthesis struct {
+	bold    bool
+	oblique bool
+}
+
+// synthesisFor reports which of the requested styles the font data does
+// not provide itself.
+func synthesisFor(data []byte, slant FontSlant, weight FontWeight) fontSynthesis {
+	wantBold := weight == FontWeightBold
+	wantSlant := slant == FontSlantItalic || slant == FontSlantOblique
+	if !wantBold && !wantSlant {
+		return fontSynthesis{}
+	}
+
+	// Fonts whose tables cannot be read are treated as regular
+	aspect := metadata.Aspect{Style: metadata.StyleNormal, Weight: metadata.WeightNormal}
+	if loaders, err := loader.NewLoaders(bytes.NewReader(data)); err == nil && len(loaders) > 0 {
+		_, aspect, _ = metadata.Describe(loaders[0], nil)
+	}
+	return fontSynthesis{
+		bold:    wantBold && aspect.Weight < metadata.WeightSemibold,
+		oblique: wantSlant && aspect.Style != metadata.StyleItalic,
+	}
+}
+
+// faceSynthesis returns the synthetic styles of a font face.
+func faceSynthesis(face FontFace) fontSynthesis {
+	switch f := face.(type) {
+	case *toyFontFace:
+		return f.synthesis
+	case *PangoCairoFont:
+		return f.synthesis
+	}
+	return fontSynthesis{}
+}
+
+// glyphSynthesis returns the synthetic styles of a scaled font's face and
+// its em size in user space.
+func glyphSynthesis(sf ScaledFont) (fontSynthesis, float64) {
+	var synthesis fontSynthesis
+	if face := sf.GetFontFace(); face != nil {
+		synthesis = faceSynthesis(face)
+		face.Destroy()
+	}
+	m := sf.GetFontMatrix()
+	return synthesis, math.Hypot(m.XY, m.YY)
+}
+
+// fillGlyph fills a glyph outline with its origin at (x, y), applying the
+// synthetic styles. size is the em size in user space. The glyph path is
+// modified for oblique faces, so it must be a copy.
+func fillGlyph(c *context, glyphPath *Path, x, y float64, synthesis fontSynthesis, size float64) {
+	if synthesis.oblique {
+		// Glyph y grows down from the baseline, so shift up-going points right
+		for i := range glyphPath.Data {
+			for j := range glyphPath.Data[i].Points {
+				p := &glyphPath.Data[i].Points[j]
+				p.X -= p.Y * syntheticObliqueShear
+			}
+		}
+	}
+
+	c.NewPath()
+	appendGlyphPath(c, glyphPath, x, y)
+	if !synthesis.bold {
+		c.Fill()
+		return
+	}
+	c.FillPreserve()
+	c.SetLineWidth(size * syntheticBoldStrength)
+	c.SetLineJoin(LineJoinRound)
+	c.SetDash(nil, 0)
+	c.Stroke()
+}
@@ -2,31 +2,48 @@ package cairo
 
 import (
 	"runtime"
+	"sync/atomic"
 )
 
 // TeeSurface is a surface that redirects drawing operations to multiple target surfaces.
+// The first target is the primary surface: it cannot be removed, and the
+// tee reports its status.
 type TeeSurface interface {
 	Surface
 	AddSurface(Surface) error
 	RemoveSurface(Surface) error
+	Index(index int) Surface
 }
 
 // teeSurface implements the TeeSurface interface.
 type teeSurface struct {
 	baseSurface
 
-	// The list of target surfaces
+	// The list of target surfaces, primary first
 	targets []Surface
 }
 
-// NewTeeSurface creates a new Tee surface.
-func NewTeeSurface() TeeSurface {
+// NewTeeSurface creates a new Tee surface drawing to primary and any
+// additional targets. A context created for the tee replays each drawing
+// operation to every target, in the order the targets were added.
+func NewTeeSurface(primary Surface, targets ...Surface) TeeSurface {
+	if primary == nil {
+		return &teeSurface{
+			baseSurface: baseSurface{
+				refCount:    1,
+				status:      StatusNullPointer,
+				surfaceType: SurfaceTypeTee,
+				userData:    make(map[*UserDataKey]interface{}),
+			},
+		}
+	}
+
 	surface := &teeSurface{
 		baseSurface: baseSurface{
 			refCount:            1,
 			status:              StatusSuccess,
 			surfaceType:         SurfaceTypeTee,
-			content:             ContentColorAlpha, // Tee surface content is the union of its targets
+			content:             primary.GetContent(),
 			userData:            make(map[*UserDataKey]interface{}),
 			fontOptions:         &FontOptions{},
 			deviceScaleX:        1.0,
@@ -34,13 +51,69 @@ func NewTeeSurface() TeeSurface {
 			fallbackResolutionX: 72.0,
 			fallbackResolutionY: 72.0,
 		},
-		targets: make([]Surface, 0),
+		targets: []Surface{primary.Reference()},
+	}
+	for _, target := range targets {
+		surface.AddSurface(target)
 	}
 
 	runtime.SetFinalizer(surface, (*teeSurface).Destroy)
 	return surface
 }
 
+// TeeSurfaceAdd adds target to the tee surface, like cairo_tee_surface_add.
+func TeeSurfaceAdd(surface Surface, target Surface) error {
+	tee, ok := surface.(TeeSurface)
+	if !ok {
+		return newError(StatusSurfaceTypeMismatch, "not a tee surface")
+	}
+	return tee.AddSurface(target)
+}
+
+// TeeSurfaceRemove removes target from the tee surface, like
+// cairo_tee_surface_remove. The primary surface cannot be removed.
+func TeeSurfaceRemove(surface Surface, target Surface) error {
+	tee, ok := surface.(TeeSurface)
+	if !ok {
+		return newError(StatusSurfaceTypeMismatch, "not a tee surface")
+	}
+	return tee.RemoveSurface(target)
+}
+
+// TeeSurfaceIndex returns the target at index, 0 being the primary surface,
+// like cairo_tee_surface_index. Out of range indices give a surface in error
+// with StatusInvalidIndex.
+func TeeSurfaceIndex(surface Surface, index int) Surface {
+	tee, ok := surface.(TeeSurface)
+	if !ok {
+		return newSurfaceInError(StatusSurfaceTypeMismatch)
+	}
+	return tee.Index(index)
+}
+
+func (s *teeSurface) Reference() Surface {
+	atomic.AddInt32(&s.refCount, 1)
+	return s
+}
+
+func (s *teeSurface) Destroy() {
+	if atomic.AddInt32(&s.refCount, -1) == 0 {
+		for _, t := range s.targets {
+			t.Destroy()
+		}
+		s.targets = nil
+		s.cleanup()
+	}
+}
+
+// Status returns the status of the primary surface.
+func (s *teeSurface) Status() Status {
+	if s.status != StatusSuccess || len(s.targets) == 0 {
+		return s.status
+	}
+	return s.targets[0].Status()
+}
+
 // AddSurface adds a surface to the list of targets.
 func (s *teeSurface) AddSurface(target Surface) error {
 	if target == nil {
@@ -55,6 +128,9 @@ func (s *teeSurface) RemoveSurface(target Surface) error {
 	if target == nil {
 		return newError(StatusNullPointer, "target surface is nil")
 	}
+	if len(s.targets) > 0 && s.targets[0] == target {
+		return newError(StatusInvalidIndex, "cannot remove the primary surface of a tee surface")
+	}
 
 	for i, t := range s.targets {
 		// Check if the target is the same object
@@ -69,13 +145,67 @@ func (s *teeSurface) RemoveSurface(target Surface) error {
 	return newError(StatusInvalidIndex, "target surface not found in tee surface")
 }
 
+// Index returns the target at index, 0 being the primary surface.
+func (s *teeSurface) Index(index int) Surface {
+	if index < 0 || index >= len(s.targets) {
+		return newSurfaceInError(StatusInvalidIndex)
+	}
+	return s.targets[index]
+}
+
 // GetTargets returns the list of target surfaces.
 func (s *teeSurface) GetTargets() []Surface {
 	return s.targets
 }
 
-// The Context implementation needs to be updated to handle TeeSurface.
-// When a Context is created with a TeeSurface, the Context's drawing operations
-// must be forwarded to a Context created for each target surface.
-// This requires a significant change in context.go's NewContext logic.
-// We will address this in a subsequent step.
+func (s *teeSurface) forwardTargets() []Surface {
+	return s.targets
+}
+
+func (s *teeSurface) Flush() error {
+	var err error
+	for i, t := range s.targets {
+		if e := t.Flush(); i == 0 {
+			err = e
+		}
+	}
+	return err
+}
+
+func (s *teeSurface) MarkDirty() {
+	for _, t := range s.targets {
+		t.MarkDirty()
+	}
+}
+
+func (s *teeSurface) MarkDirtyRectangle(x, y, width, height int) {
+	for _, t := range s.targets {
+		t.MarkDirtyRectangle(x, y, width, height)
+	}
+}
+
+func (s *teeSurface) ShowPage() {
+	for _, t := range s.targets {
+		t.ShowPage()
+	}
+}
+
+func (s *teeSurface) CopyPage() {
+	for _, t := range s.targets {
+		t.CopyPage()
+	}
+}
+
+func (s *teeSurface) Finish() error {
+	if s.finished {
+		return nil
+	}
+	var err error
+	for i, t := range s.targets {
+		if e := t.Finish(); i == 0 {
+			err = e
+		}
+	}
+	s.baseSurface.Finish()
+	return err
+}
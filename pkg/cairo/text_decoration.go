@@ -0,0 +1,133 @@
+package cairo
+
+import (
+	"math"
+
+	"github.com/go-text/typesetting/font"
+)
+
+// decorationLine is the top of a decoration line relative to the baseline,
+// with y growing down as in user space, and its thickness.
+type decorationLine struct {
+	top, thickness float64
+}
+
+// decorationMetrics holds where a scaled font draws its decorations.
+type decorationMetrics struct {
+	underline, strikethrough, overline decorationLine
+}
+
+// fontDecorationMetrics returns the decoration lines of sf in user space,
+// from the font's own metrics when it has a real face and from its font
+// extents otherwise. The overline sits at the ascent, as thick as the
+// underline.
+func fontDecorationMetrics(sf ScaledFont) decorationMetrics {
+	var face font.Face
+	switch s := sf.(type) {
+	case *scaledFont:
+		face, _ = s.getRealFace()
+	case *PangoCairoScaledFont:
+		face, _ = s.getRealFace()
+	}
+
+	if face == nil || face.Upem() == 0 {
+		fe := sf.Extents()
+		return decorationMetrics{
+			underline:     decorationLine{-fe.UnderlinePosition, fe.UnderlineThickness},
+			strikethrough: decorationLine{-fe.StrikethroughPosition, fe.StrikethroughThickness},
+			overline:      decorationLine{-fe.Ascent, fe.UnderlineThickness},
+		}
+	}
+
+	fm := sf.GetFontMatrix()
+	fontSize := math.Hypot(fm.XX, fm.YX)
+	if fontSize == 0 {
+		fontSize = 12
+	}
+	scale := fontSize / float64(face.Upem())
+	underlinePos, underlineThick, strikePos, strikeThick := fontLineMetrics(face)
+	extents, _ := face.FontHExtents()
+	return decorationMetrics{
+		underline:     decorationLine{-underlinePos * scale, underlineThick * scale},
+		strikethrough: decorationLine{-strikePos * scale, strikeThick * scale},
+		overline:      decorationLine{-float64(extents.Ascender) * scale, underlineThick * scale},
+	}
+}
+
+// ShowTextDecorated draws utf8 like ShowGlyphs does with the glyphs of
+// TextToGlyphs at the current point, or the origin when there is none, and
+// then fills the selected decorations under, through or over each line of
+// text, spanning its advance. Positions and thicknesses come from the
+// font's own underline and strikethrough metrics. As with ShowGlyphs, the
+// current point ends after the last glyph.
+func (c *context) ShowTextDecorated(utf8 string, decoration TextDecoration) {
+	if c.status != StatusSuccess {
+		return
+	}
+	if !validText(utf8) {
+		c.setError(StatusInvalidString)
+		return
+	}
+
+	x, y := c.GetCurrentPoint()
+	glyphs, _, _, status := c.TextToGlyphs(x, y, utf8)
+	if status != StatusSuccess {
+		c.setError(status)
+		return
+	}
+	if len(glyphs) == 0 {
+		return
+	}
+	c.ShowGlyphs(glyphs)
+	if decoration == TextDecorationNone || c.status != StatusSuccess {
+		return
+	}
+	endX, endY := c.currentPoint.x, c.currentPoint.y
+
+	sf := c.GetScaledFont()
+	if sf == nil {
+		return
+	}
+	defer sf.Destroy()
+	m := fontDecorationMetrics(sf)
+	var lines []decorationLine
+	for _, d := range []struct {
+		flag TextDecoration
+		line decorationLine
+	}{
+		{TextDecorationUnderline, m.underline},
+		{TextDecorationStrikethrough, m.strikethrough},
+		{TextDecorationOverline, m.overline},
+	} {
+		if decoration&d.flag != 0 {
+			lines = append(lines, d.line)
+		}
+	}
+
+	c.Save()
+	c.NewPath()
+	// Multiline text gets a decoration per line, from the start of the line
+	// to the end of its last glyph's advance. A glyph starts a new line when
+	// it sits lower than half the height of the decorations, which mark
+	// offsets within a line never reach.
+	newLine := (m.underline.top - m.overline.top) / 2
+	baseline := y
+	for i := 1; i <= len(glyphs); i++ {
+		if i < len(glyphs) && glyphs[i].Y-baseline < newLine {
+			continue
+		}
+		last := glyphs[i-1]
+		width := last.X + sf.GlyphExtents([]Glyph{last}).XAdvance - x
+		for _, line := range lines {
+			c.Rectangle(x, baseline+line.top, width, line.thickness)
+		}
+		if i < len(glyphs) {
+			baseline = glyphs[i].Y
+		}
+	}
+	c.Fill()
+	c.Restore()
+
+	c.currentPoint.x, c.currentPoint.y = endX, endY
+	c.currentPoint.hasPoint = true
+}
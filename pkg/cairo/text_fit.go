@@ -0,0 +1,131 @@
+package cairo
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-text/typesetting/font"
+	"github.com/go-text/typesetting/shaping"
+	"golang.org/x/image/math/fixed"
+)
+
+// TextFitWidth returns how many leading bytes of utf8 fit within maxWidth
+// when it is drawn as a single line, and the advance of that prefix. The
+// text is shaped as TextToGlyphs shapes it and only broken between
+// clusters, so a ligature or a character with its combining marks is kept
+// or dropped whole. When not even the first cluster fits it returns 0.
+func (s *scaledFont) TextFitWidth(utf8 string, maxWidth float64) (nBytes int, width float64) {
+	if !validText(utf8) {
+		return 0, 0
+	}
+	realFace, status := s.getRealFace()
+	if status != StatusSuccess {
+		return glyphsFitWidth(s, utf8, maxWidth)
+	}
+	fontSize := math.Hypot(s.fontMatrix.XX, s.fontMatrix.YX)
+	if fontSize == 0 {
+		fontSize = 12.0
+	}
+	return shapedFitWidth(realFace, fontSize, utf8, maxWidth)
+}
+
+// TextFitWidth returns how many leading bytes of utf8 fit within maxWidth
+// when it is drawn as a single line, and the advance of that prefix,
+// breaking only between clusters. When not even the first cluster fits it
+// returns 0.
+func (s *PangoCairoScaledFont) TextFitWidth(utf8 string, maxWidth float64) (nBytes int, width float64) {
+	if !validText(utf8) {
+		return 0, 0
+	}
+	realFace, status := s.getRealFace()
+	if status != StatusSuccess {
+		return glyphsFitWidth(s, utf8, maxWidth)
+	}
+	fontSize := math.Hypot(s.fontMatrix.XX, s.fontMatrix.YX)
+	if fontSize == 0 {
+		fontSize = 12.0
+	}
+	return shapedFitWidth(realFace, fontSize, utf8, maxWidth)
+}
+
+// shapedFitWidth shapes text with face at fontSize and adds up the
+// advances of its clusters in logical order until the next one would
+// overflow maxWidth.
+func shapedFitWidth(face font.Face, fontSize float64, text string, maxWidth float64) (int, float64) {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return 0, 0
+	}
+	output := shapeText(shaping.Input{
+		Text:      runes,
+		RunStart:  0,
+		RunEnd:    len(runes),
+		Direction: convertDirection(DetectTextDirection(text), text),
+		Face:      face,
+		Size:      fixed.I(int(fontSize)),
+		Language:  convertLanguage(DetectLanguage(text)),
+		Script:    convertScript(DetectScript(text)),
+	})
+
+	// Glyphs come in visual order; a cluster's advance is the sum of its
+	// glyphs' wherever they are
+	advances := make(map[int]fixed.Int26_6)
+	for _, g := range output.Glyphs {
+		advances[g.ClusterIndex] += g.XAdvance
+	}
+	starts := make([]int, 0, len(advances))
+	for start := range advances {
+		starts = append(starts, start)
+	}
+	sort.Ints(starts)
+
+	// Byte offset of each rune, and of the end of the text
+	offsets := make([]int, 0, len(runes)+1)
+	for i := range text {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(text))
+
+	nBytes, width := 0, 0.0
+	for i, start := range starts {
+		w := width + float64(advances[start])/64.0
+		if w > maxWidth {
+			break
+		}
+		end := len(runes)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		nBytes, width = offsets[end], w
+	}
+	return nBytes, width
+}
+
+// glyphsFitWidth fits text by the glyph positions of TextToGlyphs, for
+// fonts drawn without a real face, whose clusters are single characters.
+func glyphsFitWidth(sf ScaledFont, text string, maxWidth float64) (int, float64) {
+	glyphs, clusters, _, status := sf.TextToGlyphs(0, 0, text)
+	if status != StatusSuccess || len(glyphs) == 0 {
+		return 0, 0
+	}
+	nBytes, width, glyph := 0, 0.0, 0
+	for _, cluster := range clusters {
+		end := glyph + cluster.NumGlyphs
+		if end > len(glyphs) {
+			break
+		}
+		var w float64
+		if end < len(glyphs) {
+			w = glyphs[end].X
+		} else {
+			last := glyphs[end-1]
+			w = last.X + sf.GlyphExtents([]Glyph{last}).XAdvance
+		}
+		if w > maxWidth {
+			break
+		}
+		nBytes += cluster.NumBytes
+		width, glyph = w, end
+	}
+	return nBytes, width
+}
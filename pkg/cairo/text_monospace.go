@@ -0,0 +1,118 @@
+package cairo
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// ShowTextMonospace draws text on a grid of cells cellWidth wide, as a
+// terminal does, starting at the current point or the origin when there is
+// none. Each cluster, a character with the combining marks and joined
+// characters that follow it, is shaped on its own and takes one cell, or
+// two for East Asian wide and fullwidth characters, whatever its natural
+// advance. It is centered in its cells, so a monospace font whose advance
+// matches the cell width is drawn as ShowGlyphs would. A newline starts
+// the next row one font height below. The current point ends after the
+// last cell.
+func (c *context) ShowTextMonospace(text string, cellWidth float64) {
+	if c.status != StatusSuccess {
+		return
+	}
+	if !validText(text) {
+		c.setError(StatusInvalidString)
+		return
+	}
+	if cellWidth <= 0 || math.IsNaN(cellWidth) || math.IsInf(cellWidth, 0) {
+		c.setError(StatusInvalidSize)
+		return
+	}
+	if text == "" {
+		return
+	}
+
+	sf := c.GetScaledFont()
+	if sf == nil {
+		c.setError(StatusNullPointer)
+		return
+	}
+	defer sf.Destroy()
+	lineHeight := sf.Extents().Height
+	if lineHeight <= 0 {
+		lineHeight = math.Hypot(sf.GetFontMatrix().XX, sf.GetFontMatrix().YX) * 1.2
+	}
+
+	x, y := c.GetCurrentPoint()
+	var glyphs []Glyph
+	column := 0
+	for i, line := range splitLines(text) {
+		if i > 0 {
+			y += lineHeight
+			column = 0
+		}
+		for _, cluster := range textClusters(line) {
+			clusterGlyphs, _, _, status := sf.TextToGlyphs(0, 0, cluster)
+			if status != StatusSuccess {
+				c.setError(status)
+				return
+			}
+			// The cluster was shaped at the origin, so its advance ends
+			// where its last glyph's does
+			var advance float64
+			if n := len(clusterGlyphs); n > 0 {
+				last := clusterGlyphs[n-1]
+				advance = last.X + sf.GlyphExtents([]Glyph{last}).XAdvance
+			}
+			cells := clusterCells(cluster)
+			offset := (float64(cells)*cellWidth - advance) / 2
+			for _, g := range clusterGlyphs {
+				g.X += x + float64(column)*cellWidth + offset
+				g.Y += y
+				glyphs = append(glyphs, g)
+			}
+			column += cells
+		}
+	}
+
+	c.ShowGlyphs(glyphs)
+	if c.status != StatusSuccess {
+		return
+	}
+	c.currentPoint.x, c.currentPoint.y = x+float64(column)*cellWidth, y
+	c.currentPoint.hasPoint = true
+}
+
+// textClusters splits a line into clusters drawn in a cell together: a
+// character with the combining marks and variation selectors that follow
+// it, and characters joined to it with a zero width joiner.
+func textClusters(line string) []string {
+	var clusters []string
+	start := 0
+	joined := false
+	for i, r := range line {
+		extends := unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Variation_Selector) ||
+			r == '\u200d' || joined
+		if i > 0 && !extends {
+			clusters = append(clusters, line[start:i])
+			start = i
+		}
+		joined = r == '\u200d'
+	}
+	if start < len(line) {
+		clusters = append(clusters, line[start:])
+	}
+	return clusters
+}
+
+// clusterCells returns the number of cells a cluster takes: two when its
+// first character is East Asian wide or fullwidth, one otherwise.
+func clusterCells(cluster string) int {
+	r, _ := utf8.DecodeRuneInString(cluster)
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
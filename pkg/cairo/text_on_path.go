@@ -0,0 +1,129 @@
+package cairo
+
+import (
+	"math"
+	"sort"
+)
+
+// flatSegment is a line of a flattened path, with the arc length from the
+// start of the path to its end.
+type flatSegment struct {
+	x0, y0, x1, y1 float64
+	end            float64
+}
+
+// flattenPath approximates p by line segments, curves being subdivided
+// until they are within tolerance of their chords. Moves between subpaths
+// add no length.
+func flattenPath(p *path, tolerance float64) []flatSegment {
+	var segments []flatSegment
+	var length, x, y, startX, startY float64
+	lineTo := func(x1, y1 float64) {
+		if d := math.Hypot(x1-x, y1-y); d > 0 {
+			length += d
+			segments = append(segments, flatSegment{x, y, x1, y1, length})
+		}
+		x, y = x1, y1
+	}
+	p.forEach(func(op PathDataType, pts []point) {
+		switch op {
+		case PathMoveTo:
+			x, y = pts[0].x, pts[0].y
+			startX, startY = x, y
+		case PathLineTo:
+			lineTo(pts[0].x, pts[0].y)
+		case PathCurveTo:
+			flattenCubic(x, y, pts[0].x, pts[0].y, pts[1].x, pts[1].y, pts[2].x, pts[2].y, tolerance, 0, lineTo)
+		case PathClosePath:
+			lineTo(startX, startY)
+		}
+	})
+	return segments
+}
+
+// flattenCubic calls lineTo with the points of a polyline from (x0, y0)
+// approximating a cubic Bézier curve, excluding its start. It subdivides as
+// the rasterizer does, so flattened paths match what Fill draws.
+func flattenCubic(x0, y0, x1, y1, x2, y2, x3, y3, tolerance float64, depth int, lineTo func(x, y float64)) {
+	if depth > maxCurveDepth || curveFlat(x0, y0, x1, y1, x2, y2, x3, y3, tolerance) {
+		lineTo(x3, y3)
+		return
+	}
+
+	// Split at t = 0.5 with de Casteljau's algorithm
+	x01, y01 := (x0+x1)/2, (y0+y1)/2
+	x12, y12 := (x1+x2)/2, (y1+y2)/2
+	x23, y23 := (x2+x3)/2, (y2+y3)/2
+	x012, y012 := (x01+x12)/2, (y01+y12)/2
+	x123, y123 := (x12+x23)/2, (y12+y23)/2
+	xm, ym := (x012+x123)/2, (y012+y123)/2
+	flattenCubic(x0, y0, x01, y01, x012, y012, xm, ym, tolerance, depth+1, lineTo)
+	flattenCubic(xm, ym, x123, y123, x23, y23, x3, y3, tolerance, depth+1, lineTo)
+}
+
+// pointAtLength returns the point at arc length s along segments and the
+// direction of the path there, in radians. s must lie within the path.
+func pointAtLength(segments []flatSegment, s float64) (x, y, angle float64) {
+	i := sort.Search(len(segments), func(i int) bool { return segments[i].end >= s })
+	i = min(i, len(segments)-1)
+	seg := segments[i]
+	length := math.Hypot(seg.x1-seg.x0, seg.y1-seg.y0)
+	t := 1 - (seg.end-s)/length
+	return seg.x0 + (seg.x1-seg.x0)*t, seg.y0 + (seg.y1-seg.y0)*t, math.Atan2(seg.y1-seg.y0, seg.x1-seg.x0)
+}
+
+// ShowTextOnPath draws utf8 along the current path, as for map labels
+// following a road. The text is shaped as TextToGlyphs shapes it and the
+// glyphs are laid out from the start of the path by their advances: each
+// is placed with the middle of its advance on the path and rotated to the
+// path's direction there, so glyphs on a curve tilt to follow it. Glyphs
+// whose middle falls past the end of the path are dropped. Curves are
+// flattened with the current tolerance and moves between subpaths add no
+// length. Like Fill, it clears the current path afterwards.
+func (c *context) ShowTextOnPath(utf8 string) {
+	if c.status != StatusSuccess {
+		return
+	}
+	if !validText(utf8) {
+		c.setError(StatusInvalidString)
+		return
+	}
+
+	segments := flattenPath(c.path, c.gstate.tolerance)
+	c.NewPath()
+	if len(segments) == 0 {
+		return
+	}
+	total := segments[len(segments)-1].end
+
+	glyphs, _, _, status := c.TextToGlyphs(0, 0, utf8)
+	if status != StatusSuccess {
+		c.setError(status)
+		return
+	}
+	sf := c.GetScaledFont()
+	if sf == nil {
+		c.setError(StatusNullPointer)
+		return
+	}
+	defer sf.Destroy()
+
+	for _, glyph := range glyphs {
+		half := sf.GlyphExtents([]Glyph{glyph}).XAdvance / 2
+		mid := glyph.X + half
+		if mid < 0 || mid > total {
+			continue
+		}
+		x, y, angle := pointAtLength(segments, mid)
+		c.Save()
+		c.Translate(x, y)
+		c.Rotate(angle)
+		// Vertical offsets from shaping move the glyph off the path
+		c.ShowGlyphs([]Glyph{{Index: glyph.Index, X: -half, Y: glyph.Y}})
+		c.Restore()
+		if c.status != StatusSuccess {
+			return
+		}
+	}
+	c.NewPath()
+}
@@ -0,0 +1,83 @@
+package cairo
+
+import (
+	"encoding/binary"
+	"os"
+)
+
+// WriteToTGA writes the surface to an uncompressed TGA file. See
+// WriteToTGAStream.
+func (s *imageSurface) WriteToTGA(filename string) Status {
+	if s.status != StatusSuccess {
+		return s.status
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return StatusWriteError
+	}
+
+	status := s.WriteToTGAStream(func(closure interface{}, data []byte) error {
+		_, err := closure.(*os.File).Write(data)
+		return err
+	}, file)
+	if err := file.Close(); err != nil && status == StatusSuccess {
+		status = StatusWriteError
+	}
+	return status
+}
+
+// WriteToTGAStream encodes the surface as an uncompressed true-color TGA
+// with its rows top-down and passes the bytes to write, like
+// WriteToPNGStream. Formats without alpha give 24-bit BGR pixels, the
+// others 32-bit BGRA with straight alpha. TGA sizes are 16-bit, so larger
+// surfaces give StatusInvalidSize.
+func (s *imageSurface) WriteToTGAStream(write WriteFunc, closure interface{}) Status {
+	if s.status != StatusSuccess {
+		return s.status
+	}
+	if s.goImage == nil {
+		return StatusSurfaceTypeMismatch
+	}
+	w, h := s.width, s.height
+	if w > 0xffff || h > 0xffff {
+		return StatusInvalidSize
+	}
+
+	img := straightAlphaImage(s)
+	alpha := !opaqueFormat(s.format)
+	bytesPerPixel := 3
+	var header [18]byte
+	header[2] = 2 // uncompressed true-color
+	binary.LittleEndian.PutUint16(header[12:], uint16(w))
+	binary.LittleEndian.PutUint16(header[14:], uint16(h))
+	header[16] = 24
+	// The descriptor's bit 5 puts the first row at the top; its low bits
+	// count the alpha bits of each pixel
+	header[17] = 0x20
+	if alpha {
+		bytesPerPixel = 4
+		header[16] = 32
+		header[17] |= 8
+	}
+
+	out := &funcWriter{write, closure}
+	if _, err := out.Write(header[:]); err != nil {
+		return StatusWriteError
+	}
+	row := make([]byte, w*bytesPerPixel)
+	for y := 0; y < h; y++ {
+		src := img.Pix[y*img.Stride:]
+		for x := 0; x < w; x++ {
+			p, d := src[x*4:], row[x*bytesPerPixel:]
+			d[0], d[1], d[2] = p[2], p[1], p[0]
+			if alpha {
+				d[3] = p[3]
+			}
+		}
+		if _, err := out.Write(row); err != nil {
+			return StatusWriteError
+		}
+	}
+	return StatusSuccess
+}
@@ -0,0 +1,81 @@
+package cairo
+
+import (
+	"runtime"
+	"sync"
+)
+
+// RenderTiled draws to target on numWorkers goroutines. The surface is
+// split into horizontal bands of device pixels, one per worker, and draw is
+// called once per band with a new context on target clipped to the band.
+// Each band is rasterized independently, so the result is the same as
+// calling draw once on a single context. numWorkers of 0 or less uses
+// GOMAXPROCS workers.
+//
+// draw runs concurrently and must only share state that is not modified
+// while drawing; patterns and paths are best created inside it. It must
+// not reset the band's clip. Targets other than image surfaces are drawn
+// on a single context.
+//
+// The returned status is the first error status of a band's context, or
+// StatusSuccess.
+func RenderTiled(target Surface, numWorkers int, draw func(ctx Context)) Status {
+	if target == nil {
+		return StatusNullPointer
+	}
+	if status := target.Status(); status != StatusSuccess {
+		return status
+	}
+
+	img, ok := target.(*imageSurface)
+	if !ok {
+		ctx := NewContext(target)
+		defer ctx.Destroy()
+		draw(ctx)
+		return ctx.Status()
+	}
+
+	if numWorkers <= 0 {
+		numWorkers = runtime.GOMAXPROCS(0)
+	}
+	height := img.GetHeight()
+	numWorkers = max(min(numWorkers, height), 1)
+
+	// Detach snapshots up front, so the bands find none to detach
+	img.detachSnapshots()
+
+	statuses := make([]Status, numWorkers)
+	var wg sync.WaitGroup
+	for i := range numWorkers {
+		y0, y1 := height*i/numWorkers, height*(i+1)/numWorkers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := NewContext(target)
+			defer ctx.Destroy()
+
+			// Clip to the band in device space, which is pixel aligned and
+			// bounds the rasterizer's scans to the band. The band's pixels
+			// map back to user space through the inverse of the CTM and the
+			// surface's device scale and offset.
+			m := ctx.(*context).deviceMatrix()
+			if MatrixInvert(&m) == StatusSuccess {
+				ux0, uy0 := MatrixTransformPoint(&m, 0, float64(y0))
+				ux1, uy1 := MatrixTransformPoint(&m, float64(img.GetWidth()), float64(y1))
+				ctx.Rectangle(ux0, uy0, ux1-ux0, uy1-uy0)
+				ctx.Clip()
+			}
+
+			draw(ctx)
+			statuses[i] = ctx.Status()
+		}()
+	}
+	wg.Wait()
+
+	for _, status := range statuses {
+		if status != StatusSuccess {
+			return status
+		}
+	}
+	return StatusSuccess
+}
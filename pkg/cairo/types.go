@@ -1,6 +1,7 @@
 package cairo
 
 import (
+	"fmt"
 	"image/color"
 	"math"
 	"unsafe"
@@ -347,6 +348,50 @@ func (m *Matrix) InitSkew(shearX, shearY float64) {
 	m.YX = shearY // Skew along Y-axis
 }
 
+// Multiply sets m to a * b, the transformation a followed by b, like
+// cairo_matrix_multiply. m may be a or b.
+func (m *Matrix) Multiply(a, b *Matrix) {
+	MatrixMultiply(m, a, b)
+}
+
+// Translate applies a translation by tx, ty before m, like
+// cairo_matrix_translate: the new transformation happens in m's user space.
+func (m *Matrix) Translate(tx, ty float64) {
+	var t Matrix
+	t.InitTranslate(tx, ty)
+	MatrixMultiply(m, &t, m)
+}
+
+// Scale applies a scaling by sx, sy before m, like cairo_matrix_scale.
+func (m *Matrix) Scale(sx, sy float64) {
+	var t Matrix
+	t.InitScale(sx, sy)
+	MatrixMultiply(m, &t, m)
+}
+
+// Rotate applies a rotation by radians before m, like cairo_matrix_rotate.
+func (m *Matrix) Rotate(radians float64) {
+	var t Matrix
+	t.InitRotate(radians)
+	MatrixMultiply(m, &t, m)
+}
+
+// TransformPoint transforms the point x, y by m; see MatrixTransformPoint.
+func (m *Matrix) TransformPoint(x, y float64) (float64, float64) {
+	return MatrixTransformPoint(m, x, y)
+}
+
+// TransformDistance transforms the distance vector dx, dy by m, ignoring
+// the translation; see MatrixTransformDistance.
+func (m *Matrix) TransformDistance(dx, dy float64) (float64, float64) {
+	return MatrixTransformDistance(m, dx, dy)
+}
+
+// String formats the six components of m in XX, YX, XY, YY, X0, Y0 order.
+func (m Matrix) String() string {
+	return fmt.Sprintf("Matrix(%g, %g, %g, %g, %g, %g)", m.XX, m.YX, m.XY, m.YY, m.X0, m.Y0)
+}
+
 // MatrixDecompose decomposes the matrix into translation, rotation, scale, and shear components.
 // The decomposition is not unique, but this follows a common convention.
 func MatrixDecompose(m *Matrix) (tx, ty, rotation, scaleX, scaleY, shear float64, status Status) {
@@ -436,6 +481,78 @@ type UserDataKey struct {
 // DestroyFunc represents cairo_destroy_func_t - cleanup callback
 type DestroyFunc func(data unsafe.Pointer)
 
+// userDataEntry is the value stored in a userData map: the data together
+// with the function that releases it.
+type userDataEntry struct {
+	data    unsafe.Pointer
+	destroy DestroyFunc
+}
+
+// setUserData stores data under key, first releasing any data it replaces.
+// Storing nil data removes the key.
+func setUserData(m map[*UserDataKey]interface{}, key *UserDataKey, data unsafe.Pointer, destroy DestroyFunc) {
+	removeUserData(m, key)
+	if data != nil {
+		m[key] = userDataEntry{data: data, destroy: destroy}
+	}
+}
+
+// userDataValue is the value stored in a userData map by SetUserDataAny.
+// GetUserData returns nil for it, as GetUserDataAny does for pointers
+// stored with SetUserData.
+type userDataValue struct {
+	value any
+}
+
+// setUserDataAny stores v under key, first releasing any data it replaces.
+// Storing nil removes the key.
+func setUserDataAny(m map[*UserDataKey]interface{}, key *UserDataKey, v any) {
+	removeUserData(m, key)
+	if v != nil {
+		m[key] = userDataValue{value: v}
+	}
+}
+
+// getUserDataAny returns the value stored under key by setUserDataAny, or nil.
+func getUserDataAny(m map[*UserDataKey]interface{}, key *UserDataKey) any {
+	if entry, ok := m[key].(userDataValue); ok {
+		return entry.value
+	}
+	return nil
+}
+
+// removeUserData deletes key, calling the destroy function of data stored
+// with setUserData.
+func removeUserData(m map[*UserDataKey]interface{}, key *UserDataKey) {
+	old, ok := m[key]
+	if !ok {
+		return
+	}
+	delete(m, key)
+	if entry, ok := old.(userDataEntry); ok && entry.destroy != nil {
+		entry.destroy(entry.data)
+	}
+}
+
+// getUserData returns the data stored under key, or nil.
+func getUserData(m map[*UserDataKey]interface{}, key *UserDataKey) unsafe.Pointer {
+	if entry, ok := m[key].(userDataEntry); ok {
+		return entry.data
+	}
+	return nil
+}
+
+// destroyUserData removes all entries, calling their destroy functions.
+// It is called once when the owning object is destroyed.
+func destroyUserData(m map[*UserDataKey]interface{}) {
+	for key, v := range m {
+		delete(m, key)
+		if entry, ok := v.(userDataEntry); ok && entry.destroy != nil {
+			entry.destroy(entry.data)
+		}
+	}
+}
+
 // WriteFunc represents cairo_write_func_t - write callback for surfaces
 type WriteFunc func(closure interface{}, data []byte) error
 
@@ -468,12 +585,20 @@ type FontExtents struct {
 	// MaxYAdvance is the maximum advance height for all glyphs
 	MaxYAdvance float64
 
-	// UnderlinePosition is the position of the underline
+	// UnderlinePosition is the distance above the baseline of the top of
+	// the underline, negative for the usual underline below the baseline
 	UnderlinePosition float64
 
 	// UnderlineThickness is the thickness of the underline
 	UnderlineThickness float64
 
+	// StrikethroughPosition is the distance above the baseline of the top
+	// of the strikethrough
+	StrikethroughPosition float64
+
+	// StrikethroughThickness is the thickness of the strikethrough
+	StrikethroughThickness float64
+
 	// CapHeight is the height of capital letters
 	CapHeight float64
 
@@ -735,6 +860,35 @@ const (
 	ColorModeColor
 )
 
+// MissingGlyphStyle controls how codepoints that have no glyph in the font are drawn
+type MissingGlyphStyle int
+
+const (
+	// MissingGlyphStyleNotdef draws the font's own .notdef glyph
+	MissingGlyphStyleNotdef MissingGlyphStyle = iota
+	// MissingGlyphStyleBlank draws nothing
+	MissingGlyphStyleBlank
+	// MissingGlyphStyleBox draws an empty rectangular outline
+	MissingGlyphStyleBox
+	// MissingGlyphStyleHex draws a box containing the codepoint in hex
+	MissingGlyphStyleHex
+)
+
+// TextDecoration selects the lines ShowTextDecorated draws along text.
+// Decorations combine with bitwise or.
+type TextDecoration int
+
+const (
+	TextDecorationNone TextDecoration = 0
+	// TextDecorationUnderline draws a line below the baseline
+	TextDecorationUnderline TextDecoration = 1 << iota
+	// TextDecorationStrikethrough draws a line through the middle of the
+	// lowercase letters
+	TextDecorationStrikethrough
+	// TextDecorationOverline draws a line along the font's ascent
+	TextDecorationOverline
+)
+
 // NewGlyphTransform creates a new identity glyph transform
 func NewGlyphTransform() *GlyphTransform {
 	return &GlyphTransform{
@@ -765,3 +919,62 @@ func (gt *GlyphTransform) Translate(tx, ty float64) {
 	gt.OffsetX += tx
 	gt.OffsetY += ty
 }
+
+// PathDataType represents cairo_path_data_type_t - path segment types
+type PathDataType int
+
+const (
+	PathMoveTo PathDataType = iota
+	PathLineTo
+	PathCurveTo
+	PathClosePath
+)
+
+// PathData represents cairo_path_data_t - path segment data
+type PathData struct {
+	Type   PathDataType
+	Points []Point
+}
+
+// Path represents cairo_path_t - path data structure
+type Path struct {
+	Status Status
+	Data   []PathData
+}
+
+// DeviceType represents cairo_device_type_t
+type DeviceType int
+
+const (
+	DeviceTypeDRM DeviceType = iota
+	DeviceTypeGL
+	DeviceTypeScript
+	DeviceTypeXcb
+	DeviceTypeXlib
+	DeviceTypeXML
+	DeviceTypeCogl
+	DeviceTypeWin32
+	DeviceTypeInvalid
+	// DeviceTypeImage has no counterpart in cairo; it follows the cairo
+	// values so they keep their numbering
+	DeviceTypeImage
+)
+
+// FontType represents cairo_font_type_t
+type FontType int
+
+const (
+	FontTypeToy FontType = iota
+	FontTypeFt
+	FontTypeWin32
+	FontTypeQuartz
+	FontTypeUser
+	FontTypeDwrite
+)
+
+// RectangleList represents cairo_rectangle_list_t
+type RectangleList struct {
+	Status        Status
+	Rectangles    []*Rectangle
+	NumRectangles int
+}
@@ -49,6 +49,7 @@ func (f *userFontFace) Destroy() {
 	f.refCount--
 	if f.refCount <= 0 {
 		// Cleanup resources
+		destroyUserData(f.userData)
 		f.userData = nil
 	}
 }
@@ -76,20 +77,13 @@ func (f *userFontFace) SetUserData(key *UserDataKey, userData unsafe.Pointer, de
 	if f.userData == nil {
 		f.userData = make(map[*UserDataKey]interface{})
 	}
-	f.userData[key] = userData
-	_ = destroy // destroy func is currently ignored
+	setUserData(f.userData, key, userData, destroy)
 	return StatusSuccess
 }
 
 // GetUserData retrieves user data for the font face.
 func (f *userFontFace) GetUserData(key *UserDataKey) unsafe.Pointer {
-	if f.userData == nil {
-		return nil
-	}
-	if data, ok := f.userData[key]; ok {
-		return data.(unsafe.Pointer)
-	}
-	return nil
+	return getUserData(f.userData, key)
 }
 
 // SetInitFunc sets the initialization function for the user font face.
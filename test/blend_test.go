@@ -17,6 +17,12 @@ func TestPorterDuffOver(t *testing.T) {
 	if result.A == 0 {
 		t.Error("Over blend should not produce transparent result")
 	}
+
+	// 半透明红色覆盖不透明蓝色，各占一半
+	result = cairo.PorterDuffBlend(src, color.NRGBA{B: 255, A: 255}, cairo.OperatorOver)
+	if result.A != 255 || result.R < 127 || result.R > 129 || result.B < 126 || result.B > 128 {
+		t.Errorf("Expected about {128 0 127 255}, got %v", result)
+	}
 }
 
 // 测试 Porter-Duff Clear 操作
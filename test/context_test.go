@@ -1,8 +1,17 @@
 package cairo
 
 import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
 	"math"
+	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
+	"unsafe"
 
 	"github.com/novvoo/go-cairo/pkg/cairo"
 )
@@ -69,6 +78,61 @@ func TestContextSaveRestore(t *testing.T) {
 	}
 }
 
+// 测试失败的操作可通过 Status() 观察到，且错误状态保持不变
+func TestContextErrorStatus(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+
+	cases := []struct {
+		name     string
+		fail     func(ctx cairo.Context)
+		expected cairo.Status
+	}{
+		{"unbalanced Restore", func(ctx cairo.Context) { ctx.Restore() }, cairo.StatusInvalidRestore},
+		{"singular Scale", func(ctx cairo.Context) { ctx.Scale(0, 1) }, cairo.StatusInvalidMatrix},
+		{"negative dash", func(ctx cairo.Context) { ctx.SetDash([]float64{4, -1}, 0) }, cairo.StatusInvalidDash},
+		{"zero dashes", func(ctx cairo.Context) { ctx.SetDash([]float64{0, 0}, 0) }, cairo.StatusInvalidDash},
+		{"nil source", func(ctx cairo.Context) { ctx.SetSource(nil) }, cairo.StatusNullPointer},
+		{"PopGroup without group", func(ctx cairo.Context) { ctx.PopGroup().Destroy() }, cairo.StatusInvalidPopGroup},
+	}
+	for _, tc := range cases {
+		ctx := cairo.NewContext(surface)
+		tc.fail(ctx)
+		if ctx.Status() != tc.expected {
+			t.Errorf("%s: status = %v, expected %v", tc.name, ctx.Status(), tc.expected)
+		}
+
+		// 后续操作不执行，并返回携带该状态的错误
+		ctx.SetSourceRGB(1, 0, 0)
+		ctx.Rectangle(0, 0, 10, 10)
+		err := ctx.Fill()
+		if cerr, ok := err.(cairo.Error); !ok || cerr.Status != tc.expected {
+			t.Errorf("%s: Fill returned %v, expected an Error with %v", tc.name, err, tc.expected)
+		}
+		if ctx.Status() != tc.expected {
+			t.Errorf("%s: status changed to %v", tc.name, ctx.Status())
+		}
+		ctx.Destroy()
+	}
+
+	// 未绘制任何内容
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	if _, _, _, a := img.At(5, 5).RGBA(); a != 0 {
+		t.Error("a context in error drew to the surface")
+	}
+
+	// 正常绘制后状态保持成功
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	ctx.Save()
+	ctx.Scale(2, 2)
+	ctx.SetDash([]float64{2, 0}, 0)
+	ctx.Restore()
+	if err := ctx.Paint(); err != nil || ctx.Status() != cairo.StatusSuccess {
+		t.Errorf("valid operations failed: %v, %v", err, ctx.Status())
+	}
+}
+
 // 测试设置源颜色
 func TestContextSetSource(t *testing.T) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
@@ -180,3 +244,754 @@ func TestContextTransform(t *testing.T) {
 		t.Errorf("Rotation failed: XX=%f", matrix.XX)
 	}
 }
+
+// 测试透明度组：组内重叠区域不应被重复叠加透明度
+func TestContextOpacityGroup(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	// 白色背景
+	ctx.SetSourceRGB(1, 1, 1)
+	ctx.Paint()
+
+	ctx.PushOpacityGroup()
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.Rectangle(10, 10, 50, 50)
+	ctx.Fill()
+	ctx.Rectangle(40, 40, 50, 50)
+	ctx.Fill()
+	ctx.PopOpacityGroup(0.5)
+
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Fatalf("Context status after opacity group: %v", ctx.Status())
+	}
+	if ctx.GetTarget() != surface {
+		t.Fatal("PopOpacityGroup should restore the original target")
+	}
+
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	single, _, _, _ := img.At(20, 20).RGBA()
+	overlap, _, _, _ := img.At(50, 50).RGBA()
+	outside, _, _, _ := img.At(95, 5).RGBA()
+
+	if outside>>8 != 255 {
+		t.Errorf("Pixel outside the group should stay white, got %d", outside>>8)
+	}
+	if single>>8 < 120 || single>>8 > 136 {
+		t.Errorf("Single shape should be composited at 50%% gray, got %d", single>>8)
+	}
+	if overlap != single {
+		t.Errorf("Overlap should not be double-darkened: single=%d overlap=%d", single>>8, overlap>>8)
+	}
+}
+
+// 测试并行绘制图块：每个 goroutine 使用独立的 Context 和 Surface
+// 使用 go test -race 运行时不应报告数据竞争
+func TestContextParallelTiles(t *testing.T) {
+	const tiles = 4
+	surfaces := make([]cairo.Surface, tiles)
+	var wg sync.WaitGroup
+	for i := range surfaces {
+		surfaces[i] = cairo.NewImageSurface(cairo.FormatARGB32, 64, 64)
+		defer surfaces[i].Destroy()
+
+		wg.Add(1)
+		go func(surface cairo.Surface) {
+			defer wg.Done()
+			ctx := cairo.NewContext(surface)
+			defer ctx.Destroy()
+
+			for j := 0; j < 10; j++ {
+				ctx.SetSourceRGB(0, 0, 1)
+				ctx.MoveTo(4, 4)
+				ctx.LineTo(60, 4)
+				ctx.LineTo(32, 40)
+				ctx.ClosePath()
+				ctx.Fill()
+				ctx.Arc(32, 32, 20, 0, math.Pi)
+				ctx.Stroke()
+
+				// 文本渲染共享字体与字形缓存
+				ctx.MoveTo(4, 60)
+				layout := ctx.PangoCairoCreateLayout().(*cairo.PangoCairoLayout)
+				fontDesc := cairo.NewPangoFontDescription()
+				fontDesc.SetFamily("sans-serif")
+				fontDesc.SetSize(16)
+				layout.SetFontDescription(fontDesc)
+				layout.SetText("Tile")
+				ctx.PangoCairoShowText(layout)
+			}
+		}(surfaces[i])
+	}
+	wg.Wait()
+
+	for i, surface := range surfaces {
+		if _, _, _, a := surface.(cairo.ImageSurface).GetGoImage().At(32, 10).RGBA(); a == 0 {
+			t.Errorf("Tile %d was not drawn", i)
+		}
+	}
+}
+
+// drawPoster 绘制一个以填充为主的场景
+func drawPoster(ctx cairo.Context) {
+	w, h := 400.0, 300.0
+	grad := cairo.NewPatternLinear(0, 0, w, h)
+	grad.(cairo.LinearGradientPattern).AddColorStopRGB(0, 0.9, 0.8, 0.6)
+	grad.(cairo.LinearGradientPattern).AddColorStopRGB(1, 0.2, 0.3, 0.6)
+	ctx.SetSource(grad)
+	ctx.Paint()
+	grad.Destroy()
+
+	for i := 0; i < 12; i++ {
+		ctx.SetSourceRGBA(float64(i%3)/2, float64(i%4)/3, 0.5, 0.7)
+		ctx.Arc(w*float64(i+1)/13, h/2+40*math.Sin(float64(i)), 30+float64(i)*3, 0, 2*math.Pi)
+		ctx.Fill()
+	}
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.SetLineWidth(3)
+	ctx.MoveTo(10, 10)
+	ctx.CurveTo(100, 290, 300, 10, 390, 290)
+	ctx.Stroke()
+}
+
+// 测试分带并行渲染与单线程渲染结果一致
+func TestRenderTiled(t *testing.T) {
+	// 表面的设备缩放和偏移下分带也覆盖整个表面
+	transforms := []struct {
+		name           string
+		scale, offsetY float64
+	}{
+		{"identity", 1, 0},
+		{"scale 0.5", 0.5, 0},
+		{"offset 20", 1, 20},
+	}
+	// 先铺满背景，任何漏画的像素都会显出差异
+	draw := func(ctx cairo.Context) {
+		ctx.SetSourceRGB(0.9, 0.9, 0.8)
+		ctx.Paint()
+		drawPoster(ctx)
+	}
+	for _, tt := range transforms {
+		newSurface := func() cairo.Surface {
+			surface := cairo.NewImageSurface(cairo.FormatARGB32, 400, 300)
+			surface.SetDeviceScale(tt.scale, tt.scale)
+			surface.SetDeviceOffset(0, tt.offsetY)
+			return surface
+		}
+		want := newSurface()
+		ctx := cairo.NewContext(want)
+		draw(ctx)
+		ctx.Destroy()
+
+		for _, workers := range []int{1, 3, 8} {
+			got := newSurface()
+			if status := cairo.RenderTiled(got, workers, draw); status != cairo.StatusSuccess {
+				t.Fatalf("%s: RenderTiled with %d workers failed: %v", tt.name, workers, status)
+			}
+			wantImg := want.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+			gotImg := got.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+			if !bytes.Equal(wantImg.Pix, gotImg.Pix) {
+				t.Errorf("%s: RenderTiled with %d workers differs from a single context", tt.name, workers)
+			}
+			got.Destroy()
+		}
+		want.Destroy()
+	}
+}
+
+// 测试相同的输入在多次渲染中得到逐字节相同的像素
+func TestDeterministicRendering(t *testing.T) {
+	render := func() []byte {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 400, 300)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		drawPoster(ctx)
+
+		radial := cairo.NewPatternRadial(200, 150, 10, 200, 150, 120)
+		radial.(cairo.GradientPattern).AddColorStopRGBA(0, 1, 1, 1, 0.8)
+		radial.(cairo.GradientPattern).AddColorStopRGBA(1, 0, 0, 0, 0)
+		ctx.PushGroup()
+		ctx.SetSource(radial)
+		ctx.Paint()
+		ctx.PopGroupToSource()
+		ctx.PaintWithAlpha(0.6)
+		radial.Destroy()
+
+		ctx.SetSourceRGB(0.1, 0.1, 0.4)
+		ctx.SetDash([]float64{9, 3, 1, 3}, 2)
+		ctx.Rectangle(20.5, 20.5, 360, 260)
+		ctx.Stroke()
+
+		options := cairo.NewFontOptions()
+		for i := uint(0); i < 16; i++ {
+			options.SetCustomPaletteColor(i, float64(i)/16, 0, 0, 1)
+		}
+		ctx.SetFontOptions(options)
+		ctx.MoveTo(30, 250)
+		layout := ctx.PangoCairoCreateLayout().(*cairo.PangoCairoLayout)
+		fontDesc := cairo.NewPangoFontDescription()
+		fontDesc.SetFamily("sans-serif")
+		fontDesc.SetSize(28)
+		layout.SetFontDescription(fontDesc)
+		layout.SetText("Deterministic 确定")
+		ctx.PangoCairoShowText(layout)
+
+		if ctx.Status() != cairo.StatusSuccess {
+			t.Fatalf("Rendering failed: %v", ctx.Status())
+		}
+		img := surface.(cairo.ImageSurface)
+		img.Flush()
+		return append([]byte(nil), img.GetData()...)
+	}
+
+	want := render()
+	for i := 0; i < 3; i++ {
+		if !bytes.Equal(render(), want) {
+			t.Fatalf("Render %d differs from the first", i+2)
+		}
+	}
+}
+
+// 基准测试：分带并行渲染填充密集的场景
+func BenchmarkRenderTiled(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("Workers%d", workers), func(b *testing.B) {
+			surface := cairo.NewImageSurface(cairo.FormatARGB32, 400, 300)
+			defer surface.Destroy()
+			for i := 0; i < b.N; i++ {
+				cairo.RenderTiled(surface, workers, drawPoster)
+			}
+		})
+	}
+}
+
+// 测试用户数据的销毁回调：替换时和 Context 销毁时各调用一次
+func TestContextUserDataDestroy(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+
+	var key cairo.UserDataKey
+	first, second := 1, 2
+	calls := make(map[unsafe.Pointer]int)
+	destroy := func(data unsafe.Pointer) { calls[data]++ }
+
+	ctx.SetUserData(&key, unsafe.Pointer(&first), destroy)
+	ctx.SetUserData(&key, unsafe.Pointer(&second), destroy)
+	if calls[unsafe.Pointer(&first)] != 1 {
+		t.Errorf("Expected replaced data to be destroyed once, got %d", calls[unsafe.Pointer(&first)])
+	}
+	if got := ctx.GetUserData(&key); got != unsafe.Pointer(&second) {
+		t.Errorf("Expected the replacement data, got %v", got)
+	}
+
+	ctx.Destroy()
+	if calls[unsafe.Pointer(&second)] != 1 {
+		t.Errorf("Expected data to be destroyed once with the context, got %d", calls[unsafe.Pointer(&second)])
+	}
+	if calls[unsafe.Pointer(&first)] != 1 {
+		t.Errorf("Replaced data destroyed again: %d calls", calls[unsafe.Pointer(&first)])
+	}
+}
+
+// 测试显式销毁的 Context 不会在垃圾回收时再次释放目标表面
+func TestContextDestroyThenGC(t *testing.T) {
+	device := cairo.NewImageDevice()
+	defer device.Destroy()
+	surface := cairo.NewImageSurfaceForDevice(device, cairo.FormatARGB32, 20, 20)
+	defer surface.Destroy()
+	sub := surface.(cairo.ImageSurface).CreateForRectangle(0, 0, 10, 10)
+	defer sub.Destroy()
+	pdf := cairo.NewPDFSurface(filepath.Join(t.TempDir(), "out.pdf"), 20, 20)
+	defer pdf.Destroy()
+
+	var key cairo.UserDataKey
+	destroyed := 0
+	surface.SetUserData(&key, nil, func(unsafe.Pointer) { destroyed++ })
+
+	for _, target := range []cairo.Surface{surface, sub, pdf} {
+		ctx := cairo.NewContext(target)
+		ctx.Paint()
+		ctx.Destroy()
+	}
+
+	// 等待终结器运行：新对象的终结器执行时，之前排队的终结器也已执行
+	for i := 0; i < 3; i++ {
+		done := make(chan struct{})
+		runtime.SetFinalizer(new([16]byte), func(*[16]byte) { close(done) })
+		runtime.GC()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}
+
+	// 子表面持有父表面的一个引用，两个表面各持有设备的一个引用
+	counts := []struct {
+		name    string
+		surface cairo.Surface
+		want    int
+	}{
+		{"image", surface, 2},
+		{"subsurface", sub, 1},
+		{"PDF", pdf, 1},
+	}
+	for _, c := range counts {
+		if got := c.surface.GetReferenceCount(); got != c.want {
+			t.Errorf("Expected the %s surface to keep %d references, got %d", c.name, c.want, got)
+		}
+	}
+	if got := device.GetReferenceCount(); got != 3 {
+		t.Errorf("Expected the device to keep its references, got %d", got)
+	}
+	if destroyed != 0 {
+		t.Errorf("Expected the surface's user data to be kept, destroyed %d times", destroyed)
+	}
+	if pdf.Status() != cairo.StatusSuccess {
+		t.Errorf("Expected the PDF surface to stay usable, got %v", pdf.Status())
+	}
+}
+
+// 测试类型化用户数据：Context、Surface、Pattern 可保存任意 Go 值，且与 unsafe 接口互不混淆
+func TestUserDataAny(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	pattern := cairo.NewPatternRGB(1, 0, 0)
+	defer pattern.Destroy()
+
+	type meta struct{ name string }
+	var key cairo.UserDataKey
+	ctx.SetUserDataAny(&key, meta{"context"})
+	surface.SetUserDataAny(&key, 42)
+	pattern.SetUserDataAny(&key, "pattern")
+
+	if got, ok := ctx.GetUserDataAny(&key).(meta); !ok || got.name != "context" {
+		t.Errorf("context value = %v", ctx.GetUserDataAny(&key))
+	}
+	if got := surface.GetUserDataAny(&key); got != 42 {
+		t.Errorf("surface value = %v", got)
+	}
+	if got := pattern.GetUserDataAny(&key); got != "pattern" {
+		t.Errorf("pattern value = %v", got)
+	}
+	// 非指针值不会被当作 unsafe.Pointer 返回
+	if got := ctx.GetUserData(&key); got != nil {
+		t.Errorf("GetUserData returned %v for a typed value", got)
+	}
+
+	// 用 SetUserDataAny 替换指针数据时调用其销毁回调
+	var ptrKey cairo.UserDataKey
+	value, destroyed := 1, 0
+	ctx.SetUserData(&ptrKey, unsafe.Pointer(&value), func(unsafe.Pointer) { destroyed++ })
+	if ctx.GetUserDataAny(&ptrKey) != nil {
+		t.Error("GetUserDataAny returned pointer data")
+	}
+	ctx.SetUserDataAny(&ptrKey, "replaced")
+	if destroyed != 1 {
+		t.Errorf("replaced pointer data destroyed %d times, expected 1", destroyed)
+	}
+
+	// 存入 nil 删除该键
+	ctx.SetUserDataAny(&key, nil)
+	if ctx.GetUserDataAny(&key) != nil {
+		t.Error("value not removed")
+	}
+}
+
+// 测试 AntialiasNone 渲染硬边缘，默认模式渲染平滑边缘
+func TestAntialiasNoneHardEdges(t *testing.T) {
+	render := func(mode cairo.Antialias) image.Image {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 32, 32)
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+
+		ctx.SetAntialias(mode)
+		ctx.SetSourceRGB(0, 0, 0)
+		ctx.Rectangle(5.3, 5.3, 10.4, 10.4)
+		ctx.Fill()
+		ctx.SetLineWidth(1.5)
+		ctx.MoveTo(20.2, 4)
+		ctx.LineTo(20.2, 28)
+		ctx.Stroke()
+		return surface.(cairo.ImageSurface).GetGoImage()
+	}
+
+	countPartial := func(img image.Image) int {
+		partial := 0
+		b := img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if _, _, _, a := img.At(x, y).RGBA(); a != 0 && a != 0xffff {
+					partial++
+				}
+			}
+		}
+		return partial
+	}
+
+	if n := countPartial(render(cairo.AntialiasNone)); n != 0 {
+		t.Errorf("AntialiasNone produced %d partially covered pixels", n)
+	}
+	if n := countPartial(render(cairo.AntialiasDefault)); n == 0 {
+		t.Error("AntialiasDefault should antialias the edges")
+	}
+}
+
+// 测试嵌套矩形裁剪取交集：InClip、ClipExtents、CopyClipRectangleList 与实际绘制一致
+func TestNestedRectangularClips(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 50, 50)
+	defer surface.Destroy()
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	// 无裁剪时覆盖整个表面
+	if x1, y1, x2, y2 := ctx.ClipExtents(); x1 != 0 || y1 != 0 || x2 != 50 || y2 != 50 {
+		t.Errorf("unclipped extents = (%v, %v, %v, %v)", x1, y1, x2, y2)
+	}
+
+	// 两个重叠矩形的交集为 (10,10)-(30,30)
+	ctx.Save()
+	ctx.Rectangle(0, 0, 30, 30)
+	ctx.Clip()
+	ctx.Rectangle(10, 10, 30, 30)
+	ctx.Clip()
+	if x1, y1, x2, y2 := ctx.ClipExtents(); x1 != 10 || y1 != 10 || x2 != 30 || y2 != 30 {
+		t.Errorf("intersected extents = (%v, %v, %v, %v), expected (10, 10, 30, 30)", x1, y1, x2, y2)
+	}
+	list := ctx.CopyClipRectangleList()
+	if list.Status != cairo.StatusSuccess || list.NumRectangles != 1 ||
+		*list.Rectangles[0] != (cairo.Rectangle{X: 10, Y: 10, Width: 20, Height: 20}) {
+		t.Errorf("rectangle list = %+v", list)
+	}
+	if ctx.InClip(15, 15) != cairo.True || ctx.InClip(5, 5) != cairo.False || ctx.InClip(35, 35) != cairo.False {
+		t.Error("InClip disagrees with the intersected clip")
+	}
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Paint()
+	for _, p := range []struct {
+		x, y   int
+		inside bool
+	}{{10, 10, true}, {29, 29, true}, {9, 15, false}, {30, 15, false}, {5, 5, false}, {35, 35, false}} {
+		_, _, _, a := img.At(p.x, p.y).RGBA()
+		if (a != 0) != p.inside {
+			t.Errorf("pixel (%d, %d) alpha %d, inside = %v", p.x, p.y, a>>8, p.inside)
+		}
+	}
+	ctx.Restore()
+
+	// 不相交的矩形裁剪掉全部内容，之后的填充不改变表面
+	before := make([]byte, len(img.(*image.RGBA).Pix))
+	copy(before, img.(*image.RGBA).Pix)
+	ctx.Rectangle(0, 0, 10, 10)
+	ctx.Clip()
+	ctx.Rectangle(20, 20, 10, 10)
+	ctx.Clip()
+	ctx.SetSourceRGB(0, 0, 1)
+	ctx.Rectangle(0, 0, 50, 50)
+	ctx.Fill()
+	if !bytes.Equal(before, img.(*image.RGBA).Pix) {
+		t.Error("fill after disjoint clips changed the surface")
+	}
+	if x1, y1, x2, y2 := ctx.ClipExtents(); x1 != 0 || y1 != 0 || x2 != 0 || y2 != 0 {
+		t.Errorf("disjoint clip extents = (%v, %v, %v, %v), expected empty", x1, y1, x2, y2)
+	}
+	if list := ctx.CopyClipRectangleList(); list.Status != cairo.StatusSuccess || list.NumRectangles != 0 {
+		t.Errorf("disjoint clip rectangle list = %+v, expected empty", list)
+	}
+	if ctx.InClip(5, 5) != cairo.False || ctx.InClip(25, 25) != cairo.False {
+		t.Error("InClip reported a point inside disjoint clips")
+	}
+
+	// 非矩形裁剪无法表示为矩形列表
+	ctx.ResetClip()
+	ctx.Arc(25, 25, 10, 0, 2*math.Pi)
+	ctx.Clip()
+	if list := ctx.CopyClipRectangleList(); list.Status != cairo.StatusClipNotRepresentable {
+		t.Errorf("circle clip rectangle list status = %v", list.Status)
+	}
+}
+
+// 测试圆形裁剪的边缘随裁剪时的抗锯齿模式平滑或为硬边缘
+func TestClipAntialiasedEdges(t *testing.T) {
+	render := func(mode cairo.Antialias) image.Image {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 50, 50)
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+
+		// 裁剪使用裁剪时的抗锯齿模式，而不是绘制时的
+		ctx.SetAntialias(mode)
+		ctx.Arc(25, 25, 15.3, 0, 2*math.Pi)
+		ctx.Clip()
+		ctx.SetAntialias(cairo.AntialiasDefault)
+		ctx.SetSourceRGB(0, 0, 1)
+		ctx.Rectangle(0, 0, 50, 50)
+		ctx.Fill()
+		return surface.(cairo.ImageSurface).GetGoImage()
+	}
+
+	partial := func(img image.Image) int {
+		n := 0
+		for y := 0; y < 50; y++ {
+			for x := 0; x < 50; x++ {
+				if _, _, _, a := img.At(x, y).RGBA(); a != 0 && a != 0xffff {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	soft := render(cairo.AntialiasDefault)
+	// 半径 15.3 的圆周穿过近百个像素，它们应部分覆盖
+	if n := partial(soft); n < 40 {
+		t.Errorf("Expected an antialiased clip boundary, got %d partially covered pixels", n)
+	}
+	if _, _, _, a := soft.At(25, 25).RGBA(); a != 0xffff {
+		t.Errorf("Expected the clip center fully covered, got alpha %d", a>>8)
+	}
+	if _, _, _, a := soft.At(2, 2).RGBA(); a != 0 {
+		t.Errorf("Expected the corner clipped away, got alpha %d", a>>8)
+	}
+	if n := partial(render(cairo.AntialiasNone)); n != 0 {
+		t.Errorf("AntialiasNone clip produced %d partially covered pixels", n)
+	}
+}
+
+// 测试损坏区域跟踪：小填充得到小矩形，Paint 得到整个表面，ResetDamage 清空
+func TestContextDamage(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 60, 40)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	if d := ctx.GetDamage(); d.Width != 0 || d.Height != 0 {
+		t.Errorf("initial damage = %+v, expected empty", d)
+	}
+
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Rectangle(10, 5, 8, 6)
+	ctx.Fill()
+	if d := ctx.GetDamage(); d != (cairo.RectangleInt{X: 10, Y: 5, Width: 8, Height: 6}) {
+		t.Errorf("damage after small fill = %+v, expected {10 5 8 6}", d)
+	}
+
+	// 多次绘制累积为包围盒
+	ctx.MoveTo(40, 30)
+	ctx.LineTo(50, 30)
+	ctx.SetLineWidth(2)
+	ctx.Stroke()
+	if d := ctx.GetDamage(); d.X != 10 || d.Y != 5 || d.X+d.Width < 50 || d.Y+d.Height < 31 {
+		t.Errorf("accumulated damage = %+v", d)
+	}
+
+	ctx.ResetDamage()
+	if d := ctx.GetDamage(); d.Width != 0 || d.Height != 0 {
+		t.Errorf("damage after reset = %+v, expected empty", d)
+	}
+
+	// 在组内绘制，组绘制到目标后才计入
+	ctx.PushGroup()
+	ctx.Rectangle(0, 0, 5, 5)
+	ctx.Fill()
+	ctx.PopGroupToSource()
+	ctx.Paint()
+	if d := ctx.GetDamage(); d != (cairo.RectangleInt{X: 0, Y: 0, Width: 60, Height: 40}) {
+		t.Errorf("damage after paint = %+v, expected the whole surface", d)
+	}
+}
+
+// 测试组内 GetGroupTarget 返回组表面，GetTarget 始终返回原始目标
+func TestGetGroupTarget(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 40, 30)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	if ctx.GetGroupTarget() != surface {
+		t.Fatal("Expected the group target to be the target outside a group")
+	}
+
+	ctx.PushGroup()
+	group := ctx.GetGroupTarget()
+	if group == surface {
+		t.Fatal("Expected the group target to differ from the target inside a group")
+	}
+	if ctx.GetTarget() != surface {
+		t.Error("Expected GetTarget to return the original target inside a group")
+	}
+	if w := group.(cairo.ImageSurface).GetWidth(); w != 40 {
+		t.Errorf("Expected a group surface 40 pixels wide, got %d", w)
+	}
+
+	// 嵌套组返回最内层的组表面
+	ctx.PushGroup()
+	if inner := ctx.GetGroupTarget(); inner == group || inner == surface {
+		t.Error("Expected a nested group to have its own group target")
+	}
+	ctx.PopGroup().Destroy()
+	if ctx.GetGroupTarget() != group {
+		t.Error("Expected the outer group target after popping the nested group")
+	}
+
+	ctx.PopGroup().Destroy()
+	if ctx.GetGroupTarget() != surface {
+		t.Error("Expected the group target to be the original target after PopGroup")
+	}
+}
+
+// 测试组滤镜实现投影：模糊的黑色文字在清晰的彩色文字之后
+func TestPaintGroupWithFilterDropShadow(t *testing.T) {
+	render := func(blur float64) *image.RGBA {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 80)
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+
+		layout := ctx.PangoCairoCreateLayout().(*cairo.PangoCairoLayout)
+		fontDesc := cairo.NewPangoFontDescription()
+		fontDesc.SetFamily("sans")
+		fontDesc.SetSize(40)
+		layout.SetFontDescription(fontDesc)
+		layout.SetText("Shadow")
+
+		ctx.PushGroup()
+		ctx.SetSourceRGB(0, 0, 0)
+		ctx.MoveTo(14, 14)
+		ctx.PangoCairoShowText(layout)
+		ctx.PaintGroupWithFilter(func(img cairo.ImageSurface) {
+			img.BlurGaussian(blur)
+		})
+
+		ctx.SetSourceRGB(1, 0, 0)
+		ctx.MoveTo(10, 10)
+		ctx.PangoCairoShowText(layout)
+		if ctx.Status() != cairo.StatusSuccess {
+			t.Fatalf("Drawing failed: %v", ctx.Status())
+		}
+		return surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	}
+
+	sharp, shadowed := render(0), render(6)
+
+	// 模糊让阴影覆盖更多像素，且有半透明的黑色边缘
+	covered := func(img *image.RGBA) (n, soft int) {
+		for i := 0; i < len(img.Pix); i += 4 {
+			p := img.Pix[i : i+4]
+			if p[3] == 0 {
+				continue
+			}
+			n++
+			if p[3] < 0xff && p[0] == 0 && p[1] == 0 && p[2] == 0 {
+				soft++
+			}
+		}
+		return n, soft
+	}
+	sharpN, _ := covered(sharp)
+	shadowN, soft := covered(shadowed)
+	if shadowN <= sharpN {
+		t.Errorf("Expected the blurred shadow to cover more pixels: %d vs %d", shadowN, sharpN)
+	}
+	if soft == 0 {
+		t.Error("Expected translucent black pixels at the shadow's blurred edge")
+	}
+
+	// 彩色文字保持清晰，画在阴影之上
+	red := 0
+	for i := 0; i < len(shadowed.Pix); i += 4 {
+		if p := shadowed.Pix[i : i+4]; p[0] == 0xff && p[1] == 0 && p[2] == 0 && p[3] == 0xff {
+			red++
+		}
+	}
+	if red == 0 {
+		t.Error("Expected solid red text over the shadow")
+	}
+}
+
+// 测试 DrawImage / DrawImageScaled：按原尺寸和缩放绘制 Go 图像，遵循裁剪且不改变当前路径
+func TestDrawImage(t *testing.T) {
+	// 4x4 图像，四个象限分别为红、绿、蓝、半透明黑
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	quadrants := [4]color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {0, 0, 0, 128}}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			src.SetRGBA(x, y, quadrants[y/2*2+x/2])
+		}
+	}
+
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	ctx.SetSourceRGB(1, 1, 1)
+	ctx.Paint()
+
+	ctx.MoveTo(1, 2)
+	ctx.DrawImage(src, 10, 10)
+	if x, y := ctx.GetCurrentPoint(); x != 1 || y != 2 {
+		t.Errorf("Expected DrawImage to keep the current point, got (%v, %v)", x, y)
+	}
+	ctx.NewPath()
+
+	img := surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	checks := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{10, 10, color.RGBA{255, 0, 0, 255}},
+		{13, 11, color.RGBA{0, 255, 0, 255}},
+		{11, 13, color.RGBA{0, 0, 255, 255}},
+		{13, 13, color.RGBA{127, 127, 127, 255}},
+		{9, 10, color.RGBA{255, 255, 255, 255}},
+		{14, 14, color.RGBA{255, 255, 255, 255}},
+	}
+	for _, c := range checks {
+		if got := img.RGBAAt(c.x, c.y); !closeRGBA(got, c.want, 2) {
+			t.Errorf("DrawImage pixel (%d, %d) = %v, expected %v", c.x, c.y, got, c.want)
+		}
+	}
+
+	// 非 RGBA 图像按最近邻放大 4 倍，右半部分被裁剪
+	gray := image.NewGray(image.Rect(0, 0, 2, 1))
+	gray.Pix[0], gray.Pix[1] = 0, 200
+	nearest := cairo.NewPatternRGB(0, 0, 0)
+	nearest.SetFilter(cairo.FilterNearest)
+	ctx.SetSource(nearest)
+	nearest.Destroy()
+	ctx.Rectangle(0, 20, 24, 20)
+	ctx.Clip()
+	ctx.DrawImageScaled(gray, cairo.Rectangle{X: 20, Y: 24, Width: 8, Height: 8})
+	ctx.ResetClip()
+	if got := img.RGBAAt(21, 30); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("Scaled dark pixel = %v", got)
+	}
+	if got := img.RGBAAt(23, 25); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("Expected nearest filtering to keep a hard edge, got %v", got)
+	}
+	if got := img.RGBAAt(24, 26); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("Expected the clip to hide the right half, got %v", got)
+	}
+	if got := img.RGBAAt(20, 33); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("Expected nothing drawn below the destination, got %v", got)
+	}
+
+	ctx.DrawImageScaled(gray, cairo.Rectangle{X: 20, Y: 24, Width: 8, Height: 8})
+	if got := img.RGBAAt(26, 26); got != (color.RGBA{200, 200, 200, 255}) {
+		t.Errorf("Scaled light pixel = %v", got)
+	}
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Errorf("Context status: %v", ctx.Status())
+	}
+}
+
+// closeRGBA 判断两个颜色在每个通道上的差是否不超过 tolerance
+func closeRGBA(a, b color.RGBA, tolerance int) bool {
+	d := func(x, y uint8) bool { return int(x)-int(y) <= tolerance && int(y)-int(x) <= tolerance }
+	return d(a.R, b.R) && d(a.G, b.G) && d(a.B, b.B) && d(a.A, b.A)
+}
@@ -1,9 +1,18 @@
 package cairo
 
 import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/novvoo/go-cairo/pkg/cairo"
+	"golang.org/x/image/font/gofont/goregular"
 )
 
 // 测试 FontOptions 创建
@@ -48,6 +57,30 @@ func TestFontOptionsCopy(t *testing.T) {
 	}
 }
 
+// 测试 FontOptions 的哈希与自定义调色板的设置顺序无关且保持稳定
+func TestFontOptionsHashStable(t *testing.T) {
+	forward, backward := cairo.NewFontOptions(), cairo.NewFontOptions()
+	for i := uint(0); i < 32; i++ {
+		forward.SetCustomPaletteColor(i, float64(i)/32, 0.5, 0, 1)
+		j := 31 - i
+		backward.SetCustomPaletteColor(j, float64(j)/32, 0.5, 0, 1)
+	}
+	if !forward.Equal(backward) {
+		t.Fatal("Expected options with the same palette to be equal")
+	}
+	want := forward.Hash()
+	for i := 0; i < 20; i++ {
+		if forward.Hash() != want || backward.Hash() != want || forward.Copy().Hash() != want {
+			t.Fatal("Expected equal options to hash the same every time")
+		}
+	}
+
+	backward.SetCustomPaletteColor(7, 0, 0, 1, 1)
+	if backward.Hash() == want {
+		t.Error("Expected a changed palette entry to change the hash")
+	}
+}
+
 // 测试 FontExtents (跳过 - 需要完整的字体 API)
 func TestFontExtents(t *testing.T) {
 	t.Skip("FontExtents requires full font API implementation")
@@ -74,14 +107,427 @@ func TestTextExtents(t *testing.T) {
 	}
 }
 
-// 测试 SelectFontFace (跳过 - 需要完整的字体 API)
+// 测试 MeasureText 的墨迹与逻辑范围
+func TestMeasureText(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	text := "gjpqy"
+	ink, logical := ctx.MeasureText(text)
+
+	if ink.Height <= 0 {
+		t.Fatalf("Expected positive ink height, got %f", ink.Height)
+	}
+	if logical.Height < ink.Height {
+		t.Errorf("Logical height %f should be >= ink height %f", logical.Height, ink.Height)
+	}
+
+	shaped := ctx.TextExtents(text)
+	if logical.XAdvance != shaped.XAdvance || logical.Width != shaped.XAdvance {
+		t.Errorf("Logical advance %f/width %f should equal shaped advance %f",
+			logical.XAdvance, logical.Width, shaped.XAdvance)
+	}
+	if logical.XBearing != 0 {
+		t.Errorf("Expected logical x bearing 0, got %f", logical.XBearing)
+	}
+}
+
+// 测试 GlyphExtents 对字形墨迹框求并集并累加步进
+func TestGlyphExtents(t *testing.T) {
+	face := cairo.NewToyFontFace("sans-serif", cairo.FontSlantNormal, cairo.FontWeightNormal)
+	defer face.Destroy()
+	fontMatrix := cairo.NewMatrix()
+	fontMatrix.InitScale(40, 40)
+	scaled := cairo.NewScaledFont(face, fontMatrix, cairo.NewMatrix(), cairo.NewFontOptions())
+	defer scaled.Destroy()
+
+	glyphs, _, _, status := scaled.TextToGlyphs(0, 0, "Hi")
+	if status != cairo.StatusSuccess || len(glyphs) != 2 {
+		t.Fatalf("TextToGlyphs failed: status %v, %d glyphs", status, len(glyphs))
+	}
+
+	ext := scaled.GlyphExtents(glyphs)
+	if ext.Width <= 0 || ext.Height <= 0 {
+		t.Fatalf("Expected positive ink size, got %fx%f", ext.Width, ext.Height)
+	}
+	if ext.YBearing >= 0 {
+		t.Errorf("Expected glyphs above the baseline (negative y bearing), got %f", ext.YBearing)
+	}
+	if ext.XAdvance <= glyphs[1].X {
+		t.Errorf("Advance %f should extend past the last glyph origin %f", ext.XAdvance, glyphs[1].X)
+	}
+
+	single := scaled.GlyphExtents(glyphs[:1])
+	if ext.Width <= single.Width {
+		t.Errorf("Two glyphs should be wider than one: %f <= %f", ext.Width, single.Width)
+	}
+
+	// 平移字形不应改变相对于首字形原点的范围
+	moved := make([]cairo.Glyph, len(glyphs))
+	for i, g := range glyphs {
+		g.X += 100
+		g.Y += 50
+		moved[i] = g
+	}
+	if got := scaled.GlyphExtents(moved); *got != *ext {
+		t.Errorf("Extents changed after translating glyphs: %+v vs %+v", *got, *ext)
+	}
+}
+
+// 测试字距只应用一次：luxisr 使用 kern 表，Selawik 使用 GPOS
+func TestKerningAppliedOnce(t *testing.T) {
+	for _, path := range []string{"../resource/font/luxisr.ttf", "../resource/font/Selawik-VF-Subset.ttf"} {
+		face := cairo.NewToyFontFace(path, cairo.FontSlantNormal, cairo.FontWeightNormal)
+		fontMatrix := cairo.NewMatrix()
+		fontMatrix.InitScale(100, 100)
+		pango := cairo.NewPangoCairoScaledFont(face, fontMatrix, cairo.NewMatrix(), cairo.NewFontOptions())
+		toy := cairo.NewScaledFont(face, fontMatrix, cairo.NewMatrix(), cairo.NewFontOptions())
+
+		kerning, status := pango.GetKerning('A', 'V')
+		if status != cairo.StatusSuccess || kerning >= 0 {
+			t.Errorf("%s: expected negative A/V kerning, got %f (status %v)", path, kerning, status)
+		}
+		if toyKerning, _ := toy.GetKerning('A', 'V'); toyKerning != kerning {
+			t.Errorf("%s: kerning differs between scaled fonts: %f vs %f", path, toyKerning, kerning)
+		}
+		metrics, status := pango.GetGlyphMetrics('A')
+		if status != cairo.StatusSuccess {
+			t.Fatalf("%s: GetGlyphMetrics failed: %v", path, status)
+		}
+
+		// 无字距时的步进 + 一次字距 = 实际步进
+		want := metrics.XAdvance + kerning
+		for name, sf := range map[string]cairo.ScaledFont{"pango": pango, "toy": toy} {
+			glyphs, _, _, _ := sf.TextToGlyphs(0, 0, "AV")
+			if len(glyphs) != 2 {
+				t.Fatalf("%s/%s: expected 2 glyphs, got %d", path, name, len(glyphs))
+			}
+			if got := glyphs[1].X - glyphs[0].X; math.Abs(got-want) > 0.1 {
+				t.Errorf("%s/%s: AV advance %f, want %f (unkerned %f)", path, name, got, want, metrics.XAdvance)
+			}
+		}
+
+		toy.Destroy()
+		pango.Destroy()
+		face.Destroy()
+	}
+}
+
+// 测试可变字体轴 (wght)
+func TestFontFaceVariations(t *testing.T) {
+	face := cairo.NewToyFontFace("../resource/font/Selawik-VF-Subset.ttf", cairo.FontSlantNormal, cairo.FontWeightNormal)
+	defer face.Destroy()
+
+	vf, ok := face.(cairo.VariableFontFace)
+	if !ok {
+		t.Fatal("Toy font face does not implement VariableFontFace")
+	}
+
+	advance := func(settings string) float64 {
+		if status := vf.SetVariationsString(settings); status != cairo.StatusSuccess {
+			t.Fatalf("SetVariationsString(%q) failed: %v", settings, status)
+		}
+		fontMatrix := cairo.NewMatrix()
+		fontMatrix.InitScale(50, 50)
+		scaled := cairo.NewScaledFont(face, fontMatrix, cairo.NewMatrix(), cairo.NewFontOptions())
+		defer scaled.Destroy()
+		return scaled.TextExtents("a").XAdvance
+	}
+
+	thin := advance("wght=100")
+	black := advance("wght=900")
+	if thin == black {
+		t.Errorf("Expected advance to change with wght, got %f for both", thin)
+	}
+	if got := vf.GetVariations()["wght"]; got != 900 {
+		t.Errorf("Expected wght 900, got %f", got)
+	}
+
+	if status := vf.SetVariationsString("wght"); status != cairo.StatusInvalidString {
+		t.Errorf("Expected StatusInvalidString for malformed settings, got %v", status)
+	}
+}
+
+// renderColorFontText 使用彩色测试字体渲染文本并统计不同颜色数量
+func renderColorFontText(t *testing.T, fontPath, text string, mode cairo.ColorMode) int {
+	t.Helper()
+
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SetSourceRGB(1, 1, 1)
+	ctx.Paint()
+
+	options := cairo.NewFontOptions()
+	options.SetColorMode(mode)
+	ctx.SetFontOptions(options)
+
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.MoveTo(10, 70)
+	layout := ctx.PangoCairoCreateLayout().(*cairo.PangoCairoLayout)
+	fontDesc := cairo.NewPangoFontDescription()
+	fontDesc.SetFamily(fontPath)
+	fontDesc.SetSize(60)
+	layout.SetFontDescription(fontDesc)
+	layout.SetText(text)
+	ctx.PangoCairoShowText(layout)
+
+	return countPureColors(surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA))
+}
+
+// countPureColors 统计白色背景上各通道为 0 或 255 的不同颜色数
+func countPureColors(img *image.RGBA) int {
+	colors := make(map[color.RGBA]bool)
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			c := img.RGBAAt(x, y)
+			// 只统计完全覆盖的像素，忽略抗锯齿边缘
+			if c == (color.RGBA{255, 255, 255, 255}) || c.A != 255 {
+				continue
+			}
+			if (c.R == 0 || c.R == 255) && (c.G == 0 || c.G == 255) && (c.B == 0 || c.B == 255) {
+				colors[c] = true
+			}
+		}
+	}
+	return len(colors)
+}
+
+// 测试 COLR/CPAL 彩色字形渲染
+func TestColorGlyphRendering(t *testing.T) {
+	// 'A' 使用 COLRv0 图层，'B' 使用 COLRv1 PaintColrLayers
+	for _, text := range []string{"A", "B"} {
+		if n := renderColorFontText(t, "../resource/font/GoColorTest.ttf", text, cairo.ColorModeColor); n < 2 {
+			t.Errorf("Expected color glyph %q to render more than one color, got %d", text, n)
+		}
+		if n := renderColorFontText(t, "../resource/font/GoColorTest.ttf", text, cairo.ColorModeNoColor); n != 1 {
+			t.Errorf("Expected monochrome glyph %q with ColorModeNoColor, got %d colors", text, n)
+		}
+	}
+}
+
+// 测试不经过 PangoCairo 的 ShowGlyphs 和 ShowTextDecorated 同样绘制彩色字形
+func TestColorGlyphShowGlyphs(t *testing.T) {
+	render := func(text string, mode cairo.ColorMode, show func(ctx cairo.Context, text string)) int {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 100)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+
+		ctx.SetSourceRGB(1, 1, 1)
+		ctx.Paint()
+		options := cairo.NewFontOptions()
+		options.SetColorMode(mode)
+		ctx.SetFontOptions(options)
+		ctx.SelectFontFace("../resource/font/GoColorTest.ttf", cairo.FontSlantNormal, cairo.FontWeightNormal)
+		ctx.SetFontSize(60)
+		ctx.SetSourceRGB(0, 0, 0)
+		ctx.MoveTo(10, 70)
+		show(ctx, text)
+		if ctx.Status() != cairo.StatusSuccess {
+			t.Fatalf("Drawing %q failed: %v", text, ctx.Status())
+		}
+		return countPureColors(surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA))
+	}
+	showGlyphs := func(ctx cairo.Context, text string) {
+		x, y := ctx.GetCurrentPoint()
+		glyphs, _, _, status := ctx.TextToGlyphs(x, y, text)
+		if status != cairo.StatusSuccess {
+			t.Fatalf("TextToGlyphs failed: %v", status)
+		}
+		ctx.ShowGlyphs(glyphs)
+	}
+	showDecorated := func(ctx cairo.Context, text string) {
+		ctx.ShowTextDecorated(text, cairo.TextDecorationNone)
+	}
+
+	for _, text := range []string{"A", "B"} {
+		if n := render(text, cairo.ColorModeColor, showGlyphs); n < 2 {
+			t.Errorf("Expected ShowGlyphs to draw color glyph %q in more than one color, got %d", text, n)
+		}
+		if n := render(text, cairo.ColorModeColor, showDecorated); n < 2 {
+			t.Errorf("Expected ShowTextDecorated to draw color glyph %q in more than one color, got %d", text, n)
+		}
+		if n := render(text, cairo.ColorModeNoColor, showGlyphs); n != 1 {
+			t.Errorf("Expected monochrome glyph %q with ColorModeNoColor, got %d colors", text, n)
+		}
+	}
+}
+
+// 测试 COLRv1 图层列表引用自身时不会无限递归，字形退回单色渲染
+func TestColorGlyphCyclicPaint(t *testing.T) {
+	data, err := os.ReadFile("../resource/font/GoColorTest.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var colr []byte
+	for i := 0; i < int(binary.BigEndian.Uint16(data[4:])); i++ {
+		record := data[12+16*i:]
+		if string(record[:4]) == "COLR" {
+			offset := binary.BigEndian.Uint32(record[8:])
+			colr = data[offset : offset+binary.BigEndian.Uint32(record[12:])]
+		}
+	}
+	if colr == nil {
+		t.Fatal("font has no COLR table")
+	}
+
+	// 把 'B' 的第一个图层改为 PaintColrLayers，引用包含它自身的图层列表
+	layerList := binary.BigEndian.Uint32(colr[18:])
+	layer := layerList + binary.BigEndian.Uint32(colr[layerList+4:])
+	colr[layer] = 1
+	colr[layer+1] = 2
+	binary.BigEndian.PutUint32(colr[layer+2:], 0)
+
+	path := filepath.Join(t.TempDir(), "cyclic.ttf")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if n := renderColorFontText(t, path, "B", cairo.ColorModeColor); n != 1 {
+		t.Errorf("Expected cyclic color glyph to render in one color, got %d", n)
+	}
+	if n := renderColorFontText(t, path, "A", cairo.ColorModeColor); n < 2 {
+		t.Errorf("Expected COLRv0 glyph to keep its colors, got %d", n)
+	}
+}
+
+// 测试 CBDT 内嵌位图字形渲染
+func TestBitmapGlyphRendering(t *testing.T) {
+	const font = "../resource/font/GoBitmapTest.ttf"
+	// 位图左半为红色，右半为蓝色，按字号进行双线性缩放
+	if n := renderColorFontText(t, font, "A", cairo.ColorModeColor); n < 2 {
+		t.Errorf("Expected bitmap glyph to render more than one color, got %d", n)
+	}
+	if n := renderColorFontText(t, font, "A", cairo.ColorModeNoColor); n != 1 {
+		t.Errorf("Expected outline glyph with ColorModeNoColor, got %d colors", n)
+	}
+}
+
+// renderMissingGlyph 使用给定样式渲染一个私有区码位
+func renderMissingGlyph(t *testing.T, style cairo.MissingGlyphStyle) *image.RGBA {
+	t.Helper()
+
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	t.Cleanup(surface.Destroy)
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SetSourceRGB(1, 1, 1)
+	ctx.Paint()
+
+	ctx.SetMissingGlyphStyle(style)
+	if ctx.GetMissingGlyphStyle() != style {
+		t.Fatalf("Expected missing glyph style %v, got %v", style, ctx.GetMissingGlyphStyle())
+	}
+
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.MoveTo(10, 70)
+	layout := ctx.PangoCairoCreateLayout().(*cairo.PangoCairoLayout)
+	fontDesc := cairo.NewPangoFontDescription()
+	fontDesc.SetFamily("sans")
+	fontDesc.SetSize(60)
+	layout.SetFontDescription(fontDesc)
+	layout.SetText("\uE000")
+	ctx.PangoCairoShowText(layout)
+
+	return surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+}
+
+// 测试缺失字形的占位符样式
+func TestMissingGlyphStyle(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+
+	// Box: 边框为黑色，内部保持空白
+	img := renderMissingGlyph(t, cairo.MissingGlyphStyleBox)
+	// 盒子约为 x=[13.3,49.6], y=[20.5,70]，边框宽约 3.3 像素
+	for _, p := range []image.Point{{14, 45}, {48, 45}, {30, 21}, {30, 68}} {
+		if c := img.RGBAAt(p.X, p.Y); c.R > 64 {
+			t.Errorf("Expected box outline at %v, got %v", p, c)
+		}
+	}
+	if c := img.RGBAAt(30, 45); c != white {
+		t.Errorf("Expected empty box interior at (30,45), got %v", c)
+	}
+
+	// Hex: 盒子内部绘制码位数字
+	img = renderMissingGlyph(t, cairo.MissingGlyphStyleHex)
+	inked := false
+	for y := 25; y < 66 && !inked; y++ {
+		for x := 18; x < 45; x++ {
+			if img.RGBAAt(x, y).R < 64 {
+				inked = true
+				break
+			}
+		}
+	}
+	if !inked {
+		t.Error("Expected hex digits inside the box")
+	}
+
+	// Blank: 不绘制任何内容
+	img = renderMissingGlyph(t, cairo.MissingGlyphStyleBlank)
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if c := img.RGBAAt(x, y); c != white {
+				t.Fatalf("Expected nothing drawn with blank style, got %v at (%d,%d)", c, x, y)
+			}
+		}
+	}
+}
+
+// 测试 SelectFontFace 替换当前字体
 func TestSelectFontFace(t *testing.T) {
-	t.Skip("SelectFontFace requires full font API implementation")
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SelectFontFace("../resource/font/luxisr.ttf", cairo.FontSlantNormal, cairo.FontWeightNormal)
+	regular := ctx.GetFontFace()
+	defer regular.Destroy()
+	ctx.SelectFontFace("sans", cairo.FontSlantItalic, cairo.FontWeightBold)
+	bold := ctx.GetFontFace()
+	defer bold.Destroy()
+
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Fatalf("status = %v", ctx.Status())
+	}
+	if regular == bold || bold.Status() != cairo.StatusSuccess {
+		t.Error("SelectFontFace should replace the current font face")
+	}
 }
 
-// 测试 SetFontSize (跳过 - 需要完整的字体 API)
+// 测试 SetFontSize 改变字号后字形按比例缩放
 func TestSetFontSize(t *testing.T) {
-	t.Skip("SetFontSize requires full font API implementation")
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	advance := func() float64 {
+		glyphs, _, _, status := ctx.TextToGlyphs(0, 0, "MM")
+		if status != cairo.StatusSuccess || len(glyphs) != 2 {
+			t.Fatalf("TextToGlyphs: %v, %d glyphs", status, len(glyphs))
+		}
+		return glyphs[1].X
+	}
+	ctx.SetFontSize(10)
+	small := advance()
+	ctx.SetFontSize(20)
+	large := advance()
+	if small <= 0 || math.Abs(large-2*small) > 0.5 {
+		t.Errorf("advance at size 20 = %v, want twice %v", large, small)
+	}
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Errorf("status = %v", ctx.Status())
+	}
 }
 
 // 测试 ShowText (跳过 - 需要完整的字体 API)
@@ -115,7 +561,1002 @@ func BenchmarkTextExtents(b *testing.B) {
 	}
 }
 
-// 基准测试：ShowText (跳过 - 需要完整的字体 API)
+// 基准测试：重复测量同一标签，对比有无整形缓存
+func BenchmarkTextExtentsShapingCache(b *testing.B) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	label := "Revenue Q3 2024: 1,234,567.89"
+
+	b.Run("Uncached", func(b *testing.B) {
+		cairo.SetShapingCacheLimit(0)
+		defer cairo.SetShapingCacheLimit(cairo.DefaultShapingCacheLimit)
+		for i := 0; i < b.N; i++ {
+			ctx.TextExtents(label)
+		}
+	})
+	b.Run("Cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ctx.TextExtents(label)
+		}
+	})
+}
+
+// 基准测试：重复绘制同一段文本 (字形轮廓与度量缓存)
 func BenchmarkShowText(b *testing.B) {
-	b.Skip("ShowText requires full font API implementation")
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 400, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	layout := ctx.PangoCairoCreateLayout().(*cairo.PangoCairoLayout)
+	fontDesc := cairo.NewPangoFontDescription()
+	fontDesc.SetFamily("sans")
+	fontDesc.SetSize(24)
+	layout.SetFontDescription(fontDesc)
+	layout.SetText("the quick brown fox jumps over the lazy dog")
+
+	ctx.SetSourceRGB(0, 0, 0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.MoveTo(10, 50)
+		ctx.PangoCairoShowText(layout)
+	}
+}
+
+// 测试 Context.TextToGlyphs 返回用户空间坐标与簇信息
+func TestContextTextToGlyphs(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	// CTM 不应影响返回的用户空间坐标
+	ctx.Translate(50, 0)
+	ctx.Scale(2, 2)
+
+	glyphs, clusters, _, status := ctx.TextToGlyphs(10, 20, "abc")
+	if status != cairo.StatusSuccess {
+		t.Fatalf("TextToGlyphs failed: %v", status)
+	}
+	if len(glyphs) != 3 || len(clusters) != 3 {
+		t.Fatalf("Expected 3 glyphs and 3 clusters, got %d and %d", len(glyphs), len(clusters))
+	}
+	if glyphs[0].X != 10 || glyphs[0].Y != 20 {
+		t.Errorf("Expected first glyph at (10, 20), got (%f, %f)", glyphs[0].X, glyphs[0].Y)
+	}
+	for i := 1; i < len(glyphs); i++ {
+		if glyphs[i].X <= glyphs[i-1].X || glyphs[i].Y != 20 {
+			t.Errorf("Glyph %d at (%f, %f) does not follow glyph %d", i, glyphs[i].X, glyphs[i].Y, i-1)
+		}
+	}
+
+	sf := ctx.GetScaledFont()
+	defer sf.Destroy()
+	direct, _, _, _ := sf.TextToGlyphs(10, 20, "abc")
+	for i := range direct {
+		if direct[i] != glyphs[i] {
+			t.Errorf("Glyph %d differs from scaled font result: %+v vs %+v", i, glyphs[i], direct[i])
+		}
+	}
+}
+
+// 测试 ShowTextDecorated：下划线是基线下方横跨文本步进的矩形
+func TestShowTextDecorated(t *testing.T) {
+	// fullRows 返回整行 [x0, x1) 都被覆盖的像素行
+	fullRows := func(surface cairo.Surface, x0, x1 int) []int {
+		img := surface.(cairo.ImageSurface).GetGoImage()
+		var rows []int
+		for py := 0; py < img.Bounds().Dy(); py++ {
+			full := true
+			for px := x0; px < x1 && full; px++ {
+				_, _, _, a := img.At(px, py).RGBA()
+				full = a != 0
+			}
+			if full {
+				rows = append(rows, py)
+			}
+		}
+		return rows
+	}
+	draw := func(decoration cairo.TextDecoration) (cairo.Surface, float64) {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 300, 100)
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		fontMatrix := cairo.NewMatrix()
+		fontMatrix.InitScale(24, 24)
+		ctx.SetFontMatrix(fontMatrix)
+		ctx.SetSourceRGB(0, 0, 0)
+		ctx.MoveTo(10, 50)
+		ctx.ShowTextDecorated("Hello world", decoration)
+		if ctx.Status() != cairo.StatusSuccess {
+			t.Fatalf("ShowTextDecorated failed: %v", ctx.Status())
+		}
+		endX, endY := ctx.GetCurrentPoint()
+		if endY != 50 || endX <= 10 {
+			t.Errorf("Expected the current point after the text on the baseline, got (%f, %f)", endX, endY)
+		}
+		return surface, endX
+	}
+
+	plain, _ := draw(cairo.TextDecorationNone)
+	defer plain.Destroy()
+	if rows := fullRows(plain, 11, 100); len(rows) != 0 {
+		t.Fatalf("Expected no line across undecorated text, got rows %v", rows)
+	}
+
+	underlined, endX := draw(cairo.TextDecorationUnderline)
+	defer underlined.Destroy()
+	rows := fullRows(underlined, 10, int(endX))
+	if len(rows) == 0 {
+		t.Fatal("Expected an underline spanning the text advance")
+	}
+	for _, row := range rows {
+		if row < 50 || row > 58 {
+			t.Errorf("Expected the underline just below the baseline, found row %d", row)
+		}
+	}
+	img := underlined.(cairo.ImageSurface).GetGoImage()
+	for _, px := range []int{8, int(endX) + 2} {
+		if _, _, _, a := img.At(px, rows[0]).RGBA(); a != 0 {
+			t.Errorf("Expected the underline to end with the advance, pixel %d is covered", px)
+		}
+	}
+
+	// 删除线穿过小写字母中部，上划线位于上升部
+	both, endX := draw(cairo.TextDecorationStrikethrough | cairo.TextDecorationOverline)
+	defer both.Destroy()
+	rows = fullRows(both, 10, int(endX))
+	var strike, over bool
+	for _, row := range rows {
+		switch {
+		case row >= 40 && row < 50:
+			strike = true
+		case row >= 20 && row < 40:
+			over = true
+		default:
+			t.Errorf("Unexpected line at row %d", row)
+		}
+	}
+	if !strike || !over {
+		t.Errorf("Expected a strikethrough and an overline, got rows %v", rows)
+	}
+}
+
+// 测试下划线与删除线度量取自字体的 post 与 OS/2 表 (Go Regular:
+// upem 2048，上升 1935，下划线 -275/50，删除线 512/102)
+func TestFontLineMetrics(t *testing.T) {
+	font := cairo.NewPangoCairoFont("Go", cairo.FontSlantNormal, cairo.FontWeightNormal)
+	defer font.Destroy()
+
+	// 字号等于 upem 时度量即为字体单位
+	fm := font.GetMetrics(2048)
+	for _, m := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"ascent", fm.GetAscent(), 1935},
+		{"descent", fm.GetDescent(), 432},
+		{"underline position", fm.GetUnderlinePosition(), -275},
+		{"underline thickness", fm.GetUnderlineThickness(), 50},
+		{"strikethrough position", fm.GetStrikethroughPosition(), 512},
+		{"strikethrough thickness", fm.GetStrikethroughThickness(), 102},
+	} {
+		if math.Abs(m.got-m.want) > 1e-9 {
+			t.Errorf("Expected %s %v, got %v", m.name, m.want, m.got)
+		}
+	}
+	if half := font.GetMetrics(1024); math.Abs(half.GetStrikethroughPosition()-256) > 1e-9 {
+		t.Errorf("Expected metrics to scale with size, got strikethrough at %v", half.GetStrikethroughPosition())
+	}
+
+	// FontExtents 与上升高度的比例与字体一致
+	face, err := cairo.NewFontFaceFromBytes(goregular.TTF)
+	if err != nil {
+		t.Fatalf("NewFontFaceFromBytes failed: %v", err)
+	}
+	defer face.Destroy()
+	sf := cairo.NewScaledFont(face, cairo.NewMatrix(), cairo.NewMatrix(), nil)
+	defer sf.Destroy()
+	fe := sf.Extents()
+	for _, m := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"underline position", fe.UnderlinePosition, -275},
+		{"underline thickness", fe.UnderlineThickness, 50},
+		{"strikethrough position", fe.StrikethroughPosition, 512},
+		{"strikethrough thickness", fe.StrikethroughThickness, 102},
+	} {
+		if got := m.got / fe.Ascent; math.Abs(got-m.want/1935) > 1e-9 {
+			t.Errorf("Expected %s to be %v of the ascent, got %v", m.name, m.want/1935, got)
+		}
+	}
+}
+
+// 测试大写高度与 x 高度：优先取 OS/2 表声明的值，缺失时测量 'H' 与 'x' 字形
+func TestFontCapAndXHeight(t *testing.T) {
+	// Go Regular 声明上升 1935、大写高度 1480、x 高度 1086
+	face, err := cairo.NewFontFaceFromBytes(goregular.TTF)
+	if err != nil {
+		t.Fatalf("NewFontFaceFromBytes failed: %v", err)
+	}
+	defer face.Destroy()
+	sf := cairo.NewScaledFont(face, cairo.NewMatrix(), cairo.NewMatrix(), nil)
+	defer sf.Destroy()
+	fe := sf.Extents()
+	if got := fe.CapHeight / fe.Ascent; math.Abs(got-1480.0/1935) > 1e-6 {
+		t.Errorf("Expected cap height %v of the ascent, got %v", 1480.0/1935, got)
+	}
+	if got := fe.XHeight / fe.Ascent; math.Abs(got-1086.0/1935) > 1e-6 {
+		t.Errorf("Expected x-height %v of the ascent, got %v", 1086.0/1935, got)
+	}
+
+	// luxisr 的 OS/2 表未声明这两个值，应与字形墨迹顶部一致
+	luxi := cairo.NewToyFontFace("../resource/font/luxisr.ttf", cairo.FontSlantNormal, cairo.FontWeightNormal)
+	defer luxi.Destroy()
+	lsf := cairo.NewScaledFont(luxi, cairo.NewMatrix(), cairo.NewMatrix(), nil)
+	defer lsf.Destroy()
+	fe = lsf.Extents()
+	for _, m := range []struct {
+		name, glyph string
+		got, ratio  float64
+	}{
+		{"cap height", "H", fe.CapHeight, 0.7},
+		{"x-height", "x", fe.XHeight, 0.5},
+	} {
+		top := -lsf.TextExtents(m.glyph).YBearing
+		if math.Abs(m.got-top) > 0.05 {
+			t.Errorf("Expected %s %v measured from %q, got %v", m.name, top, m.glyph, m.got)
+		}
+		if m.got == fe.Ascent*m.ratio {
+			t.Errorf("Expected a measured %s rather than the ascent ratio", m.name)
+		}
+	}
+}
+
+// 测试两端对齐：除段落末行外，每行最后一个字形到达布局右边缘
+func TestLayoutJustify(t *testing.T) {
+	const left, width = 10, 200
+	text := "Justified text spreads the space left on each wrapped line between " +
+		"its words, so that every line but the last one of a paragraph ends " +
+		"exactly at the right edge of the layout."
+
+	// inkBands 返回每个文字行带最右侧着墨像素的横坐标
+	inkBands := func(justify bool, layoutWidth int) (int, []int) {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 300, 300)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+
+		layout := ctx.PangoCairoCreateLayout().(*cairo.PangoCairoLayout)
+		fontDesc := cairo.NewPangoFontDescription()
+		fontDesc.SetFamily("sans")
+		fontDesc.SetSize(14)
+		layout.SetFontDescription(fontDesc)
+		layout.SetWidth(layoutWidth * 1024)
+		layout.SetJustify(justify)
+		layout.SetText(text)
+
+		ctx.SetSourceRGB(0, 0, 0)
+		ctx.MoveTo(left, 20)
+		ctx.PangoCairoShowText(layout)
+		if ctx.Status() != cairo.StatusSuccess {
+			t.Fatalf("PangoCairoShowText failed: %v", ctx.Status())
+		}
+
+		img := surface.(cairo.ImageSurface).GetGoImage()
+		var rights []int
+		inBand := false
+		for y := 0; y < 300; y++ {
+			right := -1
+			for x := 0; x < 300; x++ {
+				if _, _, _, a := img.At(x, y).RGBA(); a != 0 {
+					right = x
+				}
+			}
+			switch {
+			case right >= 0 && !inBand:
+				rights = append(rights, right)
+				inBand = true
+			case right >= 0:
+				rights[len(rights)-1] = max(rights[len(rights)-1], right)
+			default:
+				inBand = false
+			}
+		}
+		return layout.GetLineCount(), rights
+	}
+
+	lines, justified := inkBands(true, width)
+	if lines < 3 || len(justified) != lines {
+		t.Fatalf("Expected the paragraph to wrap into several separate lines, got %d lines and %d ink bands", lines, len(justified))
+	}
+	for i, right := range justified[:lines-1] {
+		// 允许末字形的右侧留白
+		if right < left+width-4 || right > left+width {
+			t.Errorf("Line %d ends at %d, expected the right edge %d", i, right, left+width)
+		}
+	}
+	if last := justified[lines-1]; last > left+width {
+		t.Errorf("Expected the last line to stay within the width, it ends at %d", last)
+	}
+
+	// 不对齐时至少有一行明显短于宽度，末行保持自然宽度
+	_, ragged := inkBands(false, width)
+	short := false
+	for _, right := range ragged[:lines-1] {
+		short = short || right < left+width-4
+	}
+	if !short {
+		t.Error("Expected ragged lines to fall short of the edge without justification")
+	}
+	if ragged[lines-1] != justified[lines-1] {
+		t.Errorf("Expected the last line not to be stretched, ends at %d instead of %d", justified[lines-1], ragged[lines-1])
+	}
+
+	// 每行只有一个单词时不拉伸，也不出错
+	lines, _ = inkBands(true, 20)
+	if lines != len(strings.Fields(text)) {
+		t.Errorf("Expected one word per line in a narrow layout, got %d lines", lines)
+	}
+}
+
+// 测试制表位：制表符把笔位置移到下一个制表位，已越过的制表位被跳过
+func TestLayoutTabs(t *testing.T) {
+	const left = 10
+
+	// inkStarts 返回每段连续着墨列的起始横坐标
+	inkStarts := func(text string, tabs []float64) []int {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 300, 60)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+
+		layout := ctx.PangoCairoCreateLayout().(*cairo.PangoCairoLayout)
+		fontDesc := cairo.NewPangoFontDescription()
+		fontDesc.SetFamily("sans")
+		fontDesc.SetSize(14)
+		layout.SetFontDescription(fontDesc)
+		layout.SetTabs(tabs)
+		layout.SetText(text)
+
+		ctx.SetSourceRGB(0, 0, 0)
+		ctx.MoveTo(left, 30)
+		ctx.PangoCairoShowText(layout)
+		if ctx.Status() != cairo.StatusSuccess {
+			t.Fatalf("PangoCairoShowText failed: %v", ctx.Status())
+		}
+
+		img := surface.(cairo.ImageSurface).GetGoImage()
+		var starts []int
+		inked := false
+		for x := 0; x < 300; x++ {
+			column := false
+			for y := 0; y < 60 && !column; y++ {
+				_, _, _, a := img.At(x, y).RGBA()
+				column = a != 0
+			}
+			if column && !inked {
+				starts = append(starts, x)
+			}
+			inked = column
+		}
+		return starts
+	}
+
+	// 字形左侧留白只有一两个像素
+	near := func(got, want int) bool {
+		return got >= want-1 && got <= want+4
+	}
+
+	starts := inkStarts("a\tb\tc", []float64{100, 50})
+	if len(starts) != 3 || !near(starts[1], left+50) || !near(starts[2], left+100) {
+		t.Errorf("Expected b at %d and c at %d, columns start at %v", left+50, left+100, starts)
+	}
+
+	// 超过第一个制表位的文本跳到下一个，最后一个之后按最后间隔继续
+	starts = inkStarts("mmmmmm\tb\tc", []float64{50, 100})
+	if n := len(starts); n < 3 || !near(starts[n-2], left+100) || !near(starts[n-1], left+150) {
+		t.Errorf("Expected b at %d and c at %d, columns start at %v", left+100, left+150, starts)
+	}
+
+	// 未设置制表位时使用等间距的默认制表位
+	starts = inkStarts("a\tb\tc", nil)
+	if len(starts) != 3 || starts[2]-starts[1] < 20 {
+		t.Fatalf("Expected default tab stops to separate the columns, columns start at %v", starts)
+	}
+	if d1, d2 := starts[1]-left, starts[2]-starts[1]; d1-d2 > 2 || d2-d1 > 2 {
+		t.Errorf("Expected evenly spaced default tab stops, got %d and %d", d1, d2)
+	}
+
+	layout := cairo.NewPangoCairoLayout(nil)
+	layout.SetTabs([]float64{100, -5, 50})
+	if tabs := layout.GetTabs(); len(tabs) != 2 || tabs[0] != 50 || tabs[1] != 100 {
+		t.Errorf("Expected sorted positive tab stops, got %v", tabs)
+	}
+}
+
+// 测试等宽单元格绘制：每个字符居中于固定宽度的单元格，宽字符占两格
+func TestShowTextMonospace(t *testing.T) {
+	const left, cell = 10, 20
+
+	// inkCenters 返回每段连续着墨列的中心横坐标和绘制后的当前点
+	inkCenters := func(text string) ([]float64, float64) {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 300, 60)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		fontMatrix := cairo.NewMatrix()
+		fontMatrix.InitScale(20, 20)
+		ctx.SetFontMatrix(fontMatrix)
+		ctx.SetSourceRGB(0, 0, 0)
+		ctx.MoveTo(left, 40)
+		ctx.ShowTextMonospace(text, cell)
+		if ctx.Status() != cairo.StatusSuccess {
+			t.Fatalf("ShowTextMonospace failed: %v", ctx.Status())
+		}
+		endX, _ := ctx.GetCurrentPoint()
+
+		img := surface.(cairo.ImageSurface).GetGoImage()
+		var centers []float64
+		start := -1
+		for x := 0; x <= 300; x++ {
+			column := false
+			for y := 0; y < 60 && x < 300 && !column; y++ {
+				_, _, _, a := img.At(x, y).RGBA()
+				column = a != 0
+			}
+			if column && start < 0 {
+				start = x
+			} else if !column && start >= 0 {
+				centers = append(centers, float64(start+x)/2)
+				start = -1
+			}
+		}
+		return centers, endX
+	}
+
+	// i 和 W 的自然宽度相差很大，但都应居中于各自的单元格
+	centers, endX := inkCenters("i W")
+	if len(centers) != 2 {
+		t.Fatalf("Expected two inked glyphs, got %v", centers)
+	}
+	for i, want := range []float64{left + cell/2, left + 2*cell + cell/2} {
+		if math.Abs(centers[i]-want) > 2 {
+			t.Errorf("Glyph %d centered at %.1f, expected the cell center %.1f", i, centers[i], want)
+		}
+	}
+	if endX != left+3*cell {
+		t.Errorf("Expected the current point after three cells at %d, got %.1f", left+3*cell, endX)
+	}
+
+	// 宽字符占两个单元格，其后的字符从第三个单元格开始
+	centers, endX = inkCenters("中i")
+	if n := len(centers); n < 2 || math.Abs(centers[n-1]-(left+2*cell+cell/2)) > 2 {
+		t.Errorf("Expected i in the third cell after a wide character, ink centers %v", centers)
+	}
+	if endX != left+3*cell {
+		t.Errorf("Expected a wide character to take two cells, current point at %.1f", endX)
+	}
+
+	// 组合字符与其基字符共用一个单元格
+	if _, endX = inkCenters("e\u0301i"); endX != left+2*cell {
+		t.Errorf("Expected a combining mark to share its base's cell, current point at %.1f", endX)
+	}
+}
+
+// 测试 TextFitWidth 在簇边界处截断，并返回前缀的宽度
+func TestTextFitWidth(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	fontMatrix := cairo.NewMatrix()
+	fontMatrix.InitScale(20, 20)
+	ctx.SetFontMatrix(fontMatrix)
+	sf := ctx.GetScaledFont()
+	defer sf.Destroy()
+
+	const text = "Hello world"
+	glyphs, _, _, status := sf.TextToGlyphs(0, 0, text)
+	if status != cairo.StatusSuccess || len(glyphs) != len(text) {
+		t.Fatalf("Expected one glyph per character, got %d (%v)", len(glyphs), status)
+	}
+	// 末字形的度量前进量与整形结果可能有舍入误差
+	last := glyphs[len(glyphs)-1]
+	total := last.X + sf.GlyphExtents([]cairo.Glyph{last}).XAdvance
+
+	tests := []struct {
+		maxWidth  float64
+		wantBytes int
+		wantWidth float64
+	}{
+		{glyphs[5].X + 0.5, 5, glyphs[5].X},
+		{glyphs[5].X, 5, glyphs[5].X},
+		{glyphs[5].X - 0.01, 4, glyphs[4].X},
+		{total + 100, len(text), total},
+		{glyphs[1].X - 0.01, 0, 0},
+		{-1, 0, 0},
+	}
+	for _, tt := range tests {
+		nBytes, width := sf.TextFitWidth(text, tt.maxWidth)
+		if nBytes != tt.wantBytes || math.Abs(width-tt.wantWidth) > 0.05 {
+			t.Errorf("TextFitWidth(%q, %.2f) = %d, %.2f, want %d, %.2f",
+				text, tt.maxWidth, nBytes, width, tt.wantBytes, tt.wantWidth)
+		}
+	}
+
+	// 组合字符与基字符属于同一个簇，不会被拆开
+	combined := "e\u0301x"
+	_, eWidth := sf.TextFitWidth("e", 100)
+	if nBytes, _ := sf.TextFitWidth(combined, eWidth+0.5); nBytes != len("e\u0301") {
+		t.Errorf("Expected the prefix to end after the combining mark, got %d bytes", nBytes)
+	}
+	if nBytes, _ := sf.TextFitWidth(combined, eWidth-0.5); nBytes != 0 {
+		t.Errorf("Expected nothing to fit before the first cluster, got %d bytes", nBytes)
+	}
+}
+
+// 测试 ShowTextOnPath：沿半圆排列的字形随切线旋转，超出路径末端的字形被丢弃
+func TestShowTextOnPath(t *testing.T) {
+	draw := func(text string) *image.RGBA {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 140)
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		fontMatrix := cairo.NewMatrix()
+		fontMatrix.InitScale(20, 20)
+		ctx.SetFontMatrix(fontMatrix)
+		ctx.SetSourceRGB(0, 0, 0)
+		// 上半圆，从左端 (40, 100) 经顶部 (100, 40) 到右端 (160, 100)
+		ctx.Arc(100, 100, 60, math.Pi, 2*math.Pi)
+		ctx.ShowTextOnPath(text)
+		if ctx.Status() != cairo.StatusSuccess {
+			t.Fatalf("ShowTextOnPath failed: %v", ctx.Status())
+		}
+		if ctx.HasCurrentPoint() == cairo.True {
+			t.Error("Expected ShowTextOnPath to clear the path")
+		}
+		return surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	}
+	// inkBox 返回区域内有墨迹像素的包围盒
+	inkBox := func(img *image.RGBA, r image.Rectangle) image.Rectangle {
+		var box image.Rectangle
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if img.RGBAAt(x, y).A > 0x80 {
+					box = box.Union(image.Rect(x, y, x+1, y+1))
+				}
+			}
+		}
+		return box
+	}
+
+	text := strings.Repeat("I", 100)
+	img := draw(text)
+
+	// 起点处切线向上，字形躺倒并伸向圆外：竖笔画变成水平
+	left := inkBox(img, image.Rect(0, 95, 45, 105))
+	if left.Empty() || left.Dx() <= left.Dy() || left.Max.X > 42 {
+		t.Errorf("Expected a horizontal stem outside the start of the arc, got ink box %v", left)
+	}
+	// 顶部的字形直立在路径上方
+	top := inkBox(img, image.Rect(97, 0, 103, 45))
+	if top.Empty() || top.Max.Y > 41 || top.Dy() < 8 {
+		t.Errorf("Expected an upright stem above the top of the arc, got ink box %v", top)
+	}
+	// 文字不进入圆内
+	if inner := inkBox(img, image.Rect(60, 60, 140, 100)); !inner.Empty() {
+		t.Errorf("Expected no ink inside the arc, got ink box %v", inner)
+	}
+
+	// 路径放不下的字形被丢弃，而不是堆在末端
+	more := draw(strings.Repeat("I", 200))
+	if !bytes.Equal(img.Pix, more.Pix) {
+		t.Error("Expected glyphs past the end of the path to be dropped")
+	}
+}
+
+// 测试 ShowGlyphs 按给定位置绘制字形，当前点取最后一个字形的位置加步进
+func TestShowGlyphsExplicitPositions(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 300, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	fontMatrix := cairo.NewMatrix()
+	fontMatrix.InitScale(20, 20)
+	ctx.SetFontMatrix(fontMatrix)
+
+	glyphs, _, _, status := ctx.TextToGlyphs(10, 50, "abc")
+	if status != cairo.StatusSuccess || len(glyphs) != 3 {
+		t.Fatalf("TextToGlyphs failed: status %v, %d glyphs", status, len(glyphs))
+	}
+	// 在字形之间留出间隔
+	for i := range glyphs {
+		glyphs[i].X += float64(i) * 40
+	}
+
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.ShowGlyphs(glyphs)
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Fatalf("ShowGlyphs failed: %v", ctx.Status())
+	}
+
+	last := glyphs[len(glyphs)-1]
+	advance := ctx.GlyphExtents(glyphs[len(glyphs)-1:]).XAdvance
+	if advance <= 0 {
+		t.Fatalf("Expected a positive advance for the last glyph, got %f", advance)
+	}
+	x, y := ctx.GetCurrentPoint()
+	if math.Abs(x-(last.X+advance)) > 1e-9 || math.Abs(y-last.Y) > 1e-9 {
+		t.Errorf("Expected current point (%f, %f), got (%f, %f)", last.X+advance, last.Y, x, y)
+	}
+
+	// 最后一个字形应画在间隔之后的位置
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	inked := false
+	for py := 30; py < 55 && !inked; py++ {
+		for px := int(last.X); px < int(last.X+advance); px++ {
+			if _, _, _, a := img.At(px, py).RGBA(); a != 0 {
+				inked = true
+				break
+			}
+		}
+	}
+	if !inked {
+		t.Error("Expected the last glyph to be drawn at its explicit position")
+	}
+}
+
+// 测试字体缺少粗体时合成粗体，"M" 的墨迹多于常规字重
+func TestSyntheticBold(t *testing.T) {
+	// luxisr.ttf 只有常规字重
+	ink := func(weight cairo.FontWeight) float64 {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+
+		face := cairo.NewToyFontFace("../resource/font/luxisr.ttf", cairo.FontSlantNormal, weight)
+		defer face.Destroy()
+		ctx.SetFontFace(face)
+		fontMatrix := cairo.NewMatrix()
+		fontMatrix.InitScale(60, 60)
+		ctx.SetFontMatrix(fontMatrix)
+
+		glyphs, _, _, status := ctx.TextToGlyphs(10, 75, "M")
+		if status != cairo.StatusSuccess || len(glyphs) != 1 {
+			t.Fatalf("TextToGlyphs failed: status %v, %d glyphs", status, len(glyphs))
+		}
+		ctx.SetSourceRGB(0, 0, 0)
+		ctx.ShowGlyphs(glyphs)
+
+		total := 0.0
+		img := surface.(cairo.ImageSurface).GetGoImage()
+		for y := 0; y < 100; y++ {
+			for x := 0; x < 100; x++ {
+				_, _, _, a := img.At(x, y).RGBA()
+				total += float64(a) / 0xffff
+			}
+		}
+		return total
+	}
+
+	regular, bold := ink(cairo.FontWeightNormal), ink(cairo.FontWeightBold)
+	if regular == 0 {
+		t.Fatal("Expected the regular glyph to be drawn")
+	}
+	if bold <= regular*1.1 {
+		t.Errorf("Expected synthetic bold to add ink, got %f vs regular %f", bold, regular)
+	}
+}
+
+// 测试非法 UTF-8 文本使 Context 进入 StatusInvalidString 且不绘制
+func TestInvalidUTF8Text(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 50)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	invalid := "ab\xffc"
+	if _, _, _, status := ctx.TextToGlyphs(0, 0, invalid); status != cairo.StatusInvalidString {
+		t.Errorf("Expected TextToGlyphs to fail with StatusInvalidString, got %v", status)
+	}
+
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.MoveTo(10, 40)
+	layout := ctx.PangoCairoCreateLayout().(*cairo.PangoCairoLayout)
+	fontDesc := cairo.NewPangoFontDescription()
+	fontDesc.SetFamily("sans")
+	fontDesc.SetSize(30)
+	layout.SetFontDescription(fontDesc)
+	layout.SetText(invalid)
+	ctx.PangoCairoShowText(layout)
+
+	if ctx.Status() != cairo.StatusInvalidString {
+		t.Errorf("Expected StatusInvalidString, got %v", ctx.Status())
+	}
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	for y := 0; y < 50; y++ {
+		for x := 0; x < 100; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0 {
+				t.Fatalf("Expected nothing drawn, found ink at (%d, %d)", x, y)
+			}
+		}
+	}
+}
+
+// 测试字形四角坐标按字形 ID 计算，与 GlyphExtents 一致
+func TestGlyphCornerCoordinates(t *testing.T) {
+	face := cairo.NewPangoCairoFont("sans-serif", cairo.FontSlantNormal, cairo.FontWeightNormal)
+	defer face.Destroy()
+	fontMatrix := cairo.NewMatrix()
+	fontMatrix.InitScale(40, 40)
+	scaled := cairo.NewPangoCairoScaledFont(face, fontMatrix, cairo.NewMatrix(), cairo.NewFontOptions())
+	defer scaled.Destroy()
+
+	glyphs, _, _, status := scaled.TextToGlyphs(10, 50, "H")
+	if status != cairo.StatusSuccess || len(glyphs) != 1 {
+		t.Fatalf("TextToGlyphs failed: status %v, %d glyphs", status, len(glyphs))
+	}
+	coords, status := scaled.GetGlyphCornerCoordinates(glyphs[0])
+	if status != cairo.StatusSuccess {
+		t.Fatalf("GetGlyphCornerCoordinates failed: %v", status)
+	}
+
+	ext := scaled.GlyphExtents(glyphs)
+	left, top := glyphs[0].X+ext.XBearing, glyphs[0].Y+ext.YBearing
+	if coords.TopLeftX != left || coords.TopLeftY != top ||
+		coords.BottomRightX != left+ext.Width || coords.BottomRightY != top+ext.Height {
+		t.Errorf("Corners %+v do not match glyph extents %+v", *coords, *ext)
+	}
+}
+
+// 测试从内存字节加载字体并设置到 Context
+func TestSetFontFaceFromBytes(t *testing.T) {
+	data, err := os.ReadFile("../resource/font/luxisr.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	measure := func(setup func(ctx cairo.Context)) *cairo.TextExtents {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 100)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		fontMatrix := cairo.NewMatrix()
+		fontMatrix.InitScale(20, 20)
+		ctx.SetFontMatrix(fontMatrix)
+		setup(ctx)
+		return ctx.TextExtents("Hello World")
+	}
+
+	fromBytes := measure(func(ctx cairo.Context) {
+		if err := ctx.SetFontFaceFromBytes(data); err != nil {
+			t.Fatalf("SetFontFaceFromBytes failed: %v", err)
+		}
+	})
+	fromFile := measure(func(ctx cairo.Context) {
+		face := cairo.NewToyFontFace("../resource/font/luxisr.ttf", cairo.FontSlantNormal, cairo.FontWeightNormal)
+		defer face.Destroy()
+		ctx.SetFontFace(face)
+	})
+	builtin := measure(func(ctx cairo.Context) {
+		face := cairo.NewToyFontFace("Go", cairo.FontSlantNormal, cairo.FontWeightNormal)
+		defer face.Destroy()
+		ctx.SetFontFace(face)
+	})
+
+	if *fromBytes != *fromFile {
+		t.Errorf("Extents from bytes %+v differ from the same font loaded from file %+v", *fromBytes, *fromFile)
+	}
+	if fromBytes.XAdvance == builtin.XAdvance {
+		t.Errorf("Expected the loaded font's advance to differ from Go Regular, both %f", builtin.XAdvance)
+	}
+
+	// 无效数据应返回错误且不修改当前字体
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	if err := ctx.SetFontFaceFromBytes([]byte("not a font")); err == nil {
+		t.Error("Expected an error for invalid font data")
+	}
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Errorf("Invalid font data should not put the context in error, got %v", ctx.Status())
+	}
+}
+
+// renderSubpixelText 在白色背景上以给定的抗锯齿模式绘制黑色文字
+func renderSubpixelText(antialias cairo.Antialias, order cairo.SubpixelOrder) *image.RGBA {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 120, 60)
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SetSourceRGB(1, 1, 1)
+	ctx.Paint()
+
+	options := cairo.NewFontOptions()
+	options.SetAntialias(antialias)
+	options.SetSubpixelOrder(order)
+	ctx.SetFontOptions(options)
+
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.MoveTo(5, 45)
+	layout := ctx.PangoCairoCreateLayout().(*cairo.PangoCairoLayout)
+	fontDesc := cairo.NewPangoFontDescription()
+	fontDesc.SetFamily("../resource/font/luxisr.ttf")
+	fontDesc.SetSize(30)
+	layout.SetFontDescription(fontDesc)
+	layout.SetText("lIl")
+	ctx.PangoCairoShowText(layout)
+
+	return surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+}
+
+// countFringes 统计颜色通道不一致的像素（彩色边缘）
+func countFringes(img *image.RGBA) int {
+	n := 0
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i] != img.Pix[i+1] || img.Pix[i+1] != img.Pix[i+2] {
+			n++
+		}
+	}
+	return n
+}
+
+// 测试次像素抗锯齿在竖直笔画上产生彩色边缘
+func TestSubpixelTextAntialias(t *testing.T) {
+	if n := countFringes(renderSubpixelText(cairo.AntialiasGray, cairo.SubpixelOrderDefault)); n != 0 {
+		t.Errorf("grayscale text has %d colored pixels", n)
+	}
+
+	rgb := renderSubpixelText(cairo.AntialiasSubpixel, cairo.SubpixelOrderRGB)
+	if countFringes(rgb) == 0 {
+		t.Fatal("subpixel text should show colored fringes on vertical stems")
+	}
+
+	// RGB 顺序下，笔画左边缘的红色通道比蓝色通道覆盖更少，BGR 则相反
+	bgr := renderSubpixelText(cairo.AntialiasSubpixel, cairo.SubpixelOrderBGR)
+	var redLighter, blueLighter int
+	for i := 0; i < len(rgb.Pix); i += 4 {
+		if rgb.Pix[i] > rgb.Pix[i+2] {
+			redLighter++
+		}
+		if bgr.Pix[i+2] > bgr.Pix[i] {
+			blueLighter++
+		}
+	}
+	if redLighter == 0 || redLighter != blueLighter {
+		t.Errorf("BGR should mirror RGB fringes: %d vs %d", redLighter, blueLighter)
+	}
+}
+
+// 测试无效的字号、倾斜和字重设置各自的错误状态且不改变字体状态
+func TestInvalidFontSelection(t *testing.T) {
+	newContext := func() (cairo.Context, cairo.FontFace) {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+		ctx := cairo.NewContext(surface)
+		surface.Destroy()
+		ctx.SelectFontFace("sans", cairo.FontSlantNormal, cairo.FontWeightNormal)
+		ctx.SetFontSize(12)
+		face := ctx.GetFontFace()
+		face.Destroy()
+		return ctx, face
+	}
+
+	tests := []struct {
+		name  string
+		apply func(cairo.Context)
+		want  cairo.Status
+	}{
+		{"zero size", func(ctx cairo.Context) { ctx.SetFontSize(0) }, cairo.StatusInvalidSize},
+		{"negative size", func(ctx cairo.Context) { ctx.SetFontSize(-10) }, cairo.StatusInvalidSize},
+		{"NaN size", func(ctx cairo.Context) { ctx.SetFontSize(math.NaN()) }, cairo.StatusInvalidSize},
+		{"infinite size", func(ctx cairo.Context) { ctx.SetFontSize(math.Inf(1)) }, cairo.StatusInvalidSize},
+		{"slant", func(ctx cairo.Context) {
+			ctx.SelectFontFace("sans", cairo.FontSlant(7), cairo.FontWeightBold)
+		}, cairo.StatusInvalidSlant},
+		{"weight", func(ctx cairo.Context) {
+			ctx.SelectFontFace("sans", cairo.FontSlantItalic, cairo.FontWeight(-1))
+		}, cairo.StatusInvalidWeight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, face := newContext()
+			defer ctx.Destroy()
+			tt.apply(ctx)
+			if status := ctx.Status(); status != tt.want {
+				t.Fatalf("status = %v, want %v", status, tt.want)
+			}
+			if m := ctx.GetFontMatrix(); m.XX != 12 || m.YY != 12 || m.XY != 0 || m.YX != 0 {
+				t.Errorf("font matrix changed to %+v", *m)
+			}
+			current := ctx.GetFontFace()
+			defer current.Destroy()
+			if current != face {
+				t.Error("font face changed")
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		slant  cairo.FontSlant
+		weight cairo.FontWeight
+		want   cairo.Status
+	}{
+		{cairo.FontSlant(3), cairo.FontWeightNormal, cairo.StatusInvalidSlant},
+		{cairo.FontSlantOblique, cairo.FontWeight(2), cairo.StatusInvalidWeight},
+		{cairo.FontSlantOblique, cairo.FontWeightBold, cairo.StatusSuccess},
+	} {
+		face := cairo.NewToyFontFace("sans", tt.slant, tt.weight)
+		if status := face.Status(); status != tt.want {
+			t.Errorf("NewToyFontFace(%v, %v) status = %v, want %v", tt.slant, tt.weight, status, tt.want)
+		}
+		face.Destroy()
+	}
+}
+
+// 测试注册的字体可以按字体族名选择，清空缓存后仍然可用
+func TestRegisterFont(t *testing.T) {
+	data, err := os.ReadFile("../resource/font/luxisr.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cairo.RegisterFont("Registered Luxi", data); err != nil {
+		t.Fatalf("RegisterFont failed: %v", err)
+	}
+	if err := cairo.RegisterFont("Broken", []byte("not a font")); err == nil {
+		t.Error("RegisterFont should reject invalid font data")
+	}
+	if err := cairo.RegisterFont("", data); err == nil {
+		t.Error("RegisterFont should reject an empty name")
+	}
+
+	measure := func(family string) float64 {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 100)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		ctx.SelectFontFace(family, cairo.FontSlantNormal, cairo.FontWeightNormal)
+		ctx.SetFontSize(20)
+		return ctx.TextExtents("Hello World").XAdvance
+	}
+
+	fromFile := measure("../resource/font/luxisr.ttf")
+	registered := measure("Registered Luxi")
+	if registered != fromFile {
+		t.Errorf("registered font advance %v, want %v as loaded from file", registered, fromFile)
+	}
+	if builtin := measure("Go"); builtin == registered {
+		t.Error("registered font should differ from the built-in Go font")
+	}
+
+	cairo.ClearFontCache()
+	if again := measure("Registered Luxi"); again != registered {
+		t.Errorf("advance after ClearFontCache %v, want %v", again, registered)
+	}
+}
+
+// 基准测试：使用和不使用字体缓存创建 1000 个字体
+func BenchmarkToyFontFaceCache(b *testing.B) {
+	create := func(b *testing.B, clear bool) {
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 1000; j++ {
+				if clear {
+					cairo.ClearFontCache()
+				}
+				cairo.NewToyFontFace("Go", cairo.FontSlantNormal, cairo.FontWeightNormal).Destroy()
+			}
+		}
+	}
+	b.Run("cached", func(b *testing.B) { create(b, false) })
+	b.Run("uncached", func(b *testing.B) { create(b, true) })
 }
@@ -0,0 +1,109 @@
+package cairo
+
+import (
+	"errors"
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/novvoo/go-cairo/pkg/cairo"
+)
+
+// 参考图像保存在 testdata/ref 中，渲染有意改变后使用
+// go test ./test -update 重新生成
+var updateGolden = flag.Bool("update", false, "write rendered images as the new references in testdata/ref")
+
+const goldenRefDir = "testdata/ref"
+
+// assertGolden 将图像表面与参考图像 testdata/ref/<name>.png 比较。任一像素的
+// 任一通道（非预乘）相差超过 tolerance 时测试失败，并把渲染结果和
+// DiffSurfaces 生成的差异图写入临时目录。使用 -update 时改为写入参考图像。
+func assertGolden(t *testing.T, surface cairo.Surface, name string, tolerance int) {
+	t.Helper()
+
+	imgSurface, ok := surface.(cairo.ImageSurface)
+	if !ok {
+		t.Fatalf("golden %s: not an image surface", name)
+	}
+	refPath := filepath.Join(goldenRefDir, name+".png")
+	if *updateGolden {
+		if err := os.MkdirAll(goldenRefDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if status := imgSurface.WriteToPNG(refPath); status != cairo.StatusSuccess {
+			t.Fatalf("golden %s: writing reference: %v", name, status)
+		}
+		return
+	}
+
+	file, err := os.Open(refPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("golden %s: no reference image, run the test with -update to create it", name)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	refImage, err := png.Decode(file)
+	file.Close()
+	if err != nil {
+		t.Fatalf("golden %s: reading reference: %v", name, err)
+	}
+
+	got := imgSurface.GetGoImage()
+	bounds := got.Bounds()
+	if refImage.Bounds().Size() != bounds.Size() {
+		t.Fatalf("golden %s: size %v, reference is %v", name, bounds.Size(), refImage.Bounds().Size())
+	}
+	bad, worst := 0, 0
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			a := color.NRGBAModel.Convert(got.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+			b := color.NRGBAModel.Convert(refImage.At(refImage.Bounds().Min.X+x, refImage.Bounds().Min.Y+y)).(color.NRGBA)
+			d := channelDiff(a.A, b.A)
+			// 全透明像素的颜色没有意义
+			if a.A != 0 || b.A != 0 {
+				d = max(d, channelDiff(a.R, b.R), channelDiff(a.G, b.G), channelDiff(a.B, b.B))
+			}
+			if d > tolerance {
+				bad++
+			}
+			worst = max(worst, d)
+		}
+	}
+	if bad == 0 {
+		return
+	}
+
+	// 差异图：与参考图像不同的像素标为红色
+	ref := cairo.NewImageSurface(cairo.FormatARGB32, bounds.Dx(), bounds.Dy())
+	defer ref.Destroy()
+	dst := ref.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	draw.Draw(dst, dst.Bounds(), refImage, refImage.Bounds().Min, draw.Src)
+	ref.MarkDirty()
+	diff := cairo.DiffSurfaces(surface, ref)
+	defer diff.Destroy()
+
+	outDir := filepath.Join(os.TempDir(), "go-cairo-golden")
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	outPath := filepath.Join(outDir, name+".png")
+	diffPath := filepath.Join(outDir, name+"-diff.png")
+	imgSurface.WriteToPNG(outPath)
+	diff.(cairo.ImageSurface).WriteToPNG(diffPath)
+	t.Errorf("golden %s: %d pixels differ by more than %d (at most %d); output %s, diff %s",
+		name, bad, tolerance, worst, outPath, diffPath)
+}
+
+// channelDiff 返回两个通道值之差的绝对值
+func channelDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
@@ -125,6 +125,100 @@ func TestImageBackend(t *testing.T) {
 	}
 }
 
+// 测试 ImageSurface.Backend 原地修改表面像素
+func TestImageSurfaceBackendAliases(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.Rectangle(20, 0, 20, 40)
+	ctx.Fill()
+	ctx.Destroy()
+
+	imgSurface := surface.(cairo.ImageSurface)
+	backend := imgSurface.Backend()
+	if backend == nil {
+		t.Fatal("Backend returned nil for an ARGB32 surface")
+	}
+	if backend.GetImage() != imgSurface.GetGoImage() {
+		t.Error("backend should alias the surface image")
+	}
+
+	// 在后端上绘制和平滑，表面应立即反映修改
+	backend.FillRect(0, 0, 5, 5, color.White)
+	if _, _, _, a := imgSurface.GetGoImage().At(2, 2).RGBA(); a == 0 {
+		t.Error("FillRect through the backend should modify the surface")
+	}
+
+	backend.SmoothGaussian(2)
+	if backend.GetImage() != imgSurface.GetGoImage() {
+		t.Error("filters must keep writing into the surface buffer")
+	}
+	if _, _, _, a := imgSurface.GetGoImage().At(19, 20).RGBA(); a == 0 || a == 0xffff {
+		t.Error("smoothing through the backend should soften the edge on the surface")
+	}
+
+	// 没有 RGBA 缓冲区的格式返回 nil
+	a8 := cairo.NewImageSurface(cairo.FormatA8, 4, 4)
+	defer a8.Destroy()
+	if a8.(cairo.ImageSurface).Backend() != nil {
+		t.Error("Backend should be nil for A8 surfaces")
+	}
+}
+
+// 测试锐化和反锐化掩模增强软边缘的对比度
+func TestImageBackendSharpen(t *testing.T) {
+	// 从 x=8 到 x=11 由 64 渐变到 192 的灰色软边缘
+	softEdge := func() *cairo.ImageBackend {
+		backend := cairo.NewImageBackend(20, 8)
+		for x := 0; x < 20; x++ {
+			v := uint8(min(max(64+(x-8)*32, 64), 192))
+			backend.FillRect(x, 0, 1, 8, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+		return backend
+	}
+	contrast := func(b *cairo.ImageBackend) int {
+		img := b.GetImage()
+		return int(img.RGBAAt(12, 4).R) - int(img.RGBAAt(7, 4).R)
+	}
+
+	base := contrast(softEdge())
+
+	sharpened := softEdge()
+	sharpened.Sharpen(1)
+	if got := contrast(sharpened); got <= base {
+		t.Errorf("Sharpen contrast %d should exceed %d", got, base)
+	}
+
+	masked := softEdge()
+	masked.UnsharpMask(3, 1.5, 0)
+	if got := contrast(masked); got <= base {
+		t.Errorf("UnsharpMask contrast %d should exceed %d", got, base)
+	}
+	img := masked.GetImage()
+	if img.RGBAAt(0, 4) != (color.RGBA{R: 64, G: 64, B: 64, A: 255}) {
+		t.Errorf("flat area far from the edge changed to %v", img.RGBAAt(0, 4))
+	}
+
+	// 阈值大于细节幅度时不修改图像
+	thresholded := softEdge()
+	thresholded.UnsharpMask(3, 1.5, 255)
+	if got := contrast(thresholded); got != base {
+		t.Errorf("threshold should suppress sharpening, contrast %d != %d", got, base)
+	}
+
+	// 半透明像素锐化后仍是合法的预乘颜色
+	alpha := cairo.NewImageBackend(10, 1)
+	alpha.FillRect(5, 0, 5, 1, color.RGBA{R: 100, A: 100})
+	alpha.UnsharpMask(2, 3, 0)
+	for x := 0; x < 10; x++ {
+		if c := alpha.GetImage().RGBAAt(x, 0); c.R > c.A {
+			t.Errorf("pixel %d = %v is not valid premultiplied", x, c)
+		}
+	}
+}
+
 // 基准测试
 func BenchmarkPorterDuffBlend(b *testing.B) {
 	src := color.NRGBA{R: 255, G: 128, B: 64, A: 200}
@@ -1,6 +1,7 @@
 package cairo
 
 import (
+	"image"
 	"testing"
 
 	"github.com/novvoo/go-cairo/pkg/cairo"
@@ -126,6 +127,24 @@ func TestDash(t *testing.T) {
 	}
 }
 
+// 测试无效虚线使上下文进入 StatusInvalidDash 错误并保留原有虚线
+func TestInvalidDashKeepsPrevious(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SetDash([]float64{10, 5}, 2)
+	ctx.SetDash([]float64{-1, 5}, 0)
+	if ctx.Status() != cairo.StatusInvalidDash {
+		t.Errorf("Expected StatusInvalidDash, got %v", ctx.Status())
+	}
+	dashes, offset := ctx.GetDash()
+	if len(dashes) != 2 || dashes[0] != 10 || dashes[1] != 5 || offset != 2 {
+		t.Errorf("Expected the previous dash [10 5] offset 2 to remain, got %v offset %v", dashes, offset)
+	}
+}
+
 // 测试填充规则
 func TestFillRule(t *testing.T) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
@@ -217,6 +236,77 @@ func TestOperatorDrawing(t *testing.T) {
 	}
 }
 
+// 测试可分离混合模式：在渐变上以正片叠底叠加灰色图层应按比例变暗
+func TestSeparableBlendModes(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 64, 4)
+	defer surface.Destroy()
+	img := surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+
+	gradient := cairo.NewPatternLinear(0, 0, 64, 0)
+	defer gradient.Destroy()
+	gradient.(cairo.LinearGradientPattern).AddColorStopRGB(0, 0, 0, 0)
+	gradient.(cairo.LinearGradientPattern).AddColorStopRGB(1, 1, 1, 1)
+	gradientCtx := cairo.NewContext(surface)
+	gradientCtx.SetSource(gradient)
+	gradientCtx.Paint()
+	gradientCtx.Destroy()
+
+	before := make([]uint8, 64)
+	for x := range before {
+		before[x] = img.RGBAAt(x, 2).R
+	}
+	if before[0] > 8 || before[63] < 247 {
+		t.Fatalf("gradient not painted: %d..%d", before[0], before[63])
+	}
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SetOperator(cairo.OperatorMultiply)
+	ctx.SetSourceRGB(0.5, 0.5, 0.5)
+	ctx.Rectangle(0, 0, 64, 4)
+	ctx.Fill()
+
+	for x, b := range before {
+		got := img.RGBAAt(x, 2)
+		want := int(b) / 2
+		if d := int(got.R) - want; d < -2 || d > 2 || got.A != 255 {
+			t.Fatalf("multiply at x=%d: got %v, expected R about %d from %d", x, got, want, b)
+		}
+	}
+
+	// 滤色：50% 灰色叠加到黑色上得到 50% 灰色，叠加到白色上保持白色
+	ctx.SetOperator(cairo.OperatorSource)
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.Rectangle(0, 0, 32, 4)
+	ctx.Fill()
+	ctx.SetSourceRGB(1, 1, 1)
+	ctx.Rectangle(32, 0, 32, 4)
+	ctx.Fill()
+
+	ctx.SetOperator(cairo.OperatorScreen)
+	ctx.SetSourceRGB(0.5, 0.5, 0.5)
+	ctx.Rectangle(0, 0, 64, 4)
+	ctx.Fill()
+	if got := img.RGBAAt(10, 2).R; got < 126 || got > 129 {
+		t.Errorf("screen over black = %d, expected about 127", got)
+	}
+	if got := img.RGBAAt(50, 2).R; got < 254 {
+		t.Errorf("screen over white = %d, expected 255", got)
+	}
+
+	// 半透明源按源 alpha 在混合结果和目标之间插值
+	ctx.SetOperator(cairo.OperatorSource)
+	ctx.SetSourceRGB(1, 1, 1)
+	ctx.Paint()
+	ctx.SetOperator(cairo.OperatorDifference)
+	ctx.SetSourceRGBA(1, 1, 1, 0.5)
+	ctx.Paint()
+	if got := img.RGBAAt(10, 2).R; got < 126 || got > 129 {
+		t.Errorf("half-transparent difference = %d, expected about 127", got)
+	}
+}
+
 // 测试虚线绘制
 func TestDashDrawing(t *testing.T) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 100)
@@ -243,3 +333,58 @@ func TestDashDrawing(t *testing.T) {
 	ctx.LineTo(190, 60)
 	ctx.Stroke()
 }
+
+// 测试虚线相位在子路径之间默认重新开始，关闭后连续
+func TestDashResetPerSubpath(t *testing.T) {
+	// 第一个子路径长 15，结束在第二段 "off" 的中间
+	draw := func(reset bool) *image.RGBA {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 120, 60)
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		ctx.SetLineWidth(2)
+		ctx.SetDash([]float64{10, 10}, 0)
+		ctx.SetDashResetPerSubpath(reset)
+		ctx.MoveTo(10, 20)
+		ctx.LineTo(25, 20)
+		ctx.MoveTo(10, 50)
+		ctx.LineTo(110, 50)
+		ctx.Stroke()
+		return surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	}
+	inked := func(img *image.RGBA, x, y int) bool {
+		return img.RGBAAt(x, y).A > 0x80
+	}
+
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 1, 1)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	if !ctx.GetDashResetPerSubpath() {
+		t.Error("Expected the dash pattern to restart per subpath by default")
+	}
+	ctx.Save()
+	ctx.SetDashResetPerSubpath(false)
+	ctx.Restore()
+	if !ctx.GetDashResetPerSubpath() {
+		t.Error("Expected Restore to bring back the dash reset setting")
+	}
+
+	// 第一个子路径在两种设置下相同：[10, 20) 为 "on"
+	for _, reset := range []bool{true, false} {
+		img := draw(reset)
+		if !inked(img, 15, 20) || inked(img, 22, 20) {
+			t.Errorf("reset=%v: expected the first dash of the first subpath only", reset)
+		}
+	}
+
+	// 重新开始：第二个子路径 [10, 20) 为 "on"，[20, 30) 为 "off"
+	img := draw(true)
+	if !inked(img, 12, 50) || inked(img, 23, 50) || !inked(img, 35, 50) {
+		t.Error("Expected the dash pattern to restart at the second subpath")
+	}
+	// 连续：先走完剩余的 5 个 "off"，[15, 25) 为 "on"
+	img = draw(false)
+	if inked(img, 12, 50) || !inked(img, 20, 50) || inked(img, 28, 50) || !inked(img, 40, 50) {
+		t.Error("Expected the dash phase to continue from the first subpath")
+	}
+}
@@ -2,6 +2,7 @@ package cairo
 
 import (
 	"math"
+	"slices"
 	"testing"
 
 	"github.com/novvoo/go-cairo/pkg/cairo"
@@ -101,6 +102,41 @@ func TestRelativePathOperations(t *testing.T) {
 	}
 }
 
+// 测试 SetCurrentPoint
+func TestSetCurrentPoint(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SetCurrentPoint(20, 30)
+	if ctx.HasCurrentPoint() != cairo.True {
+		t.Fatal("Should have current point after SetCurrentPoint")
+	}
+	x, y := ctx.GetCurrentPoint()
+	if x != 20 || y != 30 {
+		t.Errorf("GetCurrentPoint: expected (20, 30), got (%f, %f)", x, y)
+	}
+
+	// 设置当前点不应产生路径操作
+	if n := len(ctx.CopyPath().Data); n != 0 {
+		t.Errorf("SetCurrentPoint should not emit path data, got %d ops", n)
+	}
+
+	ctx.RelLineTo(10, 5)
+	path := ctx.CopyPath()
+	if len(path.Data) != 2 {
+		t.Fatalf("Expected MoveTo + LineTo, got %d ops", len(path.Data))
+	}
+	if path.Data[0].Type != cairo.PathMoveTo || path.Data[0].Points[0] != (cairo.Point{X: 20, Y: 30}) {
+		t.Errorf("Line should start at the set point, got %+v", path.Data[0])
+	}
+	if path.Data[1].Type != cairo.PathLineTo || path.Data[1].Points[0] != (cairo.Point{X: 30, Y: 35}) {
+		t.Errorf("Line should end at (30, 35), got %+v", path.Data[1])
+	}
+}
+
 // 测试 Arc
 func TestArc(t *testing.T) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
@@ -128,6 +164,20 @@ func TestArcNegative(t *testing.T) {
 
 	// 绘制逆时针圆弧
 	ctx.ArcNegative(50, 50, 30, 0, -math.Pi)
+
+	// 曲线的控制点沿逆时针方向，中点落在圆上
+	path := ctx.CopyPath()
+	prev := path.Data[0].Points[0]
+	for _, d := range path.Data[1:] {
+		p1, p2, p3 := d.Points[0], d.Points[1], d.Points[2]
+		mx := (prev.X + 3*p1.X + 3*p2.X + p3.X) / 8
+		my := (prev.Y + 3*p1.Y + 3*p2.Y + p3.Y) / 8
+		if r := math.Hypot(mx-50, my-50); math.Abs(r-30) > 0.1 {
+			t.Errorf("Curve midpoint (%f, %f) is %f from the center, expected 30", mx, my, r)
+		}
+		prev = p3
+	}
+
 	ctx.SetSourceRGB(0, 1, 0)
 	err := ctx.Stroke()
 	if err != nil {
@@ -135,6 +185,85 @@ func TestArcNegative(t *testing.T) {
 	}
 }
 
+// 测试椭圆弧：关键点落在旋转后的椭圆上，描边经过这些点
+func TestEllipticalArc(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 200)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	const cx, cy, rx, ry, rotation = 100.0, 100.0, 80.0, 30.0, math.Pi / 6
+	onEllipse := func(x, y float64) float64 {
+		dx, dy := x-cx, y-cy
+		u := (dx*math.Cos(rotation) + dy*math.Sin(rotation)) / rx
+		v := (-dx*math.Sin(rotation) + dy*math.Cos(rotation)) / ry
+		return u*u + v*v
+	}
+
+	ctx.EllipticalArc(cx, cy, rx, ry, rotation, -math.Pi/4, 3*math.Pi/2)
+	path := ctx.CopyPath()
+	if path.Data[0].Type != cairo.PathMoveTo {
+		t.Fatalf("Expected the arc to start with a move, got %v", path.Data[0].Type)
+	}
+	prev := path.Data[0].Points[0]
+	for _, d := range path.Data[1:] {
+		if d.Type != cairo.PathCurveTo {
+			t.Fatalf("Expected only curves after the move, got %v", d.Type)
+		}
+		p0, p1, p2, p3 := prev, d.Points[0], d.Points[1], d.Points[2]
+		if e := onEllipse(p3.X, p3.Y); math.Abs(e-1) > 1e-9 {
+			t.Errorf("Curve end (%f, %f) is off the ellipse: %f", p3.X, p3.Y, e)
+		}
+		// 曲线中点与椭圆的偏差应在容差内
+		mx := (p0.X + 3*p1.X + 3*p2.X + p3.X) / 8
+		my := (p0.Y + 3*p1.Y + 3*p2.Y + p3.Y) / 8
+		if e := onEllipse(mx, my); math.Abs(math.Sqrt(e)-1)*rx > 0.1 {
+			t.Errorf("Curve midpoint (%f, %f) strays from the ellipse: %f", mx, my, e)
+		}
+		prev = p3
+	}
+	start, end := path.Data[0].Points[0], prev
+	wantStart := [2]float64{
+		cx + rx*math.Cos(-math.Pi/4)*math.Cos(rotation) - ry*math.Sin(-math.Pi/4)*math.Sin(rotation),
+		cy + rx*math.Cos(-math.Pi/4)*math.Sin(rotation) + ry*math.Sin(-math.Pi/4)*math.Cos(rotation),
+	}
+	if math.Abs(start.X-wantStart[0]) > 1e-9 || math.Abs(start.Y-wantStart[1]) > 1e-9 {
+		t.Errorf("Expected start %v, got (%f, %f)", wantStart, start.X, start.Y)
+	}
+
+	ctx.SetLineWidth(3)
+	ctx.Stroke()
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	// 终点（参数角 3π/2）被描边，扫过范围之外的参数角 13π/8 没有
+	if _, _, _, a := img.At(int(end.X), int(end.Y)).RGBA(); a == 0 {
+		t.Errorf("Expected the stroke to cover its end point (%f, %f)", end.X, end.Y)
+	}
+	skip := 13 * math.Pi / 8
+	skipX := cx + rx*math.Cos(skip)*math.Cos(rotation) - ry*math.Sin(skip)*math.Sin(rotation)
+	skipY := cy + rx*math.Cos(skip)*math.Sin(rotation) + ry*math.Sin(skip)*math.Cos(rotation)
+	if _, _, _, a := img.At(int(skipX), int(skipY)).RGBA(); a != 0 {
+		t.Errorf("Expected no stroke at angle 13π/8 (%f, %f), outside the sweep", skipX, skipY)
+	}
+
+	// 圆且不旋转时与 Arc 一致
+	ctx.EllipticalArc(50, 60, 20, 20, 0, 0.5, 2.5)
+	elliptical := ctx.CopyPath()
+	ctx.NewPath()
+	ctx.Arc(50, 60, 20, 0.5, 2.5)
+	arc := ctx.CopyPath()
+	if len(elliptical.Data) != len(arc.Data) {
+		t.Fatalf("Expected %d segments like Arc, got %d", len(arc.Data), len(elliptical.Data))
+	}
+	for i := range arc.Data {
+		for j, p := range arc.Data[i].Points {
+			q := elliptical.Data[i].Points[j]
+			if math.Abs(p.X-q.X) > 1e-9 || math.Abs(p.Y-q.Y) > 1e-9 {
+				t.Errorf("Segment %d point %d: Arc gives %v, EllipticalArc %v", i, j, p, q)
+			}
+		}
+	}
+}
+
 // 测试 DrawCircle
 func TestDrawCircle(t *testing.T) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
@@ -151,6 +280,199 @@ func TestDrawCircle(t *testing.T) {
 	}
 }
 
+// 测试 MoveTo 到圆心后画整圆不产生从圆心出发的辐条，且终点与起点重合
+func TestArcFullCircleNoSpoke(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.MoveTo(50, 50)
+	ctx.Arc(50, 50, 30, 0, 2*math.Pi)
+	path := ctx.CopyPath()
+	if len(path.Data) == 0 || path.Data[0].Type != cairo.PathMoveTo || path.Data[0].Points[0] != (cairo.Point{X: 80, Y: 50}) {
+		t.Fatalf("Expected the circle to start its own subpath at (80, 50), got %+v", path.Data)
+	}
+	for _, d := range path.Data[1:] {
+		if d.Type != cairo.PathCurveTo {
+			t.Fatalf("Expected only curves after the move, got %+v", path.Data)
+		}
+	}
+	last := path.Data[len(path.Data)-1].Points[2]
+	if last != path.Data[0].Points[0] {
+		t.Errorf("Expected the circle to end exactly at its start, got %+v", last)
+	}
+
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.SetLineWidth(4)
+	ctx.StrokePreserve()
+	ctx.SetSourceRGBA(0, 0, 1, 0.5)
+	ctx.Fill()
+
+	// 填充均匀：半径上不应有描边留下的深色线
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	_, _, _, center := img.At(50, 50).RGBA()
+	for x := 50; x < 75; x++ {
+		if _, _, _, a := img.At(x, 50).RGBA(); a != center {
+			t.Fatalf("Stray line from the center at (%d, 50): alpha %d, fill alpha %d", x, a, center)
+		}
+	}
+
+	// 部分圆弧仍从当前点连线，以便绘制扇形
+	ctx.MoveTo(50, 50)
+	ctx.Arc(50, 50, 30, 0, math.Pi/2)
+	if pie := ctx.CopyPath(); pie.Data[1].Type != cairo.PathLineTo {
+		t.Errorf("Expected a partial arc to join the current point with a line, got %+v", pie.Data)
+	}
+}
+
+// 测试圆弧分段数随容差变化
+func TestArcTolerance(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	curves := func(tolerance float64) int {
+		ctx.NewPath()
+		ctx.SetTolerance(tolerance)
+		ctx.Arc(0, 0, 1000, 0, math.Pi)
+		count := 0
+		for _, d := range ctx.CopyPath().Data {
+			if d.Type == cairo.PathCurveTo {
+				count++
+			}
+		}
+		return count
+	}
+
+	fine, coarse := curves(0.01), curves(1.0)
+	if fine <= coarse {
+		t.Errorf("Expected more segments at tolerance 0.01 than at 1.0, got %d and %d", fine, coarse)
+	}
+	if coarse < 2 {
+		t.Errorf("Expected at least one segment per quarter circle, got %d", coarse)
+	}
+
+	// 大半径圆弧在细容差下仍应平滑填充
+	ctx.NewPath()
+	ctx.SetTolerance(0.01)
+	ctx.Arc(50, 1050, 1000, -math.Pi/2-0.1, -math.Pi/2+0.1)
+	ctx.ClosePath()
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.Fill()
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	if _, _, _, a := img.At(50, 52).RGBA(); a == 0 {
+		t.Error("Expected the arc segment to be filled below its apex")
+	}
+}
+
+// 测试 DrawCircle 生成闭合且关于圆心对称的路径
+func TestDrawCirclePathClosedAndSymmetric(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	// 已有当前点时不应连到圆上
+	ctx.MoveTo(0, 0)
+	ctx.DrawCircle(50, 40, 30)
+	path := ctx.CopyPath()
+	data := path.Data[1:]
+
+	if len(data) != 6 || data[0].Type != cairo.PathMoveTo || data[len(data)-1].Type != cairo.PathClosePath {
+		t.Fatalf("Expected move, four curves and close, got %+v", data)
+	}
+	start := data[0].Points[0]
+	end := data[4].Points[2]
+	if start != (cairo.Point{X: 80, Y: 40}) || end != start {
+		t.Errorf("Expected the circle to start and end at (80, 40), got %+v and %+v", start, end)
+	}
+
+	// 每个点关于圆心的对称点也在路径上
+	var points []cairo.Point
+	for _, d := range data[1:5] {
+		points = append(points, d.Points...)
+	}
+	for _, p := range points {
+		found := false
+		for _, q := range points {
+			if math.Abs(p.X+q.X-100) < 1e-9 && math.Abs(p.Y+q.Y-80) < 1e-9 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Point %+v has no mirror image through the center", p)
+		}
+	}
+
+	// 同一路径可填充也可描边
+	ctx.NewPath()
+	ctx.DrawEllipse(50, 50, 40, 20)
+	ctx.SetSourceRGB(0, 0, 1)
+	if err := ctx.FillPreserve(); err != nil {
+		t.Errorf("DrawEllipse fill failed: %v", err)
+	}
+	if err := ctx.Stroke(); err != nil {
+		t.Errorf("DrawEllipse stroke failed: %v", err)
+	}
+	_, _, _, a := surface.(cairo.ImageSurface).GetGoImage().At(50, 50).RGBA()
+	if a == 0 {
+		t.Error("Expected the ellipse center to be filled")
+	}
+}
+
+// 测试沿弧长虚线描边圆：段数与周长/周期一致且间距均匀
+func TestDashedCircle(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 120, 120)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	const radius = 40.0
+	dashes := []float64{10, 10}
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.SetLineWidth(4)
+	if err := ctx.DashedCircle(60, 60, radius, dashes); err != nil {
+		t.Fatalf("DashedCircle failed: %v", err)
+	}
+
+	// 沿圆周采样，统计 "开" 段的起点
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	const samples = 1440
+	inked := make([]bool, samples)
+	for i := range inked {
+		a := 2 * math.Pi * (float64(i) + 0.5) / samples
+		_, _, _, alpha := img.At(int(60+radius*math.Cos(a)), int(60+radius*math.Sin(a))).RGBA()
+		inked[i] = alpha > 0x8000
+	}
+	var starts []int
+	for i := range inked {
+		if inked[i] && !inked[(i+samples-1)%samples] {
+			starts = append(starts, i)
+		}
+	}
+
+	want := int(math.Round(2 * math.Pi * radius / 20))
+	if len(starts) != want {
+		t.Fatalf("Expected %d dash segments, got %d", want, len(starts))
+	}
+	spacing := float64(samples) / float64(want)
+	for i := range starts {
+		gap := float64((starts[(i+1)%len(starts)] - starts[i] + samples) % samples)
+		if math.Abs(gap-spacing) > 8 {
+			t.Errorf("Dash %d: spacing %.0f samples, expected about %.0f", i, gap, spacing)
+		}
+	}
+	assertGolden(t, surface, "dashed-circle", 1)
+
+	if err := ctx.DashedCircle(60, 60, radius, []float64{-1, 2}); err == nil {
+		t.Error("Expected an error for a negative dash length")
+	}
+}
+
 // 测试复杂路径
 func TestComplexPath(t *testing.T) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 200)
@@ -264,3 +586,528 @@ func BenchmarkArcDrawing(b *testing.B) {
 		ctx.Stroke()
 	}
 }
+
+// 基准测试：十万次 LineTo 与一次 PolyLine
+func BenchmarkLineTo100k(b *testing.B) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	pts := seriesPoints(100000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.NewPath()
+		for _, p := range pts {
+			ctx.LineTo(p.X, p.Y)
+		}
+	}
+}
+
+func BenchmarkPolyLine100k(b *testing.B) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	pts := seriesPoints(100000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.NewPath()
+		ctx.PolyLine(pts)
+	}
+}
+
+// seriesPoints returns n points of a time series across a 100×100 surface.
+func seriesPoints(n int) []cairo.Point {
+	pts := make([]cairo.Point, n)
+	for i := range pts {
+		x := float64(i) / float64(n) * 100
+		pts[i] = cairo.Point{X: x, Y: 50 + 40*math.Sin(x/5)}
+	}
+	return pts
+}
+
+// 测试 PolyLine 与 Polygon 生成的路径
+func TestPolyLineAndPolygon(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	pts := []cairo.Point{{X: 10, Y: 10}, {X: 90, Y: 10}, {X: 50, Y: 80}}
+
+	// 没有当前点时第一个点开始新的子路径
+	ctx.PolyLine(pts)
+	path := ctx.CopyPath()
+	if len(path.Data) != 3 || path.Data[0].Type != cairo.PathMoveTo ||
+		path.Data[1].Type != cairo.PathLineTo || path.Data[2].Points[0] != pts[2] {
+		t.Fatalf("Unexpected PolyLine path: %+v", path.Data)
+	}
+	if x, y := ctx.GetCurrentPoint(); x != 50 || y != 80 {
+		t.Errorf("Expected current point (50, 80), got (%v, %v)", x, y)
+	}
+
+	// 有当前点时与逐个 LineTo 相同
+	ctx.NewPath()
+	ctx.MoveTo(0, 0)
+	ctx.PolyLine(pts)
+	viaPolyLine := ctx.CopyPath()
+	ctx.NewPath()
+	ctx.MoveTo(0, 0)
+	for _, p := range pts {
+		ctx.LineTo(p.X, p.Y)
+	}
+	viaLineTo := ctx.CopyPath()
+	if len(viaPolyLine.Data) != len(viaLineTo.Data) {
+		t.Fatalf("Expected %d segments, got %d", len(viaLineTo.Data), len(viaPolyLine.Data))
+	}
+	for i := range viaLineTo.Data {
+		if viaPolyLine.Data[i].Type != viaLineTo.Data[i].Type || viaPolyLine.Data[i].Points[0] != viaLineTo.Data[i].Points[0] {
+			t.Errorf("Segment %d: got %+v, want %+v", i, viaPolyLine.Data[i], viaLineTo.Data[i])
+		}
+	}
+
+	// Polygon 闭合并可填充
+	ctx.NewPath()
+	ctx.Polygon(pts)
+	path = ctx.CopyPath()
+	if len(path.Data) != 4 || path.Data[0].Type != cairo.PathMoveTo || path.Data[3].Type != cairo.PathClosePath {
+		t.Fatalf("Unexpected Polygon path: %+v", path.Data)
+	}
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.Fill()
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	if _, _, _, a := img.At(50, 30).RGBA(); a == 0 {
+		t.Error("Expected the polygon interior to be filled")
+	}
+	if _, _, _, a := img.At(15, 70).RGBA(); a != 0 {
+		t.Error("Expected pixels outside the polygon to stay clear")
+	}
+}
+
+// 测试 PathBuilder 构建的路径经 AppendPath、CopyPath 后逐段遍历保持不变
+func TestPathBuilderRoundTrip(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	b := cairo.NewPathBuilder()
+	b.MoveTo(10, 10)
+	b.LineTo(90, 10)
+	b.CurveTo(90, 50, 50, 90, 10, 90)
+	b.ClosePath()
+	b.Rectangle(30, 30, 20, 20)
+	if x, y, ok := b.CurrentPoint(); !ok || x != 30 || y != 30 {
+		t.Errorf("Expected current point (30, 30) after the rectangle, got (%f, %f, %v)", x, y, ok)
+	}
+	built := b.Path()
+
+	ctx.AppendPath(built)
+	copied := ctx.CopyPath()
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Fatalf("AppendPath failed: %v", ctx.Status())
+	}
+
+	type segment struct {
+		op  cairo.PathDataType
+		pts []cairo.Point
+	}
+	var want, got []segment
+	built.ForEach(func(op cairo.PathDataType, pts []cairo.Point) {
+		want = append(want, segment{op, append([]cairo.Point(nil), pts...)})
+	})
+	copied.ForEach(func(op cairo.PathDataType, pts []cairo.Point) {
+		got = append(got, segment{op, append([]cairo.Point(nil), pts...)})
+	})
+
+	if len(want) != 9 || len(got) != len(want) {
+		t.Fatalf("Expected 9 segments, built %d, copied %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].op != want[i].op || len(got[i].pts) != len(want[i].pts) {
+			t.Fatalf("Segment %d: got %+v, want %+v", i, got[i], want[i])
+		}
+		for j := range want[i].pts {
+			if got[i].pts[j] != want[i].pts[j] {
+				t.Errorf("Segment %d point %d: got %+v, want %+v", i, j, got[i].pts[j], want[i].pts[j])
+			}
+		}
+	}
+
+	// 修改副本不影响原路径
+	dup := copied.Copy()
+	dup.Data[0].Points[0].X = -1
+	if copied.Data[0].Points[0].X != 10 {
+		t.Error("Modifying a copied path changed the original")
+	}
+}
+
+// 测试 Path.Simplify：密集采样的近似直线简化为两个端点，闭合与曲线保持不变
+func TestPathSimplify(t *testing.T) {
+	b := cairo.NewPathBuilder()
+	b.MoveTo(0, 0)
+	for i := 1; i <= 1000; i++ {
+		// 偏离直线不超过 0.05
+		b.LineTo(float64(i)/10, 0.05*math.Sin(float64(i)))
+	}
+	line := b.Path().Simplify(0.1)
+	if line.Status != cairo.StatusSuccess || len(line.Data) != 2 {
+		t.Fatalf("Expected the line simplified to its endpoints, got %d segments", len(line.Data))
+	}
+	if line.Data[0].Type != cairo.PathMoveTo || line.Data[0].Points[0] != (cairo.Point{X: 0, Y: 0}) ||
+		line.Data[1].Type != cairo.PathLineTo || line.Data[1].Points[0] != (cairo.Point{X: 100, Y: 0.05 * math.Sin(1000)}) {
+		t.Errorf("Unexpected simplified line %+v", line.Data)
+	}
+
+	// 闭合的折线保留拐角和 ClosePath，曲线原样保留
+	b = cairo.NewPathBuilder()
+	b.MoveTo(0, 0)
+	for i := 1; i <= 10; i++ {
+		b.LineTo(float64(i), 0)
+	}
+	b.LineTo(10, 10)
+	b.CurveTo(5, 15, 5, 15, 0, 10)
+	b.ClosePath()
+	want := []cairo.PathDataType{cairo.PathMoveTo, cairo.PathLineTo, cairo.PathLineTo, cairo.PathCurveTo, cairo.PathClosePath}
+	simplified := b.Path().Simplify(0.1)
+	var got []cairo.PathDataType
+	simplified.ForEach(func(op cairo.PathDataType, pts []cairo.Point) { got = append(got, op) })
+	if !slices.Equal(got, want) {
+		t.Fatalf("Expected segments %v, got %v", want, got)
+	}
+	if simplified.Data[1].Points[0] != (cairo.Point{X: 10, Y: 0}) || simplified.Data[3].Points[2] != (cairo.Point{X: 0, Y: 10}) {
+		t.Errorf("Unexpected simplified polygon %+v", simplified.Data)
+	}
+
+	// SimplifyFlat 将曲线展平后一起简化，不再含曲线
+	flat := b.Path().SimplifyFlat(0.1)
+	if n := len(flat.Data); n < 6 || n > 20 {
+		t.Errorf("Expected the flattened curve to keep a few points, got %d segments", n)
+	}
+	for _, data := range flat.Data {
+		if data.Type == cairo.PathCurveTo {
+			t.Fatal("Expected SimplifyFlat to flatten curves")
+		}
+	}
+	if last := flat.Data[len(flat.Data)-1]; last.Type != cairo.PathClosePath {
+		t.Errorf("Expected the simplified subpath to stay closed, ends with %v", last.Type)
+	}
+}
+
+// 测试路径布尔运算：正方形减去圆得到带孔的区域，用两种填充规则填充结果相同
+func TestPathBooleanOperations(t *testing.T) {
+	square := cairo.NewPathBuilder()
+	square.Rectangle(10, 10, 80, 80)
+	circlePath := func(cx, cy, r float64) *cairo.Path {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 1, 1)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		ctx.Arc(cx, cy, r, 0, 2*math.Pi)
+		ctx.ClosePath()
+		return ctx.CopyPath()
+	}
+	circle := circlePath(50, 50, 20)
+
+	// inside 用给定填充规则填充路径并返回像素是否被覆盖
+	inside := func(p *cairo.Path, rule cairo.FillRule, x, y int) bool {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 120, 120)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		ctx.AppendPath(p)
+		ctx.SetFillRule(rule)
+		ctx.Fill()
+		_, _, _, a := surface.(cairo.ImageSurface).GetGoImage().At(x, y).RGBA()
+		return a > 0x8000
+	}
+
+	type sample struct {
+		x, y int
+		want bool
+	}
+	right := circlePath(90, 50, 20)
+	for _, tc := range []struct {
+		name    string
+		path    *cairo.Path
+		samples []sample
+	}{
+		// 正方形角落、圆心、正方形与圆之间、两者之外
+		{"difference", cairo.PathDifference(square.Path(), circle),
+			[]sample{{15, 15, true}, {50, 50, false}, {50, 25, true}, {115, 110, false}}},
+		{"intersect", cairo.PathIntersect(square.Path(), circle),
+			[]sample{{15, 15, false}, {50, 50, true}, {50, 25, false}, {115, 110, false}}},
+		// 右侧的圆与正方形右边缘重叠：重叠处、只在圆内、只在正方形内
+		{"union", cairo.PathUnion(square.Path(), right),
+			[]sample{{85, 50, true}, {105, 50, true}, {15, 15, true}, {115, 110, false}}},
+		{"xor", cairo.PathXor(square.Path(), right),
+			[]sample{{85, 50, false}, {105, 50, true}, {15, 15, true}, {115, 110, false}}},
+	} {
+		if tc.path.Status != cairo.StatusSuccess || len(tc.path.Data) == 0 {
+			t.Fatalf("%s: expected a path, got status %v with %d segments", tc.name, tc.path.Status, len(tc.path.Data))
+		}
+		for _, rule := range []cairo.FillRule{cairo.FillRuleWinding, cairo.FillRuleEvenOdd} {
+			for _, s := range tc.samples {
+				if got := inside(tc.path, rule, s.x, s.y); got != s.want {
+					t.Errorf("%s with fill rule %v: pixel (%d, %d) covered = %v, want %v", tc.name, rule, s.x, s.y, got, s.want)
+				}
+			}
+		}
+	}
+
+	// 差集的孔在一次填充中带有抗锯齿边缘
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 120, 120)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	ctx.AppendPath(cairo.PathDifference(square.Path(), circle))
+	ctx.Fill()
+	partial := false
+	for x := 25; x < 35; x++ {
+		if _, _, _, a := surface.(cairo.ImageSurface).GetGoImage().At(x, 50).RGBA(); a != 0 && a != 0xffff {
+			partial = true
+		}
+	}
+	if !partial {
+		t.Error("Expected an antialiased edge around the hole")
+	}
+
+	if p := cairo.PathUnion(nil, circle); p.Status != cairo.StatusNullPointer {
+		t.Errorf("Expected StatusNullPointer for a nil operand, got %v", p.Status)
+	}
+}
+
+// 测试 SVG 路径数据解析
+func TestAppendSVGPath(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 100)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	// 相对命令、S/T 控制点反射、二次曲线转三次、弧线和紧凑的数字写法
+	err := ctx.AppendSVGPath("M10,20 l30 0 h10 V50 c0,10 10,10 10,0 s10-10 10,0 " +
+		"Q100,20 110,50 t20,0 A20 20 0 0 1 170,50 z m5-5 1.5.5")
+	if err != nil {
+		t.Fatalf("AppendSVGPath failed: %v", err)
+	}
+
+	type segment struct {
+		typ    cairo.PathDataType
+		points []cairo.Point
+	}
+	want := []segment{
+		{cairo.PathMoveTo, []cairo.Point{{X: 10, Y: 20}}},
+		{cairo.PathLineTo, []cairo.Point{{X: 40, Y: 20}}},
+		{cairo.PathLineTo, []cairo.Point{{X: 50, Y: 20}}},
+		{cairo.PathLineTo, []cairo.Point{{X: 50, Y: 50}}},
+		{cairo.PathCurveTo, []cairo.Point{{X: 50, Y: 60}, {X: 60, Y: 60}, {X: 60, Y: 50}}},
+		{cairo.PathCurveTo, []cairo.Point{{X: 60, Y: 40}, {X: 70, Y: 40}, {X: 70, Y: 50}}},
+		{cairo.PathCurveTo, []cairo.Point{{X: 90, Y: 30}, {X: 310.0 / 3, Y: 30}, {X: 110, Y: 50}}},
+		{cairo.PathCurveTo, []cairo.Point{{X: 350.0 / 3, Y: 70}, {X: 370.0 / 3, Y: 70}, {X: 130, Y: 50}}},
+	}
+	path := ctx.CopyPath()
+	if len(path.Data) < len(want) {
+		t.Fatalf("Expected at least %d segments, got %d", len(want), len(path.Data))
+	}
+	for i, w := range want {
+		d := path.Data[i]
+		if d.Type != w.typ || len(d.Points) != len(w.points) {
+			t.Fatalf("Segment %d: expected %v %v, got %v %v", i, w.typ, w.points, d.Type, d.Points)
+		}
+		for j, p := range w.points {
+			if math.Abs(d.Points[j].X-p.X) > 1e-9 || math.Abs(d.Points[j].Y-p.Y) > 1e-9 {
+				t.Errorf("Segment %d point %d: expected %v, got %v", i, j, p, d.Points[j])
+			}
+		}
+	}
+
+	// 弧线由三次曲线组成，经过圆顶并精确结束于终点
+	rest := path.Data[len(want):]
+	arcEnd := 0
+	for arcEnd < len(rest) && rest[arcEnd].Type == cairo.PathCurveTo {
+		arcEnd++
+	}
+	if arcEnd == 0 {
+		t.Fatalf("Expected the arc as curves, got %v", rest[0].Type)
+	}
+	for _, d := range rest[:arcEnd] {
+		end := d.Points[2]
+		if r := math.Hypot(end.X-150, end.Y-50); math.Abs(r-20) > 1e-9 {
+			t.Errorf("Arc point %v is off the circle: radius %f", end, r)
+		}
+		if end.Y > 50+1e-9 {
+			t.Errorf("Arc point %v is on the wrong side for the sweep flag", end)
+		}
+	}
+	if end := rest[arcEnd-1].Points[2]; end != (cairo.Point{X: 170, Y: 50}) {
+		t.Errorf("Expected the arc to end at (170, 50), got %v", end)
+	}
+
+	// z 之后相对移动从子路径起点算起，多余的坐标对是直线
+	tail := rest[arcEnd:]
+	if len(tail) != 3 || tail[0].Type != cairo.PathClosePath ||
+		tail[1].Type != cairo.PathMoveTo || tail[1].Points[0] != (cairo.Point{X: 15, Y: 15}) ||
+		tail[2].Type != cairo.PathLineTo || tail[2].Points[0] != (cairo.Point{X: 16.5, Y: 15.5}) {
+		t.Errorf("Unexpected segments after the arc: %v", tail)
+	}
+
+	// 出错时保留出错前的命令并返回错误
+	ctx.NewPath()
+	err = ctx.AppendSVGPath("M0 0 L10 10 L20")
+	if err == nil {
+		t.Fatal("Expected an error for a truncated command")
+	}
+	if path := ctx.CopyPath(); len(path.Data) != 2 {
+		t.Errorf("Expected the 2 segments before the error, got %d", len(path.Data))
+	}
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Errorf("Expected the context to stay usable, got status %v", ctx.Status())
+	}
+}
+
+// 测试圆角矩形：路径闭合，四角为四分之一圆，半径过大时被限制
+func TestRoundedRectangle(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	const x, y, w, h, r = 10.0, 20.0, 60.0, 40.0, 12.0
+	centers := []cairo.Point{{X: x + w - r, Y: y + r}, {X: x + w - r, Y: y + h - r}, {X: x + r, Y: y + h - r}, {X: x + r, Y: y + r}}
+	ctx.RoundedRectangle(x, y, w, h, r)
+	path := ctx.CopyPath()
+	if path.Data[0].Type != cairo.PathMoveTo || path.Data[len(path.Data)-1].Type != cairo.PathClosePath {
+		t.Fatalf("Expected a closed subpath, got %v ... %v", path.Data[0].Type, path.Data[len(path.Data)-1].Type)
+	}
+	if start := path.Data[0].Points[0]; math.Abs(start.X-(x+w-r)) > 1e-9 || math.Abs(start.Y-y) > 1e-9 {
+		t.Errorf("Expected the subpath to start at the top right corner's arc, got %v", start)
+	}
+
+	// 每段曲线的端点和中点都在所属角的圆上，直线连接相邻的角
+	corner, curves := 0, 0
+	prev := path.Data[0].Points[0]
+	for _, d := range path.Data[1 : len(path.Data)-1] {
+		switch d.Type {
+		case cairo.PathLineTo:
+			corner++
+		case cairo.PathCurveTo:
+			c := centers[corner]
+			p0, p1, p2, p3 := prev, d.Points[0], d.Points[1], d.Points[2]
+			mx := (p0.X + 3*p1.X + 3*p2.X + p3.X) / 8
+			my := (p0.Y + 3*p1.Y + 3*p2.Y + p3.Y) / 8
+			if e := math.Hypot(p3.X-c.X, p3.Y-c.Y) - r; math.Abs(e) > 1e-9 {
+				t.Errorf("Corner %d curve ends off its circle by %f", corner, e)
+			}
+			if e := math.Hypot(mx-c.X, my-c.Y) - r; math.Abs(e) > 0.1 {
+				t.Errorf("Corner %d curve midpoint strays from its circle by %f", corner, e)
+			}
+			curves++
+		}
+		prev = d.Points[len(d.Points)-1]
+	}
+	if corner != 3 || curves < 4 {
+		t.Errorf("Expected four corners joined by three lines, got %d lines and %d curves", corner, curves)
+	}
+	if x1, y1, x2, y2 := pathBounds(ctx.CopyPath()); math.Abs(x1-x) > 1e-6 || math.Abs(y1-y) > 1e-6 || math.Abs(x2-(x+w)) > 1e-6 || math.Abs(y2-(y+h)) > 1e-6 {
+		t.Errorf("Expected extents (%v, %v, %v, %v), got (%v, %v, %v, %v)", x, y, x+w, y+h, x1, y1, x2, y2)
+	}
+
+	// 半径超过短边一半时限制为短边一半：两端成为半圆
+	ctx.NewPath()
+	ctx.RoundedRectangle(10, 10, 60, 20, 50)
+	for _, d := range ctx.CopyPath().Data {
+		for _, p := range d.Points {
+			if p.X > 20+1e-9 && p.X < 60-1e-9 && math.Abs(p.Y-10) > 1e-9 && math.Abs(p.Y-30) > 1e-9 {
+				t.Errorf("Point %v lies inside the straight sides of a clamped rectangle", p)
+			}
+		}
+	}
+	if x1, y1, x2, y2 := pathBounds(ctx.CopyPath()); math.Abs(x1-10) > 1e-6 || math.Abs(y1-10) > 1e-6 || math.Abs(x2-70) > 1e-6 || math.Abs(y2-30) > 1e-6 {
+		t.Errorf("Expected a clamped rectangle to keep its bounds, got (%v, %v, %v, %v)", x1, y1, x2, y2)
+	}
+
+	// 半径为 0 的角保持直角
+	ctx.NewPath()
+	ctx.RoundedRectangleCorners(10, 10, 40, 40, 0, 10, 10, 10)
+	found := false
+	for _, d := range ctx.CopyPath().Data {
+		for _, p := range d.Points {
+			if p == (cairo.Point{X: 10, Y: 10}) {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected a square top left corner at (10, 10)")
+	}
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Fill()
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	if _, _, _, a := img.At(10, 10).RGBA(); a == 0 {
+		t.Error("Expected the square corner pixel to be filled")
+	}
+	if _, _, _, a := img.At(49, 49).RGBA(); a != 0 {
+		t.Error("Expected the rounded corner pixel to be empty")
+	}
+	assertGolden(t, surface, "rounded-rectangle-corners", 1)
+}
+
+// 测试超椭圆：闭合路径上的点满足 |x/rx|^n + |y/ry|^n = 1
+func TestSuperellipse(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	const cx, cy, rx, ry = 50.0, 50.0, 40.0, 25.0
+	for _, n := range []float64{0.7, 2, 4, 50} {
+		ctx.NewPath()
+		ctx.Superellipse(cx, cy, rx, ry, n)
+		path := ctx.CopyPath()
+		if path.Data[0].Type != cairo.PathMoveTo || path.Data[len(path.Data)-1].Type != cairo.PathClosePath {
+			t.Fatalf("n=%v: expected a closed subpath", n)
+		}
+		if start := path.Data[0].Points[0]; start != (cairo.Point{X: cx + rx, Y: cy}) {
+			t.Errorf("n=%v: expected the subpath to start at (%v, %v), got %v", n, cx+rx, cy, start)
+		}
+		for _, d := range path.Data[:len(path.Data)-1] {
+			p := d.Points[0]
+			v := math.Pow(math.Abs((p.X-cx)/rx), n) + math.Pow(math.Abs((p.Y-cy)/ry), n)
+			if math.Abs(v-1) > 1e-9 {
+				t.Errorf("n=%v: point %v is off the superellipse: %f", n, p, v)
+			}
+		}
+		if x1, y1, x2, y2 := pathBounds(path); math.Abs(x1-(cx-rx)) > 1e-6 || math.Abs(y1-(cy-ry)) > 1e-6 ||
+			math.Abs(x2-(cx+rx)) > 1e-6 || math.Abs(y2-(cy+ry)) > 1e-6 {
+			t.Errorf("n=%v: expected extents of the radii, got (%v, %v, %v, %v)", n, x1, y1, x2, y2)
+		}
+	}
+
+	// n 越大越接近矩形：角落附近被填充
+	ctx.NewPath()
+	ctx.Superellipse(cx, cy, rx, ry, 50)
+	ctx.Fill()
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	if _, _, _, a := img.At(int(cx+rx)-3, int(cy+ry)-3).RGBA(); a == 0 {
+		t.Error("Expected a superellipse with a large exponent to fill near its corner")
+	}
+	assertGolden(t, surface, "superellipse", 1)
+}
+
+// pathBounds returns the bounding box of all points of a path, control
+// points included
+func pathBounds(path *cairo.Path) (x1, y1, x2, y2 float64) {
+	x1, y1 = math.Inf(1), math.Inf(1)
+	x2, y2 = math.Inf(-1), math.Inf(-1)
+	for _, d := range path.Data {
+		for _, p := range d.Points {
+			x1, y1 = math.Min(x1, p.X), math.Min(y1, p.Y)
+			x2, y2 = math.Max(x2, p.X), math.Max(y2, p.Y)
+		}
+	}
+	return x1, y1, x2, y2
+}
@@ -1,6 +1,8 @@
 package cairo
 
 import (
+	"image"
+	"math"
 	"testing"
 
 	"github.com/novvoo/go-cairo/pkg/cairo"
@@ -145,6 +147,126 @@ func TestPatternExtend(t *testing.T) {
 	}
 }
 
+// 测试渐变和表面图案的扩展模式在远离原点的负坐标处取色正确
+func TestPatternExtendFarCoordinates(t *testing.T) {
+	type rgba struct{ r, g, b, a uint8 }
+	// 红到蓝渐变在 t 处的颜色
+	at := func(t float64) rgba {
+		return rgba{uint8(255 * (1 - t)), 0, uint8(255 * t), 255}
+	}
+	samples := []struct {
+		t               float64
+		repeat, reflect float64
+		pad             float64
+	}{
+		{-3.5, 0.5, 0.5, 0},
+		{-3.25, 0.75, 0.75, 0},
+		{-0.5, 0.5, 0.5, 0},
+		{-0.25, 0.75, 0.25, 0},
+		{2.5, 0.5, 0.5, 1},
+		{2.25, 0.25, 0.25, 1},
+		{-1000.25, 0.75, 0.25, 0},
+	}
+	// 渐变以像素 4 为采样点，长度为 8
+	gradientPixel := func(extend cairo.Extend, t float64) rgba {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 8, 1)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		x0 := 4 - 8*t
+		pattern := cairo.NewPatternLinear(x0, 0, x0+8, 0)
+		defer pattern.Destroy()
+		gradient := pattern.(cairo.LinearGradientPattern)
+		gradient.AddColorStopRGB(0, 1, 0, 0)
+		gradient.AddColorStopRGB(1, 0, 0, 1)
+		pattern.SetExtend(extend)
+		ctx.SetSource(pattern)
+		ctx.Paint()
+		c := surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA).RGBAAt(4, 0)
+		return rgba{c.R, c.G, c.B, c.A}
+	}
+	for _, s := range samples {
+		for _, tc := range []struct {
+			extend cairo.Extend
+			want   float64
+		}{
+			{cairo.ExtendPad, s.pad},
+			{cairo.ExtendRepeat, s.repeat},
+			{cairo.ExtendReflect, s.reflect},
+		} {
+			if got := gradientPixel(tc.extend, s.t); got != at(tc.want) {
+				t.Errorf("Gradient extend %v at t=%v: expected %v, got %v", tc.extend, s.t, at(tc.want), got)
+			}
+		}
+		// 渐变的 ExtendNone 在 [0, 1] 之外透明
+		if got := gradientPixel(cairo.ExtendNone, s.t); got != (rgba{}) {
+			t.Errorf("Gradient extend none at t=%v: expected transparent, got %v", s.t, got)
+		}
+	}
+	if got := gradientPixel(cairo.ExtendNone, 0.5); got != at(0.5) {
+		t.Errorf("Gradient extend none at t=0.5: expected %v, got %v", at(0.5), got)
+	}
+	// 渐变默认使用 ExtendPad
+	linear := cairo.NewPatternLinear(0, 0, 1, 0)
+	defer linear.Destroy()
+	if extend := linear.GetExtend(); extend != cairo.ExtendPad {
+		t.Errorf("Expected gradients to default to ExtendPad, got %v", extend)
+	}
+
+	// 4x1 贴图：红、绿、蓝、白
+	tile := cairo.NewImageSurface(cairo.FormatARGB32, 4, 1)
+	defer tile.Destroy()
+	colors := []rgba{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 255, 255}}
+	tileCtx := cairo.NewContext(tile)
+	for i, c := range colors {
+		tileCtx.SetSourceRGB(float64(c.r)/255, float64(c.g)/255, float64(c.b)/255)
+		tileCtx.Rectangle(float64(i), 0, 1, 1)
+		tileCtx.Fill()
+	}
+	tileCtx.Destroy()
+
+	// 像素 0 在图案空间中位于 x 处
+	surfacePixel := func(extend cairo.Extend, x float64) rgba {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 1, 1)
+		defer surface.Destroy()
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		pattern := cairo.NewPatternForSurface(tile)
+		defer pattern.Destroy()
+		pattern.SetExtend(extend)
+		pattern.SetFilter(cairo.FilterNearest)
+		pattern.SetMatrix(&cairo.Matrix{XX: 1, YY: 1, X0: x})
+		ctx.SetSource(pattern)
+		ctx.Paint()
+		c := surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA).RGBAAt(0, 0)
+		return rgba{c.R, c.G, c.B, c.A}
+	}
+	transparent := rgba{}
+	for _, tc := range []struct {
+		x                          float64
+		none, pad, repeat, reflect rgba
+	}{
+		{-1003, transparent, colors[0], colors[1], colors[2]},
+		{-1, transparent, colors[0], colors[3], colors[0]},
+		{2, colors[2], colors[2], colors[2], colors[2]},
+		{6, transparent, colors[3], colors[2], colors[1]},
+	} {
+		for _, e := range []struct {
+			extend cairo.Extend
+			want   rgba
+		}{
+			{cairo.ExtendNone, tc.none},
+			{cairo.ExtendPad, tc.pad},
+			{cairo.ExtendRepeat, tc.repeat},
+			{cairo.ExtendReflect, tc.reflect},
+		} {
+			if got := surfacePixel(e.extend, tc.x); got != e.want {
+				t.Errorf("Surface extend %v at x=%v: expected %v, got %v", e.extend, tc.x, e.want, got)
+			}
+		}
+	}
+}
+
 // 测试 Pattern 过滤模式
 func TestPatternFilter(t *testing.T) {
 	pattern := cairo.NewPatternRGB(1.0, 0.0, 0.0)
@@ -179,3 +301,320 @@ func TestMeshPattern(t *testing.T) {
 		t.Errorf("Expected PatternTypeMesh, got %v", pattern.GetType())
 	}
 }
+
+// 测试 Pattern 相位：相邻矩形使用相同相位时条纹应连续
+func TestPatternPhase(t *testing.T) {
+	// 6x1 条纹贴图：左 3 像素红色，右 3 像素蓝色
+	tile := cairo.NewImageSurface(cairo.FormatARGB32, 6, 1)
+	defer tile.Destroy()
+	tileCtx := cairo.NewContext(tile)
+	tileCtx.SetSourceRGB(1, 0, 0)
+	tileCtx.Rectangle(0, 0, 3, 1)
+	tileCtx.Fill()
+	tileCtx.SetSourceRGB(0, 0, 1)
+	tileCtx.Rectangle(3, 0, 3, 1)
+	tileCtx.Fill()
+	tileCtx.Destroy()
+
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 20, 4)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	// 两个独立的 pattern，各自填充一个相邻矩形
+	for _, x := range []float64{0, 10} {
+		pattern := cairo.NewPatternForSurface(tile)
+		pattern.SetExtend(cairo.ExtendRepeat)
+		pattern.SetPhase(2, 0)
+		if px, py := pattern.GetPhase(); px != 2 || py != 0 {
+			t.Fatalf("Expected phase (2, 0), got (%f, %f)", px, py)
+		}
+		ctx.SetSource(pattern)
+		ctx.Rectangle(x, 0, 10, 4)
+		ctx.Fill()
+		pattern.Destroy()
+	}
+
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	for x := 0; x < 20; x++ {
+		r, _, b, _ := img.At(x, 2).RGBA()
+		wantRed := ((x-2)%6+6)%6 < 3
+		if gotRed := r > b; gotRed != wantRed {
+			t.Errorf("Pixel %d: expected red=%v, got r=%d b=%d", x, wantRed, r>>8, b>>8)
+		}
+	}
+}
+
+// 基准测试：在一百万像素上采样线性渐变
+func BenchmarkLinearGradientMegapixel(b *testing.B) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 1000, 1000)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	ctx.Rotate(0.3)
+
+	gradient := cairo.NewPatternLinear(0, 0, 1000, 0)
+	defer gradient.Destroy()
+	gradient.(cairo.LinearGradientPattern).AddColorStopRGB(0, 1, 0, 0)
+	gradient.(cairo.LinearGradientPattern).AddColorStopRGB(1, 0, 0, 1)
+	ctx.SetSource(gradient)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ctx.Paint()
+	}
+}
+
+// 测试各类 Pattern 的访问函数返回构造参数，类型不符时返回 StatusPatternTypeMismatch
+func TestPatternAccessors(t *testing.T) {
+	solid := cairo.NewPatternRGBA(0.1, 0.2, 0.3, 0.4)
+	defer solid.Destroy()
+	if r, g, b, a, status := cairo.PatternGetRGBA(solid); status != cairo.StatusSuccess || r != 0.1 || g != 0.2 || b != 0.3 || a != 0.4 {
+		t.Errorf("PatternGetRGBA = (%v, %v, %v, %v), %v", r, g, b, a, status)
+	}
+
+	img := cairo.NewImageSurface(cairo.FormatARGB32, 4, 4)
+	defer img.Destroy()
+	surfacePattern := cairo.NewPatternForSurface(img)
+	defer surfacePattern.Destroy()
+	if surface, status := cairo.PatternGetSurface(surfacePattern); status != cairo.StatusSuccess || surface != img {
+		t.Errorf("PatternGetSurface = %v, %v", surface, status)
+	} else {
+		surface.Destroy()
+	}
+
+	linear := cairo.NewPatternLinear(1, 2, 3, 4)
+	defer linear.Destroy()
+	linear.(cairo.GradientPattern).AddColorStopRGBA(0.75, 0, 0, 1, 0.5)
+	linear.(cairo.GradientPattern).AddColorStopRGB(0.25, 1, 0, 0)
+	if x0, y0, x1, y1, status := cairo.PatternGetLinearPoints(linear); status != cairo.StatusSuccess || x0 != 1 || y0 != 2 || x1 != 3 || y1 != 4 {
+		t.Errorf("PatternGetLinearPoints = (%v, %v, %v, %v), %v", x0, y0, x1, y1, status)
+	}
+	if n, status := cairo.PatternGetColorStopCount(linear); n != 2 || status != cairo.StatusSuccess {
+		t.Errorf("PatternGetColorStopCount = %d, %v", n, status)
+	}
+	// 色标按偏移排序
+	if offset, r, g, b, a, status := cairo.PatternGetColorStopRGBA(linear, 0); status != cairo.StatusSuccess || offset != 0.25 || r != 1 || g != 0 || b != 0 || a != 1 {
+		t.Errorf("First color stop = (%v, %v, %v, %v, %v), %v", offset, r, g, b, a, status)
+	}
+	if offset, _, _, b, a, _ := cairo.PatternGetColorStopRGBA(linear, 1); offset != 0.75 || b != 1 || a != 0.5 {
+		t.Errorf("Second color stop = offset %v, blue %v, alpha %v", offset, b, a)
+	}
+	if _, _, _, _, _, status := cairo.PatternGetColorStopRGBA(linear, 2); status != cairo.StatusInvalidIndex {
+		t.Errorf("Expected StatusInvalidIndex past the last stop, got %v", status)
+	}
+
+	radial := cairo.NewPatternRadial(1, 2, 3, 4, 5, 6)
+	defer radial.Destroy()
+	if cx0, cy0, r0, cx1, cy1, r1, status := cairo.PatternGetRadialCircles(radial); status != cairo.StatusSuccess ||
+		cx0 != 1 || cy0 != 2 || r0 != 3 || cx1 != 4 || cy1 != 5 || r1 != 6 {
+		t.Errorf("PatternGetRadialCircles = (%v, %v, %v, %v, %v, %v), %v", cx0, cy0, r0, cx1, cy1, r1, status)
+	}
+	if n, status := cairo.PatternGetColorStopCount(radial); n != 0 || status != cairo.StatusSuccess {
+		t.Errorf("Expected no color stops on a new radial gradient, got %d, %v", n, status)
+	}
+
+	mesh := cairo.NewPatternMesh()
+	defer mesh.Destroy()
+	if ref := mesh.Reference(); ref != mesh {
+		t.Error("Expected Reference to return the mesh pattern")
+	} else {
+		ref.Destroy()
+	}
+
+	acquire := func(cairo.Pattern, cairo.Surface, *cairo.Rectangle) cairo.Surface { return nil }
+	raster := cairo.NewPatternRasterSource(acquire, nil)
+	defer raster.Destroy()
+	if a, r := raster.(cairo.RasterSourcePattern).GetAcquire(); a == nil || r != nil {
+		t.Error("Expected GetAcquire to return the callbacks")
+	}
+
+	// 类型不符
+	if _, _, _, _, status := cairo.PatternGetRGBA(linear); status != cairo.StatusPatternTypeMismatch {
+		t.Errorf("PatternGetRGBA on a gradient: %v", status)
+	}
+	if _, status := cairo.PatternGetSurface(solid); status != cairo.StatusPatternTypeMismatch {
+		t.Errorf("PatternGetSurface on a solid pattern: %v", status)
+	}
+	if _, _, _, _, status := cairo.PatternGetLinearPoints(radial); status != cairo.StatusPatternTypeMismatch {
+		t.Errorf("PatternGetLinearPoints on a radial gradient: %v", status)
+	}
+	if _, _, _, _, _, _, status := cairo.PatternGetRadialCircles(linear); status != cairo.StatusPatternTypeMismatch {
+		t.Errorf("PatternGetRadialCircles on a linear gradient: %v", status)
+	}
+	if _, status := cairo.PatternGetColorStopCount(mesh); status != cairo.StatusPatternTypeMismatch {
+		t.Errorf("PatternGetColorStopCount on a mesh: %v", status)
+	}
+	if _, status := cairo.PatternGetSurface(cairo.NewPatternForSurface(nil)); status != cairo.StatusNullPointer {
+		t.Errorf("Expected a pattern in error to report its status, got %v", status)
+	}
+}
+
+// 测试 Mesh Pattern 的查询：读回每个面片的四个内部控制点和角颜色，越界索引返回 StatusInvalidIndex
+func TestMeshPatternAccessors(t *testing.T) {
+	pattern := cairo.NewPatternMesh()
+	defer pattern.Destroy()
+	mesh, ok := pattern.(cairo.MeshPattern)
+	if !ok {
+		t.Fatal("Pattern is not a MeshPattern")
+	}
+
+	corners := [4][2]float64{{0, 0}, {100, 0}, {100, 50}, {0, 50}}
+	points := [4][2]float64{{30, 10}, {70, 15}, {75, 40}, {20, 35}}
+	colors := [4][4]float64{{1, 0, 0, 1}, {0, 1, 0, 1}, {0, 0, 1, 0.5}, {1, 1, 0, 0.25}}
+	for patch := 0; patch < 2; patch++ {
+		if err := mesh.MeshPatternBeginPatch(); err != nil {
+			t.Fatal(err)
+		}
+		mesh.MeshPatternMoveTo(corners[0][0], corners[0][1])
+		for _, c := range corners[1:] {
+			mesh.MeshPatternLineTo(c[0], c[1])
+		}
+		for i := 0; i < 4; i++ {
+			mesh.MeshPatternSetControlPoint(i, points[i][0]+float64(patch), points[i][1])
+			c := colors[i]
+			mesh.MeshPatternSetCornerColor(i, c[0], c[1], c[2], c[3])
+		}
+		if n := mesh.GetPatchCount(); n != patch {
+			t.Errorf("Expected %d patches while building, got %d", patch, n)
+		}
+		if err := mesh.MeshPatternEndPatch(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, status := cairo.MeshPatternGetPatchCount(pattern); n != 2 || status != cairo.StatusSuccess {
+		t.Fatalf("MeshPatternGetPatchCount = %d, %v", n, status)
+	}
+	for patch := 0; patch < 2; patch++ {
+		for i := 0; i < 4; i++ {
+			x, y, status := mesh.GetControlPoint(patch, i)
+			if status != cairo.StatusSuccess || x != points[i][0]+float64(patch) || y != points[i][1] {
+				t.Errorf("Patch %d control point %d = (%v, %v), %v", patch, i, x, y, status)
+			}
+			r, g, b, a, status := cairo.MeshPatternGetCornerColorRGBA(pattern, patch, i)
+			if status != cairo.StatusSuccess || [4]float64{r, g, b, a} != colors[i] {
+				t.Errorf("Patch %d corner %d = (%v, %v, %v, %v), %v", patch, i, r, g, b, a, status)
+			}
+		}
+	}
+
+	for _, index := range [][2]int{{-1, 0}, {2, 0}, {0, -1}, {0, 4}} {
+		if _, _, status := cairo.MeshPatternGetControlPoint(pattern, index[0], index[1]); status != cairo.StatusInvalidIndex {
+			t.Errorf("GetControlPoint(%d, %d): expected StatusInvalidIndex, got %v", index[0], index[1], status)
+		}
+	}
+	for _, index := range [][2]int{{-1, 0}, {2, 0}, {0, -1}, {0, 4}} {
+		if _, _, _, _, status := mesh.GetCornerColor(index[0], index[1]); status != cairo.StatusInvalidIndex {
+			t.Errorf("GetCornerColor(%d, %d): expected StatusInvalidIndex, got %v", index[0], index[1], status)
+		}
+	}
+
+	solid := cairo.NewPatternRGB(0, 0, 0)
+	defer solid.Destroy()
+	if _, status := cairo.MeshPatternGetPatchCount(solid); status != cairo.StatusPatternTypeMismatch {
+		t.Errorf("MeshPatternGetPatchCount on a solid pattern: %v", status)
+	}
+}
+
+// 测试用 MoveTo/LineTo/CurveTo 构造曲边面片，以及四角面片的直边补全
+func TestMeshPatternCurvedPatch(t *testing.T) {
+	pattern := cairo.NewPatternMesh()
+	defer pattern.Destroy()
+	mesh := pattern.(cairo.MeshPattern)
+
+	// 上边为曲线，右边为直线，下边为曲线，左边由 EndPatch 闭合
+	mesh.MeshPatternBeginPatch()
+	// 没有起点时 LineTo 相当于 MoveTo
+	if err := mesh.MeshPatternLineTo(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := mesh.MeshPatternCurveTo(30, -20, 70, 20, 100, 0); err != nil {
+		t.Fatal(err)
+	}
+	mesh.MeshPatternLineTo(100, 90)
+	mesh.MeshPatternCurveTo(70, 110, 30, 70, 0, 90)
+	if err := mesh.MeshPatternMoveTo(5, 5); err == nil {
+		t.Error("Expected MoveTo after the patch's sides to fail")
+	}
+	for i := 0; i < 4; i++ {
+		mesh.MeshPatternSetCornerColor(i, 1, 0, 0, 1)
+	}
+	if err := mesh.MeshPatternEndPatch(); err != nil {
+		t.Fatal(err)
+	}
+
+	path, status := cairo.MeshPatternGetPath(pattern, 0)
+	if status != cairo.StatusSuccess || len(path.Data) != 6 || path.Data[0].Type != cairo.PathMoveTo {
+		t.Fatalf("Expected a move, four curves and a close, got %v", path)
+	}
+	want := [4][3][2]float64{
+		{{30, -20}, {70, 20}, {100, 0}},   // 上边
+		{{100, 30}, {100, 60}, {100, 90}}, // 右边
+		{{70, 110}, {30, 70}, {0, 90}},    // 下边
+		{{0, 60}, {0, 30}, {0, 0}},        // 左边，直线闭合
+	}
+	if p := path.Data[0].Points[0]; p != (cairo.Point{}) {
+		t.Errorf("Expected the boundary to start at the origin, got %v", p)
+	}
+	for side, w := range want {
+		d := path.Data[1+side]
+		if d.Type != cairo.PathCurveTo {
+			t.Fatalf("Side %d is %v, expected a curve", side, d.Type)
+		}
+		for k, pt := range d.Points {
+			if math.Abs(pt.X-w[k][0]) > 1e-9 || math.Abs(pt.Y-w[k][1]) > 1e-9 {
+				t.Errorf("Side %d point %d = %v, expected %v", side, k, pt, w[k])
+			}
+		}
+	}
+
+	// 四条直边的面片为双线性，未设置的内部控制点位于三等分点
+	mesh.MeshPatternBeginPatch()
+	mesh.MeshPatternMoveTo(0, 0)
+	mesh.MeshPatternLineTo(60, 0)
+	mesh.MeshPatternLineTo(60, 30)
+	mesh.MeshPatternLineTo(0, 30)
+	mesh.MeshPatternSetControlPoint(2, 45, 25)
+	mesh.MeshPatternEndPatch()
+	interior := [4][2]float64{{20, 10}, {40, 10}, {45, 25}, {20, 20}}
+	for i, w := range interior {
+		x, y, status := mesh.GetControlPoint(1, i)
+		if status != cairo.StatusSuccess || math.Abs(x-w[0]) > 1e-9 || math.Abs(y-w[1]) > 1e-9 {
+			t.Errorf("Interior control point %d = (%v, %v), %v; expected %v", i, x, y, status, w)
+		}
+	}
+
+	// 第五条边和没有边的面片都是构造错误
+	mesh.MeshPatternBeginPatch()
+	mesh.MeshPatternMoveTo(0, 0)
+	for i := 0; i < 4; i++ {
+		mesh.MeshPatternLineTo(float64(i), 1)
+	}
+	if err := mesh.MeshPatternLineTo(5, 5); err == nil {
+		t.Error("Expected a fifth side to fail")
+	}
+	mesh.MeshPatternEndPatch()
+	mesh.MeshPatternBeginPatch()
+	mesh.MeshPatternMoveTo(0, 0)
+	if err := mesh.MeshPatternEndPatch(); err == nil {
+		t.Error("Expected a patch without sides to fail")
+	}
+
+	// 没有 MoveTo 的面片没有边界；内部控制点只有 0 到 3
+	other := cairo.NewPatternMesh()
+	defer other.Destroy()
+	otherMesh := other.(cairo.MeshPattern)
+	otherMesh.MeshPatternBeginPatch()
+	if err := otherMesh.MeshPatternSetControlPoint(0, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, pointNum := range []int{-1, 4, 11} {
+		if err := otherMesh.MeshPatternSetControlPoint(pointNum, 0, 0); err == nil {
+			t.Errorf("Expected control point %d to be out of range", pointNum)
+		}
+	}
+	if err := otherMesh.MeshPatternEndPatch(); err == nil {
+		t.Error("Expected a patch without a boundary to fail")
+	}
+}
@@ -3,6 +3,7 @@ package cairo
 import (
 	"image"
 	"image/color"
+	"math"
 	"testing"
 
 	"github.com/novvoo/go-cairo/pkg/cairo"
@@ -108,3 +109,92 @@ func BenchmarkRasterizeBezier(b *testing.B) {
 		rast.Rasterize(img, color.Black, cairo.FillRuleWinding)
 	}
 }
+
+// polygonPoints 返回一个 n 个顶点、半径起伏的闭合多边形
+func polygonPoints(n int, cx, cy, radius float64) []cairo.Point {
+	pts := make([]cairo.Point, n)
+	for i := range pts {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		r := radius * (0.75 + 0.25*math.Sin(float64(i)*0.7))
+		pts[i] = cairo.Point{X: cx + r*math.Cos(angle), Y: cy + r*math.Sin(angle)}
+	}
+	return pts
+}
+
+// 基准测试：填充 1000 个顶点的多边形
+func BenchmarkFillPolygon1000(b *testing.B) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 500, 500)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	ctx.SetSourceRGB(0.2, 0.4, 0.8)
+	pts := polygonPoints(1000, 250, 250, 240)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.Polygon(pts)
+		ctx.Fill()
+	}
+}
+
+// 测试抗锯齿填充按精确面积计算 30° 斜边的覆盖率
+func TestFillExactCoverage30Degree(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 60, 40)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	// 填充直线 y = 5 + x·tan30° 上方的区域
+	slope := math.Tan(math.Pi / 6)
+	edge := func(x float64) float64 { return 5 + x*slope }
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.Polygon([]cairo.Point{{X: 0, Y: 0}, {X: 60, Y: 0}, {X: 60, Y: edge(60)}, {X: 0, Y: edge(0)}})
+	ctx.Fill()
+
+	img := surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 60; x++ {
+			// 在像素内对直线下方被覆盖的高度积分
+			const steps = 1000
+			exact := 0.0
+			for i := 0; i < steps; i++ {
+				h := edge(float64(x)+(float64(i)+0.5)/steps) - float64(y)
+				exact += math.Min(math.Max(h, 0), 1) / steps
+			}
+			got := float64(img.RGBAAt(x, y).A) / 255
+			if math.Abs(got-exact) > 1.5/255 {
+				t.Errorf("Pixel (%d, %d): expected coverage %.4f, got %.4f", x, y, exact, got)
+			}
+		}
+	}
+}
+
+// 测试奇偶填充规则
+func TestFillRuleEvenOdd(t *testing.T) {
+	for _, tc := range []struct {
+		rule   cairo.FillRule
+		center uint8
+	}{
+		{cairo.FillRuleWinding, 255},
+		{cairo.FillRuleEvenOdd, 0},
+	} {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+		ctx := cairo.NewContext(surface)
+		ctx.SetFillRule(tc.rule)
+		ctx.SetSourceRGB(0, 0, 0)
+		// 两个同向的嵌套矩形：中心的环绕数为 2
+		ctx.Rectangle(5, 5, 30, 30)
+		ctx.Rectangle(15, 15, 10, 10)
+		ctx.Fill()
+
+		img := surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+		if a := img.RGBAAt(20, 20).A; a != tc.center {
+			t.Errorf("Fill rule %v: expected center alpha %d, got %d", tc.rule, tc.center, a)
+		}
+		if a := img.RGBAAt(10, 10).A; a != 255 {
+			t.Errorf("Fill rule %v: expected ring alpha 255, got %d", tc.rule, a)
+		}
+		ctx.Destroy()
+		surface.Destroy()
+	}
+}
@@ -1,10 +1,26 @@
 package cairo
 
 import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/novvoo/go-cairo/pkg/cairo"
+	"golang.org/x/image/bmp"
 )
 
 // 测试创建图像 Surface
@@ -172,6 +188,111 @@ func TestSurfaceFlushAndMarkDirty(t *testing.T) {
 	surface.MarkDirtyRectangle(10, 10, 50, 50)
 }
 
+// 测试 Flush 之后 GetData 与 GetGoImage 一致
+func TestSurfaceFlushSyncsData(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SetSourceRGBA(1, 0.5, 0, 0.5)
+	ctx.Rectangle(2, 2, 4, 4)
+	ctx.Fill()
+
+	imgSurface := surface.(cairo.ImageSurface)
+	if err := imgSurface.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	data, stride := imgSurface.GetData(), imgSurface.GetStride()
+	img := imgSurface.GetGoImage().(*image.RGBA)
+	for _, p := range []image.Point{{3, 3}, {8, 8}} {
+		c := img.RGBAAt(p.X, p.Y)
+		got := data[p.Y*stride+p.X*4:][:4]
+		if want := []byte{c.A, c.R, c.G, c.B}; !bytes.Equal(got, want) {
+			t.Errorf("Pixel %v: expected ARGB %v, got %v", p, want, got)
+		}
+	}
+	if data[3*stride+3*4] == 0 {
+		t.Error("Expected the filled pixel to have alpha after Flush")
+	}
+}
+
+// 测试通过 GetData 修改像素后 MarkDirty 同步到 GetGoImage
+func TestSurfaceMarkDirtyLoadsData(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	ctx.SetSourceRGB(0, 0, 1)
+	ctx.Paint()
+
+	imgSurface := surface.(cairo.ImageSurface)
+	imgSurface.Flush()
+	data, stride := imgSurface.GetData(), imgSurface.GetStride()
+	// 半透明红色，预乘后为 A=128 R=128
+	copy(data[4*stride+5*4:], []byte{128, 128, 0, 0})
+	copy(data[7*stride+7*4:], []byte{255, 0, 255, 0})
+	imgSurface.MarkDirtyRectangle(0, 0, 6, 6)
+
+	img := imgSurface.GetGoImage().(*image.RGBA)
+	if got, want := img.RGBAAt(5, 4), (color.RGBA{R: 128, A: 128}); got != want {
+		t.Errorf("Expected %v at (5, 4) after MarkDirtyRectangle, got %v", want, got)
+	}
+	if got := img.RGBAAt(7, 7); got.B != 255 {
+		t.Errorf("Expected the pixel outside the rectangle to stay blue, got %v", got)
+	}
+	if got := img.RGBAAt(2, 2); got.B != 255 {
+		t.Errorf("Expected drawn pixels to survive MarkDirty, got %v", got)
+	}
+
+	imgSurface.MarkDirty()
+	if got := img.RGBAAt(7, 7); got != (color.RGBA{G: 255, A: 255}) {
+		t.Errorf("Expected green at (7, 7) after MarkDirty, got %v", got)
+	}
+
+	// NewImageSurfaceForData 使用传入的数据
+	pixels := make([]byte, 4*4*4)
+	copy(pixels[4*4+4:], []byte{255, 255, 255, 255})
+	fromData := cairo.NewImageSurfaceForData(pixels, cairo.FormatARGB32, 4, 4, 16)
+	defer fromData.Destroy()
+	if got := fromData.(cairo.ImageSurface).GetGoImage().(*image.RGBA).RGBAAt(1, 1); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("Expected the supplied pixel to show, got %v", got)
+	}
+}
+
+// 测试 PDF Surface 的 Flush 写出当前文档
+func TestPDFFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flush.pdf")
+	surface := cairo.NewPDFSurface(path, 20, 20)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.Paint()
+	if err := surface.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.Contains(data, []byte("/Count 1 ")) {
+		t.Error("Flush should write a one-page PDF")
+	}
+
+	// Flush 不结束 Surface，之后仍可继续绘制与翻页
+	ctx.ShowPage()
+	ctx.Paint()
+	if err := surface.Finish(); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if !bytes.Contains(data, []byte("/Count 2 ")) {
+		t.Error("Expected two pages after drawing past a Flush")
+	}
+}
+
 // 测试 Surface Finish
 func TestSurfaceFinish(t *testing.T) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
@@ -281,6 +402,68 @@ func TestSurfaceGoImage(t *testing.T) {
 	}
 }
 
+// 测试 ToGoImage 返回与表面不共享内存的非预乘副本
+func TestSurfaceToGoImage(t *testing.T) {
+	rgb := cairo.NewImageSurface(cairo.FormatRGB24, 10, 10)
+	defer rgb.Destroy()
+	ctx := cairo.NewContext(rgb)
+	ctx.SetSourceRGB(0, 0, 1)
+	ctx.Paint()
+	ctx.SetSourceRGBA(1, 0, 0, 0.5)
+	ctx.Rectangle(0, 0, 5, 10)
+	ctx.Fill()
+	ctx.Destroy()
+
+	img, ok := rgb.(cairo.ImageSurface).ToGoImage().(*image.NRGBA)
+	if !ok {
+		t.Fatal("Expected ToGoImage to return an *image.NRGBA")
+	}
+	if img.Bounds() != image.Rect(0, 0, 10, 10) {
+		t.Fatalf("Unexpected bounds %v", img.Bounds())
+	}
+	// RGB24 没有 alpha，半透明红色与蓝色混合后仍不透明
+	if c := img.NRGBAAt(2, 5); c.A != 255 || c.R < 126 || c.R > 129 || c.G != 0 || c.B < 126 || c.B > 129 {
+		t.Errorf("Expected opaque red over blue at (2, 5), got %v", c)
+	}
+	if c := img.NRGBAAt(7, 5); c != (color.NRGBA{0, 0, 255, 255}) {
+		t.Errorf("Expected opaque blue at (7, 5), got %v", c)
+	}
+	// 副本不随表面变化
+	img.SetNRGBA(7, 5, color.NRGBA{0, 255, 0, 255})
+	if _, g, _, _ := rgb.(cairo.ImageSurface).GetGoImage().At(7, 5).RGBA(); g != 0 {
+		t.Error("Modifying the copy must not change the surface")
+	}
+
+	// A8 表面的 alpha 以黑色加透明度表示
+	src := cairo.NewImageSurface(cairo.FormatARGB32, 4, 2)
+	defer src.Destroy()
+	ctx = cairo.NewContext(src)
+	ctx.SetSourceRGBA(1, 1, 1, 0.25)
+	ctx.Rectangle(0, 0, 2, 2)
+	ctx.Fill()
+	ctx.SetSourceRGBA(0, 1, 0, 1)
+	ctx.Rectangle(2, 0, 1, 2)
+	ctx.Fill()
+	ctx.Destroy()
+	a8 := src.(cairo.ImageSurface).ConvertToFormat(cairo.FormatA8)
+	defer a8.Destroy()
+
+	alpha, ok := a8.ToGoImage().(*image.NRGBA)
+	if !ok {
+		t.Fatal("Expected ToGoImage of an A8 surface to return an *image.NRGBA")
+	}
+	for x, want := range []int{64, 64, 255, 0} {
+		if c := alpha.NRGBAAt(x, 1); int(c.A) < want-1 || int(c.A) > want+1 || c.R != 0 || c.G != 0 || c.B != 0 {
+			t.Errorf("Expected black with alpha %d at (%d, 1), got %v", want, x, c)
+		}
+	}
+
+	broken := cairo.NewImageSurface(cairo.FormatARGB32, -1, 1)
+	if broken.(cairo.ImageSurface).ToGoImage() != nil {
+		t.Error("Expected nil from a surface in error")
+	}
+}
+
 // 测试 Surface 内容类型
 func TestSurfaceContent(t *testing.T) {
 	tests := []struct {
@@ -300,3 +483,1800 @@ func TestSurfaceContent(t *testing.T) {
 		surface.Destroy()
 	}
 }
+
+// 测试读取子区域像素
+func TestSurfaceRegionData(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Rectangle(20, 30, 10, 10)
+	ctx.Fill()
+
+	imgSurface := surface.(cairo.ImageSurface)
+	data, stride := imgSurface.RegionData(cairo.RectangleInt{X: 15, Y: 25, Width: 20, Height: 20})
+	if stride != imgSurface.GetStride() {
+		t.Fatalf("Expected stride %d, got %d", imgSurface.GetStride(), stride)
+	}
+	if want := 19*stride + 20*4; len(data) != want {
+		t.Fatalf("Expected region length %d, got %d", want, len(data))
+	}
+
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			px := data[y*stride+x*4 : y*stride+x*4+4]
+			inside := x >= 5 && x < 15 && y >= 5 && y < 15
+			if inside && (px[0] != 255 || px[1] != 0 || px[2] != 0 || px[3] != 255) {
+				t.Fatalf("Expected red at region (%d,%d), got %v", x, y, px)
+			}
+			if !inside && px[3] != 0 {
+				t.Fatalf("Expected transparent at region (%d,%d), got %v", x, y, px)
+			}
+		}
+	}
+
+	// 超出边界的区域会被裁剪
+	data, _ = imgSurface.RegionData(cairo.RectangleInt{X: 90, Y: 90, Width: 50, Height: 50})
+	if want := 9*stride + 10*4; len(data) != want {
+		t.Errorf("Expected clamped region length %d, got %d", want, len(data))
+	}
+	if data, _ := imgSurface.RegionData(cairo.RectangleInt{X: 200, Y: 0, Width: 10, Height: 10}); data != nil {
+		t.Error("Expected nil data for region outside the surface")
+	}
+}
+
+// 测试加载半透明 PNG 时正确预乘 alpha
+func TestLoadPNGSurfacePremultiplied(t *testing.T) {
+	// PNG 存储的是非预乘 alpha：左半部分为 50% 透明的蓝色
+	src := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 10; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{B: 255, A: 128})
+		}
+	}
+	filename := filepath.Join(t.TempDir(), "translucent.png")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, src); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	loaded, err := cairo.LoadPNGSurface(filename)
+	if err != nil {
+		t.Fatalf("Failed to load PNG: %v", err)
+	}
+	defer loaded.Destroy()
+
+	if got := loaded.(cairo.ImageSurface).GetGoImage().At(5, 10); got != (color.RGBA{B: 128, A: 128}) {
+		t.Errorf("Expected premultiplied pixel {0 0 128 128}, got %v", got)
+	}
+
+	// 合成到白色背景上应得到原始外观
+	dst := cairo.NewImageSurface(cairo.FormatARGB32, 20, 20)
+	defer dst.Destroy()
+	ctx := cairo.NewContext(dst)
+	defer ctx.Destroy()
+	ctx.SetSourceRGB(1, 1, 1)
+	ctx.Paint()
+	ctx.SetSourceSurface(loaded, 0, 0)
+	ctx.Rectangle(0, 0, 20, 20)
+	ctx.Fill()
+
+	img := dst.(cairo.ImageSurface).GetGoImage()
+	tests := []struct {
+		p       image.Point
+		r, g, b uint32
+	}{
+		{image.Pt(5, 10), 127, 127, 255},
+		{image.Pt(15, 10), 255, 255, 255},
+	}
+	for _, tt := range tests {
+		r, g, b, _ := img.At(tt.p.X, tt.p.Y).RGBA()
+		if absDiff(r>>8, tt.r) > 2 || absDiff(g>>8, tt.g) > 2 || absDiff(b>>8, tt.b) > 2 {
+			t.Errorf("Pixel %v: expected (%d,%d,%d), got (%d,%d,%d)", tt.p, tt.r, tt.g, tt.b, r>>8, g>>8, b>>8)
+		}
+	}
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// 测试 DiffSurfaces：差异像素高亮为红色，其余像素变淡
+func TestDiffSurfaces(t *testing.T) {
+	draw := func(withRect bool) cairo.Surface {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		ctx.SetSourceRGB(0.5, 0.5, 0.5)
+		ctx.Rectangle(0, 0, 40, 40)
+		ctx.Fill()
+		if withRect {
+			ctx.SetSourceRGB(0, 0, 0)
+			ctx.Rectangle(10, 10, 15, 15)
+			ctx.Fill()
+		}
+		return surface
+	}
+	a, b := draw(false), draw(true)
+	defer a.Destroy()
+	defer b.Destroy()
+
+	diff := cairo.DiffSurfaces(a, b)
+	defer diff.Destroy()
+	if diff.Status() != cairo.StatusSuccess {
+		t.Fatalf("DiffSurfaces failed: %v", diff.Status())
+	}
+
+	img := diff.(cairo.ImageSurface).GetGoImage()
+	orig := a.(cairo.ImageSurface).GetGoImage()
+	red := color.RGBA{R: 255, A: 255}
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			got := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			inRect := x >= 10 && x < 25 && y >= 10 && y < 25
+			if inRect {
+				if got != red {
+					t.Fatalf("Pixel (%d,%d) inside the changed rectangle: expected red, got %v", x, y, got)
+				}
+				continue
+			}
+			o := color.RGBAModel.Convert(orig.At(x, y)).(color.RGBA)
+			if got == red || got.A != 255 || got.R <= o.R || got.R != got.G || got.G != got.B {
+				t.Fatalf("Pixel (%d,%d) outside the rectangle: expected dimmed gray lighter than %v, got %v", x, y, o, got)
+			}
+		}
+	}
+
+	small := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer small.Destroy()
+	if status := cairo.DiffSurfaces(a, small).Status(); status != cairo.StatusInvalidSize {
+		t.Errorf("Expected StatusInvalidSize for mismatched sizes, got %v", status)
+	}
+}
+
+// 测试子表面与父表面共享像素并裁剪到子区域
+func TestCreateForRectangle(t *testing.T) {
+	parent := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+	defer parent.Destroy()
+
+	sub := parent.CreateForRectangle(10, 20, 15, 10)
+	defer sub.Destroy()
+
+	if sub.Status() != cairo.StatusSuccess {
+		t.Fatalf("subsurface status: %v", sub.Status())
+	}
+	if sub.GetType() != cairo.SurfaceTypeSubsurface {
+		t.Errorf("expected SurfaceTypeSubsurface, got %v", sub.GetType())
+	}
+	img := sub.(cairo.ImageSurface)
+	if img.GetWidth() != 15 || img.GetHeight() != 10 {
+		t.Errorf("subsurface size %dx%d, expected 15x10", img.GetWidth(), img.GetHeight())
+	}
+
+	// 在子表面上绘制超出其范围的内容，应被裁剪到子区域
+	ctx := cairo.NewContext(sub)
+	ctx.SetSourceRGB(0, 1, 0)
+	ctx.Rectangle(-5, -5, 100, 100)
+	ctx.Fill()
+	ctx.Destroy()
+
+	pimg := parent.(cairo.ImageSurface).GetGoImage()
+	for _, p := range [][2]int{{10, 20}, {24, 29}} {
+		if _, g, _, _ := pimg.At(p[0], p[1]).RGBA(); g == 0 {
+			t.Errorf("parent pixel (%d, %d) should be drawn through the subsurface", p[0], p[1])
+		}
+	}
+	for _, p := range [][2]int{{9, 20}, {25, 29}, {10, 19}, {24, 30}} {
+		if _, _, _, a := pimg.At(p[0], p[1]).RGBA(); a != 0 {
+			t.Errorf("parent pixel (%d, %d) is outside the subsurface and should be untouched", p[0], p[1])
+		}
+	}
+}
+
+// 测试超出边界的子表面矩形
+func TestCreateForRectangleOutOfBounds(t *testing.T) {
+	parent := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+	defer parent.Destroy()
+
+	// 部分越界：裁剪到父表面范围
+	sub := parent.CreateForRectangle(30, 30, 20, 20)
+	defer sub.Destroy()
+	if sub.Status() != cairo.StatusSuccess {
+		t.Fatalf("clamped subsurface status: %v", sub.Status())
+	}
+	img := sub.(cairo.ImageSurface)
+	if img.GetWidth() != 10 || img.GetHeight() != 10 {
+		t.Errorf("clamped size %dx%d, expected 10x10", img.GetWidth(), img.GetHeight())
+	}
+
+	// 完全越界：返回错误状态
+	outside := parent.CreateForRectangle(50, 50, 10, 10)
+	defer outside.Destroy()
+	if outside.Status() != cairo.StatusInvalidSize {
+		t.Errorf("expected StatusInvalidSize, got %v", outside.Status())
+	}
+}
+
+// 测试相似 Surface 继承设备缩放
+func TestCreateSimilarPreservesDeviceScale(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 200)
+	defer surface.Destroy()
+	surface.SetDeviceScale(2, 2)
+
+	similar := surface.CreateSimilar(cairo.ContentColorAlpha, 50, 30)
+	defer similar.Destroy()
+
+	if sx, sy := similar.GetDeviceScale(); sx != 2 || sy != 2 {
+		t.Errorf("device scale (%f, %f), expected (2, 2)", sx, sy)
+	}
+	img := similar.(cairo.ImageSurface)
+	if img.GetWidth() != 100 || img.GetHeight() != 60 {
+		t.Errorf("similar surface is %dx%d pixels, expected 100x60", img.GetWidth(), img.GetHeight())
+	}
+}
+
+// 测试设备缩放作用于渲染
+func TestDeviceScaleRendering(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 200, 200)
+	defer surface.Destroy()
+	surface.SetDeviceScale(2, 2)
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	// 用户空间 (0,0)-(50,50) 应覆盖设备像素 (0,0)-(100,100)
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Rectangle(0, 0, 50, 50)
+	ctx.Fill()
+
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	if _, _, _, a := img.At(99, 99).RGBA(); a == 0 {
+		t.Error("pixel (99, 99) should be covered at device scale 2")
+	}
+	if _, _, _, a := img.At(101, 101).RGBA(); a != 0 {
+		t.Error("pixel (101, 101) should be outside the scaled rectangle")
+	}
+
+	// 组也应以设备分辨率渲染
+	ctx.PushGroup()
+	ctx.SetSourceRGB(0, 0, 1)
+	ctx.Rectangle(60, 60, 10, 10)
+	ctx.Fill()
+	ctx.PopGroupToSource()
+	ctx.Paint()
+
+	if _, _, b, _ := img.At(139, 139).RGBA(); b == 0 {
+		t.Error("group content should land at device pixel (139, 139)")
+	}
+	if _, _, b, _ := img.At(141, 141).RGBA(); b != 0 {
+		t.Error("group content should not extend past device pixel 140")
+	}
+}
+
+// 测试设备偏移作用于渲染，并与设备缩放组合
+func TestDeviceOffsetRendering(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	surface.SetDeviceOffset(10, 20)
+
+	ctx := cairo.NewContext(surface)
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Rectangle(0, 0, 5, 5)
+	ctx.Fill()
+	ctx.Destroy()
+
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	if _, _, _, a := img.At(10, 20).RGBA(); a == 0 {
+		t.Error("drawing at the origin should start at device pixel (10, 20)")
+	}
+	if _, _, _, a := img.At(9, 19).RGBA(); a != 0 {
+		t.Error("pixel (9, 19) should be left untouched")
+	}
+
+	// 偏移以设备单位表示，不受缩放影响
+	scaled := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer scaled.Destroy()
+	scaled.SetDeviceScale(2, 2)
+	scaled.SetDeviceOffset(10, 20)
+
+	ctx = cairo.NewContext(scaled)
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Rectangle(5, 5, 5, 5)
+	ctx.Fill()
+	ctx.Destroy()
+
+	img = scaled.(cairo.ImageSurface).GetGoImage()
+	if _, _, _, a := img.At(20, 30).RGBA(); a == 0 {
+		t.Error("user (5, 5) should map to device pixel (20, 30)")
+	}
+	if _, _, _, a := img.At(29, 39).RGBA(); a == 0 {
+		t.Error("pixel (29, 39) should be covered")
+	}
+	if _, _, _, a := img.At(30, 40).RGBA(); a != 0 {
+		t.Error("pixel (30, 40) should be outside the rectangle")
+	}
+}
+
+// 测试 PNG 写入后再读取，像素保持一致
+func TestReadPNGSurfaceRoundTrip(t *testing.T) {
+	src := cairo.NewImageSurface(cairo.FormatARGB32, 4, 2)
+	defer src.Destroy()
+	img := src.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	img.SetRGBA(0, 0, color.RGBA{R: 255, A: 255})
+	img.SetRGBA(1, 0, color.RGBA{G: 128, A: 128})
+	img.SetRGBA(2, 1, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+
+	path := filepath.Join(t.TempDir(), "roundtrip.png")
+	if status := src.(cairo.ImageSurface).WriteToPNG(path); status != cairo.StatusSuccess {
+		t.Fatalf("WriteToPNG failed: %v", status)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	loaded, status := cairo.ReadPNGSurface(file)
+	if status != cairo.StatusSuccess {
+		t.Fatalf("ReadPNGSurface failed: %v", status)
+	}
+	defer loaded.Destroy()
+
+	if format := loaded.(cairo.ImageSurface).GetFormat(); format != cairo.FormatARGB32 {
+		t.Errorf("expected FormatARGB32, got %v", format)
+	}
+	got := loaded.(cairo.ImageSurface).GetGoImage()
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if got.At(x, y) != img.At(x, y) {
+				t.Errorf("pixel (%d, %d): got %v, want %v", x, y, got.At(x, y), img.At(x, y))
+			}
+		}
+	}
+}
+
+// 测试不带 alpha 的 PNG 读取为 RGB24
+func TestReadPNGSurfaceRGB(t *testing.T) {
+	rgb := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	for i := range rgb.Pix {
+		rgb.Pix[i] = 0xff
+	}
+	rgb.SetRGBA(1, 1, color.RGBA{R: 40, G: 50, B: 60, A: 255})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rgb); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, status := cairo.ReadPNGSurface(&buf)
+	if status != cairo.StatusSuccess {
+		t.Fatalf("ReadPNGSurface failed: %v", status)
+	}
+	defer loaded.Destroy()
+
+	imgSurface := loaded.(cairo.ImageSurface)
+	if imgSurface.GetFormat() != cairo.FormatRGB24 {
+		t.Errorf("expected FormatRGB24, got %v", imgSurface.GetFormat())
+	}
+	if got := imgSurface.GetGoImage().At(1, 1); got != (color.RGBA{R: 40, G: 50, B: 60, A: 255}) {
+		t.Errorf("pixel (1, 1) = %v", got)
+	}
+
+	// 无效数据应返回错误状态
+	bad, status := cairo.ReadPNGSurface(bytes.NewReader([]byte("not a png")))
+	if status != cairo.StatusReadError || bad.Status() != cairo.StatusReadError {
+		t.Errorf("expected StatusReadError, got %v / %v", status, bad.Status())
+	}
+}
+
+// 测试通过 WriteFunc/ReadFunc 以流的方式读写 PNG
+func TestPNGStreamRoundTrip(t *testing.T) {
+	src := cairo.NewImageSurface(cairo.FormatARGB32, 8, 8)
+	defer src.Destroy()
+	img := src.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	img.SetRGBA(3, 4, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+	img.SetRGBA(5, 6, color.RGBA{B: 64, A: 64})
+
+	var buf bytes.Buffer
+	status := src.(cairo.ImageSurface).WriteToPNGStream(func(closure interface{}, data []byte) error {
+		_, err := closure.(*bytes.Buffer).Write(data)
+		return err
+	}, &buf)
+	if status != cairo.StatusSuccess {
+		t.Fatalf("WriteToPNGStream failed: %v", status)
+	}
+
+	// 读取函数必须填满整个缓冲区，否则返回错误
+	loaded, status := cairo.ReadPNGSurfaceStream(func(closure interface{}, data []byte) error {
+		r := closure.(*bytes.Reader)
+		if r.Len() < len(data) {
+			return io.ErrUnexpectedEOF
+		}
+		_, err := r.Read(data)
+		return err
+	}, bytes.NewReader(buf.Bytes()))
+	if status != cairo.StatusSuccess {
+		t.Fatalf("ReadPNGSurfaceStream failed: %v", status)
+	}
+	defer loaded.Destroy()
+
+	got := loaded.(cairo.ImageSurface).GetGoImage()
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if got.At(x, y) != img.At(x, y) {
+				t.Errorf("pixel (%d, %d): got %v, want %v", x, y, got.At(x, y), img.At(x, y))
+			}
+		}
+	}
+
+	// 写入函数出错时返回 StatusWriteError
+	status = src.(cairo.ImageSurface).WriteToPNGStream(func(interface{}, []byte) error {
+		return io.ErrShortWrite
+	}, nil)
+	if status != cairo.StatusWriteError {
+		t.Errorf("expected StatusWriteError, got %v", status)
+	}
+}
+
+// 测试通过 Context 的 ShowPage 生成多页 PDF
+func TestPDFShowPageMultiPage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pages.pdf")
+	surface := cairo.NewPDFSurface(path, 100, 50)
+	ctx := cairo.NewContext(surface)
+
+	// 第一页
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Rectangle(10, 10, 20, 20)
+	ctx.Fill()
+	ctx.ShowPage()
+
+	// 第二页
+	ctx.SetSourceRGB(0, 0, 1)
+	ctx.Rectangle(50, 10, 20, 20)
+	ctx.Fill()
+	ctx.ShowPage()
+
+	ctx.Destroy()
+	if err := surface.Finish(); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	surface.Destroy()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Fatal("output is not a PDF file")
+	}
+	if !bytes.Contains(data, []byte("/Count 2 ")) {
+		t.Error("page tree should report 2 pages")
+	}
+	if n := bytes.Count(data, []byte("/Type /Page ")); n != 2 {
+		t.Errorf("expected 2 page objects, got %d", n)
+	}
+}
+
+// 测试 PDF 页面中的透明度组按组整体合成：重叠区域不会被重复加深
+func TestPDFOpacityGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "group.pdf")
+	surface := cairo.NewPDFSurface(path, 100, 100)
+	ctx := cairo.NewContext(surface)
+	ctx.PushOpacityGroup()
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.Rectangle(10, 10, 50, 50)
+	ctx.Fill()
+	ctx.Rectangle(40, 40, 50, 50)
+	ctx.Fill()
+	ctx.PopOpacityGroup(0.5)
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Fatalf("Opacity group failed: %v", ctx.Status())
+	}
+	ctx.Destroy()
+	surface.Destroy()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 页面以 100x100 的 RGB 图像嵌入，合成在白色背景上
+	start := bytes.Index(data, []byte("/Subtype /Image"))
+	if start < 0 {
+		t.Fatal("Expected the page to be embedded as an image")
+	}
+	start += bytes.Index(data[start:], []byte("stream\n")) + len("stream\n")
+	zr, err := zlib.NewReader(bytes.NewReader(data[start:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pix, err := io.ReadAll(zr)
+	if err != nil || len(pix) != 100*100*3 {
+		t.Fatalf("Reading the page image: %d bytes, %v", len(pix), err)
+	}
+	gray := func(x, y int) byte { return pix[(y*100+x)*3] }
+
+	if got := gray(95, 5); got != 255 {
+		t.Errorf("Expected white outside the group, got %d", got)
+	}
+	if got := gray(20, 20); got < 120 || got > 136 {
+		t.Errorf("Expected a single shape at 50%% gray, got %d", got)
+	}
+	if single, overlap := gray(20, 20), gray(50, 50); overlap != single {
+		t.Errorf("Expected the overlap not to be double-darkened: single=%d overlap=%d", single, overlap)
+	}
+}
+
+// 测试 CopyPage 保留当前页内容并输出页面
+func TestPDFCopyPage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "copy.pdf")
+	surface := cairo.NewPDFSurface(path, 20, 20)
+	ctx := cairo.NewContext(surface)
+
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.Paint()
+	ctx.CopyPage()
+	// 保留的内容在结束时作为最后一页输出
+	ctx.Destroy()
+	surface.Destroy()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("/Count 2 ")) {
+		t.Error("CopyPage should emit a page and keep content for a second one")
+	}
+}
+
+// 测试高斯模糊：对称扩散、总量守恒、均匀区域不变
+func TestBlurGaussian(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 21, 21)
+	defer surface.Destroy()
+	imgSurface := surface.(cairo.ImageSurface)
+	img := imgSurface.GetGoImage().(*image.RGBA)
+	img.SetRGBA(10, 10, color.RGBA{R: 255, A: 255})
+
+	imgSurface.BlurGaussian(4)
+
+	center := img.RGBAAt(10, 10)
+	if center.A == 255 || center.A == 0 {
+		t.Errorf("center alpha %d should be spread out", center.A)
+	}
+	if img.RGBAAt(7, 10) != img.RGBAAt(13, 10) || img.RGBAAt(10, 7) != img.RGBAAt(10, 13) {
+		t.Error("blur should be symmetric")
+	}
+	if img.RGBAAt(0, 0).A != 0 {
+		t.Error("blur should not reach beyond its radius")
+	}
+	var total int
+	for i := 3; i < len(img.Pix); i += 4 {
+		c := img.Pix[i-3 : i+1]
+		if c[0] > c[3] || c[1] != 0 || c[2] != 0 {
+			t.Fatalf("pixel %v is not valid premultiplied red", c)
+		}
+		total += int(c[3])
+	}
+	if total < 245 || total > 265 {
+		t.Errorf("total alpha %d should stay close to 255", total)
+	}
+
+	// 均匀区域（含边缘）模糊后保持不变
+	flat := cairo.NewImageSurface(cairo.FormatARGB32, 16, 16)
+	defer flat.Destroy()
+	flatImg := flat.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	for i := 0; i < len(flatImg.Pix); i += 4 {
+		copy(flatImg.Pix[i:], []byte{40, 80, 120, 200})
+	}
+	flat.(cairo.ImageSurface).BlurGaussian(5)
+	flat.(cairo.ImageSurface).BlurBox(3)
+	for i := 0; i < len(flatImg.Pix); i += 4 {
+		if !bytes.Equal(flatImg.Pix[i:i+4], []byte{40, 80, 120, 200}) {
+			t.Fatalf("flat area changed to %v", flatImg.Pix[i:i+4])
+		}
+	}
+}
+
+// 测试盒式模糊的平均效果
+func TestBlurBox(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 9, 9)
+	defer surface.Destroy()
+	img := surface.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	img.SetRGBA(4, 4, color.RGBA{B: 225, A: 225})
+
+	surface.(cairo.ImageSurface).BlurBox(1)
+
+	// 3x3 盒式核：中心附近 9 个像素各得到 1/9
+	for y := 3; y <= 5; y++ {
+		for x := 3; x <= 5; x++ {
+			if got := img.RGBAAt(x, y); got != (color.RGBA{B: 25, A: 25}) {
+				t.Errorf("pixel (%d, %d) = %v, expected 1/9 of the source", x, y, got)
+			}
+		}
+	}
+	if img.RGBAAt(2, 4).A != 0 {
+		t.Error("box blur should not reach beyond its radius")
+	}
+}
+
+// 测试面积平均缩放：4 倍缩小棋盘格得到近似均匀的灰色
+func TestScaledTo(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 64, 64)
+	defer surface.Destroy()
+	imgSurface := surface.(cairo.ImageSurface)
+	img := imgSurface.GetGoImage().(*image.RGBA)
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if (x+y)%2 == 0 {
+				img.SetRGBA(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				img.SetRGBA(x, y, color.RGBA{A: 255})
+			}
+		}
+	}
+
+	scaled := imgSurface.ScaledTo(16, 16)
+	defer scaled.Destroy()
+	if scaled.Status() != cairo.StatusSuccess {
+		t.Fatalf("ScaledTo failed: %v", scaled.Status())
+	}
+	if scaled.GetWidth() != 16 || scaled.GetHeight() != 16 || scaled.GetFormat() != cairo.FormatARGB32 {
+		t.Fatalf("unexpected result %dx%d format %v", scaled.GetWidth(), scaled.GetHeight(), scaled.GetFormat())
+	}
+	out := scaled.GetGoImage().(*image.RGBA)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			c := out.RGBAAt(x, y)
+			if c.A != 255 || c.R < 126 || c.R > 129 || c.R != c.G || c.G != c.B {
+				t.Fatalf("pixel (%d, %d) = %v, expected uniform mid gray", x, y, c)
+			}
+		}
+	}
+
+	// 透明像素不会让相邻的不透明颜色变暗
+	half := cairo.NewImageSurface(cairo.FormatARGB32, 2, 1)
+	defer half.Destroy()
+	half.(cairo.ImageSurface).GetGoImage().(*image.RGBA).SetRGBA(0, 0, color.RGBA{R: 200, A: 200})
+	one := half.(cairo.ImageSurface).ScaledTo(1, 1)
+	defer one.Destroy()
+	if got := one.GetGoImage().(*image.RGBA).RGBAAt(0, 0); got != (color.RGBA{R: 100, A: 100}) {
+		t.Errorf("premultiplied average = %v, expected {100 0 0 100}", got)
+	}
+
+	if imgSurface.ScaledTo(0, 4).Status() != cairo.StatusInvalidSize {
+		t.Error("ScaledTo should reject a non-positive size")
+	}
+}
+
+// 测试抖动：渐变转换为 RGB565 时 DitherBest 比 DitherNone 产生更多不同的值
+func TestConvertToFormatDither(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 256, 16)
+	defer surface.Destroy()
+	imgSurface := surface.(cairo.ImageSurface)
+	img := imgSurface.GetGoImage().(*image.RGBA)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 256; x++ {
+			v := uint8(x / 4)
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	uniqueValues := func(dither cairo.Dither) int {
+		imgSurface.SetDither(dither)
+		converted := imgSurface.ConvertToFormat(cairo.FormatRGB16565)
+		defer converted.Destroy()
+		if converted.Status() != cairo.StatusSuccess || converted.GetFormat() != cairo.FormatRGB16565 {
+			t.Fatalf("ConvertToFormat failed: %v", converted.Status())
+		}
+		data, stride := converted.GetData(), converted.GetStride()
+		values := make(map[uint16]bool)
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 256; x++ {
+				values[binary.LittleEndian.Uint16(data[y*stride+x*2:])] = true
+			}
+		}
+		return len(values)
+	}
+
+	none := uniqueValues(cairo.DitherNone)
+	best := uniqueValues(cairo.DitherBest)
+	if best <= none {
+		t.Errorf("DitherBest gave %d unique values, expected more than DitherNone's %d", best, none)
+	}
+	if good := uniqueValues(cairo.DitherGood); good <= none {
+		t.Errorf("DitherGood gave %d unique values, expected more than DitherNone's %d", good, none)
+	}
+	if imgSurface.GetDither() != cairo.DitherGood {
+		t.Error("GetDither should return the mode set with SetDither")
+	}
+
+	// A1：50% alpha 的有序抖动大约点亮一半像素
+	half := cairo.NewImageSurface(cairo.FormatARGB32, 16, 16)
+	defer half.Destroy()
+	halfImg := half.(cairo.ImageSurface).GetGoImage().(*image.RGBA)
+	for i := 3; i < len(halfImg.Pix); i += 4 {
+		halfImg.Pix[i] = 128
+	}
+	half.(cairo.ImageSurface).SetDither(cairo.DitherGood)
+	mask := half.(cairo.ImageSurface).ConvertToFormat(cairo.FormatA1)
+	defer mask.Destroy()
+	var set int
+	for _, b := range mask.GetData() {
+		for ; b != 0; b &= b - 1 {
+			set++
+		}
+	}
+	if set < 112 || set > 144 {
+		t.Errorf("%d of 256 A1 pixels set, expected about half", set)
+	}
+}
+
+// 测试 RGB16565：绘制后 GetData 返回正确打包的 565 值，PNG 导出扩展回 8 位
+func TestRGB16565Rendering(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatRGB16565, 8, 4)
+	defer surface.Destroy()
+	imgSurface := surface.(cairo.ImageSurface)
+	if imgSurface.GetStride() != 16 {
+		t.Fatalf("stride = %d, expected 16", imgSurface.GetStride())
+	}
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	ctx.SetSourceRGB(1, 0.5, 0.25)
+	ctx.Rectangle(0, 0, 4, 4)
+	ctx.Fill()
+
+	// R 255 -> 31，G 127 -> 31，B 63 -> 8
+	data := imgSurface.GetData()
+	if got := binary.LittleEndian.Uint16(data[2*16+1*2:]); got != 0xFBE8 {
+		t.Errorf("filled pixel = %#04x, expected 0xfbe8", got)
+	}
+	if got := binary.LittleEndian.Uint16(data[2*16+6*2:]); got != 0 {
+		t.Errorf("unfilled pixel = %#04x, expected black", got)
+	}
+
+	var buf bytes.Buffer
+	status := imgSurface.WriteToPNGStream(func(_ interface{}, p []byte) error {
+		_, err := buf.Write(p)
+		return err
+	}, nil)
+	if status != cairo.StatusSuccess {
+		t.Fatalf("WriteToPNGStream failed: %v", status)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, g, b, a := decoded.At(1, 2).RGBA()
+	if r>>8 != 255 || g>>8 != 125 || b>>8 != 66 || a>>8 != 255 {
+		t.Errorf("exported pixel = (%d, %d, %d, %d), expected (255, 125, 66, 255)", r>>8, g>>8, b>>8, a>>8)
+	}
+}
+
+// 测试浮点格式：叠加 300 层 1% 白色，结果应接近 1-0.99^300≈0.951，而不是被 8 位量化提前截断
+func TestFloatFormatAccumulation(t *testing.T) {
+	for _, tc := range []struct {
+		format   cairo.Format
+		channels int
+	}{
+		{cairo.FormatRGBA128F, 4},
+		{cairo.FormatRGB96F, 3},
+	} {
+		surface := cairo.NewImageSurface(tc.format, 4, 4)
+		imgSurface := surface.(cairo.ImageSurface)
+		ctx := cairo.NewContext(surface)
+		if tc.format == cairo.FormatRGB96F {
+			// 无 alpha 通道的表面先铺黑色
+			ctx.SetSourceRGB(0, 0, 0)
+			ctx.Paint()
+		}
+		ctx.SetSourceRGBA(1, 1, 1, 0.01)
+		for i := 0; i < 300; i++ {
+			ctx.Paint()
+		}
+
+		data := imgSurface.GetData()
+		pixel := data[2*imgSurface.GetStride()+1*tc.channels*4:]
+		for ch := 0; ch < tc.channels; ch++ {
+			v := math.Float32frombits(binary.LittleEndian.Uint32(pixel[ch*4:]))
+			if v < 0.941 || v > 0.961 {
+				t.Errorf("format %v channel %d = %.4f, expected about 0.951", tc.format, ch, v)
+			}
+		}
+
+		// PNG 导出截断到 8 位
+		var buf bytes.Buffer
+		status := imgSurface.WriteToPNGStream(func(_ interface{}, p []byte) error {
+			_, err := buf.Write(p)
+			return err
+		}, nil)
+		if status != cairo.StatusSuccess {
+			t.Fatalf("WriteToPNGStream failed: %v", status)
+		}
+		decoded, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r, _, _, _ := decoded.At(1, 2).RGBA(); r>>8 < 240 || r>>8 > 245 {
+			t.Errorf("format %v exported red = %d, expected about 242", tc.format, r>>8)
+		}
+
+		ctx.Destroy()
+		surface.Destroy()
+	}
+}
+
+// 测试 Tee Surface：一次绘制同时输出到两个目标，第二个目标设备缩放 2 倍
+func TestTeeSurface(t *testing.T) {
+	primary := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+	defer primary.Destroy()
+	copySurface := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+	defer copySurface.Destroy()
+	scaled := cairo.NewImageSurface(cairo.FormatARGB32, 80, 80)
+	defer scaled.Destroy()
+	scaled.SetDeviceScale(2, 2)
+
+	tee := cairo.NewTeeSurface(primary, copySurface)
+	defer tee.Destroy()
+	if err := cairo.TeeSurfaceAdd(tee, scaled); err != nil {
+		t.Fatal(err)
+	}
+	if cairo.TeeSurfaceIndex(tee, 0) != primary || cairo.TeeSurfaceIndex(tee, 2) != scaled {
+		t.Error("TeeSurfaceIndex returned the wrong targets")
+	}
+	if cairo.TeeSurfaceIndex(tee, 3).Status() != cairo.StatusInvalidIndex {
+		t.Error("out of range index should give StatusInvalidIndex")
+	}
+	if cairo.TeeSurfaceRemove(tee, primary) == nil {
+		t.Error("removing the primary surface should fail")
+	}
+
+	ctx := cairo.NewContext(tee)
+	defer ctx.Destroy()
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Rectangle(5, 5, 20, 10)
+	ctx.Fill()
+	ctx.SetSourceRGB(0, 0, 1)
+	ctx.Arc(20, 28, 6, 0, 2*math.Pi)
+	ctx.Fill()
+
+	a := primary.(cairo.ImageSurface).GetGoImage()
+	b := copySurface.(cairo.ImageSurface).GetGoImage()
+	c := scaled.(cairo.ImageSurface).GetGoImage()
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			if a.At(x, y) != b.At(x, y) {
+				t.Fatalf("targets differ at (%d, %d): %v vs %v", x, y, a.At(x, y), b.At(x, y))
+			}
+		}
+	}
+	if r, _, _, _ := a.At(10, 10).RGBA(); r>>8 != 255 {
+		t.Errorf("primary rectangle pixel red = %d, expected 255", r>>8)
+	}
+	if _, _, bl, _ := a.At(20, 28).RGBA(); bl>>8 != 255 {
+		t.Errorf("primary circle pixel blue = %d, expected 255", bl>>8)
+	}
+	if r, _, _, _ := c.At(20, 20).RGBA(); r>>8 != 255 {
+		t.Errorf("scaled rectangle pixel red = %d, expected 255", r>>8)
+	}
+	if _, _, bl, _ := c.At(40, 56).RGBA(); bl>>8 != 255 {
+		t.Errorf("scaled circle pixel blue = %d, expected 255", bl>>8)
+	}
+
+	// 移除的目标不再接收绘制
+	if err := cairo.TeeSurfaceRemove(tee, copySurface); err != nil {
+		t.Fatal(err)
+	}
+	ctx.SetSourceRGB(0, 1, 0)
+	ctx.Paint()
+	if _, g, _, _ := a.At(0, 0).RGBA(); g>>8 != 255 {
+		t.Errorf("primary painted green = %d, expected 255", g>>8)
+	}
+	if _, g, _, _ := b.At(0, 0).RGBA(); g != 0 {
+		t.Error("removed target was still drawn to")
+	}
+}
+
+// 测试 Observer Surface：每次 Fill 触发一次回调并带有耗时，绘制转发到目标
+func TestObserverSurface(t *testing.T) {
+	target := cairo.NewImageSurface(cairo.FormatARGB32, 20, 20)
+	defer target.Destroy()
+	observer := cairo.NewObserverSurface(target)
+	defer observer.Destroy()
+	if observer.GetType() != cairo.SurfaceTypeObserver {
+		t.Errorf("type = %v, expected SurfaceTypeObserver", observer.GetType())
+	}
+
+	var fills, strokes int
+	var total time.Duration
+	err := observer.AddCallback(cairo.ObserverFill, func(o cairo.ObserverSurface, s cairo.Surface, op cairo.ObserverCallbackType, elapsed time.Duration) {
+		if s != target || op != cairo.ObserverFill {
+			t.Errorf("callback got target %v and op %v", s, op)
+		}
+		fills++
+		total += elapsed
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	observer.AddCallback(cairo.ObserverStroke, func(cairo.ObserverSurface, cairo.Surface, cairo.ObserverCallbackType, time.Duration) {
+		strokes++
+	})
+
+	ctx := cairo.NewContext(observer)
+	defer ctx.Destroy()
+	ctx.SetSourceRGB(1, 0, 0)
+	for i := 0; i < 3; i++ {
+		ctx.Rectangle(float64(i*5), 0, 4, 4)
+		ctx.Fill()
+	}
+	ctx.MoveTo(0, 10)
+	ctx.LineTo(20, 10)
+	ctx.Stroke()
+
+	if fills != 3 || observer.Count(cairo.ObserverFill) != 3 {
+		t.Errorf("fill callback ran %d times (count %d), expected 3", fills, observer.Count(cairo.ObserverFill))
+	}
+	if strokes != 1 {
+		t.Errorf("stroke callback ran %d times, expected 1", strokes)
+	}
+	if total <= 0 || observer.Elapsed() < total {
+		t.Errorf("elapsed: callbacks %v, observer %v", total, observer.Elapsed())
+	}
+	img := target.(cairo.ImageSurface).GetGoImage()
+	if r, _, _, _ := img.At(6, 2).RGBA(); r>>8 != 255 {
+		t.Errorf("target pixel red = %d, expected 255", r>>8)
+	}
+}
+
+// 基准测试：512x512 表面半径 5 的高斯模糊
+func BenchmarkBlurGaussian(b *testing.B) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 512, 512)
+	defer surface.Destroy()
+	imgSurface := surface.(cairo.ImageSurface)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		imgSurface.BlurGaussian(5)
+	}
+}
+
+// 测试快照在原表面继续绘制后保持不变
+func TestSurfaceSnapshot(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 20, 20)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Paint()
+
+	snapshot := surface.Snapshot()
+	defer snapshot.Destroy()
+	if snapshot.Status() != cairo.StatusSuccess {
+		t.Fatalf("Snapshot failed: %v", snapshot.Status())
+	}
+
+	ctx.SetSourceRGB(0, 0, 1)
+	ctx.Paint()
+
+	snapImg := snapshot.(cairo.ImageSurface).GetGoImage()
+	if r, _, b, _ := snapImg.At(10, 10).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("Expected the snapshot to stay red, got r=%d b=%d", r>>8, b>>8)
+	}
+	if r, _, b, _ := surface.(cairo.ImageSurface).GetGoImage().At(10, 10).RGBA(); r != 0 || b>>8 != 255 {
+		t.Errorf("Expected the surface to be blue, got r=%d b=%d", r>>8, b>>8)
+	}
+
+	// 以表面自身为源绘制时读取的是绘制前的内容
+	ctx.Rectangle(10, 0, 10, 20)
+	ctx.SetSourceRGB(0, 1, 0)
+	ctx.Fill()
+	ctx.SetSourceSurface(surface, 5, 0)
+	ctx.Paint()
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	if _, g, b, _ := img.At(12, 10).RGBA(); g != 0 || b>>8 != 255 {
+		t.Errorf("Expected the shifted copy to be blue at x=12, got g=%d b=%d", g>>8, b>>8)
+	}
+	if _, g, b, _ := img.At(17, 10).RGBA(); g>>8 != 255 || b != 0 {
+		t.Errorf("Expected the shifted copy to be green at x=17, got g=%d b=%d", g>>8, b>>8)
+	}
+
+	// 非图像表面不支持快照
+	recording := cairo.NewRecordingSurface(cairo.ContentColorAlpha, 20, 20)
+	defer recording.Destroy()
+	if s := recording.Snapshot(); s.Status() != cairo.StatusSurfaceTypeMismatch {
+		t.Errorf("Expected StatusSurfaceTypeMismatch for a recording surface, got %v", s.Status())
+	}
+}
+
+// 测试在快照上绘制只改变快照，原表面保持不变
+func TestDrawToSnapshot(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 20, 20)
+	defer surface.Destroy()
+
+	alpha := func(s cairo.Surface, x, y int) uint32 {
+		_, _, _, a := s.(cairo.ImageSurface).GetGoImage().At(x, y).RGBA()
+		return a >> 8
+	}
+
+	snapshot := surface.Snapshot()
+	defer snapshot.Destroy()
+	ctx := cairo.NewContext(snapshot)
+	defer ctx.Destroy()
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Paint()
+	if a := alpha(snapshot, 10, 10); a != 255 {
+		t.Errorf("Expected the snapshot to be painted, got alpha %d", a)
+	}
+	if a := alpha(surface, 10, 10); a != 0 {
+		t.Errorf("Expected the original to stay transparent, got alpha %d", a)
+	}
+
+	// 通过快照的子表面绘制也不影响原表面
+	other := surface.Snapshot()
+	defer other.Destroy()
+	sub := other.(cairo.ImageSurface).CreateForRectangle(5, 5, 10, 10)
+	defer sub.Destroy()
+	subCtx := cairo.NewContext(sub)
+	defer subCtx.Destroy()
+	subCtx.SetSourceRGB(0, 0, 1)
+	subCtx.Paint()
+	if a := alpha(other, 10, 10); a != 255 {
+		t.Errorf("Expected the subsurface to paint the snapshot, got alpha %d", a)
+	}
+	if a := alpha(surface, 10, 10); a != 0 {
+		t.Errorf("Expected the original to stay transparent, got alpha %d", a)
+	}
+}
+
+// 测试反复设置同一源表面时复用快照，源表面被修改后才创建新快照
+func TestSetSourceSurfaceReusesSnapshot(t *testing.T) {
+	src := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer src.Destroy()
+	srcCtx := cairo.NewContext(src)
+	defer srcCtx.Destroy()
+	srcCtx.SetSourceRGB(1, 0, 0)
+	srcCtx.Paint()
+
+	dst := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer dst.Destroy()
+	ctx := cairo.NewContext(dst)
+	defer ctx.Destroy()
+
+	sourceSurface := func() cairo.Surface {
+		pattern := ctx.GetSource()
+		defer pattern.Destroy()
+		surface, status := cairo.PatternGetSurface(pattern)
+		if status != cairo.StatusSuccess {
+			t.Fatalf("PatternGetSurface failed: %v", status)
+		}
+		surface.Destroy()
+		return surface
+	}
+
+	ctx.SetSourceSurface(src, 0, 0)
+	first := sourceSurface()
+	for i := 0; i < 100; i++ {
+		ctx.SetSourceSurface(src, float64(i), 0)
+		if sourceSurface() != first {
+			t.Fatalf("Expected call %d to reuse the snapshot of the unmodified surface", i)
+		}
+	}
+
+	// 修改源表面后得到新快照，旧快照保持原内容
+	ctx.SetSourceSurface(src, 0, 0)
+	old := ctx.GetSource()
+	defer old.Destroy()
+	srcCtx.SetSourceRGB(0, 0, 1)
+	srcCtx.Paint()
+	ctx.SetSourceSurface(src, 0, 0)
+	if sourceSurface() == first {
+		t.Fatal("Expected a new snapshot after the surface was drawn to")
+	}
+	ctx.Paint()
+	if r, _, b, _ := dst.(cairo.ImageSurface).GetGoImage().At(5, 5).RGBA(); r != 0 || b>>8 != 255 {
+		t.Errorf("Expected the new snapshot to be blue, got r=%d b=%d", r>>8, b>>8)
+	}
+	ctx.SetSource(old)
+	ctx.Paint()
+	if r, _, b, _ := dst.(cairo.ImageSurface).GetGoImage().At(5, 5).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("Expected the earlier snapshot to stay red, got r=%d b=%d", r>>8, b>>8)
+	}
+}
+
+// 测试预乘与非预乘 alpha 的相互转换
+func TestPremultiplyAlphaRoundTrip(t *testing.T) {
+	// 所有合法的预乘像素（0 < a < 255）转换为非预乘再转换回来应完全一致
+	img := image.NewRGBA(image.Rect(0, 0, 256, 254))
+	for a := 1; a < 255; a++ {
+		for c := 0; c <= a; c++ {
+			img.SetRGBA(c, a-1, color.RGBA{R: uint8(c), G: uint8(a - c), B: uint8(c / 2), A: uint8(a)})
+		}
+	}
+	orig := bytes.Clone(img.Pix)
+	cairo.UnpremultiplyAlpha(img)
+	if got := img.RGBAAt(64, 127); got.R != 128 || got.A != 128 {
+		t.Errorf("Expected straight R=128 A=128 for premultiplied 64/128, got %v", got)
+	}
+	cairo.PremultiplyAlpha(img)
+	if !bytes.Equal(img.Pix, orig) {
+		t.Error("Premultiplied pixels changed after a round trip")
+	}
+
+	// 非预乘转换往返误差不超过量化步长
+	straight := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	values := []color.RGBA{{200, 100, 50, 128}, {255, 0, 10, 200}, {33, 66, 99, 96}, {1, 2, 3, 255}}
+	for i, v := range values {
+		straight.SetRGBA(i%2, i/2, v)
+	}
+	cairo.PremultiplyAlpha(straight)
+	if got := straight.RGBAAt(0, 0); got != (color.RGBA{100, 50, 25, 128}) {
+		t.Errorf("Expected premultiplied {100 50 25 128}, got %v", got)
+	}
+	cairo.UnpremultiplyAlpha(straight)
+	for i, v := range values {
+		got := straight.RGBAAt(i%2, i/2)
+		tol := 255/int(v.A)/2 + 1
+		for _, d := range [][2]uint8{{got.R, v.R}, {got.G, v.G}, {got.B, v.B}} {
+			if diff := int(d[0]) - int(d[1]); diff > tol || diff < -tol {
+				t.Errorf("Pixel %v came back as %v", v, got)
+				break
+			}
+		}
+	}
+
+	// 子图像只转换自身区域
+	sub := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	sub.SetRGBA(0, 0, color.RGBA{200, 0, 0, 128})
+	sub.SetRGBA(1, 0, color.RGBA{200, 0, 0, 128})
+	cairo.PremultiplyAlpha(sub.SubImage(image.Rect(1, 0, 2, 1)).(*image.RGBA))
+	if sub.RGBAAt(0, 0).R != 200 || sub.RGBAAt(1, 0).R != 100 {
+		t.Errorf("Expected only the sub-image to change, got %v and %v", sub.RGBAAt(0, 0), sub.RGBAAt(1, 0))
+	}
+
+	// 后端混合把表面中的预乘像素当作预乘值读取
+	backend := cairo.NewImageBackend(1, 1)
+	backend.GetImage().SetRGBA(0, 0, color.RGBA{R: 128, A: 128})
+	backend.BlendPixel(0, 0, color.RGBA{}, cairo.OperatorOver)
+	if got := backend.GetImage().RGBAAt(0, 0); got.R < 127 || got.R > 129 || got.A != 128 {
+		t.Errorf("Expected blending a clear pixel to keep {128 0 0 128}, got %v", got)
+	}
+}
+
+// decodeAPNG 按 APNG 规范拆出每一帧，把帧数据拼成独立的 PNG 再解码
+func decodeAPNG(t *testing.T, data []byte) (frames []image.Image, delays [][2]uint16) {
+	t.Helper()
+	signature := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+	if !bytes.HasPrefix(data, signature) {
+		t.Fatal("Missing PNG signature")
+	}
+
+	type chunk struct {
+		name string
+		data []byte
+	}
+	var chunks []chunk
+	for rest := data[len(signature):]; len(rest) > 0; {
+		if len(rest) < 12 {
+			t.Fatal("Truncated chunk")
+		}
+		n := binary.BigEndian.Uint32(rest)
+		c := chunk{string(rest[4:8]), rest[8 : 8+n]}
+		if crc32.ChecksumIEEE(rest[4:8+n]) != binary.BigEndian.Uint32(rest[8+n:]) {
+			t.Fatalf("Bad CRC in %s chunk", c.name)
+		}
+		chunks = append(chunks, c)
+		rest = rest[12+n:]
+	}
+
+	writeChunk := func(buf *bytes.Buffer, name string, data []byte) {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(len(data)))
+		buf.Write(b[:])
+		buf.WriteString(name)
+		buf.Write(data)
+		binary.BigEndian.PutUint32(b[:], crc32.ChecksumIEEE(append([]byte(name), data...)))
+		buf.Write(b[:])
+	}
+
+	var ihdr []byte
+	numFrames := -1
+	seq := uint32(0)
+	var frameData [][]byte
+	for _, c := range chunks {
+		switch c.name {
+		case "IHDR":
+			ihdr = c.data
+		case "acTL":
+			numFrames = int(binary.BigEndian.Uint32(c.data))
+		case "fcTL", "fdAT":
+			if got := binary.BigEndian.Uint32(c.data); got != seq {
+				t.Fatalf("Expected sequence number %d, got %d", seq, got)
+			}
+			seq++
+			if c.name == "fcTL" {
+				delays = append(delays, [2]uint16{binary.BigEndian.Uint16(c.data[20:]), binary.BigEndian.Uint16(c.data[22:])})
+				frameData = append(frameData, nil)
+			} else {
+				frameData[len(frameData)-1] = append(frameData[len(frameData)-1], c.data[4:]...)
+			}
+		case "IDAT":
+			frameData[len(frameData)-1] = append(frameData[len(frameData)-1], c.data...)
+		}
+	}
+	if numFrames != len(frameData) {
+		t.Fatalf("acTL announces %d frames, found %d", numFrames, len(frameData))
+	}
+
+	for i, d := range frameData {
+		var buf bytes.Buffer
+		buf.Write(signature)
+		writeChunk(&buf, "IHDR", ihdr)
+		writeChunk(&buf, "IDAT", d)
+		writeChunk(&buf, "IEND", nil)
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("Frame %d does not decode: %v", i, err)
+		}
+		frames = append(frames, img)
+	}
+	return frames, delays
+}
+
+// 测试 APNG 写入：三帧动画可以逐帧解码回来
+func TestAPNGWriter(t *testing.T) {
+	colors := []color.NRGBA{{255, 0, 0, 255}, {0, 255, 0, 128}, {0, 0, 255, 255}}
+
+	var out bytes.Buffer
+	writer := cairo.NewAPNGWriter(&out, 20, 10)
+	for i, c := range colors {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, 20, 10)
+		ctx := cairo.NewContext(surface)
+		ctx.SetSourceRGBA(float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, float64(c.A)/255)
+		ctx.Rectangle(0, 0, 10, 10)
+		ctx.Fill()
+		ctx.Destroy()
+		if status := writer.AddFrame(surface.(cairo.ImageSurface), 100*(i+1)); status != cairo.StatusSuccess {
+			t.Fatalf("AddFrame %d failed: %v", i, status)
+		}
+		surface.Destroy()
+	}
+	if status := writer.Finish(); status != cairo.StatusSuccess {
+		t.Fatalf("Finish failed: %v", status)
+	}
+
+	// 不支持 APNG 的解码器显示第一帧
+	if img, err := png.Decode(bytes.NewReader(out.Bytes())); err != nil {
+		t.Fatalf("APNG does not decode as a PNG: %v", err)
+	} else if got := color.NRGBAModel.Convert(img.At(5, 5)); got != colors[0] {
+		t.Errorf("Default image shows %v, expected the first frame %v", got, colors[0])
+	}
+
+	frames, delays := decodeAPNG(t, out.Bytes())
+	if len(frames) != 3 {
+		t.Fatalf("Expected 3 frames, got %d", len(frames))
+	}
+	for i, img := range frames {
+		// 半透明帧经过预乘与反预乘，允许少量误差
+		got := color.NRGBAModel.Convert(img.At(5, 5)).(color.NRGBA)
+		want := colors[i]
+		if absDiff(uint32(got.R), uint32(want.R)) > 2 || absDiff(uint32(got.G), uint32(want.G)) > 2 ||
+			absDiff(uint32(got.B), uint32(want.B)) > 2 || absDiff(uint32(got.A), uint32(want.A)) > 1 {
+			t.Errorf("Frame %d has %v inside the rectangle, expected %v", i, got, colors[i])
+		}
+		if got := color.NRGBAModel.Convert(img.At(15, 5)); got != (color.NRGBA{}) {
+			t.Errorf("Frame %d has %v outside the rectangle, expected transparent", i, got)
+		}
+		if delays[i] != [2]uint16{uint16(100 * (i + 1)), 1000} {
+			t.Errorf("Frame %d has delay %v, expected %d/1000", i, delays[i], 100*(i+1))
+		}
+	}
+
+	// 尺寸不符的帧和没有帧的动画都是错误
+	mismatched := cairo.NewAPNGWriter(io.Discard, 20, 10)
+	small := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer small.Destroy()
+	if status := mismatched.AddFrame(small.(cairo.ImageSurface), 10); status != cairo.StatusInvalidSize {
+		t.Errorf("Expected StatusInvalidSize for a frame of the wrong size, got %v", status)
+	}
+	if status := cairo.NewAPNGWriter(io.Discard, 20, 10).Finish(); status == cairo.StatusSuccess {
+		t.Error("Expected an animation without frames to fail")
+	}
+}
+
+// 测试 GIF 导出：渐变被量化到不超过 256 色的调色板，透明像素使用透明索引
+func TestWriteToGIF(t *testing.T) {
+	const w, h = 256, 64
+	// drawGradient 绘制两个方向的渐变，颜色数远超 256，右侧一列保持透明
+	drawGradient := func(dither cairo.Dither) cairo.ImageSurface {
+		surface := cairo.NewImageSurface(cairo.FormatARGB32, w, h)
+		ctx := cairo.NewContext(surface)
+		defer ctx.Destroy()
+		horizontal := cairo.NewPatternLinear(0, 0, w, 0)
+		horizontal.(cairo.LinearGradientPattern).AddColorStopRGB(0, 1, 0, 0)
+		horizontal.(cairo.LinearGradientPattern).AddColorStopRGB(1, 0, 0, 1)
+		ctx.SetSource(horizontal)
+		ctx.Rectangle(0, 0, w-16, h)
+		ctx.Fill()
+		vertical := cairo.NewPatternLinear(0, 0, 0, h)
+		vertical.(cairo.LinearGradientPattern).AddColorStopRGBA(0, 0, 1, 0, 0)
+		vertical.(cairo.LinearGradientPattern).AddColorStopRGBA(1, 0, 1, 0, 0.5)
+		ctx.SetSource(vertical)
+		ctx.Rectangle(0, 0, w-16, h)
+		ctx.Fill()
+		image := surface.(cairo.ImageSurface)
+		image.SetDither(dither)
+		return image
+	}
+
+	for _, dither := range []cairo.Dither{cairo.DitherNone, cairo.DitherGood, cairo.DitherBest} {
+		surface := drawGradient(dither)
+		path := filepath.Join(t.TempDir(), "gradient.gif")
+		if status := surface.WriteToGIF(path); status != cairo.StatusSuccess {
+			t.Fatalf("WriteToGIF with dither %v failed: %v", dither, status)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := gif.Decode(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("GIF with dither %v does not decode: %v", dither, err)
+		}
+		paletted := img.(*image.Paletted)
+		if n := len(paletted.Palette); n < 2 || n > 256 {
+			t.Errorf("Expected a palette of at most 256 colors, got %d", n)
+		}
+		if b := img.Bounds(); b.Dx() != w || b.Dy() != h {
+			t.Errorf("Expected a %dx%d image, got %v", w, h, b)
+		}
+		if _, _, _, a := img.At(w-8, h/2).RGBA(); a != 0 {
+			t.Errorf("Expected the transparent column to stay transparent, alpha %d", a)
+		}
+
+		// 以 8x8 块的平均色衡量量化误差
+		src := surface.GetGoImage()
+		worst := 0.0
+		for by := 0; by < h; by += 8 {
+			for bx := 0; bx < w-16; bx += 8 {
+				var diff [3]float64
+				for y := by; y < by+8; y++ {
+					for x := bx; x < bx+8; x++ {
+						want := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+						got := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+						diff[0] += float64(got.R) - float64(want.R)
+						diff[1] += float64(got.G) - float64(want.G)
+						diff[2] += float64(got.B) - float64(want.B)
+					}
+				}
+				for _, d := range diff {
+					worst = math.Max(worst, math.Abs(d/64))
+				}
+			}
+		}
+		if worst > 12 {
+			t.Errorf("Quantized colors with dither %v drift by up to %.1f per channel", dither, worst)
+		}
+		surface.Destroy()
+	}
+
+	// 多帧 GIF 保留帧数和延迟
+	var out bytes.Buffer
+	writer := cairo.NewGIFWriter(&out, w, h)
+	for i := 0; i < 3; i++ {
+		surface := drawGradient(cairo.DitherNone)
+		if status := writer.AddFrame(surface, 40*(i+1)); status != cairo.StatusSuccess {
+			t.Fatalf("AddFrame %d failed: %v", i, status)
+		}
+		surface.Destroy()
+	}
+	if status := writer.Finish(); status != cairo.StatusSuccess {
+		t.Fatalf("Finish failed: %v", status)
+	}
+	anim, err := gif.DecodeAll(&out)
+	if err != nil {
+		t.Fatalf("Animated GIF does not decode: %v", err)
+	}
+	if len(anim.Image) != 3 || anim.Delay[0] != 4 || anim.Delay[2] != 12 {
+		t.Errorf("Expected 3 frames with delays 4, 8 and 12, got %d frames with %v", len(anim.Image), anim.Delay)
+	}
+
+	// 不支持的格式在创建文件之前就报错，已有文件保持不变
+	path := filepath.Join(t.TempDir(), "existing.gif")
+	if err := os.WriteFile(path, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []cairo.Format{cairo.FormatA8, cairo.FormatA1, cairo.FormatRGB30} {
+		surface := cairo.NewImageSurface(format, 8, 8).(cairo.ImageSurface)
+		if status := surface.WriteToGIF(path); status != cairo.StatusInvalidFormat {
+			t.Errorf("WriteToGIF of format %v: expected StatusInvalidFormat, got %v", format, status)
+		}
+		surface.Destroy()
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "keep" {
+		t.Errorf("Expected the existing file to be left alone, got %q, %v", data, err)
+	}
+}
+
+// 测试 JPEG 导出：不透明渐变解码后与原图接近，透明像素铺在背景色上
+func TestWriteToJPEG(t *testing.T) {
+	const w, h = 128, 64
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, w, h)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	grad := cairo.NewPatternLinear(0, 0, w, h)
+	grad.(cairo.LinearGradientPattern).AddColorStopRGB(0, 0.9, 0.6, 0.1)
+	grad.(cairo.LinearGradientPattern).AddColorStopRGB(1, 0.1, 0.3, 0.8)
+	ctx.SetSource(grad)
+	ctx.Paint()
+	ctx.Destroy()
+	img := surface.(cairo.ImageSurface)
+
+	path := filepath.Join(t.TempDir(), "gradient.jpg")
+	if status := img.WriteToJPEG(path, 90); status != cairo.StatusSuccess {
+		t.Fatalf("WriteToJPEG failed: %v", status)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := jpeg.Decode(file)
+	file.Close()
+	if err != nil {
+		t.Fatalf("Output is not a valid JPEG: %v", err)
+	}
+	if b := decoded.Bounds(); b.Dx() != w || b.Dy() != h {
+		t.Fatalf("Expected a %dx%d image, got %v", w, h, b)
+	}
+	var total float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r1, g1, b1, _ := img.GetGoImage().At(x, y).RGBA()
+			r2, g2, b2, _ := decoded.At(x, y).RGBA()
+			total += float64(absDiff(r1>>8, r2>>8) + absDiff(g1>>8, g2>>8) + absDiff(b1>>8, b2>>8))
+		}
+	}
+	if mean := total / (w * h * 3); mean > 4 {
+		t.Errorf("Decoded pixels differ by %.2f on average", mean)
+	}
+
+	for _, quality := range []int{0, 101} {
+		if status := img.WriteToJPEG(filepath.Join(t.TempDir(), "bad.jpg"), quality); status != cairo.StatusInvalidSize {
+			t.Errorf("Expected StatusInvalidSize for quality %d, got %v", quality, status)
+		}
+	}
+
+	// 透明表面铺在设置的背景色上
+	blank := cairo.NewImageSurface(cairo.FormatARGB32, 16, 16).(cairo.ImageSurface)
+	defer blank.Destroy()
+	blank.SetJPEGBackground(1, 1, 1)
+	var out bytes.Buffer
+	status := blank.WriteToJPEGStream(func(closure interface{}, data []byte) error {
+		_, err := closure.(*bytes.Buffer).Write(data)
+		return err
+	}, &out, 80)
+	if status != cairo.StatusSuccess {
+		t.Fatalf("WriteToJPEGStream failed: %v", status)
+	}
+	decoded, err = jpeg.Decode(&out)
+	if err != nil {
+		t.Fatalf("Output is not a valid JPEG: %v", err)
+	}
+	if r, g, b, _ := decoded.At(8, 8).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("Expected a transparent surface to flatten to white, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+}
+
+// exportPattern 绘制一个宽度为奇数的已知图案：四个色块，其中一个半透明
+func exportPattern(format cairo.Format) cairo.ImageSurface {
+	surface := cairo.NewImageSurface(format, 5, 3).(cairo.ImageSurface)
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	for _, r := range []struct {
+		x, y, w, h float64
+		r, g, b, a float64
+	}{
+		{0, 0, 2, 2, 1, 0, 0, 1},
+		{2, 0, 3, 2, 0, 1, 0, 1},
+		{0, 2, 2, 1, 0, 0, 1, 1},
+		{2, 2, 3, 1, 1, 1, 0, 0.5},
+	} {
+		ctx.SetSourceRGBA(r.r, r.g, r.b, r.a)
+		ctx.Rectangle(r.x, r.y, r.w, r.h)
+		ctx.Fill()
+	}
+	return surface
+}
+
+// checkExported 比较导出后解码的像素与表面的非预乘像素
+func checkExported(t *testing.T, name string, surface cairo.ImageSurface, decoded image.Image) {
+	t.Helper()
+	if b := decoded.Bounds(); b.Dx() != 5 || b.Dy() != 3 {
+		t.Fatalf("%s: expected a 5x3 image, got %v", name, b)
+	}
+	opaque := surface.GetFormat() == cairo.FormatRGB24
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 5; x++ {
+			want := color.NRGBAModel.Convert(surface.GetGoImage().At(x, y)).(color.NRGBA)
+			if opaque {
+				want.A = 0xff
+			}
+			got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+			if absDiff(uint32(got.R), uint32(want.R)) > 1 || absDiff(uint32(got.G), uint32(want.G)) > 1 ||
+				absDiff(uint32(got.B), uint32(want.B)) > 1 || got.A != want.A {
+				t.Errorf("%s: pixel (%d, %d) is %v, expected %v", name, x, y, got, want)
+			}
+		}
+	}
+}
+
+// 测试 BMP 导出：32 位带 alpha 与 24 位不透明图案都能被解码回来
+func TestWriteToBMP(t *testing.T) {
+	for _, format := range []cairo.Format{cairo.FormatARGB32, cairo.FormatRGB24} {
+		surface := exportPattern(format)
+		path := filepath.Join(t.TempDir(), "pattern.bmp")
+		if status := surface.WriteToBMP(path); status != cairo.StatusSuccess {
+			t.Fatalf("WriteToBMP failed: %v", status)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := bmp.Decode(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("BMP of format %v does not decode: %v", format, err)
+		}
+		checkExported(t, fmt.Sprintf("BMP of format %v", format), surface, decoded)
+		surface.Destroy()
+	}
+}
+
+// decodeTGA 解码无压缩真彩色 TGA
+func decodeTGA(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	if len(data) < 18 || data[2] != 2 {
+		t.Fatal("Not an uncompressed true-color TGA")
+	}
+	w := int(binary.LittleEndian.Uint16(data[12:]))
+	h := int(binary.LittleEndian.Uint16(data[14:]))
+	bytesPerPixel := int(data[16]) / 8
+	topDown := data[17]&0x20 != 0
+	pix := data[18+int(data[0]):]
+	if len(pix) < w*h*bytesPerPixel {
+		t.Fatalf("TGA pixel data is truncated: %d bytes", len(pix))
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for row := 0; row < h; row++ {
+		y := row
+		if !topDown {
+			y = h - 1 - row
+		}
+		for x := 0; x < w; x++ {
+			p := pix[(row*w+x)*bytesPerPixel:]
+			c := color.NRGBA{p[2], p[1], p[0], 0xff}
+			if bytesPerPixel == 4 {
+				c.A = p[3]
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// 测试 TGA 导出：32 位带 alpha 与 24 位不透明图案都能被解码回来
+func TestWriteToTGA(t *testing.T) {
+	for _, format := range []cairo.Format{cairo.FormatARGB32, cairo.FormatRGB24} {
+		surface := exportPattern(format)
+		path := filepath.Join(t.TempDir(), "pattern.tga")
+		if status := surface.WriteToTGA(path); status != cairo.StatusSuccess {
+			t.Fatalf("WriteToTGA failed: %v", status)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantDepth := byte(32)
+		if format == cairo.FormatRGB24 {
+			wantDepth = 24
+		}
+		if data[16] != wantDepth {
+			t.Errorf("Expected %d bits per pixel for format %v, got %d", wantDepth, format, data[16])
+		}
+		checkExported(t, fmt.Sprintf("TGA of format %v", format), surface, decodeTGA(t, data))
+		surface.Destroy()
+	}
+}
+
+// 测试图像设备的生命周期：表面共享设备，Acquire/Release 串行化访问，Finish 之后无法再获取
+func TestImageDevice(t *testing.T) {
+	device := cairo.NewImageDevice()
+	defer device.Destroy()
+	if device.GetType() != cairo.DeviceTypeImage || device.Status() != cairo.StatusSuccess {
+		t.Fatalf("Expected a working image device, got type %v, status %v", device.GetType(), device.Status())
+	}
+
+	surface := cairo.NewImageSurfaceForDevice(device, cairo.FormatARGB32, 20, 20)
+	if surface.Status() != cairo.StatusSuccess {
+		t.Fatalf("NewImageSurfaceForDevice failed: %v", surface.Status())
+	}
+	if surface.GetDevice() != device {
+		t.Fatal("Expected the surface to belong to the device")
+	}
+	similar := surface.CreateSimilar(cairo.ContentColorAlpha, 5, 5)
+	sub := surface.(cairo.ImageSurface).CreateForRectangle(0, 0, 5, 5)
+	if similar.GetDevice() != device || sub.GetDevice() != device {
+		t.Error("Expected similar surfaces and subsurfaces to share the device")
+	}
+	if got := device.GetReferenceCount(); got != 4 {
+		t.Errorf("Expected each surface to hold a reference, count is %d", got)
+	}
+	similar.Destroy()
+	sub.Destroy()
+	if got := device.GetReferenceCount(); got != 2 {
+		t.Errorf("Expected destroyed surfaces to drop their references, count is %d", got)
+	}
+	if cairo.NewImageSurface(cairo.FormatARGB32, 5, 5).GetDevice() != nil {
+		t.Error("Expected plain image surfaces to have no device")
+	}
+
+	// 并发绘制时，同一时刻只有一个持有者
+	var holders, overlaps int32
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 20; j++ {
+				if status := device.Acquire(); status != cairo.StatusSuccess {
+					t.Errorf("Acquire failed: %v", status)
+					return
+				}
+				if atomic.AddInt32(&holders, 1) != 1 {
+					atomic.AddInt32(&overlaps, 1)
+				}
+				ctx := cairo.NewContext(surface)
+				ctx.SetSourceRGB(float64(i)/4, 0, 0)
+				ctx.Rectangle(float64(i*5), 0, 5, 20)
+				ctx.Fill()
+				ctx.Destroy()
+				atomic.AddInt32(&holders, -1)
+				device.Release()
+			}
+		}(i)
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	if overlaps != 0 {
+		t.Errorf("Expected Acquire to serialize access, %d overlapping holders", overlaps)
+	}
+
+	if err := device.Finish(); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if status := device.Acquire(); status != cairo.StatusDeviceFinished {
+		t.Errorf("Expected StatusDeviceFinished from a finished device, got %v", status)
+	}
+	if status := cairo.NewImageSurfaceForDevice(device, cairo.FormatARGB32, 5, 5).Status(); status != cairo.StatusDeviceFinished {
+		t.Errorf("Expected no new surfaces on a finished device, got %v", status)
+	}
+	if status := cairo.NewImageSurfaceForDevice(nil, cairo.FormatARGB32, 5, 5).Status(); status != cairo.StatusDeviceTypeMismatch {
+		t.Errorf("Expected StatusDeviceTypeMismatch without an image device, got %v", status)
+	}
+
+	// 已有的表面在设备结束后仍可使用
+	ctx := cairo.NewContext(surface)
+	ctx.SetSourceRGB(0, 0, 1)
+	ctx.Paint()
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Errorf("Expected the surface to keep working, got %v", ctx.Status())
+	}
+	ctx.Destroy()
+	surface.Destroy()
+	if got := device.GetReferenceCount(); got != 1 {
+		t.Errorf("Expected the device to be left with the caller's reference, count is %d", got)
+	}
+}
+
+// 测试脚本表面：记录的绘制回放到图像上与直接绘制逐像素一致
+func TestScriptSurface(t *testing.T) {
+	draw := func(ctx cairo.Context) {
+		ctx.SetSourceRGB(1, 1, 1)
+		ctx.Paint()
+
+		ctx.Save()
+		ctx.Rectangle(4, 4, 52, 52)
+		ctx.Clip()
+		gradient := cairo.NewPatternLinear(0, 0, 60, 0)
+		gradient.(cairo.GradientPattern).AddColorStopRGB(0, 1, 0, 0)
+		gradient.(cairo.GradientPattern).AddColorStopRGBA(1, 0, 0, 1, 0.5)
+		ctx.SetSource(gradient)
+		gradient.Destroy()
+		ctx.Arc(30, 30, 28, 0, 2*math.Pi)
+		ctx.Fill()
+		ctx.Restore()
+
+		ctx.Translate(30, 30)
+		ctx.Rotate(math.Pi / 6)
+		ctx.SetSourceRGBA(0, 0.5, 0, 0.8)
+		ctx.SetLineWidth(3)
+		ctx.SetLineJoin(cairo.LineJoinRound)
+		ctx.SetDash([]float64{6, 2}, 1)
+		ctx.Rectangle(-15, -10, 30, 20)
+		ctx.Stroke()
+
+		ctx.IdentityMatrix()
+		ctx.SetSourceRGB(1, 1, 0)
+		ctx.Rectangle(40, 40, 15, 15)
+		ctx.Clip()
+		ctx.PaintWithAlpha(0.5)
+	}
+
+	direct := cairo.NewImageSurface(cairo.FormatARGB32, 60, 60)
+	defer direct.Destroy()
+	ctx := cairo.NewContext(direct)
+	draw(ctx)
+	ctx.Destroy()
+
+	var script bytes.Buffer
+	surface := cairo.NewScriptSurface(&script, cairo.ContentColorAlpha, 60, 60)
+	if surface.Status() != cairo.StatusSuccess || surface.GetType() != cairo.SurfaceTypeScript {
+		t.Fatalf("NewScriptSurface failed: type %v, status %v", surface.GetType(), surface.Status())
+	}
+	ctx = cairo.NewContext(surface)
+	draw(ctx)
+	if ctx.Status() != cairo.StatusSuccess {
+		t.Fatalf("Tracing failed: %v", ctx.Status())
+	}
+	ctx.Destroy()
+	if err := surface.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	surface.Destroy()
+	for _, op := range []string{"%!CairoScript", "source linear", "clip ", "stroke", "paint-with-alpha 0.5"} {
+		if !bytes.Contains(script.Bytes(), []byte(op)) {
+			t.Errorf("Expected the script to contain %q:\n%s", op, script.String())
+		}
+	}
+
+	replayed := cairo.NewImageSurface(cairo.FormatARGB32, 60, 60)
+	defer replayed.Destroy()
+	ctx = cairo.NewContext(replayed)
+	if err := cairo.ReplayScript(bytes.NewReader(script.Bytes()), ctx); err != nil {
+		t.Fatalf("ReplayScript failed: %v", err)
+	}
+	// 回放不改变目标上下文的状态
+	if ctx.GetLineWidth() != 2 || ctx.GetMatrix().X0 != 0 {
+		t.Error("Expected ReplayScript to restore the target's state")
+	}
+	ctx.Destroy()
+
+	a := direct.(cairo.ImageSurface).GetGoImage()
+	b := replayed.(cairo.ImageSurface).GetGoImage()
+	for y := 0; y < 60; y++ {
+		for x := 0; x < 60; x++ {
+			if a.At(x, y) != b.At(x, y) {
+				t.Fatalf("Replay differs at (%d, %d): %v vs %v", x, y, b.At(x, y), a.At(x, y))
+			}
+		}
+	}
+
+	ctx = cairo.NewContext(replayed)
+	defer ctx.Destroy()
+	err := cairo.ReplayScript(bytes.NewReader([]byte("%!CairoScript\nfill extra\n")), ctx)
+	if cerr, ok := err.(cairo.Error); !ok || cerr.Status != cairo.StatusInvalidFormat {
+		t.Errorf("Expected StatusInvalidFormat for a malformed script, got %v", err)
+	}
+}
@@ -25,6 +25,32 @@ func TestUserToDevice(t *testing.T) {
 	}
 }
 
+// 测试用户空间到设备空间的变换包含表面的设备缩放和偏移
+func TestUserToDeviceDeviceTransform(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	surface.SetDeviceScale(2, 2)
+	surface.SetDeviceOffset(10, 20)
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	if x, y := ctx.UserToDevice(0, 0); x != 10 || y != 20 {
+		t.Errorf("UserToDevice(0, 0): expected (10, 20), got (%g, %g)", x, y)
+	}
+	if x, y := ctx.UserToDevice(100, 100); x != 210 || y != 220 {
+		t.Errorf("UserToDevice(100, 100): expected (210, 220), got (%g, %g)", x, y)
+	}
+	ctx.Translate(5, 0)
+	if x, y := ctx.UserToDevice(0, 0); x != 20 || y != 20 {
+		t.Errorf("UserToDevice after Translate: expected (20, 20), got (%g, %g)", x, y)
+	}
+	// 距离不受偏移影响
+	if dx, dy := ctx.UserToDeviceDistance(3, 4); dx != 6 || dy != 8 {
+		t.Errorf("UserToDeviceDistance: expected (6, 8), got (%g, %g)", dx, dy)
+	}
+}
+
 // 测试距离变换
 func TestUserToDeviceDistance(t *testing.T) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
@@ -61,6 +87,71 @@ func TestDeviceToUser(t *testing.T) {
 	}
 }
 
+// 测试设备缩放和偏移下设备空间与用户空间的往返变换
+func TestDeviceToUserDeviceTransform(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	surface.SetDeviceScale(2, 0.5)
+	surface.SetDeviceOffset(10, -20)
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	if x, y := ctx.DeviceToUser(10, -20); x != 0 || y != 0 {
+		t.Errorf("DeviceToUser(10, -20): expected (0, 0), got (%g, %g)", x, y)
+	}
+	if x, y := ctx.DeviceToUser(30, 30); x != 10 || y != 100 {
+		t.Errorf("DeviceToUser(30, 30): expected (10, 100), got (%g, %g)", x, y)
+	}
+	if dx, dy := ctx.DeviceToUserDistance(4, 4); dx != 2 || dy != 8 {
+		t.Errorf("DeviceToUserDistance(4, 4): expected (2, 8), got (%g, %g)", dx, dy)
+	}
+
+	ctx.Translate(3, 4)
+	ctx.Rotate(math.Pi / 6)
+	ctx.Scale(1.5, 2)
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for _, pt := range [][2]float64{{0, 0}, {10, -20}, {57, 33}, {-8, 91}} {
+		x, y := ctx.DeviceToUser(pt[0], pt[1])
+		if dx, dy := ctx.UserToDevice(x, y); !near(dx, pt[0]) || !near(dy, pt[1]) {
+			t.Errorf("Round trip of device (%g, %g) gave (%g, %g)", pt[0], pt[1], dx, dy)
+		}
+		x, y = ctx.DeviceToUserDistance(pt[0], pt[1])
+		if dx, dy := ctx.UserToDeviceDistance(x, y); !near(dx, pt[0]) || !near(dy, pt[1]) {
+			t.Errorf("Round trip of device distance (%g, %g) gave (%g, %g)", pt[0], pt[1], dx, dy)
+		}
+	}
+}
+
+// 测试逆矩阵随 Save/Restore 和 IdentityMatrix 保持同步
+func TestDeviceToUserTracksMatrix(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	check := func(step string, wantX, wantY float64) {
+		t.Helper()
+		if x, y := ctx.DeviceToUser(40, 60); math.Abs(x-wantX) > 1e-9 || math.Abs(y-wantY) > 1e-9 {
+			t.Errorf("%s: expected (%g, %g), got (%g, %g)", step, wantX, wantY, x, y)
+		}
+	}
+
+	ctx.Scale(2, 4)
+	check("Scale", 20, 15)
+	ctx.Save()
+	ctx.Translate(5, 5)
+	check("Translate", 15, 10)
+	ctx.Restore()
+	check("Restore", 20, 15)
+
+	ctx.IdentityMatrix()
+	check("IdentityMatrix", 40, 60)
+	if dx, dy := ctx.DeviceToUserDistance(3, 4); dx != 3 || dy != 4 {
+		t.Errorf("DeviceToUserDistance: expected (3, 4), got (%g, %g)", dx, dy)
+	}
+}
+
 // 测试组合变换
 func TestCombinedTransforms(t *testing.T) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
@@ -151,6 +242,37 @@ func TestTransformOnPath(t *testing.T) {
 	}
 }
 
+// 测试 Transform 与 TransformPre 的乘法顺序
+func TestTransformPre(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	translate := cairo.NewMatrix()
+	translate.InitTranslate(10, 0)
+	rotate := cairo.NewMatrix()
+	rotate.InitRotate(math.Pi / 2)
+
+	// Transform: 新矩阵作用于用户空间，先旋转再平移 => (1,0) -> (0,1) -> (10,1)
+	ctx.Transform(translate)
+	ctx.Transform(rotate)
+	x, y := ctx.UserToDevice(1, 0)
+	if math.Abs(x-10) > 1e-9 || math.Abs(y-1) > 1e-9 {
+		t.Errorf("Transform: expected (10, 1), got (%f, %f)", x, y)
+	}
+
+	// TransformPre: 新矩阵作用于设备空间，先平移再旋转 => (1,0) -> (11,0) -> (0,11)
+	ctx.IdentityMatrix()
+	ctx.TransformPre(translate)
+	ctx.TransformPre(rotate)
+	x, y = ctx.UserToDevice(1, 0)
+	if math.Abs(x) > 1e-9 || math.Abs(y-11) > 1e-9 {
+		t.Errorf("TransformPre: expected (0, 11), got (%f, %f)", x, y)
+	}
+}
+
 // 测试变换栈
 func TestTransformStack(t *testing.T) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
@@ -187,6 +309,111 @@ func TestTransformStack(t *testing.T) {
 	}
 }
 
+// 测试 Translate 与 Scale 的组合顺序与 cairo 一致
+func TestTransformCompositionOrder(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	// 先平移后缩放：用户空间原点落在设备 (10, 10)
+	ctx.Translate(10, 10)
+	ctx.Scale(2, 2)
+
+	x, y := ctx.UserToDevice(0, 0)
+	if x != 10 || y != 10 {
+		t.Errorf("origin maps to (%f, %f), expected (10, 10)", x, y)
+	}
+	x, y = ctx.UserToDevice(5, 5)
+	if x != 20 || y != 20 {
+		t.Errorf("(5, 5) maps to (%f, %f), expected (20, 20)", x, y)
+	}
+
+	// 渲染的矩形应覆盖设备空间 [10, 20)
+	ctx.SetSourceRGB(1, 0, 0)
+	ctx.Rectangle(0, 0, 5, 5)
+	ctx.Fill()
+
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	for _, p := range [][2]int{{10, 10}, {19, 19}} {
+		if _, _, _, a := img.At(p[0], p[1]).RGBA(); a == 0 {
+			t.Errorf("pixel (%d, %d) should be filled", p[0], p[1])
+		}
+	}
+	for _, p := range [][2]int{{9, 9}, {20, 20}, {4, 4}} {
+		if _, _, _, a := img.At(p[0], p[1]).RGBA(); a != 0 {
+			t.Errorf("pixel (%d, %d) should be empty", p[0], p[1])
+		}
+	}
+
+	// 旋转叠加在已有变换之后
+	ctx.Rotate(math.Pi / 2)
+	x, y = ctx.UserToDevice(5, 0)
+	if math.Abs(x-10) > 1e-9 || math.Abs(y-20) > 1e-9 {
+		t.Errorf("rotated (5, 0) maps to (%f, %f), expected (10, 20)", x, y)
+	}
+}
+
+// 测试在变换之前构建的路径保持在设备空间中的位置
+func TestPathFixedBeforeTransform(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.Rectangle(0, 0, 5, 5)
+	ctx.Translate(10, 10)
+	ctx.Scale(2, 2)
+
+	// 当前点以新的用户空间坐标返回
+	x, y := ctx.GetCurrentPoint()
+	if math.Abs(x+5) > 1e-9 || math.Abs(y+5) > 1e-9 {
+		t.Errorf("current point is (%f, %f), expected (-5, -5)", x, y)
+	}
+
+	ctx.SetSourceRGB(0, 0, 1)
+	ctx.Fill()
+
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	if _, _, _, a := img.At(2, 2).RGBA(); a == 0 {
+		t.Error("path built before the transform should stay at device (0..5)")
+	}
+	if _, _, _, a := img.At(15, 15).RGBA(); a != 0 {
+		t.Error("path built before the transform must not follow the new CTM")
+	}
+}
+
+// 测试线宽随 CTM 缩放
+func TestLineWidthScaledByCTM(t *testing.T) {
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 40, 40)
+	defer surface.Destroy()
+
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+
+	ctx.Scale(2, 2)
+	ctx.SetLineWidth(2)
+	ctx.SetSourceRGB(0, 0, 0)
+	ctx.MoveTo(0, 10)
+	ctx.LineTo(20, 10)
+	ctx.Stroke()
+
+	// 用户空间线宽 2 在设备空间中为 4：y 在 [18, 22) 内
+	img := surface.(cairo.ImageSurface).GetGoImage()
+	for _, y := range []int{18, 21} {
+		if _, _, _, a := img.At(20, y).RGBA(); a == 0 {
+			t.Errorf("pixel (20, %d) should be covered by the stroke", y)
+		}
+	}
+	for _, y := range []int{16, 23} {
+		if _, _, _, a := img.At(20, y).RGBA(); a != 0 {
+			t.Errorf("pixel (20, %d) should be outside the stroke", y)
+		}
+	}
+}
+
 // 基准测试：变换操作
 func BenchmarkTransform(b *testing.B) {
 	surface := cairo.NewImageSurface(cairo.FormatARGB32, 100, 100)
@@ -220,3 +447,57 @@ func BenchmarkCoordinateTransform(b *testing.B) {
 		ctx.DeviceToUser(100, 150)
 	}
 }
+
+// 测试 Matrix 的原地 Translate/Scale/Rotate/Multiply 与手工组合的矩阵一致
+func TestMatrixInPlaceOperations(t *testing.T) {
+	near := func(a, b *cairo.Matrix) bool {
+		return math.Abs(a.XX-b.XX) < 1e-9 && math.Abs(a.YX-b.YX) < 1e-9 &&
+			math.Abs(a.XY-b.XY) < 1e-9 && math.Abs(a.YY-b.YY) < 1e-9 &&
+			math.Abs(a.X0-b.X0) < 1e-9 && math.Abs(a.Y0-b.Y0) < 1e-9
+	}
+
+	translate := cairo.NewMatrix()
+	translate.InitTranslate(10, 20)
+	scale := cairo.NewMatrix()
+	scale.InitScale(2, 3)
+	rotate := cairo.NewMatrix()
+	rotate.InitRotate(math.Pi / 6)
+
+	// 新变换作用于矩阵自身的用户空间：m = rotate * scale * translate
+	m := cairo.NewMatrix()
+	m.Translate(10, 20)
+	m.Scale(2, 3)
+	m.Rotate(math.Pi / 6)
+
+	expected := cairo.NewMatrix()
+	cairo.MatrixMultiply(expected, scale, translate)
+	cairo.MatrixMultiply(expected, rotate, expected)
+	if !near(m, expected) {
+		t.Errorf("in-place operations = %+v, expected %+v", *m, *expected)
+	}
+
+	// 与 Context 的变换一致
+	surface := cairo.NewImageSurface(cairo.FormatARGB32, 10, 10)
+	defer surface.Destroy()
+	ctx := cairo.NewContext(surface)
+	defer ctx.Destroy()
+	ctx.Translate(10, 20)
+	ctx.Scale(2, 3)
+	ctx.Rotate(math.Pi / 6)
+	if !near(m, ctx.GetMatrix()) {
+		t.Errorf("in-place operations = %+v, context matrix %+v", *m, *ctx.GetMatrix())
+	}
+
+	// Multiply：先 a 后 b，结果可与参数相同
+	var product cairo.Matrix
+	product.Multiply(scale, translate)
+	x, y := cairo.MatrixTransformPoint(&product, 1, 1)
+	if math.Abs(x-12) > 1e-9 || math.Abs(y-23) > 1e-9 {
+		t.Errorf("Multiply: (1, 1) -> (%f, %f), expected (12, 23)", x, y)
+	}
+	aliased := *scale
+	aliased.Multiply(&aliased, translate)
+	if !near(&aliased, &product) {
+		t.Errorf("aliased Multiply = %+v, expected %+v", aliased, product)
+	}
+}